@@ -0,0 +1,165 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AlertRule triggers when more than Threshold entries matching all of its
+// conditions were seen within Window.
+type AlertRule struct {
+	Text       string
+	Conditions []AlertCondition
+	Threshold  int
+	Window     time.Duration
+}
+
+// AlertCondition matches entries whose Key is, or isn't, Value. The level
+// and message can be matched with the keys level and msg.
+type AlertCondition struct {
+	Key    string
+	Value  string
+	Negate bool
+}
+
+// ParseAlertRule reads rules like:
+//
+//	level=error count>10 per 30s
+//	service=api status!=200 count>=5 per 1m
+func ParseAlertRule(s string) (AlertRule, error) {
+	rule := AlertRule{Text: s}
+	words := strings.Fields(s)
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		switch {
+		case strings.HasPrefix(word, "count>"):
+			n, orEqual := strings.TrimPrefix(word, "count>"), false
+			if strings.HasPrefix(n, "=") {
+				n, orEqual = n[1:], true
+			}
+			threshold, err := strconv.Atoi(n)
+			if err != nil || threshold < 0 {
+				return rule, fmt.Errorf("invalid count in %q", word)
+			}
+			if orEqual {
+				threshold--
+			}
+			rule.Threshold = threshold
+		case word == "per":
+			if i+1 == len(words) {
+				return rule, fmt.Errorf("missing duration after per")
+			}
+			i++
+			window, err := time.ParseDuration(words[i])
+			if err != nil || window <= 0 {
+				return rule, fmt.Errorf("invalid duration %q", words[i])
+			}
+			rule.Window = window
+		case strings.Contains(word, "!="):
+			kv := strings.SplitN(word, "!=", 2)
+			rule.Conditions = append(rule.Conditions, AlertCondition{Key: kv[0], Value: kv[1], Negate: true})
+		case strings.Contains(word, "="):
+			kv := strings.SplitN(word, "=", 2)
+			rule.Conditions = append(rule.Conditions, AlertCondition{Key: kv[0], Value: kv[1]})
+		default:
+			return rule, fmt.Errorf("unexpected %q", word)
+		}
+	}
+	if rule.Window == 0 {
+		return rule, fmt.Errorf("missing window, i.e. per 30s")
+	}
+	return rule, nil
+}
+
+func (r *AlertRule) matches(ev *Event) bool {
+	for _, cond := range r.Conditions {
+		if !cond.matches(ev) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *AlertCondition) matches(ev *Event) bool {
+	var equal bool
+	switch c.Key {
+	case "level":
+		equal = strings.EqualFold(ev.Level, c.Value)
+	case "msg", "message":
+		equal = ev.Message == c.Value
+	default:
+		v, ok := ev.Fields[c.Key]
+		equal = ok && unquoteValue(v) == c.Value
+	}
+	return equal != c.Negate
+}
+
+// alertWindow counts the entries a rule matched within its window.
+type alertWindow struct {
+	rule  AlertRule
+	seen  []time.Time
+	fired bool
+}
+
+// observe records a match at now, telling if the rule just triggered. It
+// triggers once, then again only after the count went back under the
+// threshold.
+func (w *alertWindow) observe(now time.Time) bool {
+	w.seen = append(w.seen, now)
+	cutoff := now.Add(-w.rule.Window)
+	i := 0
+	for i < len(w.seen) && !w.seen[i].After(cutoff) {
+		i++
+	}
+	w.seen = w.seen[i:]
+
+	over := len(w.seen) > w.rule.Threshold
+	fire := over && !w.fired
+	w.fired = over
+	return fire
+}
+
+// alertStage evaluates the alert rules on the parsed entries. Triggered
+// rules run the notify command, or print a banner onto dst if there's none.
+func alertStage(dst io.Writer, opts *HandlerOptions) Stage {
+	windows := make([]*alertWindow, len(opts.Alerts))
+	for i, rule := range opts.Alerts {
+		windows[i] = &alertWindow{rule: rule}
+	}
+	return func(e *Entry, next Next) error {
+		if e.Event != nil {
+			now := time.Now()
+			for _, w := range windows {
+				if w.rule.matches(e.Event) && w.observe(now) {
+					notify(dst, opts, w.rule, len(w.seen))
+				}
+			}
+		}
+		return next(e)
+	}
+}
+
+func notify(dst io.Writer, opts *HandlerOptions, rule AlertRule, count int) {
+	text := fmt.Sprintf("%d entries matching %q within %v", count, rule.Text, rule.Window)
+	if opts.NotifyCommand == "" {
+		opts.AlertColor.Fprintf(dst, "ALERT: %s\n", text)
+		return
+	}
+	cmd := exec.Command("sh", "-c", opts.NotifyCommand)
+	cmd.Env = append(os.Environ(),
+		"HUMANLOG_ALERT="+text,
+		"HUMANLOG_ALERT_RULE="+rule.Text,
+		"HUMANLOG_ALERT_COUNT="+strconv.Itoa(count),
+	)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	if err := cmd.Start(); err != nil {
+		opts.AlertColor.Fprintf(dst, "ALERT: %s (can't notify: %v)\n", text, err)
+		return
+	}
+	go cmd.Wait()
+}
@@ -0,0 +1,64 @@
+package humanlog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAlertRule(t *testing.T) {
+	rule, err := ParseAlertRule("level=error service!=cron count>=5 per 1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.Threshold != 4 || rule.Window != time.Minute {
+		t.Errorf("want more than 4 per 1m, got more than %d per %v", rule.Threshold, rule.Window)
+	}
+	want := []AlertCondition{{Key: "level", Value: "error"}, {Key: "service", Value: "cron", Negate: true}}
+	if len(rule.Conditions) != len(want) {
+		t.Fatalf("want %v, got %v", want, rule.Conditions)
+	}
+	for i := range want {
+		if rule.Conditions[i] != want[i] {
+			t.Errorf("want %v, got %v", want[i], rule.Conditions[i])
+		}
+	}
+
+	for _, bad := range []string{"level=error count>10", "count>x per 1s", "level=error per", "oops per 1s"} {
+		if _, err := ParseAlertRule(bad); err == nil {
+			t.Errorf("%q: want an error, got none", bad)
+		}
+	}
+}
+
+func TestAlertRule_Matches(t *testing.T) {
+	rule, _ := ParseAlertRule("level=error service!=cron count>0 per 1s")
+	tests := []struct {
+		ev   *Event
+		want bool
+	}{
+		{ev: &Event{Level: "ERROR", Fields: map[string]string{"service": `"api"`}}, want: true},
+		{ev: &Event{Level: "error", Fields: map[string]string{"service": `"cron"`}}, want: false},
+		{ev: &Event{Level: "info", Fields: map[string]string{}}, want: false},
+	}
+	for _, test := range tests {
+		if got := rule.matches(test.ev); got != test.want {
+			t.Errorf("%v: want %v, got %v", test.ev, test.want, got)
+		}
+	}
+}
+
+func TestAlertWindow(t *testing.T) {
+	w := &alertWindow{rule: AlertRule{Threshold: 2, Window: 10 * time.Second}}
+	start := time.Now()
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+
+	var fired []int
+	for _, s := range []int{0, 1, 2, 3, 4, 30, 31, 32} {
+		if w.observe(at(s)) {
+			fired = append(fired, s)
+		}
+	}
+	if len(fired) != 2 || fired[0] != 2 || fired[1] != 32 {
+		t.Errorf("want to fire at 2s and again at 32s, fired at %v", fired)
+	}
+}
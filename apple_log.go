@@ -0,0 +1,64 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+)
+
+// appleLevels are the levels of the message types of the unified log.
+var appleLevels = map[string]string{
+	"Default": "info",
+	"Info":    "info",
+	"Debug":   "debug",
+	"Error":   "error",
+	"Fault":   "critical",
+}
+
+// appleNoise are the fields of the unified log that only matter to its own
+// tools.
+var appleNoise = []string{
+	"traceID", "machTimestamp", "senderProgramCounter", "senderImageUUID", "processImageUUID",
+	"bootUUID", "backtrace", "timezoneName", "formatString", "activityIdentifier", "parentActivityIdentifier",
+}
+
+// rewriteAppleLog turns the entries of macOS's unified log, as written by
+// log stream or log show with --style json or ndjson, into entries with a
+// level and the name of their process. Their timestamps are kept as they
+// are, humanlog knows their format.
+func rewriteAppleLog(line []byte) []byte {
+	if !bytes.HasPrefix(line, []byte(`{`)) || !bytes.Contains(line, []byte(`"eventMessage"`)) {
+		return nil
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return nil
+	}
+	if t, ok := entry["messageType"].(string); ok {
+		if level, ok := appleLevels[t]; ok {
+			entry["messageType"] = level
+		}
+	}
+	if path, ok := entry["processImagePath"].(string); ok && path != "" {
+		entry["process"] = filepath.Base(path)
+		delete(entry, "processImagePath")
+	}
+	if path, ok := entry["senderImagePath"].(string); ok && path != "" {
+		entry["senderImagePath"] = filepath.Base(path)
+	}
+	for _, k := range appleNoise {
+		delete(entry, k)
+	}
+	for k, v := range entry {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) == "" && k != "eventMessage" {
+			delete(entry, k)
+		}
+	}
+
+	out, err := json.Marshal(entry)
+	if err != nil {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const appleLogExample = `[{
+  "traceID" : 4383196,
+  "eventMessage" : "Connection to the server failed",
+  "eventType" : "logEvent",
+  "formatString" : "%{public}s",
+  "subsystem" : "com.example.sync",
+  "category" : "network",
+  "threadID" : 1234567,
+  "processImagePath" : "\/Applications\/Sync.app\/Contents\/MacOS\/Sync",
+  "timestamp" : "2024-01-02 10:00:00.123456-0800",
+  "machTimestamp" : 5021388745211,
+  "messageType" : "Fault",
+  "processID" : 501,
+  "timezoneName" : ""
+},{
+  "eventMessage" : "retrying",
+  "subsystem" : "com.example.sync",
+  "processImagePath" : "\/Applications\/Sync.app\/Contents\/MacOS\/Sync",
+  "timestamp" : "2024-01-02 10:00:01.000000-0800",
+  "messageType" : "Default"
+}]`
+
+func TestAppleLogPreset(t *testing.T) {
+	p, ok := LookupPreset("apple")
+	if !ok {
+		t.Fatal("no apple preset")
+	}
+	opts := *DefaultOptions
+	p.Apply(&opts)
+
+	var events []*Event
+	if err := ScanEvents(strings.NewReader(appleLogExample), &opts, func(ev *Event) error {
+		events = append(events, ev)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("want 2 events, got %d", len(events))
+	}
+	want := time.Date(2024, 1, 2, 18, 0, 0, 123456e3, time.UTC)
+	if ev := events[0]; !ev.Time.Equal(want) || ev.Level != "critical" || ev.Message != "Connection to the server failed" || ev.Fields["process"] != `"Sync"` {
+		t.Errorf("got %+v", ev)
+	}
+	if _, ok := events[0].Fields["machTimestamp"]; ok {
+		t.Errorf("want the fields only the unified log's tools need dropped, got %v", events[0].Fields)
+	}
+	if ev := events[1]; ev.Level != "info" || ev.Message != "retrying" {
+		t.Errorf("got %+v", ev)
+	}
+}
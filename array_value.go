@@ -0,0 +1,60 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// arrayItem formats an item of an array: strings as they are, anything
+// else as compact JSON.
+func arrayItem(item interface{}) string {
+	if s, ok := item.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Sprintf("%v", item)
+	}
+	return string(b)
+}
+
+// shouldExpandArray tells whether the array held by key is shown item by
+// item, by ExpandArrays or ExpandArrayFields.
+func (h *HandlerOptions) shouldExpandArray(key string) bool {
+	return h.ExpandArrays || containsField(h.ExpandArrayFields, key)
+}
+
+// arraySummary returns an array of key as its length with its first item,
+// like list[5](web,…), so that long lists of labels or tags don't take
+// over the line.
+func (h *HandlerOptions) arraySummary(key string, items []interface{}) string {
+	summary := h.ContextColor.Sprintf("list[%d]", len(items))
+	if len(items) == 0 {
+		return summary
+	}
+	first := h.ValColor.Sprint(isolateBidi(h.truncateValue(key, arrayItem(items[0]))))
+	more := ""
+	if len(items) > 1 {
+		more = ",…"
+	}
+	return summary + h.ContextColor.Sprint("(") + first + h.ContextColor.Sprint(more+")")
+}
+
+// arrayBlock returns the items of an array of key on lines of their own, if
+// it's to be expanded.
+func (h *HandlerOptions) arrayBlock(key string, items []interface{}) (string, bool) {
+	if !h.shouldExpandArray(key) || len(items) == 0 {
+		return "", false
+	}
+	var sb strings.Builder
+	sb.WriteString("    ")
+	sb.WriteString(h.KeyColor.Sprint(key))
+	sb.WriteString(h.ContextColor.Sprintf(" list[%d]:", len(items)))
+	for _, item := range items {
+		sb.WriteString("\n      ")
+		sb.WriteString(h.ContextColor.Sprint("- "))
+		sb.WriteString(h.ValColor.Sprint(isolateBidi(arrayItem(item))))
+	}
+	return sb.String(), true
+}
@@ -0,0 +1,44 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestArrayValues(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	line := []byte(`{"level":"info","msg":"deployed","tags":["web","prod","eu-west-1","canary","v2"],"ports":[8080,{"tls":true}],"none":[]}`)
+	render := func(opts *HandlerOptions) string {
+		h := JSONHandler{Opts: opts}
+		if !h.TryHandle(line) {
+			t.Fatal("want the line handled")
+		}
+		return string(h.Prettify(false))
+	}
+
+	opts := *DefaultOptions
+	got := render(&opts)
+	for _, want := range []string{"tags=list[5](web,…)", "ports=list[2](8080,…)", "none=list[0]"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	}
+
+	opts.ExpandArrayFields = []string{"ports"}
+	got = render(&opts)
+	if !strings.Contains(got, "    ports list[2]:\n      - 8080\n      - {\"tls\":true}") {
+		t.Errorf("want the ports expanded, got %q", got)
+	}
+	if !strings.Contains(got, "tags=list[5](web,…)") {
+		t.Errorf("want the tags left folded, got %q", got)
+	}
+
+	opts.ExpandArrays = true
+	if got := render(&opts); !strings.Contains(got, "    tags list[5]:\n      - web\n      - prod\n      - eu-west-1") {
+		t.Errorf("want all arrays expanded, got %q", got)
+	}
+}
@@ -0,0 +1,72 @@
+package humanlog
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// BackgroundQuery asks the terminal the color of its background (OSC 11).
+// Terminals that know it reply with ReadBackgroundReply's format, the others
+// ignore it.
+const BackgroundQuery = "\x1b]11;?\x1b\\"
+
+// ReadBackgroundReply reads the reply of a terminal to BackgroundQuery, like
+// \x1b]11;rgb:ffff/ffff/dddd\x07, and tells whether the background it names
+// is light. ok is false until the reply is whole, or if it isn't one.
+func ReadBackgroundReply(reply []byte) (light, ok bool) {
+	i := bytes.Index(reply, []byte("\x1b]11;"))
+	if i < 0 {
+		return false, false
+	}
+	reply = reply[i+len("\x1b]11;"):]
+	// replies end like the query did, with ST, or with BEL
+	end := bytes.IndexAny(reply, "\x07\x1b")
+	if end < 0 {
+		return false, false
+	}
+	spec := string(reply[:end])
+	if !strings.HasPrefix(spec, "rgb:") {
+		return false, false
+	}
+	parts := strings.Split(spec[len("rgb:"):], "/")
+	if len(parts) != 3 {
+		return false, false
+	}
+	var rgb [3]float64
+	for i, part := range parts {
+		// each component has 1 to 4 hex digits, scaled to their maximum
+		if len(part) < 1 || len(part) > 4 {
+			return false, false
+		}
+		v, err := strconv.ParseUint(part, 16, 16)
+		if err != nil {
+			return false, false
+		}
+		rgb[i] = float64(v) / float64(uint64(1)<<(4*uint(len(part)))-1) * 255
+	}
+	return lightBackground(rgb), true
+}
+
+// BackgroundFromColorFGBG tells whether the background COLORFGBG names is
+// light. Terminals like rxvt and iTerm set it to the indexes of their
+// foreground and background colors, like 0;15, with the default colors in
+// between sometimes.
+func BackgroundFromColorFGBG(value string) (light, ok bool) {
+	parts := strings.Split(value, ";")
+	if len(parts) < 2 {
+		return false, false
+	}
+	bg, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil || bg < 0 || bg > 15 {
+		return false, false
+	}
+	// the dark colors and the bright black are dark, like vim has them
+	return bg == 7 || bg > 8, true
+}
+
+// lightBackground tells whether black text reads better than white text on
+// a background.
+func lightBackground(rgb [3]float64) bool {
+	return contrastRatio(rgb, ansiRGB["black"]) > contrastRatio(rgb, ansiRGB["hi-white"])
+}
@@ -0,0 +1,77 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestReadBackgroundReply(t *testing.T) {
+	tests := []struct {
+		reply     string
+		light, ok bool
+	}{
+		{reply: "\x1b]11;rgb:ffff/ffff/dddd\x07", light: true, ok: true},
+		{reply: "\x1b]11;rgb:0000/2b2b/3636\x1b\\", light: false, ok: true},
+		{reply: "\x1b]11;rgb:fd/f6/e3\x1b\\", light: true, ok: true},
+		{reply: "\x1b]11;rgb:8/8/8\x07", light: true, ok: true},
+		{reply: "\x1b]11;rgb:1e1e/1e1e/1e1e\x07", light: false, ok: true},
+		// a reply cut short, or something else
+		{reply: "\x1b]11;rgb:ffff/ff"},
+		{reply: "\x1b]11;#ffffff\x07"},
+		{reply: "\x1b]11;rgb:fffff/ffff/ffff\x07"},
+		{reply: "\x1b[?62;c"},
+	}
+	for _, test := range tests {
+		light, ok := ReadBackgroundReply([]byte(test.reply))
+		if light != test.light || ok != test.ok {
+			t.Errorf("%q: want light=%v ok=%v, got light=%v ok=%v", test.reply, test.light, test.ok, light, ok)
+		}
+	}
+}
+
+func TestBackgroundFromColorFGBG(t *testing.T) {
+	tests := []struct {
+		value     string
+		light, ok bool
+	}{
+		{value: "0;15", light: true, ok: true},
+		{value: "15;0", light: false, ok: true},
+		{value: "0;default;7", light: true, ok: true},
+		{value: "7;8", light: false, ok: true},
+		{value: "15;default"},
+		{value: ""},
+	}
+	for _, test := range tests {
+		light, ok := BackgroundFromColorFGBG(test.value)
+		if light != test.light || ok != test.ok {
+			t.Errorf("%q: want light=%v ok=%v, got light=%v ok=%v", test.value, test.light, test.ok, light, ok)
+		}
+	}
+}
+
+func TestLightBg_MessageColor(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	src := `{"msg": "json"}` + "\n" + `msg=logfmt user=ann`
+	for _, light := range []bool{true, false} {
+		opts := *DefaultOptions
+		opts.LightBg = light
+		msgColor := opts.MsgDarkBgColor
+		if light {
+			msgColor = opts.MsgLightBgColor
+		}
+		var out bytes.Buffer
+		if err := Scanner(strings.NewReader(src), &out, &opts); err != nil {
+			t.Fatal(err)
+		}
+		for _, msg := range []string{"json", "logfmt"} {
+			if want := msgColor.Sprint(msg); !strings.Contains(out.String(), want) {
+				t.Errorf("light=%v: want %q in %q", light, want, out.String())
+			}
+		}
+	}
+}
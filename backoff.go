@@ -0,0 +1,106 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+)
+
+// Backoff computes how long to wait before attempting to reconnect, doubling
+// the wait after each failed attempt up to Max, with some jitter so many
+// clients don't all retry at once.
+type Backoff struct {
+	Min    time.Duration
+	Max    time.Duration
+	Jitter float64
+
+	attempt int
+}
+
+// DefaultBackoff is used by network inputs and outputs.
+var DefaultBackoff = Backoff{Min: 500 * time.Millisecond, Max: 30 * time.Second, Jitter: 0.2}
+
+// Next returns how long to wait before the next attempt, and which attempt
+// it will be.
+func (b *Backoff) Next() (time.Duration, int) {
+	b.attempt++
+	wait := b.Min
+	for i := 1; i < b.attempt && wait < b.Max; i++ {
+		wait *= 2
+	}
+	if wait > b.Max {
+		wait = b.Max
+	}
+	if b.Jitter > 0 {
+		delta := float64(wait) * b.Jitter
+		wait += time.Duration(delta*2*rand.Float64() - delta)
+	}
+	return wait, b.attempt
+}
+
+// Reset is called once connected again.
+func (b *Backoff) Reset() { b.attempt = 0 }
+
+// Attempt is the number of failed attempts since the last reset.
+func (b *Backoff) Attempt() int { return b.attempt }
+
+// RetryError is returned by network outputs while they wait to reconnect.
+type RetryError struct {
+	Attempt int
+	Wait    time.Duration
+	Err     error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v (reconnecting, attempt %d in %v)", e.Err, e.Attempt, e.Wait.Round(time.Millisecond))
+}
+
+// reconnectingReader reads from a stream, reopening it with a backoff
+// whenever it fails or ends, and writes status lines about it.
+type reconnectingReader struct {
+	name    string
+	dial    func() (io.ReadCloser, error)
+	status  io.Writer
+	backoff Backoff
+	cur     io.ReadCloser
+}
+
+// Reconnecting returns a reader that never ends: when the stream opened by
+// dial fails or ends, a status line is written and it's opened again, after
+// waiting more and more between consecutive failures.
+func Reconnecting(name string, dial func() (io.ReadCloser, error), status io.Writer) io.Reader {
+	return &reconnectingReader{name: name, dial: dial, status: status, backoff: DefaultBackoff}
+}
+
+func (r *reconnectingReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			rc, err := r.dial()
+			if err != nil {
+				r.wait(err)
+				continue
+			}
+			if r.backoff.Attempt() > 0 {
+				fmt.Fprintf(r.status, "reconnected to %s\n", r.name)
+			}
+			r.cur = rc
+		}
+		n, err := r.cur.Read(p)
+		if n > 0 {
+			r.backoff.Reset()
+			return n, nil
+		}
+		if err != nil {
+			_ = r.cur.Close()
+			r.cur = nil
+			r.wait(err)
+		}
+	}
+}
+
+func (r *reconnectingReader) wait(err error) {
+	wait, attempt := r.backoff.Next()
+	fmt.Fprintf(r.status, "lost %s: %v, reconnecting, attempt %d in %v\n", r.name, err, attempt, wait.Round(time.Millisecond))
+	time.Sleep(wait)
+}
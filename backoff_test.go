@@ -0,0 +1,57 @@
+package humanlog
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Min: time.Second, Max: 5 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		got, attempt := b.Next()
+		if got != w || attempt != i+1 {
+			t.Errorf("attempt %d: want %v, got %v (attempt %d)", i+1, w, got, attempt)
+		}
+	}
+	b.Reset()
+	if got, _ := b.Next(); got != time.Second {
+		t.Errorf("want %v after a reset, got %v", time.Second, got)
+	}
+}
+
+func TestReconnecting(t *testing.T) {
+	streams := []func() (io.ReadCloser, error){
+		func() (io.ReadCloser, error) { return ioutil.NopCloser(strings.NewReader("first\n")), nil },
+		func() (io.ReadCloser, error) { return nil, errors.New("unreachable") },
+		func() (io.ReadCloser, error) { return ioutil.NopCloser(strings.NewReader("second\n")), nil },
+	}
+	var dials int
+	var status strings.Builder
+	r := Reconnecting("test", func() (io.ReadCloser, error) {
+		dial := streams[dials%len(streams)]
+		dials++
+		return dial()
+	}, &status).(*reconnectingReader)
+	r.backoff = Backoff{Min: time.Millisecond, Max: time.Millisecond}
+
+	buf := make([]byte, 64)
+	var got strings.Builder
+	for got.Len() < len("first\nsecond\n") {
+		n, err := r.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got.Write(buf[:n])
+	}
+	if got.String() != "first\nsecond\n" {
+		t.Errorf("want %q, got %q, want != got", "first\nsecond\n", got.String())
+	}
+	if !strings.Contains(status.String(), "attempt 2") || !strings.Contains(status.String(), "reconnected to test") {
+		t.Errorf("missing status lines, got %q", status.String())
+	}
+}
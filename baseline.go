@@ -0,0 +1,81 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/fatih/color"
+)
+
+// Baseline is the values fields are expected to have, like the version
+// every host should be running. A field can be allowed several values.
+type Baseline map[string][]string
+
+// LoadBaseline reads a baseline from a JSON object, whose values are the
+// expected ones, or arrays of them:
+//
+//	{"version": "1.4.2", "region": ["us-east-1", "us-west-2"], "replicas": 3}
+func LoadBaseline(path string) (Baseline, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	b := make(Baseline, len(raw))
+	for key, v := range raw {
+		var values []json.RawMessage
+		if bytes.HasPrefix(bytes.TrimSpace(v), []byte("[")) {
+			if err := json.Unmarshal(v, &values); err != nil {
+				return nil, fmt.Errorf("%s: %s: %v", path, key, err)
+			}
+		} else {
+			values = []json.RawMessage{v}
+		}
+		for _, value := range values {
+			value = bytes.TrimSpace(value)
+			if len(value) == 0 || value[0] == '{' || value[0] == '[' {
+				return nil, fmt.Errorf("%s: %s: want strings, numbers or booleans", path, key)
+			}
+			b[key] = append(b[key], unquoteValue(string(value)))
+		}
+	}
+	return b, nil
+}
+
+// deviates tells if the field has a value the baseline doesn't expect.
+// Fields the baseline doesn't mention, and entries that don't have the
+// field, never deviate.
+func (b Baseline) deviates(key, v string) bool {
+	expected, ok := b[key]
+	if !ok {
+		return false
+	}
+	v = unquoteValue(v)
+	for _, want := range expected {
+		if v == want {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldColor is the color of a value, the one of its budget if its field
+// has one, or DeviationColor when it deviates from the baseline. It tells
+// true and false apart.
+func (h *HandlerOptions) fieldColor(key, v string, kind valueKind) *color.Color {
+	if c, ok := h.budgetColor(key, v); ok {
+		return c
+	}
+	if h.Baseline.deviates(key, v) {
+		return h.DeviationColor
+	}
+	if kind == kindBool && v == "false" && h.FalseColor != nil {
+		return h.FalseColor
+	}
+	return h.valueColor(kind)
+}
@@ -0,0 +1,74 @@
+package humanlog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestLoadBaseline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "baseline.json")
+	write := func(s string) {
+		t.Helper()
+		if err := ioutil.WriteFile(path, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"version": "1.4.2", "region": ["us-east-1", "us-west-2"], "replicas": 3, "canary": false}`)
+	b, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Baseline{"version": {"1.4.2"}, "region": {"us-east-1", "us-west-2"}, "replicas": {"3"}, "canary": {"false"}}
+	if !reflect.DeepEqual(b, want) {
+		t.Errorf("want %v, got %v", want, b)
+	}
+
+	write(`{"version": {"major": 1}}`)
+	if _, err := LoadBaseline(path); err == nil {
+		t.Error("want an error for an object")
+	}
+}
+
+func TestBaselineDeviations(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	opts := *DefaultOptions
+	opts.Baseline = Baseline{"version": {"1.4.2"}, "region": {"us-east-1", "us-west-2"}, "replicas": {"3"}}
+	opts.SkipUnchanged = false
+
+	src := strings.Join([]string{
+		`{"msg": "a", "host": "web-1", "version": "1.4.2", "region": "us-east-1", "replicas": 3}`,
+		`{"msg": "b", "host": "web-2", "version": "1.4.1", "region": "us-west-2"}`,
+		`msg=c host=web-3 version=1.4.2 region=eu-west-1 replicas=2`,
+	}, "\n")
+	var out bytes.Buffer
+	if err := Scanner(strings.NewReader(src), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	deviation := func(v string) string { return opts.DeviationColor.Sprint(v) }
+
+	if strings.Contains(lines[0], "\x1b[41") {
+		t.Errorf("want nothing highlighted in %q", lines[0])
+	}
+	if !strings.Contains(lines[1], deviation(`"1.4.1"`)) || strings.Contains(lines[1], deviation(`"us-west-2"`)) {
+		t.Errorf("want only the stale version highlighted in %q", lines[1])
+	}
+	if !strings.Contains(lines[2], deviation("eu-west-1")) || !strings.Contains(lines[2], deviation("2")) {
+		t.Errorf("want the region and replicas highlighted in %q", lines[2])
+	}
+}
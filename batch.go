@@ -0,0 +1,120 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// batchSplitter explodes the records holding a batch of entries into one
+// record per entry, in order.
+type batchSplitter struct {
+	src     recordSource
+	pending []record
+}
+
+func (b *batchSplitter) next() (record, bool) {
+	if len(b.pending) != 0 {
+		rec := b.pending[0]
+		b.pending = b.pending[1:]
+		return rec, true
+	}
+	rec, ok := b.src.next()
+	if !ok {
+		return rec, false
+	}
+	items := explodeBatch(rec.data)
+	if len(items) == 0 {
+		return rec, true
+	}
+	for _, item := range items[1:] {
+		b.pending = append(b.pending, record{data: item, line: rec.line, offset: rec.offset})
+	}
+	return record{data: items[0], line: rec.line, offset: rec.offset}, true
+}
+
+func (b *batchSplitter) err() error { return b.src.err() }
+
+func (b *batchSplitter) stop() { b.src.stop() }
+
+// explodeBatch returns the entries of a batch: a JSON array of objects, or
+// an object with a field holding newline delimited JSON objects. The other
+// fields of such an object are added to each of its entries. It returns
+// nothing if data isn't a batch.
+func explodeBatch(data []byte) [][]byte {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+	switch data[0] {
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil || len(items) == 0 {
+			return nil
+		}
+		out := make([][]byte, 0, len(items))
+		for _, item := range items {
+			item = bytes.TrimSpace(item)
+			if len(item) == 0 || item[0] != '{' {
+				return nil
+			}
+			var buf bytes.Buffer
+			if err := json.Compact(&buf, item); err != nil {
+				return nil
+			}
+			out = append(out, buf.Bytes())
+		}
+		return out
+	case '{':
+		if !bytes.Contains(data, []byte(`\n{`)) {
+			return nil
+		}
+		return explodeNDJSONField(data)
+	}
+	return nil
+}
+
+func explodeNDJSONField(data []byte) [][]byte {
+	var outer map[string]json.RawMessage
+	if err := json.Unmarshal(data, &outer); err != nil {
+		return nil
+	}
+	for key, raw := range outer {
+		var s string
+		if len(raw) == 0 || raw[0] != '"' || json.Unmarshal(raw, &s) != nil || !strings.Contains(s, "\n") {
+			continue
+		}
+		var entries []map[string]json.RawMessage
+		for _, line := range strings.Split(s, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			var entry map[string]json.RawMessage
+			if line[0] != '{' || json.Unmarshal([]byte(line), &entry) != nil {
+				entries = nil
+				break
+			}
+			entries = append(entries, entry)
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		out := make([][]byte, 0, len(entries))
+		for _, entry := range entries {
+			for k, v := range outer {
+				if _, ok := entry[k]; !ok && k != key {
+					entry[k] = v
+				}
+			}
+			b, err := json.Marshal(entry)
+			if err != nil {
+				return nil
+			}
+			out = append(out, b)
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package humanlog
+
+import (
+	"testing"
+)
+
+func TestExplodeBatch(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{
+			in:   `[{"msg": "one"}, {"msg": "two"}]`,
+			want: []string{`{"msg":"one"}`, `{"msg":"two"}`},
+		},
+		{
+			in:   `{"stream": "api", "logs": "{\"msg\": \"a\"}\n{\"msg\": \"b\", \"stream\": \"own\"}\n"}`,
+			want: []string{`{"msg":"a","stream":"api"}`, `{"msg":"b","stream":"own"}`},
+		},
+		{in: `[1, 2]`},
+		{in: `[]`},
+		{in: `{"msg": "two lines\n{not json"}`},
+		{in: `{"msg": "plain"}`},
+		{in: `not json`},
+	}
+	for _, test := range tests {
+		got := explodeBatch([]byte(test.in))
+		if len(got) != len(test.want) {
+			t.Errorf("%s: want %d entries, got %d", test.in, len(test.want), len(got))
+			continue
+		}
+		for i := range got {
+			if string(got[i]) != test.want[i] {
+				t.Errorf("%s: entry %d: want %s, got %s", test.in, i, test.want[i], got[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// DefaultBellEvery is how often the bell rings at most, unless told.
+const DefaultBellEvery = 5 * time.Second
+
+// Bell rings the terminal's bell, or runs Command with the shell, when
+// entries ranking at or above Level appear, for a terminal in the background
+// to draw attention to them. It rings at most once every Every, and the
+// command is passed the entry like the hooks are.
+type Bell struct {
+	Level   string
+	Command string
+	Every   time.Duration
+}
+
+// bellStage rings the bell for the parsed entries at or above its level.
+// Entries whose level isn't known never ring it.
+func bellStage(dst io.Writer, opts *HandlerOptions) Stage {
+	min, _ := opts.LevelRank(opts.Bell.Level)
+	r := &hookRunner{hook: ExecHook{Text: "bell on " + opts.Bell.Level, Command: opts.Bell.Command, Every: opts.Bell.Every}}
+	return func(e *Entry, next Next) error {
+		if e.Event == nil {
+			return next(e)
+		}
+		rank, ok := opts.LevelRank(e.Event.Level)
+		if !ok || rank < min || !r.due(time.Now()) {
+			return next(e)
+		}
+		if r.hook.Command == "" {
+			io.WriteString(dst, "\a")
+			r.done()
+			return next(e)
+		}
+		entry, _ := json.Marshal(newNDJSONRecord(e, opts))
+		if err := r.run(append(entry, '\n')); err != nil {
+			opts.DiagnosticColor.Fprintf(dst, "── can't run the bell command: %v\n", err)
+		}
+		return next(e)
+	}
+}
@@ -0,0 +1,42 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBell(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Bell = &Bell{Level: "error", Every: time.Hour}
+
+	src := strings.Join([]string{
+		`{"level":"info","msg":"starting"}`,
+		`{"level":"warn","msg":"slow"}`,
+		`{"level":"error","msg":"failed"}`,
+		`{"level":"fatal","msg":"giving up"}`,
+		`{"level":"oops","msg":"unknown level"}`,
+		`not parsed`,
+	}, "\n")
+	var out bytes.Buffer
+	if err := Scanner(strings.NewReader(src), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+	// the fatal entry comes within the hour the error rang the bell
+	if n := strings.Count(out.String(), "\a"); n != 1 {
+		t.Errorf("want the bell rung once, got %d times", n)
+	}
+	if i := strings.Index(out.String(), "\a"); i < strings.Index(out.String(), "slow") {
+		t.Error("want the bell rung on the error")
+	}
+
+	opts.Bell.Every = 0
+	out.Reset()
+	if err := Scanner(strings.NewReader(src), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(out.String(), "\a"); n != 2 {
+		t.Errorf("want the bell rung on each entry without a limit, got %d times", n)
+	}
+}
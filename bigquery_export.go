@@ -0,0 +1,113 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// bigQueryExporter inserts events with the BigQuery streaming insert API.
+// It authenticates with the token in $BIGQUERY_ACCESS_TOKEN, or asks gcloud
+// for one.
+type bigQueryExporter struct {
+	endpoint string
+	token    string
+}
+
+func newBigQueryExporter(u *url.URL, table string) (*bigQueryExporter, error) {
+	project, dataset := u.Host, strings.Trim(u.Path, "/")
+	if project == "" || dataset == "" {
+		return nil, fmt.Errorf("bigquery: want bigquery://project/dataset, got %q", u.String())
+	}
+	token := os.Getenv("BIGQUERY_ACCESS_TOKEN")
+	if token == "" {
+		out, err := exec.Command("gcloud", "auth", "print-access-token").Output()
+		if err != nil {
+			return nil, fmt.Errorf("bigquery: no $BIGQUERY_ACCESS_TOKEN and gcloud failed: %v", err)
+		}
+		token = strings.TrimSpace(string(out))
+	}
+	return &bigQueryExporter{
+		endpoint: fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/datasets/%s/tables/%s/insertAll",
+			url.PathEscape(project), url.PathEscape(dataset), url.PathEscape(table)),
+		token: token,
+	}, nil
+}
+
+type bigQueryRow struct {
+	// Time is left out, and null, for the events without one.
+	Time    string `json:"time,omitempty"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	// Fields is sent as a string, to be stored in a JSON column.
+	Fields string `json:"fields"`
+}
+
+func (b *bigQueryExporter) Insert(events []*Event) error {
+	type insertRow struct {
+		JSON bigQueryRow `json:"json"`
+	}
+	req := struct {
+		Rows []insertRow `json:"rows"`
+	}{Rows: make([]insertRow, 0, len(events))}
+	for _, ev := range events {
+		row := newExportRow(ev, time.RFC3339Nano)
+		fields, err := json.Marshal(row.Fields)
+		if err != nil {
+			return err
+		}
+		req.Rows = append(req.Rows, insertRow{JSON: bigQueryRow{
+			Time:    row.Time,
+			Level:   row.Level,
+			Message: row.Message,
+			Fields:  string(fields),
+		}})
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, b.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+b.token)
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := exportClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bigquery: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	var result struct {
+		InsertErrors []struct {
+			Index  int `json:"index"`
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		} `json:"insertErrors"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return err
+	}
+	if n := len(result.InsertErrors); n != 0 {
+		first := result.InsertErrors[0]
+		msg := "unknown error"
+		if len(first.Errors) != 0 {
+			msg = first.Errors[0].Message
+		}
+		return fmt.Errorf("bigquery: %d rows rejected, row %d: %s", n, first.Index, msg)
+	}
+	return nil
+}
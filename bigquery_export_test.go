@@ -0,0 +1,40 @@
+package humanlog
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBigQueryExporter(t *testing.T) {
+	var body, auth string
+	reply := `{}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, auth = string(b), r.Header.Get("Authorization")
+		w.Write([]byte(reply))
+	}))
+	defer ts.Close()
+
+	b := &bigQueryExporter{endpoint: ts.URL, token: "t0k3n"}
+	events := []*Event{
+		{Time: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), Level: "error", Message: "failed", Fields: map[string]string{"status": "502"}},
+		{Message: "no time"},
+	}
+	if err := b.Insert(events); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"rows":[{"json":{"time":"2024-01-02T10:00:00Z","level":"error","message":"failed","fields":"{\"status\":\"502\"}"}},` +
+		`{"json":{"level":"","message":"no time","fields":"{}"}}]}`
+	if body != want || auth != "Bearer t0k3n" {
+		t.Errorf("want\n%s\ngot\n%s (%s)", want, body, auth)
+	}
+
+	reply = `{"insertErrors":[{"index":1,"errors":[{"message":"no such field"}]}]}`
+	if err := b.Insert(events); err == nil || !strings.Contains(err.Error(), "row 1: no such field") {
+		t.Errorf("want the rejected rows reported, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package humanlog
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Budget is how long the requests a field times may take: its values are
+// shown in WithinBudgetColor up to Warn, in WarnLevelColor from Warn and in
+// ErrorLevelColor from Error. Thresholds are in the unit of the field's
+// numbers, before Units converts them, and in milliseconds for the values
+// that are durations like 1.5s.
+//
+//	{"budget": {"duration_ms": {"warn": 500, "error": 2000}}}
+type Budget struct {
+	Warn  float64 `json:"warn"`
+	Error float64 `json:"error"`
+}
+
+func (b Budget) validate() error {
+	if b.Warn <= 0 || b.Error <= 0 {
+		return fmt.Errorf("want warn and error thresholds above zero")
+	}
+	if b.Error < b.Warn {
+		return fmt.Errorf("want the error threshold %g at or above the warn one %g", b.Error, b.Warn)
+	}
+	return nil
+}
+
+// budgetColor returns the color of v against the budget of key, if it has
+// one and v is a number or a duration.
+func (h *HandlerOptions) budgetColor(key, v string) (*color.Color, bool) {
+	b, ok := h.Budgets[key]
+	if !ok {
+		return nil, false
+	}
+	v = unquoteValue(v)
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, false
+		}
+		n = float64(d) / float64(time.Millisecond)
+	}
+	switch {
+	case n >= b.Error:
+		return h.ErrorLevelColor, true
+	case n >= b.Warn:
+		return h.WarnLevelColor, true
+	default:
+		return h.WithinBudgetColor, true
+	}
+}
@@ -0,0 +1,61 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestBudgetColor(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Budgets = map[string]Budget{"duration_ms": {Warn: 500, Error: 2000}, "latency": {Warn: 100, Error: 1000}}
+	for _, tt := range []struct {
+		key, value string
+		want       string
+	}{
+		{"duration_ms", "120", "within"},
+		{"duration_ms", "500", "warn"},
+		{"duration_ms", "1999.5", "warn"},
+		{"duration_ms", "2000", "error"},
+		{"latency", `"85ms"`, "within"},
+		{"latency", "1.5s", "error"},
+		{"latency", "250µs", "within"},
+	} {
+		c, ok := opts.budgetColor(tt.key, tt.value)
+		if !ok {
+			t.Errorf("%s=%s: want a color", tt.key, tt.value)
+			continue
+		}
+		want := map[string]*color.Color{"within": opts.WithinBudgetColor, "warn": opts.WarnLevelColor, "error": opts.ErrorLevelColor}[tt.want]
+		if c != want {
+			t.Errorf("%s=%s: want the %s color", tt.key, tt.value, tt.want)
+		}
+	}
+	for _, kv := range [][2]string{{"duration_ms", "slow"}, {"status", "500"}} {
+		if _, ok := opts.budgetColor(kv[0], kv[1]); ok {
+			t.Errorf("%s=%s: want no color", kv[0], kv[1])
+		}
+	}
+}
+
+func TestReadConfig_Budget(t *testing.T) {
+	cfg, err := ReadConfig(writeConfig(t, `{"budget": {"duration_ms": {"warn": 500, "error": 2000}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := *DefaultOptions
+	cfg.Apply(&opts)
+	if b := opts.Budgets["duration_ms"]; b.Warn != 500 || b.Error != 2000 {
+		t.Errorf("got %+v", opts.Budgets)
+	}
+
+	for _, content := range []string{
+		`{"budget": {"duration_ms": {"warn": 2000, "error": 500}}}`,
+		`{"budget": {"duration_ms": {"warn": 500}}}`,
+	} {
+		if _, err := ReadConfig(writeConfig(t, content)); err == nil || !strings.Contains(err.Error(), "budget of duration_ms") {
+			t.Errorf("%s: want an error, got %v", content, err)
+		}
+	}
+}
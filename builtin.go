@@ -0,0 +1,42 @@
+package humanlog
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// builtin holds the presets, themes and grok patterns humanlog ships, so
+// that they can be listed and exported as they're written.
+//
+//go:embed builtin
+var builtin embed.FS
+
+// mustReadBuiltin returns the content of a file under builtin, which has to
+// be there.
+func mustReadBuiltin(name string) []byte {
+	data, err := builtin.ReadFile(path.Join("builtin", name))
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// builtinNames lists the files of a directory under builtin with that
+// extension, without it, in alphabetical order.
+func builtinNames(dir, ext string) []string {
+	entries, err := fs.ReadDir(builtin, path.Join("builtin", dir))
+	if err != nil {
+		panic(err)
+	}
+	var names []string
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ext) {
+			names = append(names, strings.TrimSuffix(e.Name(), ext))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
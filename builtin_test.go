@@ -0,0 +1,107 @@
+package humanlog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuiltinPresets(t *testing.T) {
+	if len(presets) == 0 {
+		t.Fatal("want the presets loaded")
+	}
+	for _, name := range PresetNames() {
+		p, _ := LookupPreset(name)
+		if p.Name != name || p.Description == "" {
+			t.Errorf("%s: want a name matching its file and a description, got %+v", name, p)
+		}
+		if (p.StartPattern != "") != (p.MultilineStart != nil) || (p.RewriteName != "") != (p.Rewrite != nil) {
+			t.Errorf("%s: want its pattern and rewrite compiled", name)
+		}
+	}
+	if p, _ := LookupPreset("journal"); p.Rewrite == nil {
+		t.Error("want the journal preset to rewrite the lines")
+	}
+	if p, _ := LookupPreset("postgres"); !p.MultilineStart.MatchString("2024-01-02 10:00:00.123 UTC [12345] LOG:  ready") {
+		t.Error("want the postgres preset to match the first line of entries")
+	}
+}
+
+func TestLoadPreset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// an exported preset, changed, is loaded from its path
+	data, ok := PresetSource("python")
+	if !ok {
+		t.Fatal("want the source of the python preset")
+	}
+	path := filepath.Join(dir, "python.json")
+	data = bytes.Replace(data, []byte(`"levelname"`), []byte(`"lvl"`), 1)
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadPreset(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.LevelFields[0] != "lvl" || p.MessageFields[0] != "message" {
+		t.Errorf("got %+v", p)
+	}
+
+	for content, want := range map[string]string{
+		`{"name": "x", "rewrite": "nope"}`:           `unknown rewrite "nope"`,
+		`{"name": "x", "multiline-start": "(a"}`:     "multiline-start",
+		`{"name": "x", "level-field": ["lvl"]}`:      "unknown field",
+		`{"description": "no name", "gutter": true}`: "missing name",
+	} {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadPreset(path); err == nil || !bytes.Contains([]byte(err.Error()), []byte(want)) {
+			t.Errorf("%s: want an error about %s, got %v", content, want, err)
+		}
+	}
+	if _, err := LoadPreset("nope"); err == nil {
+		t.Error("want an error for an unknown preset")
+	}
+}
+
+func TestBuiltinThemes(t *testing.T) {
+	if len(Themes) == 0 || Themes[0].Name != "default" {
+		t.Fatalf("want the default theme first, got %v", Themes)
+	}
+	for _, th := range Themes {
+		if _, err := LoadTheme(th.Name); err != nil {
+			t.Errorf("%s: %v", th.Name, err)
+		}
+		if _, ok := ThemeSource(th.Name); !ok {
+			t.Errorf("%s: want its source", th.Name)
+		}
+	}
+}
+
+func TestBuiltinGrokPatterns(t *testing.T) {
+	names := GrokPatternNames()
+	if len(names) != len(grokPatterns) || names[0] != "USERNAME" {
+		t.Fatalf("want every pattern named once, in the order of the file, got %d names for %d patterns", len(names), len(grokPatterns))
+	}
+	for _, name := range names {
+		g := GrokFormat{Pattern: "%{" + name + "}"}
+		if err := g.compile(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if def, ok := GrokPattern("QS"); !ok || def != "%{QUOTEDSTRING}" {
+		t.Errorf("got %q", def)
+	}
+	patterns, _ := parseGrokPatterns(GrokPatternsSource())
+	if len(patterns) != len(grokPatterns) {
+		t.Error("want the source to hold the patterns")
+	}
+}
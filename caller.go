@@ -0,0 +1,78 @@
+package humanlog
+
+import (
+	"strings"
+)
+
+// callerOf returns the source location the entry was logged from, and the
+// fields it was read from. Fields that don't hold a location, like a source
+// naming a service, are left alone.
+func (h *HandlerOptions) callerOf(fields map[string]string) (string, []string) {
+	for _, field := range h.CallerFields {
+		v, ok := fields[field]
+		if !ok {
+			continue
+		}
+		loc := unquoteValue(v)
+		keys := []string{field}
+		for _, lineField := range h.CallerLineFields {
+			if line, ok := fields[lineField]; ok {
+				loc += ":" + unquoteValue(line)
+				keys = append(keys, lineField)
+				break
+			}
+		}
+		if isLocation(loc) {
+			return loc, keys
+		}
+	}
+	return "", nil
+}
+
+// isLocation tells if s looks like path:line.
+func isLocation(s string) bool {
+	i := strings.LastIndexByte(s, ':')
+	if i <= 0 || i == len(s)-1 {
+		return false
+	}
+	for _, c := range s[i+1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// shortenPath drops the leading directories of loc until it fits in width,
+// marking what was dropped with an ellipsis.
+func shortenPath(loc string, width int) string {
+	if width <= 0 || displayWidth(loc) <= width {
+		return loc
+	}
+	parts := strings.Split(loc, "/")
+	for i := 1; i < len(parts); i++ {
+		short := "…/" + strings.Join(parts[i:], "/")
+		if displayWidth(short) <= width {
+			return short
+		}
+	}
+	file := parts[len(parts)-1]
+	if displayWidth(file) < width {
+		return file
+	}
+	return "…" + tailWidth(file, width-1)
+}
+
+// callerColumn renders the caller right-aligned in a column of CallerWidth,
+// followed by a space. It's empty when there's no caller to show.
+func (h *HandlerOptions) callerColumn(loc string) string {
+	if loc == "" {
+		return ""
+	}
+	loc = shortenPath(loc, h.CallerWidth)
+	pad := h.CallerWidth - displayWidth(loc)
+	if pad < 0 {
+		pad = 0
+	}
+	return strings.Repeat(" ", pad) + h.CallerColor.Sprint(loc) + " "
+}
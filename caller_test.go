@@ -0,0 +1,55 @@
+package humanlog
+
+import "testing"
+
+func TestShortenPath(t *testing.T) {
+	tests := []struct {
+		loc   string
+		width int
+		want  string
+	}{
+		{loc: "a/b/c/server.go:42", width: 24, want: "a/b/c/server.go:42"},
+		{loc: "a/b/c/server.go:42", width: 16, want: "…/c/server.go:42"},
+		{loc: "a/b/c/server.go:42", width: 14, want: "…/server.go:42"},
+		{loc: "a/b/c/server.go:42", width: 8, want: "…r.go:42"},
+		{loc: "server.go:42", width: 0, want: "server.go:42"},
+	}
+	for _, test := range tests {
+		got := shortenPath(test.loc, test.width)
+		if got != test.want {
+			t.Errorf("%q in %d: want %q, got %q", test.loc, test.width, test.want, got)
+		}
+	}
+}
+
+func TestCallerOf(t *testing.T) {
+	opts := *DefaultOptions
+	tests := []struct {
+		fields   map[string]string
+		wantLoc  string
+		wantKeys []string
+	}{
+		{
+			fields:   map[string]string{"caller": `"server/main.go:12"`},
+			wantLoc:  "server/main.go:12",
+			wantKeys: []string{"caller"},
+		},
+		{
+			fields:   map[string]string{"file": `"main.go"`, "line": "7"},
+			wantLoc:  "main.go:7",
+			wantKeys: []string{"file", "line"},
+		},
+		{
+			fields: map[string]string{"source": `"billing-api"`},
+		},
+	}
+	for _, test := range tests {
+		loc, keys := opts.callerOf(test.fields)
+		if loc != test.wantLoc {
+			t.Errorf("%v: want %q, got %q", test.fields, test.wantLoc, loc)
+		}
+		if len(keys) != len(test.wantKeys) {
+			t.Errorf("%v: want keys %q, got %q", test.fields, test.wantKeys, keys)
+		}
+	}
+}
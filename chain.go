@@ -0,0 +1,283 @@
+package humanlog
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrStop is returned by a stage to end the processing early. Process
+// returns nil when it stops because of it.
+var ErrStop = errors.New("humanlog: stop processing")
+
+// Entry is a log entry on its way through a chain of stages.
+type Entry struct {
+	// Raw is the entry as it was read from the input.
+	Raw []byte
+	// Line is the number of the line the entry starts on, Offset is where it
+	// starts in the input, in bytes.
+	Line   uint64
+	Offset int64
+
+	// Event is the parsed entry, or nil if no handler recognized it. It's
+	// only valid until the stage returns.
+	Event *Event
+	// Format names the format the entry was parsed from.
+	Format string
+
+	h        handler
+	skip     bool
+	rendered bool
+	// more reads the lines after the entry, for the handlers whose entries
+	// span several lines
+	more *pushback
+}
+
+// Set changes the value of a field of a parsed entry, so that the stages
+// that follow and the rendered output see the new value.
+func (e *Entry) Set(key, value string) {
+	if e.h == nil {
+		return
+	}
+	e.h.setField([]byte(key), []byte(value))
+	e.Event = e.h.event()
+}
+
+// SetLevel changes the level of a parsed entry, so that the stages that
+// follow and the rendered output see the new level.
+func (e *Entry) SetLevel(level string) {
+	if e.h == nil {
+		return
+	}
+	e.h.setLevel([]byte(level))
+	e.Event = e.h.event()
+}
+
+// release lets go of the handler that parsed the entry, unless rendering it
+// already did.
+func (e *Entry) release() {
+	if e.h != nil && !e.rendered {
+		e.h.clear()
+	}
+}
+
+// readMore feeds h the lines after the entry until it has all of them, and
+// returns the entry's lines, joined, and whether it's complete.
+func (e *Entry) readMore(h MultilineHandler, raw []byte) ([]byte, bool) {
+	// the lines read next may overwrite the one the entry was read from
+	joined := append([]byte(nil), raw...)
+	for n := 1; h.NeedsMore() && e.more != nil && n < maxRecordLines; n++ {
+		rec, ok := e.more.next()
+		if !ok {
+			break
+		}
+		if !h.Append(rec.data) {
+			e.more.unread(rec)
+			break
+		}
+		joined = append(append(joined, '\n'), rec.data...)
+	}
+	return joined, !h.NeedsMore()
+}
+
+// Next passes an entry on to the rest of a chain.
+type Next func(e *Entry) error
+
+// Stage is a step entries go through between being read and being rendered.
+// A stage calls next to pass the entry on, or returns without calling it to
+// drop the entry.
+type Stage func(e *Entry, next Next) error
+
+// Chain composes stages into a single one, that runs them in order.
+//
+//	humanlog.Chain(humanlog.Parse(opts), humanlog.Redact("password"), humanlog.Render(os.Stdout, opts))
+func Chain(stages ...Stage) Stage {
+	return func(e *Entry, next Next) error {
+		return runStages(stages, e, next)
+	}
+}
+
+func runStages(stages []Stage, e *Entry, next Next) error {
+	if len(stages) == 0 {
+		return next(e)
+	}
+	return stages[0](e, func(e *Entry) error {
+		return runStages(stages[1:], e, next)
+	})
+}
+
+func endOfChain(*Entry) error { return nil }
+
+// Process reads the entries of src and sends each of them through the chain.
+func Process(src io.Reader, opts *HandlerOptions, chain Stage) error {
+	var lines recordSource
+	if m, ok := src.(*MappedFile); ok && m.isUTF8(opts.Encoding) && !m.isWrapped() && opts.Proto == nil {
+		lines = &mappedLines{m: m}
+	} else {
+		src, err := unwrapInput(src)
+		if err != nil {
+			return err
+		}
+		if opts.Proto != nil {
+			lines = newLineReader(newProtoReader(src, opts.Proto))
+		} else if lines, err = decodeInput(src, opts.Encoding); err != nil {
+			return err
+		}
+	}
+	records := &pushback{src: newRecordSource(lines, opts)}
+	defer records.stop()
+	grep := newGrepFilter(opts)
+	for {
+		rec, ok := records.next()
+		if !ok {
+			break
+		}
+		if grep != nil && !grep.match(rec.data) {
+			continue
+		}
+		e := &Entry{Raw: trimSyslog(rec.data), Line: rec.line, Offset: rec.offset, more: records}
+		err := chain(e, endOfChain)
+		e.release()
+		if err == ErrStop {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	return records.err()
+}
+
+// Parse tries each format humanlog knows about on the entries. Entries that
+// no format recognized are passed on with a nil Event.
+func Parse(opts *HandlerOptions) Stage {
+	p := newParser(opts)
+	profiles := &profileParsers{opts: opts, parsers: make(map[*SourceProfile]*parser)}
+	return func(e *Entry, next Next) error {
+		raw := e.Raw
+		for _, rewrite := range opts.Rewrites {
+			if line := rewrite(raw); line != nil {
+				raw = line
+				break
+			}
+		}
+		res := p.parse(raw)
+		if ml, ok := res.entry.(MultilineHandler); ok && ml.NeedsMore() {
+			var complete bool
+			e.Raw, complete = e.readMore(ml, raw)
+			raw = e.Raw
+			if !complete {
+				res.entry.clear()
+				res = parseResult{}
+			}
+		}
+		if res.entry != nil && len(opts.Sources) != 0 {
+			res = profiles.parse(raw, res)
+		}
+		if res.entry != nil {
+			e.h, e.Format, e.skip = res.entry, res.name, res.skip
+			e.Event = res.entry.event()
+		}
+		return next(e)
+	}
+}
+
+// Filter drops the parsed entries keep returns false for. Entries that
+// weren't parsed are passed on.
+func Filter(keep func(ev *Event) bool) Stage {
+	return func(e *Entry, next Next) error {
+		if e.Event != nil && !keep(e.Event) {
+			return nil
+		}
+		return next(e)
+	}
+}
+
+// Redact replaces the value of the given fields, wherever they're set.
+func Redact(keys ...string) Stage {
+	return func(e *Entry, next Next) error {
+		if e.Event != nil {
+			for _, key := range keys {
+				if _, ok := e.Event.Fields[key]; ok {
+					e.Set(key, "[redacted]")
+				}
+			}
+		}
+		return next(e)
+	}
+}
+
+// enrichStage adds the fields captured by the enrichment rules.
+func enrichStage(rules []EnrichRule) Stage {
+	return func(e *Entry, next Next) error {
+		if e.h != nil {
+			enrich(rules, e.h)
+			e.Event = e.h.event()
+		}
+		return next(e)
+	}
+}
+
+// timeRangeStage drops the entries outside of Since and Until, and stops
+// once the input is sorted and went past Until.
+func timeRangeStage(opts *HandlerOptions) Stage {
+	return func(e *Entry, next Next) error {
+		if e.Event == nil || opts.inTimeRange(e.Event.Time) {
+			return next(e)
+		}
+		if opts.SortedInput && !opts.Until.IsZero() && e.Event.Time.After(opts.Until) {
+			return ErrStop
+		}
+		return nil
+	}
+}
+
+// sinkStage sends the parsed entries to the sinks.
+func sinkStage(opts *HandlerOptions) Stage {
+	return func(e *Entry, next Next) error {
+		if e.Event != nil {
+			visible := opts.visibleEvent(e.Event)
+			for _, sink := range opts.Sinks {
+				// sinks report their own failures, rendering goes on
+				_ = sink.Send(visible)
+			}
+		}
+		return next(e)
+	}
+}
+
+// builtinStages are the stages the options ask for, that run between
+// parsing and rendering.
+func builtinStages(opts *HandlerOptions) []Stage {
+	stages := []Stage{Parse(opts)}
+	if len(opts.ClockOffsets) != 0 {
+		stages = append(stages, clockOffsetStage(opts))
+	}
+	if len(opts.Enrich) != 0 {
+		stages = append(stages, enrichStage(opts.Enrich))
+	}
+	if len(opts.Transforms) != 0 {
+		stages = append(stages, transformStage(opts.Transforms))
+	}
+	if len(opts.LevelRules) != 0 {
+		stages = append(stages, levelRuleStage(opts.LevelRules))
+	}
+	if len(opts.Spans) != 0 {
+		stages = append(stages, spanStage(opts))
+	}
+	if opts.Fingerprints || len(opts.OnlyFingerprints) != 0 {
+		stages = append(stages, fingerprintStage(opts))
+	}
+	if opts.MinLevel != "" {
+		stages = append(stages, minLevelStage(opts))
+	}
+	if len(opts.Where) != 0 {
+		stages = append(stages, Filter(opts.matchesWhere))
+	}
+	if !opts.Since.IsZero() || !opts.Until.IsZero() {
+		stages = append(stages, timeRangeStage(opts))
+	}
+	// the entries are counted once the filters above let them through
+	if opts.Head > 0 {
+		stages = append(stages, headStage(opts.Head))
+	}
+	return append(stages, opts.Stages...)
+}
@@ -0,0 +1,154 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestChain(t *testing.T) {
+	src := strings.Join([]string{
+		`{"msg": "login", "user": "ann", "password": "hunter2"}`,
+		`{"msg": "healthcheck"}`,
+		`not structured`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.SkipUnchanged = false
+
+	var (
+		out    bytes.Buffer
+		events []string
+	)
+	chain := Chain(
+		Parse(&opts),
+		Redact("password"),
+		Filter(func(ev *Event) bool { return ev.Message != "healthcheck" }),
+		func(e *Entry, next Next) error {
+			if e.Event != nil {
+				events = append(events, e.Event.Fields["password"])
+			}
+			return next(e)
+		},
+		Render(&out, &opts),
+	)
+	if err := Process(strings.NewReader(src), &opts, chain); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || events[0] != "[redacted]" {
+		t.Errorf("want the password redacted, got %q", events)
+	}
+	got := out.String()
+	if strings.Contains(got, "hunter2") {
+		t.Errorf("password was rendered: %q", got)
+	}
+	if strings.Contains(got, "healthcheck") {
+		t.Errorf("filtered entry was rendered: %q", got)
+	}
+	if !strings.Contains(got, "not structured") {
+		t.Errorf("unparsed line wasn't rendered: %q", got)
+	}
+}
+
+func TestChain_SkipUnchangedAfterDropped(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	for _, src := range []string{
+		"{\"msg\": \"a\", \"k\": 1}\n{\"msg\": \"b\", \"k\": 2}\n{\"msg\": \"c\", \"k\": 2}",
+		"msg=a k=1\nmsg=b k=2\nmsg=c k=2",
+	} {
+		opts := *DefaultOptions
+		opts.SkipUnchanged = true
+		var out bytes.Buffer
+		chain := Chain(
+			Parse(&opts),
+			Filter(func(ev *Event) bool { return ev.Message != "b" }),
+			Render(&out, &opts),
+		)
+		if err := Process(strings.NewReader(src), &opts, chain); err != nil {
+			t.Fatal(err)
+		}
+		// k=2 was never shown, c's can't be skipped
+		if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || !strings.Contains(lines[1], "k=2") {
+			t.Errorf("want c's k shown, got %q", out.String())
+		}
+	}
+}
+
+func TestChain_Stop(t *testing.T) {
+	opts := *DefaultOptions
+	var seen int
+	chain := Chain(Parse(&opts), func(e *Entry, next Next) error {
+		seen++
+		if seen == 2 {
+			return ErrStop
+		}
+		return next(e)
+	})
+	if err := Process(strings.NewReader("a\nb\nc\n"), &opts, chain); err != nil {
+		t.Fatal(err)
+	}
+	if seen != 2 {
+		t.Errorf("want 2 entries seen before stopping, got %d", seen)
+	}
+}
+
+func TestParse_DropsSkippedKeys(t *testing.T) {
+	src := strings.Join([]string{
+		`{"msg": "json", "service": "api", "pid": 12}`,
+		`msg=logfmt service=api pid=12`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.Gutter = true
+	opts.SetSkip([]string{"pid", "service"})
+
+	var events []*Event
+	chain := Chain(Parse(&opts), func(e *Entry, next Next) error {
+		events = append(events, e.Event)
+		return next(e)
+	})
+	if err := Process(strings.NewReader(src), &opts, chain); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("want 2 events, got %d", len(events))
+	}
+	for _, ev := range events {
+		if _, ok := ev.Fields["pid"]; ok {
+			t.Errorf("%s: want pid dropped, got %q", ev.Message, ev.Fields)
+		}
+		if _, ok := ev.Fields["service"]; !ok {
+			t.Errorf("%s: want service kept for the gutter, got %q", ev.Message, ev.Fields)
+		}
+	}
+}
+
+func TestProcess_CRLF(t *testing.T) {
+	src := "{\"msg\": \"json\"}\r\nmsg=logfmt user=ann\r\nnot structured\r\n\r\nlast\r"
+
+	opts := *DefaultOptions
+	opts.MultilineJSON = true
+	var got []string
+	chain := Chain(Parse(&opts), func(e *Entry, next Next) error {
+		if e.Event != nil {
+			got = append(got, e.Event.Message+" "+e.Event.Fields["user"])
+		} else {
+			got = append(got, string(e.Raw))
+		}
+		return next(e)
+	})
+	if err := Process(strings.NewReader(src), &opts, chain); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"json ", "logfmt ann", "not structured", "", "last"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
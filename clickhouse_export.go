@@ -0,0 +1,98 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// clickhouseExporter inserts events through the ClickHouse HTTP interface,
+// as JSONEachRow.
+type clickhouseExporter struct {
+	endpoint string
+	user     *url.Userinfo
+	database string
+	table    string
+}
+
+const clickhouseTimeLayout = "2006-01-02 15:04:05.000000"
+
+// clickhouseIdentifier matches the names of tables that need no quoting.
+var clickhouseIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func newClickhouseExporter(u *url.URL, table string) (*clickhouseExporter, error) {
+	if !clickhouseIdentifier.MatchString(table) {
+		return nil, fmt.Errorf("clickhouse: want a table name of letters, digits and underscores, got %q", table)
+	}
+	scheme := "http"
+	if u.Scheme == "clickhouse+https" {
+		scheme = "https"
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host += ":8123"
+	}
+	return &clickhouseExporter{
+		endpoint: scheme + "://" + host + "/",
+		user:     u.User,
+		database: strings.Trim(u.Path, "/"),
+		table:    "`" + table + "`",
+	}, nil
+}
+
+// CreateTable creates the table events are inserted in, if needed. Events
+// without a time get the column's default, the epoch.
+func (c *clickhouseExporter) CreateTable() error {
+	return c.exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	time DateTime64(6, 'UTC'),
+	level LowCardinality(String),
+	message String,
+	fields Map(String, String)
+) ENGINE = MergeTree ORDER BY time`, c.table), nil)
+}
+
+func (c *clickhouseExporter) Insert(events []*Event) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, ev := range events {
+		if err := enc.Encode(newExportRow(ev, clickhouseTimeLayout)); err != nil {
+			return err
+		}
+	}
+	return c.exec(fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", c.table), &body)
+}
+
+func (c *clickhouseExporter) exec(query string, body *bytes.Buffer) error {
+	params := url.Values{}
+	params.Set("query", query)
+	if c.database != "" {
+		params.Set("database", c.database)
+	}
+	var payload []byte
+	if body != nil {
+		payload = body.Bytes()
+	}
+	req, err := http.NewRequest(http.MethodPost, c.endpoint+"?"+params.Encode(), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	if c.user != nil {
+		password, _ := c.user.Password()
+		req.SetBasicAuth(c.user.Username(), password)
+	}
+	resp, err := exportClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("clickhouse: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package humanlog
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClickhouseExporter(t *testing.T) {
+	type request struct {
+		query, database, user, body string
+	}
+	var got []request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		user, _, _ := r.BasicAuth()
+		got = append(got, request{r.URL.Query().Get("query"), r.URL.Query().Get("database"), user, string(body)})
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(strings.Replace(ts.URL, "http://", "clickhouse://ann:secret@", 1) + "/logs")
+	c, err := newClickhouseExporter(u, "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	err = c.Insert([]*Event{
+		{Time: time.Date(2024, 1, 2, 10, 0, 0, 5000, time.UTC), Level: "info", Message: "served", Fields: map[string]string{"status": "200", "path": `"/"`}},
+		{Level: "warn", Message: "no time"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("want 2 requests, got %d", len(got))
+	}
+	if !strings.HasPrefix(got[0].query, "CREATE TABLE IF NOT EXISTS `events` (") || got[0].database != "logs" || got[0].user != "ann" {
+		t.Errorf("want the table created in logs, got %+v", got[0])
+	}
+	if want := "INSERT INTO `events` FORMAT JSONEachRow"; got[1].query != want {
+		t.Errorf("want %q, got %q", want, got[1].query)
+	}
+	want := `{"time":"2024-01-02 10:00:00.000005","level":"info","message":"served","fields":{"path":"/","status":"200"}}` + "\n" +
+		`{"level":"warn","message":"no time","fields":{}}` + "\n"
+	if got[1].body != want {
+		t.Errorf("want\n%s\ngot\n%s", want, got[1].body)
+	}
+}
+
+func TestClickhouseExporter_TableName(t *testing.T) {
+	u, _ := url.Parse("clickhouse://localhost/logs")
+	for _, table := range []string{"events; DROP TABLE users", "a`b", "1events", ""} {
+		if _, err := newClickhouseExporter(u, table); err == nil {
+			t.Errorf("%q: want an error", table)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ParseClockOffset parses a correction of the clock of a source, like
+// web-2=+2.5s or db=-300ms.
+func ParseClockOffset(text string) (string, time.Duration, error) {
+	i := strings.LastIndex(text, "=")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("want source=offset, like web-2=+2.5s")
+	}
+	d, err := time.ParseDuration(strings.TrimPrefix(text[i+1:], "+"))
+	if err != nil {
+		return "", 0, err
+	}
+	return text[:i], d, nil
+}
+
+// clockOffsetStage moves the time of the entries of the sources whose clock
+// is off by the offsets they were given.
+func clockOffsetStage(opts *HandlerOptions) Stage {
+	return func(e *Entry, next Next) error {
+		if e.h != nil {
+			if d, ok := opts.ClockOffsets[opts.sourceOf(e.Event)]; ok {
+				e.h.shiftTime(d)
+				e.Event = e.h.event()
+			}
+		}
+		return next(e)
+	}
+}
+
+const (
+	// skewSamples is how many entries of a source are compared with those
+	// of the other sources before telling whether its clock is off.
+	skewSamples = 32
+	// skewTolerance is how far apart the clocks of sources can be, with the
+	// time entries take to arrive, before they're said to be off.
+	skewTolerance = time.Second
+)
+
+// clockSkew notices the sources, in a stream interleaving several of them,
+// whose clock is off: either consistently ahead or behind the others, or
+// going back in time. Each source is warned about once.
+type clockSkew struct {
+	sources    map[string]*sourceClock
+	lastSource string
+	lastTime   time.Time
+}
+
+type sourceClock struct {
+	last   time.Time
+	deltas []time.Duration
+	next   int
+	// skewed tells whether the clock was said to be off, after which the
+	// other sources aren't compared with it anymore
+	skewed      bool
+	steppedBack bool
+}
+
+func newClockSkew() *clockSkew {
+	return &clockSkew{sources: make(map[string]*sourceClock)}
+}
+
+// observe warns about the clock of the source of ev, before ev, if it's
+// found to be off.
+func (c *clockSkew) observe(dst io.Writer, opts *HandlerOptions, ev *Event) {
+	source := opts.sourceOf(ev)
+	if source == "" || ev.Time.IsZero() {
+		return
+	}
+	sc, ok := c.sources[source]
+	if !ok {
+		sc = &sourceClock{deltas: make([]time.Duration, 0, skewSamples)}
+		c.sources[source] = sc
+	}
+
+	if back := sc.last.Sub(ev.Time); !sc.last.IsZero() && back > skewTolerance && !sc.steppedBack {
+		sc.steppedBack = true
+		opts.DiagnosticColor.Fprintf(dst, "── the clock of %s went back %s\n", source, back)
+	}
+	sc.last = ev.Time
+
+	// the entries of the other sources that came right before are the best
+	// guess of what time it was
+	if c.lastSource != "" && c.lastSource != source && !sc.skewed && !c.sources[c.lastSource].skewed {
+		sc.add(ev.Time.Sub(c.lastTime))
+		if len(sc.deltas) == skewSamples {
+			if d := sc.median(); d > skewTolerance || d < -skewTolerance {
+				sc.skewed = true
+				way := "ahead of"
+				if d < 0 {
+					way = "behind"
+				}
+				// the entries being apart, it's no more precise than this
+				fix := -d.Round(100 * time.Millisecond)
+				sign := "+"
+				if fix < 0 {
+					sign = ""
+				}
+				opts.DiagnosticColor.Fprintf(dst, "── the clock of %s looks %s %s the other sources, --clock-offset %s=%s%s would make up for it\n",
+					source, absDuration(fix), way, source, sign, fix)
+			}
+		}
+	}
+	c.lastSource, c.lastTime = source, ev.Time
+}
+
+func (sc *sourceClock) add(d time.Duration) {
+	if len(sc.deltas) < skewSamples {
+		sc.deltas = append(sc.deltas, d)
+		return
+	}
+	sc.deltas[sc.next] = d
+	sc.next = (sc.next + 1) % skewSamples
+}
+
+func (sc *sourceClock) median() time.Duration {
+	sorted := append([]time.Duration(nil), sc.deltas...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
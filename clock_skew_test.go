@@ -0,0 +1,91 @@
+package humanlog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+func TestParseClockOffset(t *testing.T) {
+	source, d, err := ParseClockOffset("web-2=+2.5s")
+	if err != nil || source != "web-2" || d != 2500*time.Millisecond {
+		t.Errorf("want web-2 and 2.5s, got %q, %v, %v", source, d, err)
+	}
+	if _, d, err := ParseClockOffset("db=-300ms"); err != nil || d != -300*time.Millisecond {
+		t.Errorf("want -300ms, got %v, %v", d, err)
+	}
+	if _, _, err := ParseClockOffset("+2s"); err == nil {
+		t.Error("want an error without a source")
+	}
+}
+
+// skewedStream interleaves the entries of web-1 and web-2, whose clock is
+// ahead by skew.
+func skewedStream(skew time.Duration) string {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	var lines []string
+	for i := 0; i < 2*skewSamples+2; i++ {
+		source, at := "web-1", start.Add(time.Duration(i)*10*time.Millisecond)
+		if i%2 == 1 {
+			source, at = "web-2", at.Add(skew)
+		}
+		lines = append(lines, fmt.Sprintf(`{"time": %q, "msg": "m%d", "service": %q}`, at.Format(time.RFC3339Nano), i, source))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestClockSkew(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	opts := *DefaultOptions
+	var out bytes.Buffer
+	if err := Scanner(strings.NewReader(skewedStream(2500*time.Millisecond)), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+	warnings := 0
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.HasPrefix(line, "── the clock of") {
+			warnings++
+			if !strings.Contains(line, "2.5s ahead of") || !strings.Contains(line, "--clock-offset web-2=-2.5s") {
+				t.Errorf("want web-2 said to be 2.5s ahead, got %q", line)
+			}
+		}
+	}
+	if warnings != 1 {
+		t.Errorf("want a single warning, got %d in\n%s", warnings, out.String())
+	}
+
+	// corrected, it's not off anymore
+	opts.ClockOffsets = map[string]time.Duration{"web-2": -2500 * time.Millisecond}
+	out.Reset()
+	if err := Scanner(strings.NewReader(skewedStream(2500*time.Millisecond)), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "── the clock of") {
+		t.Errorf("want no warning once corrected, got\n%s", out.String())
+	}
+}
+
+func TestClockSteppingBack(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	src := strings.Join([]string{
+		`{"time": "2024-01-02T10:00:05Z", "msg": "a", "service": "db"}`,
+		`{"time": "2024-01-02T10:00:01Z", "msg": "b", "service": "db"}`,
+		`{"time": "2024-01-02T10:00:00Z", "msg": "c", "service": "db"}`,
+	}, "\n")
+	opts := *DefaultOptions
+	var out bytes.Buffer
+	if err := Scanner(strings.NewReader(src), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(out.String(), "── the clock of db went back 4s"); got != 1 {
+		t.Errorf("want the clock said to go back once, got\n%s", out.String())
+	}
+}
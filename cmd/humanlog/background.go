@@ -0,0 +1,21 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/zbartl/humanlog"
+)
+
+// backgroundTimeout is how long to wait for the terminal to tell the color
+// of its background, for the terminals that never do.
+const backgroundTimeout = 200 * time.Millisecond
+
+// detectLightBg tells whether the terminal has a light background, asking
+// it first, then from COLORFGBG. ok is false if neither tells.
+func detectLightBg() (light, ok bool) {
+	if light, ok := queryBackground(backgroundTimeout); ok {
+		return light, true
+	}
+	return humanlog.BackgroundFromColorFGBG(os.Getenv("COLORFGBG"))
+}
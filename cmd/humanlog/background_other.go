@@ -0,0 +1,12 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package main
+
+import "time"
+
+// queryBackground can't put the terminal in raw mode here, to read its
+// reply, so COLORFGBG is all there is to go by.
+func queryBackground(timeout time.Duration) (light, ok bool) {
+	return false, false
+}
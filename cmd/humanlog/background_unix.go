@@ -0,0 +1,56 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/zbartl/humanlog"
+)
+
+// queryBackground asks the terminal the color of its background, with the
+// terminal put in raw mode for its reply not to be echoed, nor to wait for
+// a newline.
+func queryBackground(timeout time.Duration) (light, ok bool) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return false, false
+	}
+	defer tty.Close()
+
+	fd := tty.Fd()
+	var saved syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(&saved))); errno != 0 {
+		return false, false
+	}
+	raw := saved
+	raw.Lflag &^= syscall.ICANON | syscall.ECHO
+	// reads return after a tenth of a second without input
+	raw.Cc[syscall.VMIN], raw.Cc[syscall.VTIME] = 0, 1
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(&raw))); errno != 0 {
+		return false, false
+	}
+	defer syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(&saved)))
+
+	if _, err := tty.WriteString(humanlog.BackgroundQuery); err != nil {
+		return false, false
+	}
+	var reply []byte
+	buf := make([]byte, 64)
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		n, err := tty.Read(buf)
+		if err != nil && n == 0 {
+			return false, false
+		}
+		reply = append(reply, buf[:n]...)
+		if light, ok := humanlog.ReadBackgroundReply(reply); ok {
+			return light, true
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli"
+	"github.com/zbartl/humanlog"
+)
+
+const bashCompletion = `_humanlog() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+    local IFS=$'\n'
+    COMPREPLY=($(humanlog __complete "$prev" "$cur" 2>/dev/null))
+}
+complete -o default -F _humanlog humanlog
+`
+
+const zshCompletion = `#compdef humanlog
+_humanlog() {
+    local -a candidates
+    candidates=("${(@f)$(humanlog __complete "${words[CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)}")
+    if [[ -n "${candidates[1]}" ]]; then
+        compadd -- "${candidates[@]}"
+    else
+        _files
+    fi
+}
+compdef _humanlog humanlog
+`
+
+const fishCompletion = `complete -c humanlog -a '(humanlog __complete (commandline -opc)[-1] (commandline -ct) 2>/dev/null)'
+`
+
+func completionCommand() cli.Command {
+	return cli.Command{
+		Name:      "completion",
+		Usage:     "prints the completion script of a shell, i.e. source <(humanlog completion bash), or humanlog completion fish | source",
+		ArgsUsage: "bash|zsh|fish",
+		Action: func(c *cli.Context) error {
+			switch shell := c.Args().First(); shell {
+			case "bash":
+				fmt.Print(bashCompletion)
+			case "zsh":
+				fmt.Print(zshCompletion)
+			case "fish":
+				fmt.Print(fishCompletion)
+			default:
+				log.Fatalf("no completion for shell %q, only for bash, zsh and fish", shell)
+			}
+			return nil
+		},
+	}
+}
+
+// fieldFlags are the flags taking the name of a field, whose values are
+// completed with the fields seen recently.
+var fieldFlags = []string{
+	"skip", "keep", "message-fields", "time-fields", "level-fields", "source-fields",
+	"caller-fields", "query-fields", "spark", "count-by", "m", "t", "l",
+}
+
+// completeCommand prints what the word being typed could be, given the
+// word before it, one per line. The scripts of the completion command call
+// it.
+func completeCommand() cli.Command {
+	return cli.Command{
+		Name:            "__complete",
+		Hidden:          true,
+		SkipFlagParsing: true,
+		Action: func(c *cli.Context) error {
+			prev, cur := c.Args().Get(0), c.Args().Get(1)
+			for _, candidate := range completions(c.App, prev, cur) {
+				if strings.HasPrefix(candidate, cur) {
+					fmt.Println(candidate)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func completions(app *cli.App, prev, cur string) []string {
+	if strings.HasPrefix(cur, "-") {
+		var names []string
+		for _, f := range app.Flags {
+			for _, name := range strings.Split(f.GetName(), ",") {
+				if name = strings.TrimSpace(name); len(name) > 1 {
+					names = append(names, "--"+name)
+				}
+			}
+		}
+		return names
+	}
+
+	flag := strings.TrimLeft(prev, "-")
+	switch {
+	case !strings.HasPrefix(prev, "-"):
+		if prev != "humanlog" && !strings.HasSuffix(prev, "/humanlog") {
+			return nil
+		}
+		var names []string
+		for _, cmd := range app.Commands {
+			if !cmd.Hidden {
+				names = append(names, cmd.Name)
+			}
+		}
+		return names
+	case flag == "config":
+		return configFiles()
+	case isFieldFlag(flag):
+		cache, err := humanlog.LoadFieldCache(humanlog.DefaultFieldCachePath())
+		if err != nil {
+			return nil
+		}
+		return cache.Names()
+	}
+	return flagChoices(flag)
+}
+
+// flagChoices are the values a flag can take, for the flags taking one of
+// a few.
+func flagChoices(flag string) []string {
+	switch flag {
+	case "theme":
+		var names []string
+		for _, t := range humanlog.Themes {
+			names = append(names, t.Name)
+		}
+		return names
+	case "preset":
+		return humanlog.PresetNames()
+	case "output":
+		return []string{"terminal", "html", "ndjson-normalized"}
+	case "encoding":
+		return []string{"auto", "utf-8", "utf-16le", "utf-16be", "latin-1"}
+	case "duplicate-keys":
+		return humanlog.DuplicateKeyPolicies
+	case "min-level":
+		return []string{"trace", "debug", "info", "warn", "error", "fatal"}
+	case "fields-json":
+		return []string{"end", "line"}
+	}
+	return nil
+}
+
+func isFieldFlag(flag string) bool {
+	for _, f := range fieldFlags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// configFiles lists the configuration files next to the default one, for
+// keeping one per project or environment.
+func configFiles() []string {
+	path := humanlog.DefaultConfigPath()
+	if path == "" {
+		return nil
+	}
+	dir := filepath.Dir(path)
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, fi := range infos {
+		if !fi.IsDir() && filepath.Ext(fi.Name()) == ".json" {
+			files = append(files, filepath.Join(dir, fi.Name()))
+		}
+	}
+	return files
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"io"
+	"os"
+)
+
+// newConsole returns a writer onto f, a terminal or a file. Terminals
+// interpret escape sequences themselves.
+func newConsole(f *os.File) io.Writer {
+	return f
+}
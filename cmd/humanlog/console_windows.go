@@ -0,0 +1,34 @@
+package main
+
+import (
+	"io"
+	"os"
+	"syscall"
+
+	"github.com/mattn/go-colorable"
+)
+
+const enableVirtualTerminalProcessing = 0x4
+
+var procSetConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+// newConsole returns a writer onto f, a console or a file. Consoles that can
+// interpret escape sequences themselves, since Windows 10, are asked to, so
+// that 256 colors, hyperlinks and erasing lines work. Older consoles get the
+// colors translated to console attributes. Either way, os.File writes text
+// to consoles as UTF-16.
+func newConsole(f *os.File) io.Writer {
+	h := syscall.Handle(f.Fd())
+	var mode uint32
+	if err := syscall.GetConsoleMode(h, &mode); err != nil {
+		// not a console, redirected to a file or a pipe
+		return f
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return f
+	}
+	if ok, _, _ := procSetConsoleMode.Call(uintptr(h), uintptr(mode|enableVirtualTerminalProcessing)); ok != 0 {
+		return f
+	}
+	return colorable.NewColorable(f)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/zbartl/humanlog"
+)
+
+// listenControl listens for the commands of --control on a Unix socket at
+// addr, or on a loopback TCP address like localhost:7070. The socket is
+// only for its owner, and is removed by the returned func.
+func listenControl(addr string) (net.Listener, func(), error) {
+	if host, _, err := net.SplitHostPort(addr); err == nil && !strings.ContainsAny(addr, `/\`) {
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return nil, nil, fmt.Errorf("%s isn't a loopback address, anyone reaching it could drive humanlog", host)
+		}
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ln, func() { ln.Close() }, nil
+	}
+
+	// a socket left behind by a humanlog that's gone is replaced, one that's
+	// still answering isn't
+	if fi, err := os.Stat(addr); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", addr); err == nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("%s is in use by another humanlog", addr)
+		}
+		os.Remove(addr)
+	}
+	ln, err := net.Listen("unix", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.Chmod(addr, 0600); err != nil {
+		ln.Close()
+		return nil, nil, err
+	}
+	return ln, func() { ln.Close() }, nil
+}
+
+// serveControl runs the commands of --control in the background, once
+// everything they can drive is set up.
+func serveControl(ctl *humanlog.Control, ln net.Listener) {
+	if ctl == nil {
+		return
+	}
+	go func() {
+		if err := ctl.Serve(ln); err != nil && !isClosed(err) {
+			log.Printf("stopped taking commands: %v", err)
+		}
+	}()
+	log.Printf("taking commands on %s", ln.Addr())
+}
+
+// isClosed tells whether err comes from using a listener that was closed.
+func isClosed(err error) bool {
+	return strings.Contains(err.Error(), "use of closed network connection")
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+	"github.com/zbartl/humanlog"
+)
+
+// envNames are the environment variables that aren't named after their flag.
+var envNames = map[string]string{
+	"skip": "HUMANLOG_SKIP_FIELDS",
+	"keep": "HUMANLOG_KEEP_FIELDS",
+}
+
+// envName is the environment variable setting a flag, i.e.
+// HUMANLOG_TIME_FORMAT for --time-format.
+func envName(flag string) string {
+	if name, ok := envNames[flag]; ok {
+		return name
+	}
+	return "HUMANLOG_" + strings.ToUpper(strings.Replace(flag, "-", "_", -1))
+}
+
+// flagName is the long name of a flag, without its aliases.
+func flagName(f cli.Flag) string {
+	return strings.TrimSpace(strings.Split(f.GetName(), ",")[0])
+}
+
+// configPath is where to read the configuration file from, and whether it
+// was asked for explicitly.
+func configPath(c *cli.Context) (string, bool) {
+	if c.GlobalIsSet("config") {
+		return c.GlobalString("config"), true
+	}
+	if path := os.Getenv(envName("config")); path != "" {
+		return path, true
+	}
+	return c.GlobalString("config"), false
+}
+
+// applyDefaults sets the flags that weren't given on the command line from
+// the configuration file or, failing that, from the environment.
+func applyDefaults(c *cli.Context, flags []cli.Flag) error {
+	var cfg *humanlog.Config
+	if path, explicit := configPath(c); path != "" {
+		var err error
+		cfg, err = humanlog.ReadConfig(path)
+		switch {
+		case os.IsNotExist(err) && !explicit:
+			cfg = nil
+		case err != nil:
+			return fmt.Errorf("can't read configuration: %v", err)
+		}
+	}
+
+	known := make(map[string]bool, len(flags))
+	for _, f := range flags {
+		name := flagName(f)
+		known[name] = true
+		if name == "config" || c.GlobalIsSet(name) {
+			continue
+		}
+		_, isSlice := f.(cli.StringSliceFlag)
+
+		var values []string
+		if cfg != nil {
+			if v, ok := cfg.Options[name]; ok {
+				var err error
+				if values, err = optionValues(v); err != nil {
+					return fmt.Errorf("can't read configuration: option %q: %v", name, err)
+				}
+			}
+		}
+		if values == nil {
+			v, ok := os.LookupEnv(envName(name))
+			if !ok {
+				continue
+			}
+			if isSlice {
+				values = strings.Split(v, ",")
+			} else {
+				values = []string{v}
+			}
+		}
+		for _, v := range values {
+			if err := c.GlobalSet(name, v); err != nil {
+				return fmt.Errorf("invalid value %q for --%s: %v", v, name, err)
+			}
+		}
+	}
+
+	if cfg != nil {
+		for name := range cfg.Options {
+			if !known[name] {
+				return fmt.Errorf("can't read configuration: unknown option %q", name)
+			}
+		}
+	}
+	return nil
+}
+
+// optionValues turns an option of the configuration file into the values to
+// give its flag.
+func optionValues(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, elem := range v {
+			s, ok := elem.(string)
+			if !ok {
+				return nil, fmt.Errorf("want a list of strings")
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("want a string, number, boolean or list of strings")
+	}
+}
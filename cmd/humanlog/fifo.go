@@ -0,0 +1,36 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// openFIFO opens the named pipe at path, creating it if it doesn't exist.
+// It's opened for writing too, so that humanlog always holds a writer and
+// the input doesn't end when the processes writing into it go away. remove
+// deletes the pipe if it was created.
+func openFIFO(path string) (f *os.File, remove func(), err error) {
+	remove = func() {}
+	fi, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		if err := syscall.Mkfifo(path, 0600); err != nil {
+			return nil, nil, &os.PathError{Op: "mkfifo", Path: path, Err: err}
+		}
+		remove = func() { os.Remove(path) }
+	case err != nil:
+		return nil, nil, err
+	case fi.Mode()&os.ModeNamedPipe == 0:
+		return nil, nil, fmt.Errorf("%s exists and isn't a named pipe", path)
+	}
+	f, err = os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		remove()
+		return nil, nil, err
+	}
+	return f, remove, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+	"github.com/zbartl/humanlog"
+)
+
+// capabilities describe what this humanlog can do, for the tools building
+// on it to know without parsing its help.
+type capabilities struct {
+	Version  string                 `json:"version"`
+	Formats  []humanlog.InputFormat `json:"formats"`
+	Presets  []presetInfo           `json:"presets"`
+	Themes   []string               `json:"themes"`
+	Options  []optionInfo           `json:"options"`
+	Commands []commandInfo          `json:"commands"`
+}
+
+type presetInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// optionInfo describes a flag, also settable in the configuration file's
+// options under its name, or with its environment variable.
+type optionInfo struct {
+	Name    string      `json:"name"`
+	Aliases []string    `json:"aliases,omitempty"`
+	Type    string      `json:"type"`
+	Default interface{} `json:"default,omitempty"`
+	Usage   string      `json:"usage"`
+	Env     string      `json:"env"`
+	Values  []string    `json:"values,omitempty"`
+}
+
+type commandInfo struct {
+	Name  string `json:"name"`
+	Usage string `json:"usage"`
+}
+
+func formatsCommand() cli.Command {
+	return cli.Command{
+		Name:  "formats",
+		Usage: "lists the input formats humanlog recognizes, and with --json its presets, themes, options and commands too, for other tools to build on",
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "json",
+				Usage: "describe everything as a JSON object",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if !c.Bool("json") {
+				w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+				for _, f := range humanlog.InputFormats {
+					fmt.Fprintf(w, "%s\t%s\n", f.Name, f.Description)
+				}
+				return w.Flush()
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(describeApp(c.App)); err != nil {
+				log.Fatalf("can't describe humanlog: %v", err)
+			}
+			return nil
+		},
+	}
+}
+
+func describeApp(app *cli.App) *capabilities {
+	caps := &capabilities{
+		Version:  app.Version,
+		Formats:  humanlog.InputFormats,
+		Presets:  []presetInfo{},
+		Themes:   flagChoices("theme"),
+		Options:  []optionInfo{},
+		Commands: []commandInfo{},
+	}
+	for _, name := range humanlog.PresetNames() {
+		p, _ := humanlog.LookupPreset(name)
+		caps.Presets = append(caps.Presets, presetInfo{Name: p.Name, Description: p.Description})
+	}
+	for _, f := range app.Flags {
+		if opt, ok := describeFlag(f); ok {
+			caps.Options = append(caps.Options, opt)
+		}
+	}
+	for _, cmd := range app.Commands {
+		if !cmd.Hidden {
+			caps.Commands = append(caps.Commands, commandInfo{Name: cmd.Name, Usage: cmd.Usage})
+		}
+	}
+	return caps
+}
+
+func describeFlag(f cli.Flag) (optionInfo, bool) {
+	names := strings.Split(f.GetName(), ",")
+	opt := optionInfo{Name: flagName(f)}
+	for _, alias := range names[1:] {
+		opt.Aliases = append(opt.Aliases, strings.TrimSpace(alias))
+	}
+	opt.Env = envName(opt.Name)
+	opt.Values = flagChoices(opt.Name)
+
+	switch f := f.(type) {
+	case cli.BoolFlag:
+		opt.Type, opt.Usage, opt.Default = "bool", f.Usage, false
+	case cli.BoolTFlag:
+		opt.Type, opt.Usage, opt.Default = "bool", f.Usage, true
+	case cli.IntFlag:
+		opt.Type, opt.Usage, opt.Default = "int", f.Usage, f.Value
+	case cli.StringFlag:
+		opt.Type, opt.Usage = "string", f.Usage
+		if f.Value != "" {
+			opt.Default = f.Value
+		}
+	case cli.DurationFlag:
+		opt.Type, opt.Usage, opt.Default = "duration", f.Usage, f.Value.String()
+	case cli.StringSliceFlag:
+		opt.Type, opt.Usage = "list", f.Usage
+		if f.Value != nil && len(*f.Value) != 0 {
+			opt.Default = []string(*f.Value)
+		}
+	default:
+		return opt, false
+	}
+	return opt, true
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/urfave/cli"
+	"github.com/zbartl/humanlog"
+)
+
+func inferCommand() cli.Command {
+	lines := cli.IntFlag{
+		Name:  "lines",
+		Usage: "how many lines of stdin to look at",
+		Value: 500,
+	}
+	write := cli.StringFlag{
+		Name:  "write",
+		Usage: "write the configuration to this file instead of printing it, if it doesn't exist yet",
+	}
+
+	return cli.Command{
+		Name:  "infer",
+		Usage: "reads a sample of logs from stdin and prints a configuration for the fields holding their time, level and message",
+		Flags: []cli.Flag{lines, write},
+		Action: func(c *cli.Context) error {
+			inf, err := humanlog.Infer(os.Stdin, c.Int(lines.Name))
+			if err != nil {
+				log.Fatalf("can't read the sample: %v", err)
+			}
+			if inf.Parsed == 0 {
+				log.Fatalf("none of the %d lines read is JSON or logfmt", inf.Lines)
+			}
+			log.Printf("%d of %d lines are structured", inf.Parsed, inf.Lines)
+			if inf.TimeField != "" {
+				log.Printf("time: %s, as %s", inf.TimeField, inf.TimeFormat)
+			}
+			if inf.LevelField != "" {
+				log.Printf("level: %s, as %s", inf.LevelField, inf.LevelScheme)
+				if inf.LevelScheme == "syslog" {
+					log.Print("syslog severities aren't recognized, the levels will show as ???")
+				}
+			}
+			if inf.MessageField != "" {
+				log.Printf("message: %s", inf.MessageField)
+			}
+
+			out, err := json.MarshalIndent(inf.Config(), "", "  ")
+			if err != nil {
+				return err
+			}
+			out = append(out, '\n')
+			path := c.String(write.Name)
+			if path == "" {
+				_, err = os.Stdout.Write(out)
+				return err
+			}
+			if _, err := os.Stat(path); err == nil {
+				log.Fatalf("%s already exists, merge this in by hand:\n%s", path, out)
+			}
+			if err := ioutil.WriteFile(path, out, 0644); err != nil {
+				log.Fatalf("can't write the configuration: %v", err)
+			}
+			log.Printf("wrote %s", path)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/zbartl/humanlog"
+)
+
+// readCommands reads commands from the terminal, one per line, while the
+// entries are read from stdin. Most change the filters, and the active
+// filters are printed after each change. With truncs, show N prints the
+// value truncated as …[N] in full.
+func readCommands(filters *humanlog.LiveFilters, truncs *humanlog.Truncations) {
+	name := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		name = "CONIN$"
+	}
+	tty, err := os.Open(name)
+	if err != nil {
+		log.Printf("can't read commands from the terminal: %v", err)
+		return
+	}
+	log.Print("type +key=value or -key=value and enter to show or hide entries, undo or clear to go back")
+	go func() {
+		defer tty.Close()
+		sc := bufio.NewScanner(tty)
+		for sc.Scan() {
+			cmd := strings.TrimSpace(sc.Text())
+			switch {
+			case cmd == "":
+			case strings.HasPrefix(cmd, "show "):
+				showTruncated(truncs, strings.TrimSpace(cmd[len("show "):]))
+			default:
+				if err := filters.Exec(cmd); err != nil {
+					log.Print(err)
+					continue
+				}
+				log.Print(filters)
+			}
+		}
+	}()
+}
+
+func showTruncated(truncs *humanlog.Truncations, arg string) {
+	if truncs == nil {
+		log.Print("values are only numbered with --truncation-markers")
+		return
+	}
+	n, err := strconv.Atoi(strings.Trim(arg, "[]"))
+	if err != nil {
+		log.Printf("want the number of a truncated value, got %q", arg)
+		return
+	}
+	key, value, ok := truncs.Get(n)
+	if !ok {
+		log.Printf("no truncated value [%d], only the last ones are kept", n)
+		return
+	}
+	log.Printf("[%d] %s=%s", n, key, value)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/urfave/cli"
+	"github.com/zbartl/humanlog"
+)
+
+func journalCommand() cli.Command {
+	unit := cli.StringSliceFlag{
+		Name:  "unit, u",
+		Usage: "only show the entries of this systemd unit, can be repeated",
+	}
+	follow := cli.BoolFlag{
+		Name:  "follow, f",
+		Usage: "keep showing the entries as they're written",
+	}
+	boot := cli.StringFlag{
+		Name:  "boot, b",
+		Usage: "only show the entries of this boot, 0 for the current one, -1 for the one before, or a boot ID",
+	}
+	lines := cli.IntFlag{
+		Name:  "lines, n",
+		Usage: "start with this many of the latest entries (0 shows them all)",
+	}
+
+	return cli.Command{
+		Name:  "journal",
+		Usage: "reads the systemd journal, with its timestamps to the microsecond, priorities and fields",
+		Flags: []cli.Flag{unit, follow, boot, lines},
+		Action: func(c *cli.Context) error {
+			opts := globalOptions(c)
+			preset, _ := humanlog.LookupPreset("journal")
+			preset.Apply(opts)
+
+			args := []string{"--output=json", "--no-pager"}
+			for _, u := range c.StringSlice("unit") {
+				args = append(args, "--unit="+u)
+			}
+			if c.Bool("follow") {
+				args = append(args, "--follow")
+			}
+			if c.IsSet("boot") {
+				args = append(args, "--boot="+c.String("boot"))
+			}
+			if n := c.Int("lines"); n > 0 {
+				args = append(args, "--lines="+strconv.Itoa(n))
+			}
+
+			// the journal's files are read by journalctl, which knows their
+			// format across systemd versions, exporting them as JSON
+			cmd := exec.Command("journalctl", args...)
+			cmd.Stderr = os.Stderr
+			out, err := cmd.StdoutPipe()
+			if err != nil {
+				log.Fatalf("can't read the journal: %v", err)
+			}
+			if err := cmd.Start(); err != nil {
+				log.Fatalf("can't read the journal: %v", err)
+			}
+			if err := humanlog.Scanner(out, newConsole(os.Stdout), opts); err != nil {
+				log.Fatalf("scanning caught an error: %v", err)
+			}
+			if err := cmd.Wait(); err != nil {
+				log.Fatalf("journalctl: %v", err)
+			}
+			return nil
+		},
+	}
+}
@@ -1,21 +1,36 @@
 package main
 
 import (
+	"fmt"
+	"io"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"regexp"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/aybabtme/rgbterm"
-	"github.com/mattn/go-colorable"
+	"github.com/mattn/go-isatty"
 	"github.com/urfave/cli"
 	"github.com/zbartl/humanlog"
 )
 
 var Version = "devel"
 
+// atExit are run by fatalf before exiting, in reverse order, for what calls
+// deferred by the action would have done, like sending what the sinks
+// queued.
+var atExit []func()
+
 func fatalf(c *cli.Context, format string, args ...interface{}) {
 	log.Printf(format, args...)
+	for i := len(atExit) - 1; i >= 0; i-- {
+		atExit[i]()
+	}
 	cli.ShowAppHelp(c)
 	os.Exit(1)
 }
@@ -25,7 +40,7 @@ func main() {
 
 	prefix := rgbterm.FgString(app.Name+"> ", 99, 99, 99)
 
-	log.SetOutput(colorable.NewColorableStderr())
+	log.SetOutput(newConsole(os.Stderr))
 	log.SetFlags(0)
 	log.SetPrefix(prefix)
 	err := app.Run(os.Args)
@@ -41,13 +56,13 @@ func newApp() *cli.App {
 
 	skipFlag := cli.StringSliceFlag{
 		Name:  "skip",
-		Usage: "keys to skip when parsing a log entry",
+		Usage: "keys to skip when parsing a log entry, globs like kubernetes.* and *_id, or JSON Pointers like /labels/app.kubernetes.io~1name",
 		Value: &skip,
 	}
 
 	keepFlag := cli.StringSliceFlag{
 		Name:  "keep",
-		Usage: "keys to keep when parsing a log entry",
+		Usage: "keys to keep when parsing a log entry, globs like kubernetes.* and *_id, or JSON Pointers like /service.name",
 		Value: &keep,
 	}
 
@@ -61,6 +76,70 @@ func newApp() *cli.App {
 		Usage: "skip keys that have the same value than the previous entry",
 	}
 
+	lineWidth := cli.IntFlag{
+		Name:  "line-width",
+		Usage: "keep each entry on a line this wide (i.e. $COLUMNS), dropping the fields that don't fit, those of lowest --field-priority first",
+	}
+
+	fieldPriority := cli.StringSliceFlag{
+		Name:  "field-priority",
+		Usage: "priority of a field for --line-width, like user_id=10 to keep it or debug_info=-1 to drop it first (0 for the others), can be repeated",
+	}
+
+	fieldType := cli.StringSliceFlag{
+		Name:  "type",
+		Usage: "treat a field as a string, int, float, bool or time whatever its values look like, like user_id=string or started_at=time, can be repeated",
+	}
+
+	linkLabels := cli.BoolFlag{
+		Name:  "link-labels",
+		Usage: "show the links to Sentry issues, Grafana dashboards, S3 objects and those of the configuration file's links as a short label, like sentry:4123, linking to them",
+	}
+
+	fieldsJSON := cli.StringFlag{
+		Name:  "fields-json",
+		Usage: "show the fields as a compact JSON object, easy to copy, at the end of the line (end) or on the line below (line), instead of key=value pairs",
+	}
+
+	hideNull := cli.BoolFlag{
+		Name:  "hide-null",
+		Usage: "hide the keys whose value is null",
+	}
+
+	hideEncoded := cli.BoolTFlag{
+		Name:  "hide-encoded",
+		Usage: "show the values that look like base64 or hex payloads as their size, like base64[182B]",
+	}
+
+	decodeFields := cli.StringSlice{}
+	decodeFieldsFlag := cli.StringSliceFlag{
+		Name:  "decode-field",
+		Usage: "decode the base64 or hex payload of a field on lines of its own, as text or a hexdump. (i.e. payload)",
+		Value: &decodeFields,
+	}
+
+	unnestMessages := cli.BoolTFlag{
+		Name:  "unnest-messages",
+		Usage: "parse the messages of JSON entries holding a JSON document of their own, taking its time, level and message, and its other fields prefixed with the key of the message (i.e. msg.user_id)",
+	}
+
+	expandArrays := cli.BoolFlag{
+		Name:  "expand-arrays",
+		Usage: "show the items of arrays on lines of their own, rather than their length and first item, like list[5](web,…)",
+	}
+
+	expandArrayFields := cli.StringSlice{}
+	expandArrayFieldsFlag := cli.StringSliceFlag{
+		Name:  "expand-array",
+		Usage: "show the items of the array of a field on lines of their own. (i.e. tags)",
+		Value: &expandArrayFields,
+	}
+
+	stickyHeader := cli.IntFlag{
+		Name:  "sticky-header",
+		Usage: "with --skip-unchanged, reprint the keys it hides every this many entries, for whoever starts reading mid stream",
+	}
+
 	truncates := cli.BoolFlag{
 		Name:  "truncate",
 		Usage: "truncates values that are longer than --truncate-length",
@@ -74,7 +153,7 @@ func newApp() *cli.App {
 
 	lightBg := cli.BoolFlag{
 		Name:  "light-bg",
-		Usage: "use black as the base foreground color (for terminals with light backgrounds)",
+		Usage: "use black as the base foreground color (for terminals with light backgrounds), instead of asking the terminal which background it has; --light-bg=false for dark ones",
 	}
 
 	timeFormat := cli.StringFlag{
@@ -90,26 +169,440 @@ func newApp() *cli.App {
 
 	messageFields := cli.StringSlice{}
 	messageFieldsFlag := cli.StringSliceFlag{
-		Name:   "message-fields, m",
-		Usage:  "Custom JSON fields to search for the log message. (i.e. mssge, data.body.message)",
-		EnvVar: "HUMANLOG_MESSAGE_FIELDS",
-		Value:  &messageFields,
+		Name:  "message-fields, m",
+		Usage: "Custom JSON fields to search for the log message. (i.e. mssge, data.body.message, or JSON Pointers like /data/items/0/message)",
+		Value: &messageFields,
 	}
 
 	timeFields := cli.StringSlice{}
 	timeFieldsFlag := cli.StringSliceFlag{
-		Name:   "time-fields, t",
-		Usage:  "Custom JSON fields to search for the log time. (i.e. logtime, data.body.datetime)",
-		EnvVar: "HUMANLOG_TIME_FIELDS",
-		Value:  &timeFields,
+		Name:  "time-fields, t",
+		Usage: "Custom JSON fields to search for the log time. (i.e. logtime, data.body.datetime, or JSON Pointers like /@timestamp)",
+		Value: &timeFields,
 	}
 
 	levelFields := cli.StringSlice{}
 	levelFieldsFlag := cli.StringSliceFlag{
-		Name:   "level-fields, l",
-		Usage:  "Custom JSON fields to search for the log level. (i.e. somelevel, data.level)",
-		EnvVar: "HUMANLOG_LEVEL_FIELDS",
-		Value:  &levelFields,
+		Name:  "level-fields, l",
+		Usage: "Custom JSON fields to search for the log level. (i.e. somelevel, data.level, or JSON Pointers like /log.level)",
+		Value: &levelFields,
+	}
+
+	spark := cli.StringFlag{
+		Name:  "spark",
+		Usage: "draw a sparkline of the recent values of this numeric field at the end of each line",
+	}
+
+	sparkWidth := cli.IntFlag{
+		Name:  "spark-width",
+		Usage: "number of recent values to draw in the --spark sparkline",
+		Value: humanlog.DefaultOptions.SparkWidth,
+	}
+
+	forward := cli.StringSlice{}
+	forwardFlag := cli.StringSliceFlag{
+		Name:  "forward",
+		Usage: "also forward parsed entries to a collector (i.e. fluent://localhost:24224?tag=app, syslog://localhost:514, syslog+tcp://localhost:514, otlp://localhost:4318 to send a span for each error entry), in the background, dropping the entries the collector can't keep up with",
+		Value: &forward,
+	}
+
+	lineNumbers := cli.BoolFlag{
+		Name:  "line-numbers",
+		Usage: "prefix each line with its line number in the input, and its byte offset when reading from a file",
+	}
+
+	strict := cli.BoolFlag{
+		Name:  "strict",
+		Usage: "annotate lines with why they couldn't be parsed, and print a summary of the reasons at the end",
+	}
+
+	gutter := cli.BoolFlag{
+		Name:  "gutter",
+		Usage: "draw a colored bar at the start of each line, one color per source (see --source-fields)",
+	}
+
+	sourceFields := cli.StringSlice{}
+	sourceFieldsFlag := cli.StringSliceFlag{
+		Name:  "source-fields",
+		Usage: "Custom fields identifying where an entry comes from. (i.e. app, kubernetes.pod)",
+		Value: &sourceFields,
+	}
+
+	callerFields := cli.StringSlice{}
+	callerFieldsFlag := cli.StringSliceFlag{
+		Name:  "caller-fields",
+		Usage: "Custom fields holding the source location an entry was logged from. (i.e. logger.caller)",
+		Value: &callerFields,
+	}
+
+	callerWidth := cli.IntFlag{
+		Name:  "caller-width",
+		Usage: "width of the column showing where entries were logged from, paths are shortened to fit (0 shows them among the other fields)",
+		Value: humanlog.DefaultOptions.CallerWidth,
+	}
+
+	fifo := cli.StringFlag{
+		Name:  "fifo",
+		Usage: "create a named pipe at this path and keep rendering what any process writes into it, instead of reading stdin",
+	}
+
+	showFingerprint := cli.BoolFlag{
+		Name:  "show-fingerprint",
+		Usage: "show a fingerprint of each entry, the same for every occurrence of a log line whatever the values in its message",
+	}
+
+	onlyFingerprints := cli.StringSlice{}
+	onlyFingerprint := cli.StringSliceFlag{
+		Name:  "only-fingerprint",
+		Usage: "only show the entries with this fingerprint (see --show-fingerprint)",
+		Value: &onlyFingerprints,
+	}
+
+	count := cli.BoolFlag{
+		Name:  "count",
+		Usage: "only print how many entries went through the filters",
+	}
+
+	countBy := cli.StringFlag{
+		Name:  "count-by",
+		Usage: "only print how many entries went through the filters for each value of this field, implies --count",
+	}
+
+	mmap := cli.BoolFlag{
+		Name:  "mmap",
+		Usage: "map a file redirected to stdin in memory instead of reading it, which is much faster for huge files (shows a progress bar when stdout isn't a terminal); the file must not be truncated or rewritten while it's read, like logrotate's copytruncate does, or humanlog is killed",
+	}
+
+	table := cli.BoolFlag{
+		Name:  "table",
+		Usage: "render the entries as rows of a table, with a column for each field all of the first --table-sample entries had",
+	}
+
+	tableSample := cli.IntFlag{
+		Name:  "table-sample",
+		Usage: "how many entries to look at before picking the columns of --table",
+		Value: humanlog.DefaultOptions.TableSample,
+	}
+
+	alerts := cli.StringSlice{}
+	alertFlag := cli.StringSliceFlag{
+		Name:  "alert",
+		Usage: "alert when entries match too often (i.e. 'level=error count>10 per 30s')",
+		Value: &alerts,
+	}
+
+	notifyCmd := cli.StringFlag{
+		Name:  "notify-cmd",
+		Usage: "shell command to run when an alert triggers, with $HUMANLOG_ALERT describing it (prints a banner if not set)",
+	}
+
+	execOn := cli.StringSliceFlag{
+		Name:  "exec-on",
+		Usage: "run a shell command with the entry as JSON on stdin when an entry matches conditions, at most every 10s unless given (i.e. 'level=fatal: ./page-me.sh', 'msg~deadlock every 5m: ./dump.sh'), can be repeated",
+	}
+
+	bellOn := cli.StringFlag{
+		Name:  "bell-on",
+		Usage: "ring the terminal's bell when entries at or above this level appear (i.e. error), at most every --bell-every",
+	}
+
+	bellCmd := cli.StringFlag{
+		Name:  "bell-cmd",
+		Usage: "with --bell-on, run this shell command instead of ringing the bell, with the entry as JSON on stdin (i.e. 'paplay /usr/share/sounds/freedesktop/stereo/bell.oga')",
+	}
+
+	bellEvery := cli.DurationFlag{
+		Name:  "bell-every",
+		Usage: "ring the bell of --bell-on at most this often",
+		Value: humanlog.DefaultBellEvery,
+	}
+
+	span := cli.StringSliceFlag{
+		Name:  "span",
+		Usage: "pair the entries starting and ending spans by a key, adding how long they took to the end entries that don't say, within 10m unless given (i.e. 'request_id: msg=started .. msg=finished', 'job: msg~^running .. msg~^done within 1h'), can be repeated",
+	}
+
+	exceptions := cli.BoolTFlag{
+		Name:  "exceptions",
+		Usage: "show unstructured exceptions and stack traces indented under the entry before them",
+	}
+
+	batches := cli.BoolTFlag{
+		Name:  "batches",
+		Usage: "show the entries of a batch, a JSON array of objects or a field holding newline delimited JSON, one by one",
+	}
+
+	numberLocale := cli.StringFlag{
+		Name:  "number-locale",
+		Usage: "separate the thousands of numeric values the way a locale does (i.e. en, de_DE, fr), auto to use $LC_NUMERIC or $LANG",
+	}
+
+	thousandsSeparator := cli.StringFlag{
+		Name:  "thousands-separator",
+		Usage: "separate the thousands of numeric values with this",
+	}
+
+	decimalSeparator := cli.StringFlag{
+		Name:  "decimal-separator",
+		Usage: "write the decimal point of numeric values as this",
+	}
+
+	floatPrecision := cli.IntFlag{
+		Name:  "float-precision",
+		Usage: "round numeric values with decimals to this many digits",
+	}
+
+	share := cli.StringFlag{
+		Name:  "share",
+		Usage: "also serve the entries live to browsers on this address (i.e. :8080), for others to watch along, with a filtering viewer on /viewer and a Connect/gRPC-Web stream on /humanlog.v1.LogService/Watch",
+	}
+
+	shareOrigins := cli.StringSlice{}
+	shareOriginsFlag := cli.StringSliceFlag{
+		Name:  "share-origin",
+		Usage: "with --share, let the scripts of the pages of this origin (i.e. http://localhost:3000) watch the entries, which only the pages served by --share can otherwise",
+		Value: &shareOrigins,
+	}
+
+	output := cli.StringFlag{
+		Name:  "output",
+		Usage: "how to write the entries, one of terminal, html (a standalone page, written to the file given as argument or stdout), ndjson-normalized (a JSON object per entry with the keys ts, level, msg, fields and source)",
+		Value: "terminal",
+	}
+
+	config := cli.StringFlag{
+		Name:  "config",
+		Usage: "configuration file to read",
+		Value: humanlog.DefaultConfigPath(),
+	}
+
+	separators := cli.DurationFlag{
+		Name:  "separators",
+		Usage: "draw a rule whenever the time of the entries crosses into a new period of this length (i.e. 1m, 1h)",
+	}
+
+	clockOffset := cli.StringSliceFlag{
+		Name:  "clock-offset",
+		Usage: "correct the time of the entries of a source whose clock is off, like web-2=+2.5s (see --source-fields), can be repeated",
+	}
+
+	assumeZone := cli.StringFlag{
+		Name:  "assume-zone",
+		Usage: "time zone of the timestamps that don't say theirs, UTC, Local or a name like Europe/Paris",
+		Value: "UTC",
+	}
+
+	clockSkew := cli.BoolTFlag{
+		Name:  "clock-skew",
+		Usage: "warn about the sources whose clock looks off compared to the others, or goes back",
+	}
+
+	coalesce := cli.DurationFlag{
+		Name:  "coalesce",
+		Usage: "only show the first of the entries with the same message and level coming within this long of each other (i.e. 2s), and how many there were once they stop",
+	}
+
+	levelBar := cli.DurationFlag{
+		Name:  "level-bar",
+		Usage: "when stdout is a terminal, keep a bar of the share of each level among the entries of this last while (i.e. 1m) below them",
+	}
+
+	protoDescriptor := cli.StringFlag{
+		Name:  "proto-descriptor",
+		Usage: "read the input as length-delimited protobuf messages of --proto-message, described in this descriptor set (i.e. protoc --include_imports --descriptor_set_out set.pb)",
+	}
+
+	protoMessage := cli.StringFlag{
+		Name:  "proto-message",
+		Usage: "the full name of the protobuf messages of the input, like my.pkg.LogEntry",
+	}
+
+	encoding := cli.StringFlag{
+		Name:  "encoding",
+		Usage: "encoding of the input, one of auto, utf-8, utf-16le, utf-16be, latin-1",
+		Value: "auto",
+	}
+
+	duplicateKeys := cli.StringFlag{
+		Name:  "duplicate-keys",
+		Usage: "what to do with keys JSON entries have more than once: keep the last value (last), keep it and list the keys in a duplicate_keys field (flag), keep the first value (first) or all of them (list)",
+		Value: "last",
+	}
+
+	queryFields := cli.StringSlice{}
+	queryFieldsFlag := cli.StringSliceFlag{
+		Name:  "query-fields",
+		Usage: "Custom fields holding SQL or GraphQL queries to reflow on their own lines. (i.e. statement, db.query)",
+		Value: &queryFields,
+	}
+
+	queryMinLength := cli.IntFlag{
+		Name:  "query-min-length",
+		Usage: "only reflow queries at least this long",
+		Value: humanlog.DefaultOptions.QueryMinLength,
+	}
+
+	index := cli.StringFlag{
+		Name:  "index",
+		Usage: "also save parsed entries in this SQLite database, to search them later with the query command (requires sqlite3)",
+	}
+
+	grep := cli.StringSliceFlag{
+		Name:  "grep",
+		Usage: "only parse the lines containing this string, can be repeated to keep the lines containing any of them",
+	}
+
+	grepRegex := cli.StringFlag{
+		Name:  "grep-regex",
+		Usage: "only parse the lines matching this regexp, as well as those kept by --grep",
+	}
+
+	multilineStart := cli.StringFlag{
+		Name:  "multiline-start",
+		Usage: "regexp matching the first line of entries spanning multiple lines, the lines that don't match are appended to the entry before them",
+	}
+
+	multilineJSON := cli.BoolFlag{
+		Name:  "multiline-json",
+		Usage: "assemble JSON documents spanning multiple lines, until their brackets balance",
+	}
+
+	multilineTimeout := cli.DurationFlag{
+		Name:  "multiline-timeout",
+		Usage: "consider a multi-line entry complete once no line came in for this long",
+		Value: humanlog.DefaultOptions.MultilineTimeout,
+	}
+
+	preset := cli.StringFlag{
+		Name:  "preset",
+		Usage: "look for the fields used by some libraries first, one of: " + strings.Join(humanlog.PresetNames(), ", ") + ", or the path of a JSON preset (see humanlog preset export)",
+	}
+
+	since := cli.StringFlag{
+		Name:  "since",
+		Usage: "drop the entries before this time, absolute (2006-01-02T15:04) or relative to now (-1h)",
+	}
+
+	until := cli.StringFlag{
+		Name:  "until",
+		Usage: "drop the entries after this time, absolute or relative to --since if given, now otherwise (+15m)",
+	}
+
+	head := cli.IntFlag{
+		Name:  "head",
+		Usage: "only show the first this many parsed entries the filters keep, then exit",
+	}
+
+	tail := cli.IntFlag{
+		Name:  "tail",
+		Usage: "only show the last this many parsed entries of the file given on stdin, reading it from the end",
+	}
+
+	lnavFormats := cli.StringSlice{}
+	lnavFormat := cli.StringSliceFlag{
+		Name:  "lnav-format",
+		Usage: "parse the lines humanlog can't with the regexps of an lnav format definition file",
+		Value: &lnavFormats,
+	}
+
+	grokPatterns := cli.StringSlice{}
+	grok := cli.StringSliceFlag{
+		Name:  "grok",
+		Usage: "parse the lines humanlog can't with a grok pattern, like '%{TIMESTAMP_ISO8601:time} %{LOGLEVEL:level} %{GREEDYDATA:msg}'",
+		Value: &grokPatterns,
+	}
+
+	levelRules := cli.StringSlice{}
+	levelRule := cli.StringSliceFlag{
+		Name:  "level-rule",
+		Usage: "change the level of the entries matching conditions, the last matching rule winning (i.e. 'status>=500 => error', 'msg~health => trace')",
+		Value: &levelRules,
+	}
+
+	tailBuffer := cli.IntFlag{
+		Name:  "tail-buffer",
+		Usage: "keep up to this many of the last entries the filters dropped, and show them dimmed above the next error",
+	}
+
+	memoryBudget := cli.StringFlag{
+		Name:  "memory-budget",
+		Usage: "cap the memory --tail-buffer, --truncation-markers and --snapshot take together (i.e. 256MiB), moving their oldest lines to a temporary file past it",
+	}
+
+	baseline := cli.StringFlag{
+		Name:  "baseline",
+		Usage: "highlight the values of fields that deviate from the ones in this JSON file, like {\"version\": \"1.4.2\", \"region\": [\"us-east-1\", \"us-west-2\"]}",
+	}
+
+	theme := cli.StringFlag{
+		Name:  "theme",
+		Usage: "color the keys, values and levels with a theme: default, deuteranopia, protanopia, tritanopia, or the path of a JSON file (see humanlog theme check)",
+	}
+
+	minLevel := cli.StringFlag{
+		Name:  "min-level",
+		Usage: "drop the entries whose level ranks below this one, like warn (levels that aren't known are kept)",
+	}
+
+	whereExprs := cli.StringSlice{}
+	whereFlag := cli.StringSliceFlag{
+		Name:  "where",
+		Usage: "only show the entries matching a filter, like '(level>=warn && service=api) || msg~timeout'",
+		Value: &whereExprs,
+	}
+
+	filterNames := cli.StringSlice{}
+	filterFlag := cli.StringSliceFlag{
+		Name:  "filter",
+		Usage: "only show the entries matching a filter saved under this name in the configuration file",
+		Value: &filterNames,
+	}
+
+	snapshot := cli.IntFlag{
+		Name:  "snapshot",
+		Usage: "remember this many of the last lines shown, and save them to a file with and without colors on SIGUSR1",
+	}
+
+	snapshotDir := cli.StringFlag{
+		Name:  "snapshot-dir",
+		Usage: "directory to save --snapshot files in",
+		Value: ".",
+	}
+
+	truncationMarkers := cli.BoolFlag{
+		Name:  "truncation-markers",
+		Usage: "number the truncated values (i.e. …[3]) and print them in full after the entries, or on show 3 with --interactive",
+	}
+
+	interactive := cli.BoolFlag{
+		Name:  "interactive",
+		Usage: "read filters from the terminal while the entries are shown, pasting a key=value after + to only show it or - to hide it",
+	}
+
+	control := cli.StringFlag{
+		Name:  "control",
+		Usage: "take commands changing the filters, hidden fields and theme, or saving a --snapshot, from other programs on this Unix socket or loopback address (i.e. /tmp/humanlog.sock or localhost:7070), a line each",
+	}
+
+	panes := cli.BoolFlag{
+		Name:  "panes",
+		Usage: "show the entries of each source (see --source-fields) in a pane of their own, in a grid filling the terminal, rather than interleaved; --level-bar, --tail-buffer and --table don't apply",
+	}
+
+	paneScrollback := cli.IntFlag{
+		Name:  "pane-scrollback",
+		Usage: "how many lines each of the --panes keeps, to scroll back to with --control's pane command",
+		Value: humanlog.DefaultPaneScrollback,
+	}
+
+	title := cli.BoolFlag{
+		Name:  "title",
+		Usage: "keep the terminal's title up to date with the errors seen in the last minute and the last error message",
+	}
+
+	tmuxOption := cli.StringFlag{
+		Name:  "tmux-option",
+		Usage: "also set this user option of the tmux window, like @humanlog, to the title, for window-status-format to show with #{@humanlog}",
 	}
 
 	app := cli.NewApp()
@@ -119,17 +612,189 @@ func newApp() *cli.App {
 	app.Version = Version
 	app.Usage = "reads structured logs from stdin, makes them pretty on stdout!"
 
-	app.Flags = []cli.Flag{skipFlag, keepFlag, sortLongest, skipUnchanged, truncates, truncateLength, lightBg, timeFormat, ignoreInterrupts, messageFieldsFlag, timeFieldsFlag, levelFieldsFlag}
+	app.Commands = []cli.Command{exportCommand(), queryCommand(), systemdUnitCommand(), inferCommand(), templatesCommand(), themeCommand(), presetCommand(), grokCommand(), journalCommand(), sshCommand(), completionCommand(), formatsCommand(), completeCommand()}
+	app.Description = "Every flag can also be set in the configuration file's options, or with an environment variable\n" +
+		"   named after it, i.e. HUMANLOG_TIME_FORMAT for --time-format (HUMANLOG_SKIP_FIELDS and HUMANLOG_KEEP_FIELDS\n" +
+		"   for --skip and --keep). Flags take precedence over the configuration file, which takes precedence over\n" +
+		"   the environment."
+
+	app.Flags = []cli.Flag{skipFlag, keepFlag, sortLongest, skipUnchanged, hideNull, hideEncoded, decodeFieldsFlag, unnestMessages, expandArrays, expandArrayFieldsFlag, lineWidth, fieldPriority, fieldType, linkLabels, fieldsJSON, stickyHeader, truncates, truncateLength, lightBg, timeFormat, ignoreInterrupts, messageFieldsFlag, timeFieldsFlag, levelFieldsFlag, spark, sparkWidth, forwardFlag, lineNumbers, strict, gutter, sourceFieldsFlag, callerFieldsFlag, callerWidth, showFingerprint, onlyFingerprint, count, countBy, table, tableSample, alertFlag, notifyCmd, execOn, bellOn, bellCmd, bellEvery, span, exceptions, batches, numberLocale, thousandsSeparator, decimalSeparator, floatPrecision, share, shareOriginsFlag, output, fifo, mmap, config, separators, clockOffset, clockSkew, assumeZone, coalesce, levelBar, encoding, protoDescriptor, protoMessage, duplicateKeys, queryFieldsFlag, queryMinLength, index, grep, grepRegex, multilineStart, multilineJSON, multilineTimeout, preset, since, until, head, tail, lnavFormat, grok, levelRule, minLevel, whereFlag, filterFlag, tailBuffer, memoryBudget, snapshot, snapshotDir, truncationMarkers, interactive, control, panes, paneScrollback, theme, baseline, title, tmuxOption}
+
+	app.Before = func(c *cli.Context) error {
+		if err := applyDefaults(c, app.Flags); err != nil {
+			return err
+		}
+		zone, err := time.LoadLocation(c.String(assumeZone.Name))
+		if err != nil {
+			fatalf(c, "invalid --%s: %v", assumeZone.Name, err)
+		}
+		humanlog.AssumedZone = zone
+		return nil
+	}
 
 	app.Action = func(c *cli.Context) error {
 
 		opts := humanlog.DefaultOptions
+		loadConfig(c, opts)
+
 		opts.SortLongest = c.BoolT(sortLongest.Name)
 		opts.SkipUnchanged = c.BoolT(skipUnchanged.Name)
+		opts.HideNull = c.Bool(hideNull.Name)
+		opts.HideEncoded = c.BoolT(hideEncoded.Name)
+		opts.DecodeFields = decodeFields
+		opts.UnnestMessages = c.BoolT(unnestMessages.Name)
+		opts.ExpandArrays = c.Bool(expandArrays.Name)
+		opts.ExpandArrayFields = expandArrayFields
+		opts.LinkLabels = c.Bool(linkLabels.Name)
+		opts.LineWidth = c.Int(lineWidth.Name)
+		for _, text := range c.StringSlice(fieldPriority.Name) {
+			key, priority, err := humanlog.ParseFieldPriority(text)
+			if err != nil {
+				fatalf(c, "invalid --%s %q: %v", fieldPriority.Name, text, err)
+			}
+			if opts.FieldPriorities == nil {
+				opts.FieldPriorities = make(map[string]int)
+			}
+			opts.FieldPriorities[key] = priority
+		}
+		for _, text := range c.StringSlice(fieldType.Name) {
+			key, typ, err := humanlog.ParseFieldType(text)
+			if err != nil {
+				fatalf(c, "invalid --%s %q: %v", fieldType.Name, text, err)
+			}
+			if opts.Types == nil {
+				opts.Types = make(map[string]string)
+			}
+			opts.Types[key] = typ
+		}
+		switch opts.FieldsJSON = c.String(fieldsJSON.Name); opts.FieldsJSON {
+		case "", "end", "line":
+		default:
+			fatalf(c, "--%s must be end or line", fieldsJSON.Name)
+		}
+		opts.StickyHeader = c.Int(stickyHeader.Name)
 		opts.Truncates = c.BoolT(truncates.Name)
 		opts.TruncateLength = c.Int(truncateLength.Name)
-		opts.LightBg = c.BoolT(lightBg.Name)
+		if c.IsSet(lightBg.Name) {
+			opts.LightBg = c.Bool(lightBg.Name)
+		} else if isatty.IsTerminal(os.Stdout.Fd()) {
+			opts.LightBg, _ = detectLightBg()
+		}
 		opts.TimeFormat = c.String(timeFormat.Name)
+		opts.SparkField = c.String(spark.Name)
+		opts.SparkWidth = c.Int(sparkWidth.Name)
+		opts.LineNumbers = c.Bool(lineNumbers.Name)
+		opts.Strict = c.Bool(strict.Name)
+		opts.Gutter = c.Bool(gutter.Name)
+		opts.Separators = c.Duration(separators.Name)
+		opts.Coalesce = c.Duration(coalesce.Name)
+		if isatty.IsTerminal(os.Stdout.Fd()) {
+			opts.LevelBar = c.Duration(levelBar.Name)
+		}
+		opts.ClockSkew = c.BoolT(clockSkew.Name)
+		for _, text := range c.StringSlice(clockOffset.Name) {
+			source, d, err := humanlog.ParseClockOffset(text)
+			if err != nil {
+				fatalf(c, "invalid --%s %q: %v", clockOffset.Name, text, err)
+			}
+			if opts.ClockOffsets == nil {
+				opts.ClockOffsets = make(map[string]time.Duration)
+			}
+			opts.ClockOffsets[source] = d
+		}
+		opts.Encoding = c.String(encoding.Name)
+		if path, name := c.String(protoDescriptor.Name), c.String(protoMessage.Name); path != "" || name != "" {
+			if path == "" || name == "" {
+				fatalf(c, "--%s and --%s go together", protoDescriptor.Name, protoMessage.Name)
+			}
+			msg, err := humanlog.LoadProtoMessage(path, name)
+			if err != nil {
+				fatalf(c, "invalid --%s: %v", protoDescriptor.Name, err)
+			}
+			opts.Proto = msg
+		}
+		opts.DuplicateKeys = c.String(duplicateKeys.Name)
+		if !validDuplicateKeys(opts.DuplicateKeys) {
+			fatalf(c, "--%s must be one of %s", duplicateKeys.Name, strings.Join(humanlog.DuplicateKeyPolicies, ", "))
+		}
+		opts.QueryMinLength = c.Int(queryMinLength.Name)
+		opts.CallerWidth = c.Int(callerWidth.Name)
+		opts.Fingerprints = c.Bool(showFingerprint.Name)
+		opts.OnlyFingerprints = onlyFingerprints
+		opts.TailBuffer = c.Int(tailBuffer.Name)
+		if c.IsSet(memoryBudget.Name) {
+			limit, err := humanlog.ParseSize(c.String(memoryBudget.Name))
+			if err != nil {
+				fatalf(c, "invalid --%s: %v", memoryBudget.Name, err)
+			}
+			opts.MemoryBudget = humanlog.NewMemoryBudget(limit)
+			defer opts.MemoryBudget.Close()
+		}
+		opts.Table = c.Bool(table.Name)
+		opts.TableSample = c.Int(tableSample.Name)
+		opts.NotifyCommand = c.String(notifyCmd.Name)
+		opts.Exceptions = c.BoolT(exceptions.Name)
+		opts.Batches = c.BoolT(batches.Name)
+		if c.IsSet(numberLocale.Name) {
+			locale := c.String(numberLocale.Name)
+			if locale == "auto" {
+				locale = systemLocale()
+			}
+			var ok bool
+			opts.ThousandsSeparator, opts.DecimalSeparator, ok = humanlog.LocaleSeparators(locale)
+			if !ok {
+				fatalf(c, "unknown --%s %q", numberLocale.Name, locale)
+			}
+		}
+		if c.IsSet(thousandsSeparator.Name) {
+			opts.ThousandsSeparator = c.String(thousandsSeparator.Name)
+		}
+		if c.IsSet(decimalSeparator.Name) {
+			opts.DecimalSeparator = c.String(decimalSeparator.Name)
+		}
+		opts.FloatPrecision = c.Int(floatPrecision.Name)
+		for _, text := range alerts {
+			rule, err := humanlog.ParseAlertRule(text)
+			if err != nil {
+				fatalf(c, "invalid --%s %q: %v", alertFlag.Name, text, err)
+			}
+			opts.Alerts = append(opts.Alerts, rule)
+		}
+		for _, text := range c.StringSlice(execOn.Name) {
+			hook, err := humanlog.ParseExecHook(text)
+			if err != nil {
+				fatalf(c, "invalid --%s %q: %v", execOn.Name, text, err)
+			}
+			opts.Hooks = append(opts.Hooks, hook)
+		}
+		for _, text := range c.StringSlice(span.Name) {
+			rule, err := humanlog.ParseSpanRule(text)
+			if err != nil {
+				fatalf(c, "invalid --%s %q: %v", span.Name, text, err)
+			}
+			opts.Spans = append(opts.Spans, rule)
+		}
+		opts.MultilineJSON = c.Bool(multilineJSON.Name)
+		opts.MultilineTimeout = c.Duration(multilineTimeout.Name)
+		opts.Grep = c.StringSlice(grep.Name)
+		if c.IsSet(grepRegex.Name) {
+			re, err := regexp.Compile(c.String(grepRegex.Name))
+			if err != nil {
+				fatalf(c, "invalid --%s: %v", grepRegex.Name, err)
+			}
+			opts.GrepRegexp = re
+		}
+		if c.IsSet(multilineStart.Name) {
+			re, err := regexp.Compile(c.String(multilineStart.Name))
+			if err != nil {
+				fatalf(c, "invalid --%s: %v", multilineStart.Name, err)
+			}
+			opts.MultilineStart = re
+		}
+		if fi, err := os.Stdin.Stat(); err == nil && fi.Mode().IsRegular() {
+			// offsets only make sense when there's a file to seek into
+			opts.ByteOffsets = opts.LineNumbers
+		}
 
 		switch {
 		case c.IsSet(skipFlag.Name) && c.IsSet(keepFlag.Name):
@@ -140,6 +805,107 @@ func newApp() *cli.App {
 			opts.SetKeep(keep)
 		}
 
+		now := time.Now()
+		if c.IsSet(since.Name) {
+			t, err := humanlog.ParseTimeBound(c.String(since.Name), now)
+			if err != nil {
+				fatalf(c, "invalid --%s: %v", since.Name, err)
+			}
+			opts.Since = t
+		}
+		if c.IsSet(until.Name) {
+			base := now
+			if !opts.Since.IsZero() {
+				base = opts.Since
+			}
+			t, err := humanlog.ParseTimeBound(c.String(until.Name), base)
+			if err != nil {
+				fatalf(c, "invalid --%s: %v", until.Name, err)
+			}
+			opts.Until = t
+		}
+		opts.Head = c.Int(head.Name)
+
+		for _, path := range lnavFormats {
+			formats, err := humanlog.LoadLnavFormats(path)
+			if err != nil {
+				fatalf(c, "invalid --%s: %v", lnavFormat.Name, err)
+			}
+			for _, f := range formats {
+				opts.Rewrites = append(opts.Rewrites, f.Rewrite)
+			}
+		}
+		for _, text := range grokPatterns {
+			f, err := humanlog.ParseGrokFormat(text)
+			if err != nil {
+				fatalf(c, "invalid --%s %q: %v", grok.Name, text, err)
+			}
+			opts.Rewrites = append(opts.Rewrites, f.Rewrite)
+		}
+		for _, text := range levelRules {
+			rule, err := humanlog.ParseLevelRule(text)
+			if err != nil {
+				fatalf(c, "invalid --%s %q: %v", levelRule.Name, text, err)
+			}
+			opts.LevelRules = append(opts.LevelRules, rule)
+		}
+		if c.IsSet(minLevel.Name) {
+			opts.MinLevel = c.String(minLevel.Name)
+			if _, ok := opts.LevelRank(opts.MinLevel); !ok {
+				fatalf(c, "unknown --%s %q", minLevel.Name, opts.MinLevel)
+			}
+		}
+		for _, name := range filterNames {
+			expr, ok := opts.NamedFilters[name]
+			if !ok {
+				fatalf(c, "unknown --%s %q, not among the filters of the configuration file", filterFlag.Name, name)
+			}
+			f, err := humanlog.ParseFilterExpr(expr, opts)
+			if err != nil {
+				fatalf(c, "invalid --%s %q: %v", filterFlag.Name, name, err)
+			}
+			opts.Where = append(opts.Where, f)
+		}
+		for _, expr := range whereExprs {
+			f, err := humanlog.ParseFilterExpr(expr, opts)
+			if err != nil {
+				fatalf(c, "invalid --%s %q: %v", whereFlag.Name, expr, err)
+			}
+			opts.Where = append(opts.Where, f)
+		}
+
+		if c.IsSet(bellOn.Name) {
+			level := c.String(bellOn.Name)
+			if _, ok := opts.LevelRank(level); !ok {
+				fatalf(c, "unknown --%s %q", bellOn.Name, level)
+			}
+			opts.Bell = &humanlog.Bell{Level: level, Command: c.String(bellCmd.Name), Every: c.Duration(bellEvery.Name)}
+		}
+
+		if c.IsSet(baseline.Name) {
+			b, err := humanlog.LoadBaseline(c.String(baseline.Name))
+			if err != nil {
+				fatalf(c, "can't load baseline: %v", err)
+			}
+			opts.Baseline = b
+		}
+
+		if c.IsSet(theme.Name) {
+			t, err := humanlog.LoadTheme(c.String(theme.Name))
+			if err != nil {
+				fatalf(c, "can't load theme: %v", err)
+			}
+			t.Apply(opts)
+		}
+
+		if c.IsSet(preset.Name) {
+			p, err := humanlog.LoadPreset(c.String(preset.Name))
+			if err != nil {
+				fatalf(c, "can't load preset: %v", err)
+			}
+			p.Apply(opts)
+		}
+
 		if c.IsSet(strings.Split(messageFieldsFlag.Name, ",")[0]) {
 			opts.MessageFields = messageFields
 		}
@@ -152,15 +918,404 @@ func newApp() *cli.App {
 			opts.LevelFields = levelFields
 		}
 
-		if c.IsSet(strings.Split(ignoreInterrupts.Name, ",")[0]) {
-			signal.Ignore(os.Interrupt)
+		if c.IsSet(queryFieldsFlag.Name) {
+			opts.QueryFields = queryFields
+		}
+
+		if c.IsSet(sourceFieldsFlag.Name) {
+			opts.SourceFields = sourceFields
+		}
+
+		if c.IsSet(callerFieldsFlag.Name) {
+			opts.CallerFields = callerFields
+		}
+
+		stop := stopOnSignal(c.IsSet(strings.Split(ignoreInterrupts.Name, ",")[0]))
+
+		for _, rawurl := range forward {
+			sink, err := humanlog.NewSink(rawurl)
+			if err != nil {
+				fatalf(c, "invalid --%s %q: %v", forwardFlag.Name, rawurl, err)
+			}
+			// a collector that's slow or unreachable doesn't hold up the
+			// entries
+			queued := humanlog.Queue(&loggedSink{Sink: sink, name: rawurl}, 4096)
+			closeSink := func(rawurl string) func() {
+				return func() {
+					if err := queued.Close(); err != nil {
+						log.Printf("can't forward to %s: %v", rawurl, err)
+					}
+				}
+			}(rawurl)
+			atExit = append(atExit, closeSink)
+			defer closeSink()
+			opts.Sinks = append(opts.Sinks, queued)
+		}
+
+		if c.IsSet(index.Name) {
+			idx, err := humanlog.OpenIndex(c.String(index.Name))
+			if err != nil {
+				fatalf(c, "can't open index: %v", err)
+			}
+			closeIndex := func() {
+				if err := idx.Close(); err != nil {
+					log.Printf("can't save index: %v", err)
+				}
+			}
+			atExit = append(atExit, closeIndex)
+			defer closeIndex()
+			opts.Sinks = append(opts.Sinks, &loggedSink{Sink: idx, name: c.String(index.Name)})
+		}
+
+		if c.IsSet(share.Name) {
+			startSharing(c, c.String(share.Name), shareOrigins, opts)
+		}
+
+		if c.Bool(truncationMarkers.Name) {
+			opts.Truncations = humanlog.NewTruncations(opts.MemoryBudget)
+		}
+
+		filters := new(humanlog.LiveFilters)
+		var ctl *humanlog.Control
+		var ctlListener net.Listener
+		if c.IsSet(control.Name) {
+			ln, closeControl, err := listenControl(c.String(control.Name))
+			if err != nil {
+				fatalf(c, "can't listen on --%s: %v", control.Name, err)
+			}
+			defer closeControl()
+			ctl, ctlListener = humanlog.NewControl(opts, filters), ln
+			opts.Stages = append(opts.Stages, ctl.Stage)
+		} else if c.Bool(interactive.Name) {
+			opts.Stages = append(opts.Stages, filters.Stage)
+		}
+		if c.Bool(interactive.Name) {
+			readCommands(filters, opts.Truncations)
+		}
+
+		if c.Bool(title.Name) || c.IsSet(tmuxOption.Name) {
+			var term io.Writer
+			if c.Bool(title.Name) && isatty.IsTerminal(os.Stderr.Fd()) {
+				term = os.Stderr
+			}
+			status := humanlog.NewTitleStatus(opts, term, c.String(tmuxOption.Name))
+			opts.Stages = append(opts.Stages, status.Stage)
+			ticker := time.NewTicker(5 * time.Second)
+			refreshing := make(chan struct{})
+			go func() {
+				for {
+					select {
+					case <-ticker.C:
+						status.Refresh()
+					case <-refreshing:
+						return
+					}
+				}
+			}()
+			defer status.Close()
+			defer func() {
+				ticker.Stop()
+				close(refreshing)
+			}()
+		}
+
+		// the fields seen are remembered for shell completion to suggest
+		if path := humanlog.DefaultFieldCachePath(); path != "" {
+			if cache, err := humanlog.LoadFieldCache(path); err == nil {
+				opts.Stages = append(opts.Stages, cache.Stage)
+				defer func() {
+					if err := cache.Save(path); err != nil {
+						log.Printf("can't save the fields seen for completion: %v", err)
+					}
+				}()
+			}
+		}
+
+		if !opts.Since.IsZero() && !opts.LineNumbers {
+			skipToSince(opts)
+		}
+		if n := c.Int(tail.Name); n > 0 {
+			if err := skipToTail(n, opts); err != nil {
+				fatalf(c, "can't use --%s: %v", tail.Name, err)
+			}
+		}
+
+		var input io.Reader = os.Stdin
+		if path := c.String(fifo.Name); path != "" {
+			f, remove, err := openFIFO(path)
+			if err != nil {
+				fatalf(c, "can't open --%s: %v", fifo.Name, err)
+			}
+			// fatalf exits without running what's deferred
+			atExit = append(atExit, remove)
+			defer remove()
+			defer f.Close()
+			input = f
+			log.Printf("reading %s...", path)
+		} else {
+			counting := c.Bool(count.Name) || c.IsSet(countBy.Name)
+			if c.Bool(mmap.Name) {
+				if m := mapStdin(counting); m != nil {
+					defer m.Close()
+					input = m
+				}
+			}
+			if input == os.Stdin && showProgress(counting) {
+				if pr := progressStdin(); pr != nil {
+					defer pr.Close()
+					input = pr
+				}
+			}
+			log.Print("reading stdin...")
 		}
+		input = humanlog.Interruptible(input, stop)
 
-		log.Print("reading stdin...")
-		if err := humanlog.Scanner(os.Stdin, colorable.NewColorableStdout(), opts); err != nil {
-			log.Fatalf("scanning caught an error: %v", err)
+		var err error
+		switch {
+		case c.Bool(count.Name) || c.IsSet(countBy.Name):
+			serveControl(ctl, ctlListener)
+			err = humanlog.Count(input, os.Stdout, opts, c.String(countBy.Name))
+		case c.String(output.Name) == "terminal":
+			stdout := newConsole(os.Stdout)
+			if n := c.Int(snapshot.Name); n > 0 {
+				sb := humanlog.NewScrollback(stdout, n, opts.MemoryBudget)
+				snapshotOnSignal(sb, c.String(snapshotDir.Name))
+				if ctl != nil {
+					dir := c.String(snapshotDir.Name)
+					ctl.Snapshot = func() (string, error) { return sb.Save(dir, time.Now()) }
+				}
+				stdout = sb
+			}
+			if c.Bool(panes.Name) {
+				p := newPanes(stdout, opts, c.Int(paneScrollback.Name))
+				opts.Panes = p
+				if ctl != nil {
+					ctl.Panes = p
+				}
+			}
+			serveControl(ctl, ctlListener)
+			err = humanlog.Scanner(input, stdout, opts)
+		case c.String(output.Name) == "html":
+			serveControl(ctl, ctlListener)
+			err = writeHTMLReport(c, input, opts)
+		case c.String(output.Name) == "ndjson-normalized":
+			serveControl(ctl, ctlListener)
+			err = humanlog.NDJSON(input, os.Stdout, opts)
+		default:
+			fatalf(c, "unknown --%s %q", output.Name, c.String(output.Name))
+		}
+		if err != nil {
+			return fmt.Errorf("scanning caught an error: %v", err)
 		}
 		return nil
 	}
 	return app
 }
+
+// systemLocale is the locale numbers are formatted with, per the
+// environment.
+func systemLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if v := os.Getenv(name); v != "" && v != "C" && v != "POSIX" {
+			return v
+		}
+	}
+	return "en"
+}
+
+// startSharing serves the entries on addr, in the background.
+func startSharing(c *cli.Context, addr string, origins []string, opts *humanlog.HandlerOptions) {
+	srv := humanlog.NewShareServer(opts)
+	srv.AllowedOrigins = origins
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		fatalf(c, "can't share: %v", err)
+	}
+	go func() {
+		if err := http.Serve(ln, srv); err != nil {
+			log.Printf("stopped sharing: %v", err)
+		}
+	}()
+	opts.Stages = append(opts.Stages, srv.Stage)
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		if name, err := os.Hostname(); err == nil {
+			host = name
+		}
+	}
+	log.Printf("sharing on http://%s/, viewer on http://%[1]s/viewer", net.JoinHostPort(host, port))
+}
+
+// writeHTMLReport writes the report to the file named by the first argument,
+// or to stdout.
+func writeHTMLReport(c *cli.Context, src io.Reader, opts *humanlog.HandlerOptions) error {
+	if path := c.Args().First(); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			fatalf(c, "can't create report: %v", err)
+		}
+		err = humanlog.HTMLReport(src, f, opts)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+	return humanlog.HTMLReport(src, os.Stdout, opts)
+}
+
+// stopOnSignal returns a channel that's closed when humanlog is asked to
+// stop, so that the input ends and what's pending is written out before
+// exiting. Asking again exits right away.
+func stopOnSignal(ignoreInterrupts bool) <-chan struct{} {
+	signals := []os.Signal{syscall.SIGTERM}
+	if ignoreInterrupts {
+		signal.Ignore(os.Interrupt)
+	} else {
+		signals = append(signals, os.Interrupt)
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, signals...)
+
+	stop := make(chan struct{})
+	go func() {
+		<-sigs
+		log.Print("stopping, interrupt again to exit right away")
+		close(stop)
+		<-sigs
+		os.Exit(130)
+	}()
+	return stop
+}
+
+// loggedSink reports forwarding failures once per attempt at reconnecting,
+// until the sink recovers.
+type loggedSink struct {
+	humanlog.Sink
+	name    string
+	failed  bool
+	attempt int
+}
+
+func (s *loggedSink) Send(ev *humanlog.Event) error {
+	err := s.Sink.Send(ev)
+	retry, isRetry := err.(*humanlog.RetryError)
+	switch {
+	case isRetry && retry.Attempt != s.attempt:
+		log.Printf("can't forward to %s: %v", s.name, retry)
+		s.failed, s.attempt = true, retry.Attempt
+	case err != nil && !isRetry && !s.failed:
+		log.Printf("can't forward to %s: %v", s.name, err)
+		s.failed = true
+	case err == nil && s.failed:
+		log.Printf("forwarding to %s again", s.name)
+		s.failed, s.attempt = false, 0
+	}
+	return err
+}
+
+// loadConfig applies the configuration file. A missing file is only an error
+// if it was asked for explicitly.
+func loadConfig(c *cli.Context, opts *humanlog.HandlerOptions) {
+	path, explicit := configPath(c)
+	if path == "" {
+		return
+	}
+	cfg, err := humanlog.ReadConfig(path)
+	switch {
+	case os.IsNotExist(err) && !explicit:
+		return
+	case err != nil:
+		fatalf(c, "can't read configuration: %v", err)
+	}
+	cfg.Apply(opts)
+}
+
+// mapStdin maps stdin in memory when it's a file. It draws a progress bar
+// on stderr if it's a terminal that isn't also showing the entries.
+func mapStdin(counting bool) *humanlog.MappedFile {
+	if fi, err := os.Stdin.Stat(); err != nil || !fi.Mode().IsRegular() {
+		return nil
+	}
+	m, err := humanlog.MapFile(os.Stdin)
+	if err == humanlog.ErrMapUnsupported {
+		return nil
+	} else if err != nil {
+		log.Printf("can't map stdin in memory, reading it: %v", err)
+		return nil
+	}
+	if showProgress(counting) {
+		m.ShowProgress(os.Stderr)
+	}
+	return m
+}
+
+// showProgress tells whether a progress bar can be drawn on stderr, without
+// getting mixed with the entries.
+func showProgress(counting bool) bool {
+	return isatty.IsTerminal(os.Stderr.Fd()) && (counting || !isatty.IsTerminal(os.Stdout.Fd()))
+}
+
+// progressStdin draws the progress of reading stdin, when it's a file.
+func progressStdin() *humanlog.ProgressReader {
+	fi, err := os.Stdin.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		return nil
+	}
+	off, err := os.Stdin.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil
+	}
+	return humanlog.NewProgressReader(os.Stdin, fi.Size()-off, os.Stderr)
+}
+
+// skipToTail moves stdin to where its last n entries start, when it's a
+// file.
+func skipToTail(n int, opts *humanlog.HandlerOptions) error {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("stdin isn't a file")
+	}
+	off, err := humanlog.SeekTail(os.Stdin, fi.Size(), n, opts)
+	if err != nil {
+		return err
+	}
+	// after --since, only move forward
+	cur, err := os.Stdin.Seek(0, io.SeekCurrent)
+	if err != nil || off <= cur {
+		return err
+	}
+	_, err = os.Stdin.Seek(off, io.SeekStart)
+	return err
+}
+
+// skipToSince moves stdin to the first entry in the time range when it's a
+// file, assuming it's sorted by time.
+func skipToSince(opts *humanlog.HandlerOptions) {
+	fi, err := os.Stdin.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		return
+	}
+	off, err := humanlog.SeekSince(os.Stdin, fi.Size(), opts.Since, opts)
+	if err != nil {
+		log.Printf("can't look for --since in the file, reading all of it: %v", err)
+		return
+	}
+	if _, err := os.Stdin.Seek(off, io.SeekStart); err != nil {
+		log.Printf("can't skip ahead in the file, reading all of it: %v", err)
+		return
+	}
+	opts.SortedInput = true
+}
+
+func validDuplicateKeys(policy string) bool {
+	for _, p := range humanlog.DuplicateKeyPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/zbartl/humanlog"
+)
+
+// newPanes returns panes filling the terminal of stdout, resized with it.
+func newPanes(dst io.Writer, opts *humanlog.HandlerOptions, scrollback int) *humanlog.Panes {
+	width, height, ok := terminalSize(os.Stdout)
+	if !ok {
+		width, height = envSize("COLUMNS", 80), envSize("LINES", 24)
+	}
+	p := humanlog.NewPanes(dst, opts, width, height, scrollback)
+	onResize(os.Stdout, p.Resize)
+	return p
+}
+
+func envSize(name string, fallback int) int {
+	if n, err := strconv.Atoi(os.Getenv(name)); err == nil && n > 0 {
+		return n
+	}
+	return fallback
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package main
+
+import "os"
+
+// terminalSize can't ask the terminal its size here, the panes fill the
+// usual 80 by 24 unless $COLUMNS and $LINES say otherwise.
+func terminalSize(f *os.File) (width, height int, ok bool) {
+	return 0, 0, false
+}
+
+func onResize(f *os.File, resize func(width, height int)) {}
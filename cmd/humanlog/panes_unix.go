@@ -0,0 +1,34 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// terminalSize returns how many columns and rows the terminal of f has.
+func terminalSize(f *os.File) (width, height int, ok bool) {
+	var ws struct{ Row, Col, Xpixel, Ypixel uint16 }
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws))); errno != 0 || ws.Col == 0 {
+		return 0, 0, false
+	}
+	return int(ws.Col), int(ws.Row), true
+}
+
+// onResize calls resize with the new size of the terminal of f whenever
+// it's resized.
+func onResize(f *os.File, resize func(width, height int)) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGWINCH)
+	go func() {
+		for range sigs {
+			if width, height, ok := terminalSize(f); ok {
+				resize(width, height)
+			}
+		}
+	}()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+	"github.com/zbartl/humanlog"
+)
+
+func presetCommand() cli.Command {
+	return cli.Command{
+		Name:  "preset",
+		Usage: "lists, shows and exports the presets humanlog ships, to start a preset of your own from",
+		Subcommands: []cli.Command{
+			{
+				Name:  "list",
+				Usage: "lists the presets humanlog ships",
+				Action: func(c *cli.Context) error {
+					w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+					for _, name := range humanlog.PresetNames() {
+						p, _ := humanlog.LookupPreset(name)
+						fmt.Fprintf(w, "%s\t%s\n", p.Name, p.Description)
+					}
+					return w.Flush()
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "prints the JSON file of a preset",
+				ArgsUsage: "name",
+				Action: func(c *cli.Context) error {
+					data, ok := humanlog.PresetSource(c.Args().First())
+					if !ok {
+						log.Fatalf("no preset named %q", c.Args().First())
+					}
+					_, err := os.Stdout.Write(data)
+					return err
+				},
+			},
+			{
+				Name:      "export",
+				Usage:     "writes the JSON file of a preset, to be changed and given to --preset",
+				ArgsUsage: "name [path, name.json by default]",
+				Action: func(c *cli.Context) error {
+					name := c.Args().First()
+					data, ok := humanlog.PresetSource(name)
+					if !ok {
+						log.Fatalf("no preset named %q", name)
+					}
+					exportBuiltin(c.Args().Get(1), name+".json", data)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func grokCommand() cli.Command {
+	return cli.Command{
+		Name:  "grok",
+		Usage: "lists, shows and exports the grok patterns humanlog ships, which --grok expressions can refer to",
+		Subcommands: []cli.Command{
+			{
+				Name:  "list",
+				Usage: "lists the grok patterns humanlog ships",
+				Action: func(c *cli.Context) error {
+					for _, name := range humanlog.GrokPatternNames() {
+						fmt.Println(name)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "prints the regexp of a grok pattern",
+				ArgsUsage: "name",
+				Action: func(c *cli.Context) error {
+					def, ok := humanlog.GrokPattern(c.Args().First())
+					if !ok {
+						log.Fatalf("no grok pattern named %q", c.Args().First())
+					}
+					fmt.Println(def)
+					return nil
+				},
+			},
+			{
+				Name:      "export",
+				Usage:     "writes the grok patterns, a name and a regexp per line like logstash's",
+				ArgsUsage: "[path, grok-patterns by default]",
+				Action: func(c *cli.Context) error {
+					exportBuiltin(c.Args().First(), "grok-patterns", humanlog.GrokPatternsSource())
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// exportBuiltin writes the file of something humanlog ships to path, or to
+// name when there's no path, without overwriting a file that's there.
+func exportBuiltin(path, name string, data []byte) {
+	if path == "" {
+		path = name
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		log.Fatalf("can't export %s: %v", name, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		log.Fatalf("can't export %s: %v", name, err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalf("can't export %s: %v", name, err)
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s\n", path)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/urfave/cli"
+	"github.com/zbartl/humanlog"
+)
+
+func queryCommand() cli.Command {
+	index := cli.StringFlag{
+		Name:  "index",
+		Usage: "SQLite database written with --index",
+		Value: "humanlog.db",
+	}
+
+	return cli.Command{
+		Name:      "query",
+		Usage:     "runs a SQL query against the events saved with --index",
+		ArgsUsage: "'SELECT level, count(*) FROM events GROUP BY level'",
+		Flags:     []cli.Flag{index},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				fatalf(c, "want exactly one query, got %d arguments", c.NArg())
+			}
+			if err := humanlog.QueryIndex(c.String(index.Name), c.Args().First(), os.Stdout); err != nil {
+				log.Fatalf("query failed: %v", err)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/zbartl/humanlog"
+)
+
+// snapshotOnSignal saves the scrollback into dir whenever humanlog gets a
+// SIGUSR1.
+func snapshotOnSignal(sb *humanlog.Scrollback, dir string) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1)
+	go func() {
+		for range sigs {
+			path, err := sb.Save(dir, time.Now())
+			if err != nil {
+				log.Printf("can't save snapshot: %v", err)
+				continue
+			}
+			log.Printf("saved snapshot to %s", path)
+		}
+	}()
+	log.Printf("run `kill -USR1 %d` to save the last lines shown", os.Getpid())
+}
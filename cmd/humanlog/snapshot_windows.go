@@ -0,0 +1,11 @@
+package main
+
+import (
+	"log"
+
+	"github.com/zbartl/humanlog"
+)
+
+func snapshotOnSignal(sb *humanlog.Scrollback, dir string) {
+	log.Print("snapshots aren't supported on Windows, there's no signal to ask for them")
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/urfave/cli"
+	"github.com/zbartl/humanlog"
+)
+
+func sshCommand() cli.Command {
+	lines := cli.IntFlag{
+		Name:  "lines, n",
+		Usage: "start with this many of the last lines of each file",
+		Value: 10,
+	}
+	sshOptions := cli.StringSliceFlag{
+		Name:  "ssh-option, o",
+		Usage: "option passed on to ssh, like ConnectTimeout=5, can be repeated",
+	}
+
+	return cli.Command{
+		Name:      "ssh",
+		Usage:     "follows files on remote hosts over ssh, i.e. web{1..3}:/var/log/app.log, prefixing the entries with their host",
+		ArgsUsage: "host:/path...",
+		Flags:     []cli.Flag{lines, sshOptions},
+		Action: func(c *cli.Context) error {
+			opts := globalOptions(c)
+
+			var targets []sshTarget
+			for _, arg := range c.Args() {
+				for _, expanded := range expandBraces(arg) {
+					target, err := parseSSHTarget(expanded)
+					if err != nil {
+						fatalf(c, "invalid target %q: %v", arg, err)
+					}
+					targets = append(targets, target)
+				}
+			}
+			if len(targets) == 0 {
+				fatalf(c, "give the files to follow, like host:/var/log/app.log")
+			}
+
+			stdout := newConsole(os.Stdout)
+			var mu sync.Mutex
+			width := 0
+			for _, t := range targets {
+				if len(t.host) > width {
+					width = len(t.host)
+				}
+			}
+
+			var wg sync.WaitGroup
+			for _, t := range targets {
+				// the connections that drop are made again, following the
+				// file from where it is rather than from its last lines
+				lines := c.Int("lines")
+				dial := func(t sshTarget) func() (io.ReadCloser, error) {
+					return func() (io.ReadCloser, error) {
+						rc, err := startSSH(t, lines, c.StringSlice("ssh-option"))
+						lines = 0
+						return rc, err
+					}
+				}(t)
+				out := humanlog.Reconnecting(t.String(), dial, os.Stderr)
+
+				// every host gets its own options, for skip-unchanged to
+				// compare the entries of a host with each other
+				hostOpts := *opts
+				var dst io.Writer = &lockedWriter{mu: &mu, dst: stdout}
+				if len(targets) > 1 {
+					label := fmt.Sprintf("%-*s │ ", width, t.host)
+					dst = &prefixWriter{mu: &mu, dst: stdout, prefix: hostOpts.SourceColor(t.host).Sprint(label)}
+				}
+
+				wg.Add(1)
+				go func(t sshTarget, out io.Reader, dst io.Writer) {
+					defer wg.Done()
+					if err := humanlog.Scanner(out, dst, &hostOpts); err != nil {
+						log.Printf("%s: scanning caught an error: %v", t, err)
+					}
+				}(t, out, dst)
+			}
+			wg.Wait()
+			return nil
+		},
+	}
+}
+
+// startSSH runs tail on the host of t, following its file from its last
+// lines.
+func startSSH(t sshTarget, lines int, options []string) (io.ReadCloser, error) {
+	args := []string{"-T"}
+	for _, o := range options {
+		args = append(args, "-o", o)
+	}
+	// the remote shell splits the command again, so the path is quoted for
+	// it, and the host can't be taken for an option
+	args = append(args, "--", t.host, "tail", "-n", strconv.Itoa(lines), "-F", "--", shellQuote(t.path))
+
+	cmd := exec.Command("ssh", args...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &sshStream{cmd: cmd, out: out}, nil
+}
+
+// sshStream reads what tail writes, until ssh exits, which ends the stream
+// with the reason it exited.
+type sshStream struct {
+	cmd *exec.Cmd
+	out io.Reader
+}
+
+func (s *sshStream) Read(p []byte) (int, error) {
+	n, err := s.out.Read(p)
+	if err == io.EOF {
+		if werr := s.cmd.Wait(); werr != nil {
+			return n, fmt.Errorf("ssh: %v", werr)
+		}
+	}
+	return n, err
+}
+
+func (s *sshStream) Close() error {
+	if s.cmd.ProcessState == nil {
+		_ = s.cmd.Process.Kill()
+		_ = s.cmd.Wait()
+	}
+	return nil
+}
+
+// sshTarget is a file to follow on a host.
+type sshTarget struct {
+	host, path string
+}
+
+func (t sshTarget) String() string {
+	if i := strings.LastIndex(t.host, "@"); strings.Contains(t.host[i+1:], ":") {
+		return t.host[:i+1] + "[" + t.host[i+1:] + "]:" + t.path
+	}
+	return t.host + ":" + t.path
+}
+
+// parseSSHTarget reads host:/path, where the host can be user@host, and an
+// IPv6 address is in brackets, like [::1]:/path.
+func parseSSHTarget(arg string) (sshTarget, error) {
+	user, rest := "", arg
+	if i := strings.Index(arg, "@"); i >= 0 && i < strings.IndexAny(arg, ":[") {
+		user, rest = arg[:i+1], arg[i+1:]
+	}
+	var host, path string
+	if strings.HasPrefix(rest, "[") {
+		end := strings.Index(rest, "]:")
+		if end < 0 {
+			return sshTarget{}, fmt.Errorf("want [address]:/path")
+		}
+		host, path = rest[1:end], rest[end+2:]
+	} else if i := strings.Index(rest, ":"); i >= 0 {
+		host, path = rest[:i], rest[i+1:]
+	}
+	if host == "" || path == "" {
+		return sshTarget{}, fmt.Errorf("want host:/path")
+	}
+	if strings.HasPrefix(user+host, "-") {
+		return sshTarget{}, fmt.Errorf("the host can't start with -")
+	}
+	return sshTarget{host: user + host, path: path}, nil
+}
+
+// expandBraces expands the first group of braces in s, and those after it,
+// like shells do: a{1..3} is a1, a2 and a3, and a{b,c} is ab and ac. It's
+// for the shells that don't, or when the argument is quoted.
+func expandBraces(s string) []string {
+	open := strings.Index(s, "{")
+	if open < 0 {
+		return []string{s}
+	}
+	end := strings.Index(s[open:], "}")
+	if end < 0 {
+		return []string{s}
+	}
+	end += open
+	prefix, body, suffix := s[:open], s[open+1:end], s[end+1:]
+
+	var alternatives []string
+	if from, to, ok := braceRange(body); ok {
+		pad := 0
+		if lo := strings.SplitN(body, "..", 2)[0]; len(lo) > 1 && lo[0] == '0' {
+			pad = len(lo)
+		}
+		step := 1
+		if to < from {
+			step = -1
+		}
+		for i := from; ; i += step {
+			alternatives = append(alternatives, fmt.Sprintf("%0*d", pad, i))
+			if i == to {
+				break
+			}
+		}
+	} else if strings.Contains(body, ",") {
+		alternatives = strings.Split(body, ",")
+	} else {
+		return []string{s}
+	}
+
+	var out []string
+	for _, alt := range alternatives {
+		for _, rest := range expandBraces(suffix) {
+			out = append(out, prefix+alt+rest)
+		}
+	}
+	return out
+}
+
+func braceRange(body string) (int, int, bool) {
+	bounds := strings.SplitN(body, "..", 2)
+	if len(bounds) != 2 {
+		return 0, 0, false
+	}
+	from, err := strconv.Atoi(bounds[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	to, err := strconv.Atoi(bounds[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return from, to, true
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// lockedWriter writes onto dst, one write at a time.
+type lockedWriter struct {
+	mu  *sync.Mutex
+	dst io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dst.Write(p)
+}
+
+// prefixWriter writes whole lines onto dst, each behind the prefix, so that
+// the lines of several writers don't get mixed.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	dst    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	i := bytes.LastIndexByte(w.buf, '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+	var out bytes.Buffer
+	for _, line := range bytes.SplitAfter(w.buf[:i+1], []byte{'\n'}) {
+		if len(line) != 0 {
+			out.WriteString(w.prefix)
+			out.Write(line)
+		}
+	}
+	w.buf = append(w.buf[:0], w.buf[i+1:]...)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.dst.Write(out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
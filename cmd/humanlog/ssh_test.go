@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestExpandBraces(t *testing.T) {
+	for _, tt := range []struct {
+		arg  string
+		want []string
+	}{
+		{arg: "web1:/var/log/app.log", want: []string{"web1:/var/log/app.log"}},
+		{arg: "web{1..3}:/log", want: []string{"web1:/log", "web2:/log", "web3:/log"}},
+		{arg: "web{3..1}:/log", want: []string{"web3:/log", "web2:/log", "web1:/log"}},
+		{arg: "web{08..10}:/log", want: []string{"web08:/log", "web09:/log", "web10:/log"}},
+		{arg: "{api,db}:/log", want: []string{"api:/log", "db:/log"}},
+		{arg: "{api,db}{1..2}:/log", want: []string{"api1:/log", "api2:/log", "db1:/log", "db2:/log"}},
+		{arg: "web:/log/{a}.log", want: []string{"web:/log/{a}.log"}},
+		{arg: "web{1..3:/log", want: []string{"web{1..3:/log"}},
+		{arg: "web{a..c}:/log", want: []string{"web{a..c}:/log"}},
+	} {
+		if got := expandBraces(tt.arg); !reflect.DeepEqual(tt.want, got) {
+			t.Errorf("%s: want %q, got %q, want != got", tt.arg, tt.want, got)
+		}
+	}
+}
+
+func TestParseSSHTarget(t *testing.T) {
+	for _, tt := range []struct {
+		arg  string
+		want sshTarget
+		err  bool
+	}{
+		{arg: "web1:/var/log/app.log", want: sshTarget{host: "web1", path: "/var/log/app.log"}},
+		{arg: "deploy@web1:logs/app.log", want: sshTarget{host: "deploy@web1", path: "logs/app.log"}},
+		{arg: "web1:/logs/a:b.log", want: sshTarget{host: "web1", path: "/logs/a:b.log"}},
+		{arg: "[::1]:/var/log/app.log", want: sshTarget{host: "::1", path: "/var/log/app.log"}},
+		{arg: "deploy@[fe80::1]:/logs/a.log", want: sshTarget{host: "deploy@fe80::1", path: "/logs/a.log"}},
+		{arg: "web1:/logs/me@host.log", want: sshTarget{host: "web1", path: "/logs/me@host.log"}},
+		{arg: "/var/log/app.log", err: true},
+		{arg: "-oProxyCommand=touch /tmp/x:/x", err: true},
+		{arg: "-@web1:/x", err: true},
+		{arg: "[::1/var/log/app.log", err: true},
+		{arg: "[]:/var/log/app.log", err: true},
+		{arg: ":/var/log/app.log", err: true},
+		{arg: "web1:", err: true},
+	} {
+		got, err := parseSSHTarget(tt.arg)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: want an error, got %+v", tt.arg, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.arg, err)
+		} else if got != tt.want {
+			t.Errorf("%s: want %+v, got %+v, want != got", tt.arg, tt.want, got)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for _, tt := range []struct {
+		arg, want string
+	}{
+		{arg: "/var/log/app.log", want: `'/var/log/app.log'`},
+		{arg: "", want: `''`},
+		{arg: "/logs/my app.log", want: `'/logs/my app.log'`},
+		{arg: "/logs/$(rm -rf ~).log", want: `'/logs/$(rm -rf ~).log'`},
+		{arg: "/logs/it's.log", want: `'/logs/it'\''s.log'`},
+	} {
+		if got := shellQuote(tt.arg); got != tt.want {
+			t.Errorf("%q: want %s, got %s, want != got", tt.arg, tt.want, got)
+		}
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{name: "whole lines", writes: []string{"a\nb\n"}, want: "web1 │ a\nweb1 │ b\n"},
+		{name: "split line", writes: []string{"hel", "lo\nwor", "ld\n"}, want: "web1 │ hello\nweb1 │ world\n"},
+		{name: "unfinished line", writes: []string{"a\nb"}, want: "web1 │ a\n"},
+		{name: "empty line", writes: []string{"\n"}, want: "web1 │ \n"},
+	} {
+		var out bytes.Buffer
+		w := &prefixWriter{mu: &sync.Mutex{}, dst: &out, prefix: "web1 │ "}
+		for _, p := range tt.writes {
+			if n, err := w.Write([]byte(p)); err != nil || n != len(p) {
+				t.Fatalf("%s: want %d written, got %d (%v)", tt.name, len(p), n, err)
+			}
+		}
+		if got := out.String(); got != tt.want {
+			t.Errorf("%s: want %q, got %q, want != got", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestSSHTarget_String(t *testing.T) {
+	for _, tt := range []struct {
+		target sshTarget
+		want   string
+	}{
+		{target: sshTarget{host: "web1", path: "/log"}, want: "web1:/log"},
+		{target: sshTarget{host: "::1", path: "/log"}, want: "[::1]:/log"},
+		{target: sshTarget{host: "deploy@fe80::1", path: "/log"}, want: "deploy@[fe80::1]:/log"},
+	} {
+		if got := tt.target.String(); got != tt.want {
+			t.Errorf("want %s, got %s, want != got", tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/urfave/cli"
+)
+
+var systemdUnit = template.Must(template.New("unit").Parse(`[Unit]
+Description=humanlog rendering what's written to {{.FIFO}}
+
+[Service]
+ExecStart={{.ExecStart}}
+Restart=on-failure
+{{- if .TTY}}
+StandardOutput=tty
+TTYPath={{.TTY}}
+{{- end}}
+
+[Install]
+WantedBy=default.target
+`))
+
+func systemdUnitCommand() cli.Command {
+	fifo := cli.StringFlag{
+		Name:  "fifo",
+		Usage: "named pipe the service reads from",
+		Value: defaultFIFOPath(),
+	}
+	tty := cli.StringFlag{
+		Name:  "tty",
+		Usage: "terminal the service renders onto, i.e. /dev/pts/3 (see `tty`); the journal if empty",
+	}
+
+	return cli.Command{
+		Name:      "systemd-unit",
+		Usage:     "prints a systemd user unit rendering a named pipe, to keep a dev log window open",
+		ArgsUsage: "[-- humanlog flags...]",
+		Description: "Save it as ~/.config/systemd/user/humanlog.service, then run:\n\n" +
+			"   systemctl --user daemon-reload && systemctl --user enable --now humanlog",
+		Flags: []cli.Flag{fifo, tty},
+		Action: func(c *cli.Context) error {
+			exe, err := os.Executable()
+			if err != nil {
+				fatalf(c, "can't find humanlog's path: %v", err)
+			}
+			if abs, err := filepath.EvalSymlinks(exe); err == nil {
+				exe = abs
+			}
+			return writeSystemdUnit(os.Stdout, exe, c.String(fifo.Name), c.String(tty.Name), c.Args())
+		},
+	}
+}
+
+// writeSystemdUnit writes the unit running exe on the named pipe at fifo,
+// with the humanlog flags in args.
+func writeSystemdUnit(w io.Writer, exe, fifo, tty string, args []string) error {
+	cmd := []string{exe, "--fifo", fifo}
+	cmd = append(cmd, args...)
+	for i, arg := range cmd {
+		cmd[i] = systemdQuote(arg)
+	}
+	return systemdUnit.Execute(w, struct {
+		FIFO      string
+		ExecStart string
+		TTY       string
+	}{fifo, strings.Join(cmd, " "), tty})
+}
+
+// defaultFIFOPath is in the user's runtime directory, which systemd user
+// units have access to.
+func defaultFIFOPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "humanlog.fifo")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("humanlog-%d.fifo", os.Getuid()))
+}
+
+// systemdQuote quotes an argument of ExecStart, if it needs to be.
+func systemdQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\$%;") {
+		return arg
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`, `%`, `%%`)
+	return `"` + r.Replace(arg) + `"`
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSystemdQuote(t *testing.T) {
+	for _, tt := range []struct {
+		arg, want string
+	}{
+		{arg: "--gutter", want: "--gutter"},
+		{arg: "/run/user/1000/humanlog.fifo", want: "/run/user/1000/humanlog.fifo"},
+		{arg: "", want: `""`},
+		{arg: "level=error msg", want: `"level=error msg"`},
+		{arg: "$HOME", want: `"$$HOME"`},
+		{arg: "100%", want: `"100%%"`},
+		{arg: `say "hi"`, want: `"say \"hi\""`},
+		{arg: `C:\logs`, want: `"C:\\logs"`},
+		{arg: "a;b", want: `"a;b"`},
+		{arg: "it's", want: `"it's"`},
+	} {
+		if got := systemdQuote(tt.arg); got != tt.want {
+			t.Errorf("%q: want %s, got %s, want != got", tt.arg, tt.want, got)
+		}
+	}
+}
+
+func TestWriteSystemdUnit(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		tty  string
+		args []string
+		want string
+	}{
+		{
+			name: "journal",
+			args: []string{"--time-format", "15:04 %Z"},
+			want: `[Unit]
+Description=humanlog rendering what's written to /run/user/1000/humanlog.fifo
+
+[Service]
+ExecStart=/usr/local/bin/humanlog --fifo /run/user/1000/humanlog.fifo --time-format "15:04 %%Z"
+Restart=on-failure
+
+[Install]
+WantedBy=default.target
+`,
+		},
+		{
+			name: "tty",
+			tty:  "/dev/pts/3",
+			want: `[Unit]
+Description=humanlog rendering what's written to /run/user/1000/humanlog.fifo
+
+[Service]
+ExecStart=/usr/local/bin/humanlog --fifo /run/user/1000/humanlog.fifo
+Restart=on-failure
+StandardOutput=tty
+TTYPath=/dev/pts/3
+
+[Install]
+WantedBy=default.target
+`,
+		},
+	} {
+		var out bytes.Buffer
+		if err := writeSystemdUnit(&out, "/usr/local/bin/humanlog", "/run/user/1000/humanlog.fifo", tt.tty, tt.args); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.String(); got != tt.want {
+			t.Errorf("%s: want %q, got %q, want != got", tt.name, tt.want, got)
+		}
+	}
+}
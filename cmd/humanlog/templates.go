@@ -0,0 +1,29 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/urfave/cli"
+	"github.com/zbartl/humanlog"
+)
+
+func templatesCommand() cli.Command {
+	top := cli.IntFlag{
+		Name:  "top",
+		Usage: "only list this many templates, the most frequent (0 lists them all)",
+	}
+
+	return cli.Command{
+		Name:  "templates",
+		Usage: "reads logs from stdin and reports the templates of their messages, with how often, at which levels and when they were logged",
+		Flags: []cli.Flag{top},
+		Action: func(c *cli.Context) error {
+			opts := globalOptions(c)
+			if err := humanlog.Templates(os.Stdin, os.Stdout, opts, c.Int(top.Name)); err != nil {
+				log.Fatalf("reading templates caught an error: %v", err)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/urfave/cli"
+	"github.com/zbartl/humanlog"
+)
+
+func themeCommand() cli.Command {
+	return cli.Command{
+		Name:  "theme",
+		Usage: "lists, checks and exports the themes the entries can be colored with",
+		Subcommands: []cli.Command{
+			{
+				Name:  "list",
+				Usage: "lists the themes humanlog ships",
+				Action: func(c *cli.Context) error {
+					for _, t := range humanlog.Themes {
+						fmt.Println(t.Name)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "prints the JSON file of a theme",
+				ArgsUsage: "name",
+				Action: func(c *cli.Context) error {
+					data, ok := humanlog.ThemeSource(c.Args().First())
+					if !ok {
+						log.Fatalf("no theme named %q", c.Args().First())
+					}
+					_, err := os.Stdout.Write(data)
+					return err
+				},
+			},
+			{
+				Name:      "export",
+				Usage:     "writes the JSON file of a theme, to be changed and given to --theme",
+				ArgsUsage: "name [path, name.json by default]",
+				Action: func(c *cli.Context) error {
+					name := c.Args().First()
+					data, ok := humanlog.ThemeSource(name)
+					if !ok {
+						log.Fatalf("no theme named %q", name)
+					}
+					exportBuiltin(c.Args().Get(1), name+".json", data)
+					return nil
+				},
+			},
+			{
+				Name:      "check",
+				Usage:     "reports the contrast of a theme's colors on common terminal backgrounds, and the levels that are hard to tell apart with color blindness",
+				ArgsUsage: "[name or path of a JSON theme]",
+				Action: func(c *cli.Context) error {
+					name := c.Args().First()
+					if name == "" {
+						name = "default"
+					}
+					t, err := humanlog.LoadTheme(name)
+					if err != nil {
+						log.Fatalf("can't load theme: %v", err)
+					}
+					if _, err := humanlog.CheckTheme(t, os.Stdout); err != nil {
+						log.Fatalf("checking the theme caught an error: %v", err)
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
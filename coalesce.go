@@ -0,0 +1,68 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// coalescer tames bursts of the same entry, like retry storms and flapping
+// health checks. The first entry of a burst is rendered, the ones with the
+// same message and level coming within the window of the one before them
+// aren't, and once the burst is over a single line tells how many there
+// were and when the first and last ones came.
+type coalescer struct {
+	window time.Duration
+	now    func() time.Time
+
+	level, msg  string
+	first, last time.Time
+	n           int
+	// swallowing tells whether the last entry was dropped, for the lines
+	// continuing it, like its stack trace, to be dropped too
+	swallowing bool
+}
+
+func newCoalescer(window time.Duration) *coalescer {
+	return &coalescer{window: window, now: time.Now}
+}
+
+// observe tells whether ev repeats the burst, in which case it mustn't be
+// rendered. Otherwise, it ends the burst and starts a new one.
+func (c *coalescer) observe(dst io.Writer, opts *HandlerOptions, ev *Event) bool {
+	t := ev.Time
+	if t.IsZero() {
+		t = c.now()
+	}
+	if c.n > 0 && ev.Level == c.level && ev.Message == c.msg && t.Sub(c.last) <= c.window {
+		c.n++
+		c.last = t
+		c.swallowing = true
+		return true
+	}
+	c.flush(dst, opts)
+	c.level, c.msg, c.first, c.last, c.n = ev.Level, ev.Message, t, t, 1
+	return false
+}
+
+// continues tells whether a line that wasn't parsed is part of an entry
+// that was dropped, like its stack trace. Lines that aren't part of an entry
+// end the burst.
+func (c *coalescer) continues(dst io.Writer, opts *HandlerOptions, continuation bool) bool {
+	if continuation {
+		return c.swallowing
+	}
+	c.flush(dst, opts)
+	return false
+}
+
+// flush writes how many entries the burst had, if some of them were
+// dropped.
+func (c *coalescer) flush(dst io.Writer, opts *HandlerOptions) {
+	if c.n > 1 {
+		opts.ContextColor.Fprintf(dst, "── ×%d from %s to %s (%s)\n", c.n,
+			c.first.Format(opts.TimeFormat), c.last.Format(opts.TimeFormat),
+			fmt.Sprint(c.last.Sub(c.first).Round(time.Millisecond)))
+	}
+	c.n, c.swallowing = 0, false
+}
@@ -0,0 +1,53 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+func TestCoalesce(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	src := strings.Join([]string{
+		`{"time": "2024-01-02T10:00:00Z", "level": "error", "msg": "connection refused"}`,
+		`java.net.ConnectException: Connection refused`,
+		`	at java.net.Socket.connect(Socket.java:1)`,
+		`{"time": "2024-01-02T10:00:01.5Z", "level": "error", "msg": "connection refused"}`,
+		`java.net.ConnectException: Connection refused`,
+		`	at java.net.Socket.connect(Socket.java:1)`,
+		`{"time": "2024-01-02T10:00:02Z", "level": "error", "msg": "connection refused"}`,
+		`{"time": "2024-01-02T10:00:03Z", "level": "info", "msg": "connected"}`,
+		`{"time": "2024-01-02T10:00:10Z", "level": "info", "msg": "connected"}`,
+		`{"time": "2024-01-02T10:00:11Z", "level": "warn", "msg": "health check failed"}`,
+		`{"time": "2024-01-02T10:00:12Z", "level": "warn", "msg": "health check failed"}`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.TimeFormat = "15:04:05"
+	opts.Coalesce = 2 * time.Second
+
+	var out bytes.Buffer
+	if err := Scanner(strings.NewReader(src), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		`10:00:00 |ERRO| connection refused `,
+		`      java.net.ConnectException: Connection refused`,
+		`      	at java.net.Socket.connect(Socket.java:1)`,
+		`── ×3 from 10:00:00 to 10:00:02 (2s)`,
+		`10:00:03 |INFO| connected `,
+		`10:00:10 |INFO| connected `,
+		`10:00:11 |WARN| health check failed `,
+		`── ×2 from 10:00:11 to 10:00:12 (1s)`,
+		``,
+	}, "\n")
+	if got := out.String(); got != want {
+		t.Errorf("want\n%s\ngot\n%s", want, got)
+	}
+}
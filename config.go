@@ -0,0 +1,230 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Config is the content of humanlog's configuration file, a JSON document.
+type Config struct {
+	// Enrich lists rules extracting new fields out of existing ones.
+	Enrich []EnrichRule `json:"enrich,omitempty"`
+	// Transform rewrites fields, keyed by their name, through a pipeline
+	// of steps.
+	//
+	//	{"transform": {"email": "lowercase", "url": "parse-url(host, path)"}}
+	Transform map[string]string `json:"transform,omitempty"`
+	// Sources override options for the sources matching a pattern, the
+	// first profile matching a source winning.
+	Sources []SourceProfile `json:"sources,omitempty"`
+	// Levels define custom levels, with their rank and color.
+	Levels []Level `json:"levels,omitempty"`
+	// LevelRules change the level of the entries matching conditions, the
+	// last matching rule winning.
+	//
+	//	{"level-rules": [{"when": "status>=500", "level": "error"}]}
+	LevelRules []LevelRule `json:"level-rules,omitempty"`
+	// Filters are filters saved under a name, for --filter to pick.
+	//
+	//	{"filters": {"prod-errors": "env=prod && level>=error"}}
+	Filters map[string]string `json:"filters,omitempty"`
+	// Units are the units of the numbers of fields, keyed by the fields'
+	// name, optionally converted to another unit.
+	//
+	//	{"units": {"duration_ms": "ms->s", "mem": "bytes->MiB", "temp": "°C"}}
+	Units map[string]string `json:"units,omitempty"`
+	// Budget colors the values of fields timing requests green, yellow or
+	// red against thresholds, keyed by the fields' name.
+	//
+	//	{"budget": {"duration_ms": {"warn": 500, "error": 2000}}}
+	Budget map[string]Budget `json:"budget,omitempty"`
+	// Types are the types fields are treated as, among FieldTypes, keyed by
+	// the fields' names.
+	//
+	//	{"types": {"user_id": "string", "port": "int", "started_at": "time"}}
+	Types map[string]string `json:"types,omitempty"`
+	// Links label the links to artifacts, with --link-labels, before
+	// DefaultLinkRules.
+	//
+	//	{"links": [{"pattern": "^https://ci\\.example\\.com/builds/(\\d+)$", "label": "build:$1"}]}
+	Links []LinkRule `json:"links,omitempty"`
+	// Grok parses the lines humanlog can't with grok expressions.
+	//
+	//	{"grok": [{"pattern": "%{TIMESTAMP_ISO8601:time} %{LOGLEVEL:level} %{GREEDYDATA:msg}"}]}
+	Grok []GrokFormat `json:"grok,omitempty"`
+	// LnavFormats are paths to lnav format definitions, relative to the
+	// configuration file, whose regexps parse the lines humanlog can't.
+	LnavFormats []string `json:"lnav-formats,omitempty"`
+	// Options set the command line flags, keyed by their name, for the flags
+	// that aren't given on the command line.
+	//
+	//	{"options": {"truncate": false, "time-format": "15:04:05", "skip": ["pid"]}}
+	Options map[string]interface{} `json:"options,omitempty"`
+
+	transforms []FieldTransform
+	units      map[string]FieldUnit
+	lnav       []*LnavFormat
+}
+
+// EnrichRule matches Pattern against a field (the message, if Field is
+// empty), and adds each named capture group of the match as a field.
+//
+//	{"pattern": "login for user (?P<user_id>\\d+) failed"}
+type EnrichRule struct {
+	Field   string `json:"field,omitempty"`
+	Pattern string `json:"pattern"`
+
+	re *regexp.Regexp
+}
+
+// DefaultConfigPath is where humanlog looks for a configuration file when
+// none is specified.
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "humanlog", "config.json")
+}
+
+// ReadConfig reads and validates the configuration file at path.
+func ReadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg := new(Config)
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for i := range cfg.Enrich {
+		rule := &cfg.Enrich[i]
+		rule.re, err = regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: enrich rule %d: %v", path, i, err)
+		}
+	}
+	for i := range cfg.Sources {
+		if err := cfg.Sources[i].compile(); err != nil {
+			return nil, fmt.Errorf("%s: source %d: %v", path, i, err)
+		}
+	}
+	for i := range cfg.Levels {
+		if err := cfg.Levels[i].compile(); err != nil {
+			return nil, fmt.Errorf("%s: level %d: %v", path, i, err)
+		}
+	}
+	for i := range cfg.LevelRules {
+		if err := cfg.LevelRules[i].compile(); err != nil {
+			return nil, fmt.Errorf("%s: level rule %d: %v", path, i, err)
+		}
+	}
+	levels := &HandlerOptions{Levels: cfg.Levels}
+	for name, expr := range cfg.Filters {
+		if _, err := ParseFilterExpr(expr, levels); err != nil {
+			return nil, fmt.Errorf("%s: filter %s: %v", path, name, err)
+		}
+	}
+	for i := range cfg.Links {
+		if err := cfg.Links[i].compile(); err != nil {
+			return nil, fmt.Errorf("%s: link %d: %v", path, i, err)
+		}
+	}
+	for i := range cfg.Grok {
+		if err := cfg.Grok[i].compile(); err != nil {
+			return nil, fmt.Errorf("%s: grok %d: %v", path, i, err)
+		}
+	}
+	for key, typ := range cfg.Types {
+		if !validFieldType(typ) {
+			return nil, fmt.Errorf("%s: type of %s: unknown type %q, want one of %s", path, key, typ, strings.Join(FieldTypes, ", "))
+		}
+	}
+	for key, b := range cfg.Budget {
+		if err := b.validate(); err != nil {
+			return nil, fmt.Errorf("%s: budget of %s: %v", path, key, err)
+		}
+	}
+	for key, spec := range cfg.Units {
+		u, err := ParseFieldUnit(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: unit of %s: %v", path, key, err)
+		}
+		if cfg.units == nil {
+			cfg.units = make(map[string]FieldUnit, len(cfg.Units))
+		}
+		cfg.units[key] = u
+	}
+	for _, name := range cfg.LnavFormats {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(filepath.Dir(path), name)
+		}
+		formats, err := LoadLnavFormats(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: lnav format: %v", path, err)
+		}
+		cfg.lnav = append(cfg.lnav, formats...)
+	}
+	fields := make([]string, 0, len(cfg.Transform))
+	for field := range cfg.Transform {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		t, err := ParseTransform(field, cfg.Transform[field])
+		if err != nil {
+			return nil, fmt.Errorf("%s: transform %s: %v", path, field, err)
+		}
+		cfg.transforms = append(cfg.transforms, t)
+	}
+	return cfg, nil
+}
+
+// Apply sets the options configured in the file.
+func (c *Config) Apply(opts *HandlerOptions) {
+	opts.Enrich = append(opts.Enrich, c.Enrich...)
+	opts.Transforms = append(opts.Transforms, c.transforms...)
+	opts.Sources = append(opts.Sources, c.Sources...)
+	opts.Levels = append(opts.Levels, c.Levels...)
+	opts.LevelRules = append(opts.LevelRules, c.LevelRules...)
+	opts.LinkRules = append(opts.LinkRules, c.Links...)
+	if len(c.Filters) != 0 && opts.NamedFilters == nil {
+		opts.NamedFilters = make(map[string]string, len(c.Filters))
+	}
+	for name, expr := range c.Filters {
+		opts.NamedFilters[name] = expr
+	}
+	if len(c.Budget) != 0 && opts.Budgets == nil {
+		opts.Budgets = make(map[string]Budget, len(c.Budget))
+	}
+	for key, b := range c.Budget {
+		opts.Budgets[key] = b
+	}
+	if len(c.units) != 0 && opts.Units == nil {
+		opts.Units = make(map[string]FieldUnit, len(c.units))
+	}
+	for key, u := range c.units {
+		opts.Units[key] = u
+	}
+	if len(c.Types) != 0 && opts.Types == nil {
+		opts.Types = make(map[string]string, len(c.Types))
+	}
+	for key, typ := range c.Types {
+		opts.Types[key] = typ
+	}
+	for i := range c.Grok {
+		opts.Rewrites = append(opts.Rewrites, c.Grok[i].Rewrite)
+	}
+	for _, f := range c.lnav {
+		opts.Rewrites = append(opts.Rewrites, f.Rewrite)
+	}
+}
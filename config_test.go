@@ -0,0 +1,70 @@
+package humanlog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadConfig_Enrich(t *testing.T) {
+	path := writeConfig(t, `{"enrich": [
+		{"pattern": "login for user (?P<user_id>\\d+) failed"},
+		{"field": "path", "pattern": "^/api/(?P<api_version>v\\d+)/"}
+	]}`)
+	cfg, err := ReadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &JSONHandler{}
+	if !h.TryHandle([]byte(`{"msg": "login for user 1234 failed", "path": "/api/v2/users"}`)) {
+		t.Fatal("failed to handle log")
+	}
+	enrich(cfg.Enrich, h)
+
+	if h.Fields["user_id"] != "1234" {
+		t.Errorf("want %q, got %q; want != got", "1234", h.Fields["user_id"])
+	}
+	if h.Fields["api_version"] != "v2" {
+		t.Errorf("want %q, got %q; want != got", "v2", h.Fields["api_version"])
+	}
+}
+
+func TestReadConfig_InvalidPattern(t *testing.T) {
+	path := writeConfig(t, `{"enrich": [{"pattern": "(unclosed"}]}`)
+	if _, err := ReadConfig(path); err == nil {
+		t.Fatal("want an error for an invalid pattern, got none")
+	}
+}
+
+func TestReadConfig_Options(t *testing.T) {
+	path := writeConfig(t, `{"options": {"truncate": false, "time-format": "15:04:05", "skip": ["pid", "host"]}}`)
+	cfg, err := ReadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := cfg.Options["truncate"].(bool); !ok || v {
+		t.Errorf("want truncate false, got %v", cfg.Options["truncate"])
+	}
+	if v := cfg.Options["time-format"]; v != "15:04:05" {
+		t.Errorf("want time-format %q, got %v", "15:04:05", v)
+	}
+	if v, ok := cfg.Options["skip"].([]interface{}); !ok || len(v) != 2 {
+		t.Errorf("want 2 keys to skip, got %v", cfg.Options["skip"])
+	}
+}
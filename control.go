@@ -0,0 +1,160 @@
+package humanlog
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Control lets other programs, like editor plugins and scripts, drive a
+// running session: they connect to the listener it serves and write a
+// command per line, and read a line back, starting with ok or error:
+//
+//	+key=value, -key=value, undo, clear  change the filters, like LiveFilters.Exec
+//	filters                              list the active filters
+//	hide key, show key                   hide a field, or show it again
+//	decode key, encode key               decode the payload of a field, or stop
+//	theme name                           switch to a theme, by name or path
+//	snapshot                             save the last lines shown
+//	pane source command                  filter or scroll a pane, like Panes.Exec
+//
+// Changes to the options are made between two entries, by Stage, so that
+// no entry is rendered halfway through one.
+type Control struct {
+	opts    *HandlerOptions
+	filters *LiveFilters
+	// Snapshot saves the last lines shown and returns the path of their
+	// file, when they're kept.
+	Snapshot func() (string, error)
+	// Panes are the panes of the entries, when they're shown in panes.
+	Panes *Panes
+
+	mu      sync.Mutex
+	pending []func()
+}
+
+// NewControl returns a Control changing opts and filters.
+func NewControl(opts *HandlerOptions, filters *LiveFilters) *Control {
+	return &Control{opts: opts, filters: filters}
+}
+
+// Exec runs a command, and returns what to reply.
+func (c *Control) Exec(cmd string) (string, error) {
+	cmd = strings.TrimSpace(cmd)
+	name, arg := cmd, ""
+	if i := strings.IndexByte(cmd, ' '); i > 0 {
+		name, arg = cmd[:i], strings.TrimSpace(cmd[i+1:])
+	}
+	switch name {
+	case "filters":
+		return c.filters.String(), nil
+	case "hide", "show":
+		if arg == "" {
+			return "", fmt.Errorf("want the key to %s", name)
+		}
+		hide := name == "hide"
+		c.later(func() {
+			if hide {
+				c.opts.SetSkip([]string{arg})
+			} else if _, ok := c.opts.Skip[arg]; ok {
+				delete(c.opts.Skip, arg)
+				c.opts.SetSkip(nil)
+			}
+		})
+		return name + " " + arg, nil
+	case "decode", "encode":
+		if arg == "" {
+			return "", fmt.Errorf("want the key to %s", name)
+		}
+		decode := name == "decode"
+		c.later(func() {
+			var fields []string
+			for _, field := range c.opts.DecodeFields {
+				if field != arg {
+					fields = append(fields, field)
+				}
+			}
+			if decode {
+				fields = append(fields, arg)
+			}
+			c.opts.DecodeFields = fields
+		})
+		return name + " " + arg, nil
+	case "theme":
+		if arg == "" {
+			return "", fmt.Errorf("want the name of a theme, or the path of one")
+		}
+		t, err := LoadTheme(arg)
+		if err != nil {
+			return "", err
+		}
+		c.later(func() { t.Apply(c.opts) })
+		return "theme " + t.Name, nil
+	case "pane":
+		if c.Panes == nil {
+			return "", fmt.Errorf("the entries are only shown in panes with --panes")
+		}
+		return c.Panes.Exec(arg)
+	case "snapshot":
+		if c.Snapshot == nil {
+			return "", fmt.Errorf("the lines shown are only kept with --snapshot")
+		}
+		return c.Snapshot()
+	}
+	if err := c.filters.Exec(cmd); err != nil {
+		return "", err
+	}
+	return c.filters.String(), nil
+}
+
+// later queues a change to the options, for Stage to make.
+func (c *Control) later(change func()) {
+	c.mu.Lock()
+	c.pending = append(c.pending, change)
+	c.mu.Unlock()
+}
+
+// Stage makes the changes queued since the entry before, then lets the
+// entries matching the filters through.
+func (c *Control) Stage(e *Entry, next Next) error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+	for _, change := range pending {
+		change()
+	}
+	return c.filters.Stage(e, next)
+}
+
+// Serve runs the commands of the connections ln accepts, until it's closed.
+func (c *Control) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go c.serveConn(conn)
+	}
+}
+
+func (c *Control) serveConn(conn net.Conn) {
+	defer conn.Close()
+	sc := bufio.NewScanner(conn)
+	for sc.Scan() {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		reply, err := c.Exec(sc.Text())
+		if err != nil {
+			reply = "error: " + err.Error()
+		} else {
+			reply = "ok " + reply
+		}
+		if _, err := fmt.Fprintln(conn, reply); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package humanlog
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestControl(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Skip = nil
+	ctl := NewControl(&opts, new(LiveFilters))
+
+	var shown []*Event
+	pass := func(ev *Event) {
+		t.Helper()
+		e := &Entry{Event: ev}
+		if err := ctl.Stage(e, func(e *Entry) error {
+			shown = append(shown, e.Event)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := ctl.Exec("theme tritanopia"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ctl.Exec("hide request_id"); err != nil {
+		t.Fatal(err)
+	}
+	if reply, err := ctl.Exec("+level=error"); err != nil || reply != "filters: +level=error" {
+		t.Fatalf("got %q (%v)", reply, err)
+	}
+	// the options only change between entries
+	if !opts.shouldShowKey("request_id") || opts.InfoLevelColor != DefaultOptions.InfoLevelColor {
+		t.Error("want the options unchanged until the next entry")
+	}
+	pass(&Event{Level: "info"})
+	pass(&Event{Level: "error"})
+	if len(shown) != 1 || shown[0].Level != "error" {
+		t.Errorf("want only the error shown, got %v", shown)
+	}
+	if opts.shouldShowKey("request_id") {
+		t.Error("want request_id hidden")
+	}
+	if opts.InfoLevelColor == DefaultOptions.InfoLevelColor {
+		t.Error("want the colors of the theme")
+	}
+
+	ctl.Exec("show request_id")
+	ctl.Exec("decode payload")
+	pass(&Event{Level: "error"})
+	if !opts.shouldShowKey("request_id") {
+		t.Error("want request_id shown again")
+	}
+	if !containsField(opts.DecodeFields, "payload") {
+		t.Error("want payload decoded")
+	}
+	ctl.Exec("encode payload")
+	pass(&Event{Level: "error"})
+	if len(opts.DecodeFields) != 0 {
+		t.Errorf("want payload left encoded, got %v", opts.DecodeFields)
+	}
+
+	for _, cmd := range []string{"theme nope", "hide", "decode", "snapshot", "frobnicate"} {
+		if _, err := ctl.Exec(cmd); err == nil {
+			t.Errorf("%q: want an error", cmd)
+		}
+	}
+	ctl.Snapshot = func() (string, error) { return "/tmp/humanlog-20240102-100000.log", nil }
+	if reply, err := ctl.Exec("snapshot"); err != nil || !strings.HasSuffix(reply, ".log") {
+		t.Errorf("got %q (%v)", reply, err)
+	}
+}
+
+func TestControl_Serve(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	opts := *DefaultOptions
+	ctl := NewControl(&opts, new(LiveFilters))
+	go ctl.Serve(ln)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "-msg=\"health check\"\n\nfilters\nundo\nundo\n")
+	replies := bufio.NewScanner(conn)
+	for _, want := range []string{
+		`ok filters: -msg=health check`,
+		`ok filters: -msg=health check`,
+		`ok no filters`,
+		`error: nothing to undo`,
+	} {
+		if !replies.Scan() {
+			t.Fatalf("want %q, got nothing (%v)", want, replies.Err())
+		}
+		if got := replies.Text(); got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Count reads the log lines of src and writes onto dst how many entries were
+// parsed and went through the filters, instead of the entries. When by isn't
+// empty, the entries are counted for each value of that field, which can be
+// one of the level or message fields, the most frequent first, like
+// `sort | uniq -c | sort -rn` would.
+func Count(src io.Reader, dst io.Writer, opts *HandlerOptions, by string) error {
+	var (
+		total  int
+		counts = make(map[string]int)
+	)
+	if by != "" {
+		withBy := *opts
+		withBy.needed = append(withBy.needed[:len(withBy.needed):len(withBy.needed)], by)
+		opts = &withBy
+	}
+	stages := append(builtinStages(opts), func(e *Entry, next Next) error {
+		if e.Event == nil {
+			return next(e)
+		}
+		total++
+		if by != "" {
+			counts[countKey(opts, e.Event, by)]++
+		}
+		return next(e)
+	})
+	if err := Process(src, opts, Chain(stages...)); err != nil {
+		return err
+	}
+
+	if by == "" {
+		_, err := fmt.Fprintln(dst, total)
+		return err
+	}
+	values := make([]string, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if counts[values[i]] != counts[values[j]] {
+			return counts[values[i]] > counts[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	for _, v := range values {
+		if _, err := fmt.Fprintf(dst, "%7d %s\n", counts[v], v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countKey returns the value of the field of ev entries are counted by.
+func countKey(opts *HandlerOptions, ev *Event, by string) string {
+	switch {
+	case containsField(opts.LevelFields, by):
+		return ev.Level
+	case containsField(opts.MessageFields, by):
+		return ev.Message
+	}
+	v, ok := ev.Fields[by]
+	if !ok {
+		return "<none>"
+	}
+	return unquoteValue(v)
+}
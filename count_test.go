@@ -0,0 +1,51 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	src := strings.Join([]string{
+		`{"msg": "a", "service": "api", "level": "info"}`,
+		`{"msg": "b", "service": "db", "level": "info"}`,
+		`{"msg": "c", "service": "api", "level": "info"}`,
+		`not structured`,
+		`{"msg": "d", "level": "info"}`,
+		`{"msg": "e", "service": "api", "level": "error"}`,
+	}, "\n")
+
+	tests := []struct {
+		name string
+		by   string
+		only []string
+		skip []string
+		want string
+	}{
+		{name: "total", want: "5\n"},
+		{name: "by field", by: "service", want: "      3 api\n      1 <none>\n      1 db\n"},
+		{name: "by level", by: "level", want: "      4 info\n      1 error\n"},
+		{
+			name: "filtered",
+			by:   "service",
+			only: []string{Fingerprint(&Event{Level: "error", Message: "e"})},
+			want: "      1 api\n",
+		},
+		{name: "by skipped field", by: "service", skip: []string{"service"}, want: "      3 api\n      1 <none>\n      1 db\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := *DefaultOptions
+			opts.OnlyFingerprints = tt.only
+			opts.SetSkip(tt.skip)
+			var out bytes.Buffer
+			if err := Count(strings.NewReader(src), &out, &opts, tt.by); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("want %q, got %q", tt.want, out.String())
+			}
+		})
+	}
+}
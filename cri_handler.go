@@ -0,0 +1,58 @@
+package humanlog
+
+import (
+	"bytes"
+	"regexp"
+	"time"
+)
+
+// criLogsPrefixRe parses out the prefix the container runtime interface puts
+// in front of each line of the files under /var/log/pods, like
+// '2024-01-02T03:04:05.123456789Z stdout F '. It has four parts:
+// 1. The time at which the runtime received the line
+// 2. The stream the line was written to
+// 3. A tag, F for a full line or P for a partial one
+// 4. The rest of the line
+var criLogsPrefixRe = regexp.MustCompile(`^(?P<timestamp>\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})) (?P<stream>stdout|stderr) (?P<tag>[FP]) (?P<rest_of_line>.*)$`)
+
+// sniffCRI tells CRI lines by their time followed by the stream.
+func sniffCRI(d []byte) confidence {
+	if !hasDatePrefix(d, 'T') {
+		return notFormat
+	}
+	if i := bytes.IndexByte(d, ' '); i > 0 && (bytes.HasPrefix(d[i:], []byte(" stdout ")) || bytes.HasPrefix(d[i:], []byte(" stderr "))) {
+		return surelyFormat
+	}
+	return notFormat
+}
+
+func tryCRIPrefix(d []byte, nextHandler handler) bool {
+	matches := criLogsPrefixRe.FindSubmatch(d)
+	if matches == nil || !nextHandler.TryHandle(matches[4]) {
+		return false
+	}
+	setCRIFields(matches, nextHandler)
+	return true
+}
+
+// tryCRIPlainText handles CRI lines whose content isn't structured, making
+// the content the message of the entry.
+func tryCRIPlainText(d []byte, h *JSONHandler) bool {
+	matches := criLogsPrefixRe.FindSubmatch(d)
+	if matches == nil {
+		return false
+	}
+	h.clear()
+	h.Message = string(matches[4])
+	setCRIFields(matches, h)
+	return true
+}
+
+func setCRIFields(matches [][]byte, h handler) {
+	if t, err := time.Parse(time.RFC3339Nano, string(matches[1])); err == nil {
+		h.defaultTime(t)
+	}
+	if string(matches[2]) == "stderr" {
+		h.setField([]byte("stream"), matches[2])
+	}
+}
@@ -0,0 +1,88 @@
+package humanlog
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_tryCRIPrefix(t *testing.T) {
+	tests := []struct {
+		name      string
+		logLine   []byte
+		wantMatch bool
+
+		wantTime    time.Time
+		wantLevel   string
+		wantMessage string
+		wantStream  string
+	}{
+		{
+			name: "no match",
+
+			logLine: []byte(`{"msg": "no prefix"}`),
+
+			wantMatch: false,
+		},
+		{
+			name: "json on stdout",
+
+			logLine: []byte(`2024-01-02T03:04:05.123456789Z stdout F {"msg": "some message", "level": "info"}`),
+
+			wantMatch:   true,
+			wantTime:    time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC),
+			wantLevel:   "info",
+			wantMessage: "some message",
+		},
+		{
+			name: "json with its own time on stderr",
+
+			logLine: []byte(`2024-01-02T03:04:05.123456789Z stderr F {"msg": "some message", "level": "error", "time": "2024-01-02T03:04:01Z"}`),
+
+			wantMatch:   true,
+			wantTime:    time.Date(2024, 1, 2, 3, 4, 1, 0, time.UTC),
+			wantLevel:   "error",
+			wantMessage: "some message",
+			wantStream:  "stderr",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := &JSONHandler{}
+			m := tryCRIPrefix(test.logLine, h)
+
+			if m != test.wantMatch {
+				t.Fatalf("want match %v, got %v", test.wantMatch, m)
+			}
+			if !test.wantMatch {
+				return
+			}
+
+			if !h.Time.Equal(test.wantTime) {
+				t.Errorf("want %v, got %v; want != got", test.wantTime, h.Time)
+			}
+			if h.Level != test.wantLevel {
+				t.Errorf("want %q, got %q; want != got", test.wantLevel, h.Level)
+			}
+			if h.Message != test.wantMessage {
+				t.Errorf("want %q, got %q; want != got", test.wantMessage, h.Message)
+			}
+			if h.Fields["stream"] != test.wantStream {
+				t.Errorf("want %q, got %q; want != got", test.wantStream, h.Fields["stream"])
+			}
+		})
+	}
+}
+
+func Test_tryCRIPlainText(t *testing.T) {
+	h := &JSONHandler{}
+	if !tryCRIPlainText([]byte(`2024-01-02T03:04:05Z stdout F starting up`), h) {
+		t.Fatal("expected the prefix to match, it did not")
+	}
+	if h.Message != "starting up" {
+		t.Errorf("want %q, got %q; want != got", "starting up", h.Message)
+	}
+	if h.Time.IsZero() {
+		t.Errorf("want a parsed time, got empty time; want != got")
+	}
+}
@@ -1,7 +1,9 @@
 package humanlog
 
 import (
+	"bytes"
 	"regexp"
+	"time"
 )
 
 // dcLogsPrefixRe parses out a prefix like 'web_1 | ' from docker-compose
@@ -15,7 +17,22 @@ var dcLogsPrefixRe = regexp.MustCompile("^(?:\x1b\\[\\d+m)?(?P<service_name>[a-z
 
 type handler interface {
 	TryHandle([]byte) bool
+	Prettify(skipUnchanged bool) []byte
 	setField(key, val []byte)
+	setLevel(val []byte)
+	defaultTime(t time.Time)
+	shiftTime(d time.Duration)
+	event() *Event
+	clear()
+}
+
+// sniffDockerComposePrefix tells the lines that may have the name of a
+// service in front of them, separated by a pipe.
+func sniffDockerComposePrefix(d []byte) confidence {
+	if bytes.IndexByte(d, '|') >= 0 {
+		return mayBeFormat
+	}
+	return notFormat
 }
 
 func tryDockerComposePrefix(d []byte, nextHandler handler) bool {
@@ -0,0 +1,100 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"regexp"
+	"time"
+)
+
+// dockerTimestampRe parses out the time `docker logs --timestamps` puts in
+// front of each line, like '2024-01-02T03:04:05.123456789Z '.
+var dockerTimestampRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})) (.*)$`)
+
+// sniffDockerTimestamp tells the lines behind the time docker received them.
+func sniffDockerTimestamp(d []byte) confidence {
+	if hasDatePrefix(d, 'T') {
+		return likelyFormat
+	}
+	return notFormat
+}
+
+// tryDockerTimestamp handles the lines of `docker logs --timestamps`, the
+// time docker received them being the time of the entries that don't have
+// one.
+func tryDockerTimestamp(d []byte, nextHandler handler) bool {
+	matches := dockerTimestampRe.FindSubmatch(d)
+	if matches == nil || !nextHandler.TryHandle(matches[2]) {
+		return false
+	}
+	if t, err := time.Parse(time.RFC3339Nano, string(matches[1])); err == nil {
+		nextHandler.defaultTime(t)
+	}
+	return true
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// isWrapped tells, from the first bytes of a stream, whether its lines are
+// compressed with gzip, or multiplexed like the logs of a container without
+// a terminal are by the Docker Engine API: in frames made of a header,
+// whose first byte tells the stream and last 4 bytes the size of the
+// payload, followed by the payload.
+func isWrapped(head []byte) (gzipped, multiplexed bool) {
+	if bytes.HasPrefix(head, gzipMagic) {
+		return true, false
+	}
+	if len(head) < 8 || head[0] > 2 || head[1] != 0 || head[2] != 0 || head[3] != 0 {
+		return false, false
+	}
+	return false, binary.BigEndian.Uint32(head[4:8]) != 0
+}
+
+// unwrapInput decompresses src, or demultiplexes it, if it has to be.
+func unwrapInput(src io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(src)
+	head, _ := br.Peek(8)
+	switch gzipped, multiplexed := isWrapped(head); {
+	case gzipped:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		// what was compressed may be multiplexed too
+		return unwrapInput(zr)
+	case multiplexed:
+		return &dockerDemuxer{src: br}, nil
+	}
+	return br, nil
+}
+
+// dockerDemuxer reads the payloads of the frames of a multiplexed stream,
+// stdout's and stderr's alike.
+type dockerDemuxer struct {
+	src  io.Reader
+	left uint32
+}
+
+func (d *dockerDemuxer) Read(p []byte) (int, error) {
+	for d.left == 0 {
+		var header [8]byte
+		if _, err := io.ReadFull(d.src, header[:]); err == io.ErrUnexpectedEOF {
+			return 0, io.EOF
+		} else if err != nil {
+			return 0, err
+		}
+		d.left = binary.BigEndian.Uint32(header[4:])
+	}
+	if uint32(len(p)) > d.left {
+		p = p[:d.left]
+	}
+	n, err := d.src.Read(p)
+	d.left -= uint32(n)
+	if err == io.EOF && d.left != 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
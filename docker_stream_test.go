@@ -0,0 +1,95 @@
+package humanlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scanMessages(t *testing.T, src io.Reader) []*Event {
+	t.Helper()
+	var got []*Event
+	err := ScanEvents(src, DefaultOptions, func(ev *Event) error {
+		got = append(got, ev)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestDockerTimestamps(t *testing.T) {
+	src := strings.Join([]string{
+		`2024-01-02T03:04:05.123456789Z {"level":"info","msg":"no time of its own"}`,
+		`2024-01-02T03:04:06Z {"level":"info","msg":"its own time","time":"2024-01-02T03:04:00Z"}`,
+		`2024-01-02T03:04:07Z level=warn msg="as logfmt"`,
+	}, "\n")
+	got := scanMessages(t, strings.NewReader(src))
+	want := []struct {
+		msg  string
+		time time.Time
+	}{
+		{"no time of its own", time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)},
+		{"its own time", time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)},
+		{"as logfmt", time.Date(2024, 1, 2, 3, 4, 7, 0, time.UTC)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %d entries, got %d", len(want), len(got))
+	}
+	for i, w := range want {
+		if got[i].Message != w.msg || !got[i].Time.Equal(w.time) {
+			t.Errorf("%d: want %q at %v, got %q at %v", i, w.msg, w.time, got[i].Message, got[i].Time)
+		}
+	}
+}
+
+func multiplex(frames ...string) []byte {
+	var buf bytes.Buffer
+	for i, frame := range frames {
+		header := [8]byte{byte(1 + i%2)}
+		binary.BigEndian.PutUint32(header[4:], uint32(len(frame)))
+		buf.Write(header[:])
+		buf.WriteString(frame)
+	}
+	return buf.Bytes()
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUnwrapInput(t *testing.T) {
+	lines := `{"level":"info","msg":"one"}` + "\n" + `{"level":"error","msg":"two"}` + "\n"
+	mux := multiplex(`{"level":"info","msg":"one"}`+"\n", `{"level":"error",`, `"msg":"two"}`+"\n")
+	for name, data := range map[string][]byte{
+		"gzip":             gzipped(t, []byte(lines)),
+		"multiplexed":      mux,
+		"gzip multiplexed": gzipped(t, mux),
+	} {
+		got := scanMessages(t, bytes.NewReader(data))
+		if len(got) != 2 || got[0].Message != "one" || got[1].Message != "two" {
+			t.Errorf("%s: got %v", name, got)
+		}
+	}
+}
+
+func TestIsWrapped(t *testing.T) {
+	for _, head := range []string{`{"msg"`, "level=info", "\x01\x00\x00\x00\x00\x00\x00\x00", "\x01\x00\x00"} {
+		if gz, mux := isWrapped([]byte(head)); gz || mux {
+			t.Errorf("%q: want it read as it is", head)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DuplicateKeysField is the field listing the keys an entry had more than
+// once, when DuplicateKeys is "flag".
+const DuplicateKeysField = "duplicate_keys"
+
+// DuplicateKeyPolicies are the values of DuplicateKeys: keep the last value
+// of duplicate keys like encoding/json does, keep the last one and list the
+// keys in DuplicateKeysField, keep the first one, or keep them all in a list.
+var DuplicateKeyPolicies = []string{"last", "flag", "first", "list"}
+
+// decodeJSONObject decodes a JSON object like json.Unmarshal, except for the
+// keys objects have more than once, whose values are kept as the policy
+// says. It also returns the paths of those keys, dotted.
+func decodeJSONObject(data []byte, policy string) (map[string]interface{}, []string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	d := &duplicateDecoder{dec: dec, policy: policy}
+	v, err := d.value("")
+	if err != nil {
+		return nil, nil, err
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("not a JSON object")
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, nil, fmt.Errorf("data after the JSON object")
+	}
+	return obj, d.dups, nil
+}
+
+type duplicateDecoder struct {
+	dec    *json.Decoder
+	policy string
+	dups   []string
+}
+
+func (d *duplicateDecoder) value(path string) (interface{}, error) {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		return d.object(path)
+	case json.Delim('['):
+		var arr []interface{}
+		for d.dec.More() {
+			v, err := d.value(path)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := d.dec.Token()
+		return arr, err
+	default:
+		return tok, nil
+	}
+}
+
+func (d *duplicateDecoder) object(path string) (interface{}, error) {
+	obj := make(map[string]interface{})
+	// listed are the keys whose values were gathered in a list, to tell
+	// them apart from keys whose value is an array
+	var listed map[string]bool
+	for d.dec.More() {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+		v, err := d.value(path + key + ".")
+		if err != nil {
+			return nil, err
+		}
+		old, seen := obj[key]
+		if !seen {
+			obj[key] = v
+			continue
+		}
+		if !containsField(d.dups, path+key) {
+			d.dups = append(d.dups, path+key)
+		}
+		switch d.policy {
+		case "first":
+		case "list":
+			if listed[key] {
+				obj[key] = append(old.([]interface{}), v)
+			} else {
+				if listed == nil {
+					listed = make(map[string]bool)
+				}
+				listed[key] = true
+				obj[key] = []interface{}{old, v}
+			}
+		default:
+			obj[key] = v
+		}
+	}
+	_, err := d.dec.Token()
+	return obj, err
+}
+
+// flagDuplicates lists the duplicate keys in raw, if the policy asks.
+func flagDuplicates(raw map[string]interface{}, dups []string, policy string) {
+	if policy == "flag" && len(dups) != 0 {
+		raw[DuplicateKeysField] = strings.Join(dups, ",")
+	}
+}
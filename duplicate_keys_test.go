@@ -0,0 +1,78 @@
+package humanlog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSONObjectDuplicates(t *testing.T) {
+	src := `{"user": "alice", "n": 1, "user": "admin", "ctx": {"ip": "10.0.0.1", "ip": "1.2.3.4"}, "user": "root", "tags": ["a"]}`
+
+	tests := []struct {
+		policy string
+		user   interface{}
+		ip     interface{}
+	}{
+		{"flag", "root", "1.2.3.4"},
+		{"first", "alice", "10.0.0.1"},
+		{"list", []interface{}{"alice", "admin", "root"}, []interface{}{"10.0.0.1", "1.2.3.4"}},
+	}
+	for _, tt := range tests {
+		raw, dups, err := decodeJSONObject([]byte(src), tt.policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(raw["user"], tt.user) {
+			t.Errorf("%s: want user %v, got %v", tt.policy, tt.user, raw["user"])
+		}
+		if ip := raw["ctx"].(map[string]interface{})["ip"]; !reflect.DeepEqual(ip, tt.ip) {
+			t.Errorf("%s: want ip %v, got %v", tt.policy, tt.ip, ip)
+		}
+		if want := []string{"user", "ctx.ip"}; !reflect.DeepEqual(dups, want) {
+			t.Errorf("%s: want duplicates %q, got %q", tt.policy, want, dups)
+		}
+		if want := []interface{}{"a"}; !reflect.DeepEqual(raw["tags"], want) {
+			t.Errorf("%s: want arrays kept as they are, got %v", tt.policy, raw["tags"])
+		}
+	}
+
+	for _, bad := range []string{`[1]`, `{"a": 1} {}`, `{"a": }`} {
+		if _, _, err := decodeJSONObject([]byte(bad), "first"); err == nil {
+			t.Errorf("want an error for %s", bad)
+		}
+	}
+}
+
+func TestDuplicateKeysOption(t *testing.T) {
+	src := `{"msg": "login", "user": "alice", "user": "admin"}`
+
+	opts := *DefaultOptions
+	opts.DuplicateKeys = "flag"
+	var got []*Event
+	err := ScanEvents(strings.NewReader(src), &opts, func(ev *Event) error {
+		got = append(got, opts.visibleEvent(ev))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Fields[DuplicateKeysField] != `"user"` || got[0].Fields["user"] != `"admin"` {
+		t.Errorf("want the duplicate key flagged, got %v", got)
+	}
+
+	opts.DuplicateKeys = "first"
+	got = nil
+	if err := ScanEvents(strings.NewReader(src), &opts, func(ev *Event) error {
+		got = append(got, opts.visibleEvent(ev))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Fields["user"] != `"alice"` {
+		t.Errorf("want the first value kept, got %v", got)
+	}
+	if _, ok := got[0].Fields[DuplicateKeysField]; ok {
+		t.Error("want duplicates only flagged when asked")
+	}
+}
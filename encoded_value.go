@@ -0,0 +1,157 @@
+package humanlog
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// minBase64Length and minHexLength are how long a value has to be to be
+	// taken for an encoded payload, rather than for an ID, a token or a hash.
+	minBase64Length = 48
+	minHexLength    = 96
+	// maxDecodedBytes is how much of a decoded payload is shown.
+	maxDecodedBytes = 512
+)
+
+// decodeValue returns the bytes v holds, and the name of their encoding,
+// when v looks like a base64 or hex encoded payload.
+func decodeValue(v string) (encoding string, data []byte, ok bool) {
+	if len(v) >= minHexLength && len(v)%2 == 0 && isHex(v) {
+		data, err := hex.DecodeString(v)
+		return "hex", data, err == nil
+	}
+	if len(v) < minBase64Length || !looksBase64(v) {
+		return "", nil, false
+	}
+	enc := base64.StdEncoding
+	if strings.ContainsAny(v, "-_") {
+		enc = base64.URLEncoding
+	}
+	if !strings.HasSuffix(v, "=") {
+		enc = enc.WithPadding(base64.NoPadding)
+	}
+	data, err := enc.DecodeString(v)
+	return "base64", data, err == nil
+}
+
+// isHex reports whether v is made of hex digits, all of the same case.
+func isHex(v string) bool {
+	var lower, upper bool
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; {
+		case c >= '0' && c <= '9':
+		case c >= 'a' && c <= 'f':
+			lower = true
+		case c >= 'A' && c <= 'F':
+			upper = true
+		default:
+			return false
+		}
+	}
+	return !(lower && upper)
+}
+
+// looksBase64 reports whether v is made of the characters of one base64
+// alphabet, mixed the way encoded bytes are rather than the way words and
+// paths are.
+func looksBase64(v string) bool {
+	var upper, lower, digits int
+	var std, url bool
+	body := strings.TrimRight(v, "=")
+	if len(v)-len(body) > 2 {
+		return false
+	}
+	for i := 0; i < len(body); i++ {
+		switch c := body[i]; {
+		case c >= 'A' && c <= 'Z':
+			upper++
+		case c >= 'a' && c <= 'z':
+			lower++
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '+' || c == '/':
+			std = true
+		case c == '-' || c == '_':
+			url = true
+		default:
+			return false
+		}
+	}
+	if std && url || body[0] == '/' {
+		return false
+	}
+	// about two in five characters of encoded bytes are capitals, which
+	// identifiers written in camel case are far from
+	return lower > 0 && digits > 0 && upper*8 >= len(body)
+}
+
+// encodedPlaceholder returns what to show in place of v when it looks like
+// an encoded payload, like base64[182B], with the size of what it holds.
+func (h *HandlerOptions) encodedPlaceholder(v string) (string, bool) {
+	if !h.HideEncoded {
+		return "", false
+	}
+	encoding, data, ok := decodeValue(unquoteValue(v))
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s[%dB]", encoding, len(data)), true
+}
+
+// decodedBlock returns the payload held by key decoded on lines of their
+// own, if key is one of the DecodeFields: as text when it's printable, or
+// as a hexdump otherwise.
+func (h *HandlerOptions) decodedBlock(key, val string) (string, bool) {
+	if !containsField(h.DecodeFields, key) {
+		return "", false
+	}
+	encoding, data, ok := decodeValue(unquoteValue(val))
+	if !ok {
+		return "", false
+	}
+	more := len(data) - maxDecodedBytes
+	if more > 0 {
+		data = data[:maxDecodedBytes]
+	}
+	var lines []string
+	if isPrintable(data) {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	} else {
+		lines = strings.Split(strings.TrimSuffix(hex.Dump(data), "\n"), "\n")
+	}
+	if more > 0 {
+		lines = append(lines, fmt.Sprintf("... %d more bytes ...", more))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("    ")
+	sb.WriteString(h.KeyColor.Sprint(key))
+	sb.WriteString(h.ContextColor.Sprintf(" (%s):", encoding))
+	for _, line := range lines {
+		sb.WriteString("\n      ")
+		sb.WriteString(h.ValColor.Sprint(line))
+	}
+	return sb.String(), true
+}
+
+// isPrintable reports whether data is text that can be shown as it is,
+// cut anywhere in its last character.
+func isPrintable(data []byte) bool {
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size <= 1 {
+			// the end of a character cut by maxDecodedBytes
+			return len(data) < utf8.UTFMax && !utf8.FullRune(data)
+		}
+		if !unicode.IsPrint(r) && r != '\n' && r != '\t' && r != '\r' {
+			return false
+		}
+		data = data[size:]
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestDecodeValue(t *testing.T) {
+	payload := []byte("\x00\x01binary payload\xff\xfe of some length, enough to be taken for one")
+	for _, tt := range []struct {
+		value    string
+		encoding string
+	}{
+		{base64.StdEncoding.EncodeToString(payload), "base64"},
+		{base64.RawURLEncoding.EncodeToString(payload), "base64"},
+		{hex.EncodeToString(payload), "hex"},
+		{strings.ToUpper(hex.EncodeToString(payload)), "hex"},
+		// IDs, hashes, paths and identifiers are left alone
+		{"550e8400-e29b-41d4-a716-446655440000", ""},
+		{"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", ""},
+		{"/var/lib/containers/storage/overlay/l/2024/volumes/data", ""},
+		{"handleIncomingConnectionRequestsFromUpstreamLoadBalancer2", ""},
+		{"the quick brown fox jumps over the lazy dog, twice over", ""},
+	} {
+		encoding, data, ok := decodeValue(tt.value)
+		if encoding != tt.encoding || ok != (tt.encoding != "") {
+			t.Errorf("%q: want %q, got %q (%v)", tt.value, tt.encoding, encoding, ok)
+			continue
+		}
+		if ok && !bytes.Equal(data, payload) {
+			t.Errorf("%q: got %q", tt.value, data)
+		}
+	}
+}
+
+func TestEncodedValues(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	text := base64.StdEncoding.EncodeToString([]byte(`{"order":42,"items":["book","pen"],"note":"gift"}`))
+	binary := hex.EncodeToString(bytes.Repeat([]byte{0xde, 0xad, 0xbe, 0xef}, 16))
+	line := []byte(`{"level":"info","msg":"received","body":"` + text + `","sig":"` + binary + `"}`)
+
+	render := func(opts *HandlerOptions) string {
+		h := JSONHandler{Opts: opts}
+		if !h.TryHandle(line) {
+			t.Fatal("want the line handled")
+		}
+		return string(h.Prettify(false))
+	}
+
+	opts := *DefaultOptions
+	got := render(&opts)
+	if !strings.Contains(got, "body=base64[49B]") || !strings.Contains(got, "sig=hex[64B]") {
+		t.Errorf("want placeholders, got %q", got)
+	}
+
+	opts.DecodeFields = []string{"body", "sig"}
+	got = render(&opts)
+	if !strings.Contains(got, "    body (base64):\n      "+`{"order":42,"items":["book","pen"],"note":"gift"}`) {
+		t.Errorf("want the body decoded as text, got %q", got)
+	}
+	if !strings.Contains(got, "    sig (hex):\n      00000000  de ad be ef") {
+		t.Errorf("want the signature as a hexdump, got %q", got)
+	}
+
+	opts = *DefaultOptions
+	opts.HideEncoded, opts.Truncates = false, false
+	if got := render(&opts); !strings.Contains(got, text) {
+		t.Errorf("want the payload as it is, got %q", got)
+	}
+}
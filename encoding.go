@@ -0,0 +1,123 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// decodeInput reads the lines of src, converted from the given encoding to
+// UTF-8. With an empty or "auto" encoding, it is guessed from the byte order
+// mark, from the zero bytes UTF-16 puts next to ASCII characters, or taken
+// to be Latin-1 when the start of src isn't valid UTF-8. The
+// lines are split before they're converted, so their offsets are the ones
+// they have in src.
+func decodeInput(src io.Reader, encoding string) (*lineReader, error) {
+	br := bufio.NewReader(src)
+	switch strings.ToLower(strings.Replace(encoding, "_", "-", -1)) {
+	case "", "auto":
+		return detectEncoding(br), nil
+	case "utf-8", "utf8":
+		return newEncodedLineReader(br, skipBOM(br, bomUTF8), bufio.ScanLines, nil), nil
+	case "utf-16le", "utf16le", "utf-16":
+		return utf16Lines(br, skipBOM(br, bomUTF16LE), true), nil
+	case "utf-16be", "utf16be":
+		return utf16Lines(br, skipBOM(br, bomUTF16BE), false), nil
+	case "latin-1", "latin1", "iso-8859-1", "iso8859-1":
+		return newEncodedLineReader(br, 0, bufio.ScanLines, charmap.ISO8859_1.NewDecoder()), nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+}
+
+func detectEncoding(br *bufio.Reader) *lineReader {
+	head, _ := br.Peek(4)
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		return newEncodedLineReader(br, skipBOM(br, bomUTF8), bufio.ScanLines, nil)
+	case bytes.HasPrefix(head, bomUTF16LE):
+		return utf16Lines(br, skipBOM(br, bomUTF16LE), true)
+	case bytes.HasPrefix(head, bomUTF16BE):
+		return utf16Lines(br, skipBOM(br, bomUTF16BE), false)
+	case len(head) == 4 && head[0] != 0 && head[1] == 0 && head[2] != 0 && head[3] == 0:
+		return utf16Lines(br, 0, true)
+	case len(head) == 4 && head[0] == 0 && head[1] != 0 && head[2] == 0 && head[3] != 0:
+		return utf16Lines(br, 0, false)
+	}
+	// what the first read brought in, without waiting for more of a stream
+	if head, _ = br.Peek(br.Buffered()); isLatin1(head) {
+		return newEncodedLineReader(br, 0, bufio.ScanLines, charmap.ISO8859_1.NewDecoder())
+	}
+	return newLineReader(br)
+}
+
+// sniffLen is how much of the input, at most, is looked at to guess its
+// encoding: what the first read of a bufio.Reader brings in.
+const sniffLen = 4096
+
+// isLatin1 tells whether head, the start of the input, is Latin-1 rather
+// than UTF-8, because it isn't valid UTF-8. A character cut at the end of
+// head doesn't count.
+func isLatin1(head []byte) bool {
+	for len(head) > 0 {
+		r, size := utf8.DecodeRune(head)
+		if r == utf8.RuneError && size == 1 {
+			return utf8.FullRune(head)
+		}
+		head = head[size:]
+	}
+	return false
+}
+
+// skipBOM discards the byte order mark br starts with, if any, and returns
+// its length.
+func skipBOM(br *bufio.Reader, bom []byte) int64 {
+	if head, _ := br.Peek(len(bom)); bytes.Equal(head, bom) {
+		n, _ := br.Discard(len(bom))
+		return int64(n)
+	}
+	return 0
+}
+
+// utf16Lines reads the lines of UTF-16 input, the byte order mark having
+// been skipped already. Unpaired surrogates become U+FFFD.
+func utf16Lines(br *bufio.Reader, skipped int64, little bool) *lineReader {
+	order := unicode.BigEndian
+	if little {
+		order = unicode.LittleEndian
+	}
+	dec := unicode.UTF16(order, unicode.IgnoreBOM).NewDecoder()
+	return newEncodedLineReader(br, skipped, scanUTF16Lines(little), dec)
+}
+
+// scanUTF16Lines splits UTF-16 input on its newlines, which are the two
+// bytes of a code unit rather than a '\n' byte.
+func scanUTF16Lines(little bool) bufio.SplitFunc {
+	newline := [2]byte{0, '\n'}
+	if little {
+		newline = [2]byte{'\n', 0}
+	}
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == newline[0] && data[i+1] == newline[1] {
+				return i + 2, data[:i], nil
+			}
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
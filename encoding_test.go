@@ -0,0 +1,120 @@
+package humanlog
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+func encodeUTF16(s string, little bool) []byte {
+	var b bytes.Buffer
+	for _, unit := range utf16.Encode([]rune(s)) {
+		if little {
+			b.WriteByte(byte(unit))
+			b.WriteByte(byte(unit >> 8))
+		} else {
+			b.WriteByte(byte(unit >> 8))
+			b.WriteByte(byte(unit))
+		}
+	}
+	return b.Bytes()
+}
+
+func decodeLines(t *testing.T, input []byte, encoding string) ([]string, []int64) {
+	t.Helper()
+	r, err := decodeInput(bytes.NewReader(input), encoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		lines   []string
+		offsets []int64
+	)
+	for {
+		rec, ok := r.next()
+		if !ok {
+			break
+		}
+		lines = append(lines, string(rec.data))
+		offsets = append(offsets, rec.offset)
+	}
+	if err := r.err(); err != nil {
+		t.Fatal(err)
+	}
+	return lines, offsets
+}
+
+func TestDecodeInput(t *testing.T) {
+	first, second := `{"msg": "héllo 😀"}`, `{"msg": "bye"}`
+	input := first + "\n" + second + "\n"
+	le, be := encodeUTF16(first+"\n", true), encodeUTF16(first+"\n", false)
+	tests := []struct {
+		name     string
+		encoding string
+		input    []byte
+		// where the lines start in the input
+		offsets []int64
+	}{
+		{name: "plain utf-8", input: []byte(input), offsets: []int64{0, int64(len(first) + 1)}},
+		{name: "utf-8 with BOM", input: append(append([]byte{}, bomUTF8...), input...), offsets: []int64{3, int64(3 + len(first) + 1)}},
+		{name: "utf-16le with BOM", input: append(append([]byte{}, bomUTF16LE...), encodeUTF16(input, true)...), offsets: []int64{2, int64(2 + len(le))}},
+		{name: "utf-16be with BOM", input: append(append([]byte{}, bomUTF16BE...), encodeUTF16(input, false)...), offsets: []int64{2, int64(2 + len(be))}},
+		{name: "utf-16le without BOM", input: encodeUTF16(input, true), offsets: []int64{0, int64(len(le))}},
+		{name: "explicit utf-16be", encoding: "utf-16be", input: encodeUTF16(input, false), offsets: []int64{0, int64(len(be))}},
+		{name: "explicit utf-8 with BOM", encoding: "utf-8", input: append(append([]byte{}, bomUTF8...), input...), offsets: []int64{3, int64(3 + len(first) + 1)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines, offsets := decodeLines(t, test.input, test.encoding)
+			if want := []string{first, second}; !reflect.DeepEqual(want, lines) {
+				t.Errorf("want %q, got %q, want != got", want, lines)
+			}
+			if !reflect.DeepEqual(test.offsets, offsets) {
+				t.Errorf("want %v, got %v, want != got", test.offsets, offsets)
+			}
+		})
+	}
+
+	t.Run("lone surrogate", func(t *testing.T) {
+		// a high surrogate followed by an 'A' rather than a low surrogate
+		lines, offsets := decodeLines(t, []byte("\x00\xd8A\x00\r\x00\n\x00B\x00"), "utf-16le")
+		if want := []string{"�A", "B"}; !reflect.DeepEqual(want, lines) {
+			t.Errorf("want %q, got %q, want != got", want, lines)
+		}
+		if want := []int64{0, 8}; !reflect.DeepEqual(want, offsets) {
+			t.Errorf("want %v, got %v, want != got", want, offsets)
+		}
+	})
+
+	t.Run("latin-1", func(t *testing.T) {
+		lines, offsets := decodeLines(t, []byte("h\xe9llo\r\nbye"), "latin-1")
+		if want := []string{"héllo", "bye"}; !reflect.DeepEqual(want, lines) {
+			t.Errorf("want %q, got %q, want != got", want, lines)
+		}
+		if want := []int64{0, 7}; !reflect.DeepEqual(want, offsets) {
+			t.Errorf("want %v, got %v, want != got", want, offsets)
+		}
+	})
+
+	t.Run("guessed latin-1", func(t *testing.T) {
+		lines, _ := decodeLines(t, []byte("h\xe9llo\nbye"), "")
+		if want := []string{"héllo", "bye"}; !reflect.DeepEqual(want, lines) {
+			t.Errorf("want %q, got %q, want != got", want, lines)
+		}
+	})
+}
+
+func TestIsLatin1(t *testing.T) {
+	for head, want := range map[string]bool{
+		"":             false,
+		"plain":        false,
+		"héllo":        false,
+		"h\xe9llo":     true,
+		"cut at h\xc3": false,
+	} {
+		if got := isLatin1([]byte(head)); got != want {
+			t.Errorf("%q: want %v, got %v", head, want, got)
+		}
+	}
+}
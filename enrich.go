@@ -0,0 +1,28 @@
+package humanlog
+
+// enrich adds the fields captured by the enrichment rules to the entry.
+func enrich(rules []EnrichRule, entry handler) {
+	ev := entry.event()
+	for _, rule := range rules {
+		if rule.re == nil {
+			continue
+		}
+		src := ev.Message
+		if rule.Field != "" {
+			v, ok := ev.Fields[rule.Field]
+			if !ok {
+				continue
+			}
+			src = unquoteValue(v)
+		}
+		matches := rule.re.FindStringSubmatch(src)
+		if matches == nil {
+			continue
+		}
+		for i, name := range rule.re.SubexpNames() {
+			if i != 0 && name != "" {
+				entry.setField([]byte(name), []byte(matches[i]))
+			}
+		}
+	}
+}
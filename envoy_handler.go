@@ -0,0 +1,149 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// envoyTextLine matches the start of Envoy's default access log format, up
+// to the response code:
+//
+//	[2016-04-15T20:17:00.310Z] "POST /api/v1/locations HTTP/2" 204 - 154 0 226 100 "10.0.35.28" "nsq2http" ...
+var envoyTextLine = regexp.MustCompile(`^\[([^\]]+)\] "(\S+) (\S+) ([^"]+)" (\d+|-) (.*)$`)
+
+// envoyToken matches the rest of the line's values, quoted or not.
+var envoyToken = regexp.MustCompile(`"[^"]*"|\S+`)
+
+// sniffEnvoy tells Envoy's JSON access logs by their keys, and its text ones
+// by their opening bracket.
+func sniffEnvoy(d []byte) confidence {
+	switch {
+	case bytes.HasPrefix(d, []byte(`{`)) && bytes.Contains(d, []byte(`"response_code"`)) && bytes.Contains(d, []byte(`"start_time"`)):
+		return surelyFormat
+	case bytes.HasPrefix(d, []byte(`[`)):
+		return likelyFormat
+	}
+	return notFormat
+}
+
+// envoyColumns are the fields following the response code in Envoy's
+// default format, and in Istio's, named like the keys of their JSON
+// formats.
+var envoyColumns = map[int][]string{
+	10: {
+		"response_flags", "bytes_received", "bytes_sent", "duration", "upstream_service_time",
+		"x_forwarded_for", "user_agent", "request_id", "authority", "upstream_host",
+	},
+	19: {
+		"response_flags", "response_code_details", "connection_termination_details", "upstream_transport_failure_reason",
+		"bytes_received", "bytes_sent", "duration", "upstream_service_time",
+		"x_forwarded_for", "user_agent", "request_id", "authority", "upstream_host", "upstream_cluster",
+		"upstream_local_address", "downstream_local_address", "downstream_remote_address",
+		"requested_server_name", "route_name",
+	},
+}
+
+// tryEnvoy handles the access logs of Envoy and Istio, in their default text
+// format or as JSON. The method and path make the message of the entry, and
+// the response code its level. The durations, response flags, route and
+// the other values are fields.
+func tryEnvoy(d []byte, h *LogfmtHandler) bool {
+	if bytes.HasPrefix(d, []byte(`{`)) {
+		return tryEnvoyJSON(d, h)
+	}
+	if !bytes.HasPrefix(d, []byte(`[`)) {
+		return false
+	}
+	m := envoyTextLine.FindSubmatch(d)
+	if m == nil {
+		return false
+	}
+	values := envoyToken.FindAll(m[6], -1)
+	columns, ok := envoyColumns[len(values)]
+	if !ok {
+		return false
+	}
+
+	fields := map[string]string{
+		"start_time":    string(m[1]),
+		"method":        string(m[2]),
+		"path":          string(m[3]),
+		"protocol":      string(m[4]),
+		"response_code": string(m[5]),
+	}
+	for i, name := range columns {
+		fields[name] = strings.Trim(string(values[i]), `"`)
+	}
+	setEnvoyEntry(fields, h)
+	return true
+}
+
+// tryEnvoyJSON handles the JSON access logs, which have the keys of Istio's
+// default JSON format.
+func tryEnvoyJSON(d []byte, h *LogfmtHandler) bool {
+	if !bytes.Contains(d, []byte(`"response_code"`)) || !bytes.Contains(d, []byte(`"start_time"`)) {
+		return false
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(d, &raw); err != nil {
+		return false
+	}
+	fields := make(map[string]string, len(raw))
+	for k, v := range raw {
+		var s string
+		if json.Unmarshal(v, &s) == nil {
+			fields[k] = s
+		} else if string(v) != "null" {
+			fields[k] = string(v)
+		}
+	}
+	setEnvoyEntry(fields, h)
+	return true
+}
+
+// setEnvoyEntry sets the entry of h from the values of an access log line.
+// Values Envoy didn't have, written as -, are left out.
+func setEnvoyEntry(fields map[string]string, h *LogfmtHandler) {
+	for k, v := range fields {
+		if v == "" || v == "-" {
+			delete(fields, k)
+		}
+	}
+
+	if t, ok := tryParseTime(fields["start_time"]); ok {
+		h.Time = t
+	}
+	h.Message = strings.TrimSpace(fields["method"] + " " + fields["path"])
+	if h.Message == "" {
+		// TCP connections have neither, the upstream tells them apart
+		h.Message = fields["upstream_cluster"]
+		if h.Message == "" {
+			h.Message = fields["upstream_host"]
+		}
+	}
+	h.Level = envoyLevel(fields["response_code"], fields["response_flags"])
+
+	for k, v := range fields {
+		switch k {
+		case "start_time", "method", "path":
+			continue
+		}
+		if _, drop := h.drop[k]; !drop {
+			h.setField([]byte(k), []byte(v))
+		}
+	}
+}
+
+// envoyLevel tells how bad a response was by its code, or by its flags when
+// there was no response, like when the upstream couldn't be reached.
+func envoyLevel(code, flags string) string {
+	if code != "" && code != "0" {
+		return statusLevel(code)
+	}
+	if flags != "" {
+		return "error"
+	}
+	return "info"
+}
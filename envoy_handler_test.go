@@ -0,0 +1,86 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvoyAccessLogs(t *testing.T) {
+	src := strings.Join([]string{
+		`[2016-04-15T20:17:00.310Z] "POST /api/v1/locations HTTP/2" 204 - 154 0 226 100 "10.0.35.28" "nsq2http" "cc21d9b0-cf5c-432b-8c7e-98aeb7988cd2" "locations" "tcp://10.0.2.1:80"`,
+		`[2020-11-25T21:26:18.409Z] "GET /status/418 HTTP/1.1" 503 UF,URX upstream_reset_before_response_started{connection_failure} - "-" 0 91 4 - "-" "curl/7.73.0-DEV" "84961386-6d84-929d-98bd-c5aee93b5c88" "httpbin:8000" "10.44.1.27:80" outbound|8000||httpbin.foo.svc.cluster.local - 10.0.45.184:8000 10.44.1.23:46520 - default`,
+		`{"start_time": "2020-11-25T21:26:18.409Z", "method": "GET", "path": "/headers", "protocol": "HTTP/1.1", "response_code": 404, "response_flags": "NR", "duration": 1, "upstream_service_time": null, "route_name": "-"}`,
+		`[2020-11-25T21:26:19.000Z] "- - -" 0 UF 0 0 1001 - "-" "-" "-" "-" "10.44.1.27:3306"`,
+		`[2020-11-25T21:26:19.000Z] "GET / HTTP/1.1" 200 too few columns`,
+	}, "\n")
+
+	var got []*Event
+	err := ScanEvents(strings.NewReader(src), DefaultOptions, func(ev *Event) error {
+		got = append(got, ev)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		time    time.Time
+		level   string
+		message string
+		fields  map[string]string
+	}{
+		{
+			time:    time.Date(2016, 4, 15, 20, 17, 0, 310e6, time.UTC),
+			level:   "info",
+			message: "POST /api/v1/locations",
+			fields: map[string]string{
+				"protocol": "HTTP/2", "response_code": "204", "bytes_received": "154", "bytes_sent": "0", "duration": "226",
+				"upstream_service_time": "100", "x_forwarded_for": "10.0.35.28", "user_agent": "nsq2http",
+				"request_id": "cc21d9b0-cf5c-432b-8c7e-98aeb7988cd2", "authority": "locations", "upstream_host": "tcp://10.0.2.1:80",
+			},
+		},
+		{
+			time:    time.Date(2020, 11, 25, 21, 26, 18, 409e6, time.UTC),
+			level:   "error",
+			message: "GET /status/418",
+			fields: map[string]string{
+				"protocol": "HTTP/1.1", "response_code": "503", "response_flags": "UF,URX",
+				"response_code_details": "upstream_reset_before_response_started{connection_failure}",
+				"bytes_received":        "0", "bytes_sent": "91", "duration": "4", "user_agent": "curl/7.73.0-DEV",
+				"request_id": "84961386-6d84-929d-98bd-c5aee93b5c88", "authority": "httpbin:8000", "upstream_host": "10.44.1.27:80",
+				"upstream_cluster": "outbound|8000||httpbin.foo.svc.cluster.local", "downstream_local_address": "10.0.45.184:8000",
+				"downstream_remote_address": "10.44.1.23:46520", "route_name": "default",
+			},
+		},
+		{
+			time:    time.Date(2020, 11, 25, 21, 26, 18, 409e6, time.UTC),
+			level:   "warn",
+			message: "GET /headers",
+			fields:  map[string]string{"protocol": "HTTP/1.1", "response_code": "404", "response_flags": "NR", "duration": "1"},
+		},
+		{
+			time:    time.Date(2020, 11, 25, 21, 26, 19, 0, time.UTC),
+			level:   "error",
+			message: "10.44.1.27:3306",
+			fields:  map[string]string{"response_code": "0", "response_flags": "UF", "bytes_received": "0", "bytes_sent": "0", "duration": "1001", "upstream_host": "10.44.1.27:3306"},
+		},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %d entries, got %d", len(want), len(got))
+	}
+	for i, w := range want {
+		ev := got[i]
+		if !ev.Time.Equal(w.time) || ev.Level != w.level || ev.Message != w.message {
+			t.Errorf("%d: want %v %s %q, got %v %s %q", i, w.time, w.level, w.message, ev.Time, ev.Level, ev.Message)
+		}
+		if len(ev.Fields) != len(w.fields) {
+			t.Errorf("%d: want fields %q, got %q", i, w.fields, ev.Fields)
+		}
+		for k, v := range w.fields {
+			if ev.Fields[k] != v {
+				t.Errorf("%d: %s: want %q, got %q", i, k, v, ev.Fields[k])
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package humanlog
+
+import (
+	"strconv"
+	"time"
+)
+
+// Event is a log entry once a handler has parsed it, independent of the
+// format it was read from.
+type Event struct {
+	Time    time.Time
+	Level   string
+	Message string
+	Fields  map[string]string
+}
+
+// unquoteValue strips the quoting JSON string values carry in Fields.
+func unquoteValue(v string) string {
+	if len(v) >= 2 && v[0] == '"' {
+		if s, err := strconv.Unquote(v); err == nil {
+			return s
+		}
+	}
+	return v
+}
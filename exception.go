@@ -0,0 +1,48 @@
+package humanlog
+
+import (
+	"io"
+	"regexp"
+)
+
+var (
+	// exceptionStart matches the first line of a Python traceback, or of a
+	// Java or Node exception.
+	exceptionStart = regexp.MustCompile(`^(Traceback \(most recent call last\):|Exception in thread "[^"]*" |([\w$]+\.)+[\w$]*(Exception|Error)(: |$)|(\w*Error|Exception): )`)
+	// exceptionFrame matches the lines that follow: stack frames, causes,
+	// and Python's closing "SomeError: message".
+	exceptionFrame = regexp.MustCompile(`^(\s+at |\s+\.\.\. \d+ (more|common frames omitted)|\s+File "|\s+|Caused by: |Suppressed: |([\w$]+\.)*[\w$]*(Exception|Error|Exit|Interrupt)(: |$)|During handling of the above exception|The above exception was the direct cause)`)
+)
+
+// exceptions recognizes the unstructured exceptions following a structured
+// entry, so they can be shown as part of it.
+type exceptions struct {
+	afterEntry bool
+	inside     bool
+}
+
+// entry is called for each structured entry.
+func (x *exceptions) entry() {
+	x.afterEntry, x.inside = true, false
+}
+
+// continues tells if an unstructured line is part of an exception following
+// the last structured entry.
+func (x *exceptions) continues(line []byte) bool {
+	if !x.afterEntry || len(line) == 0 {
+		x.afterEntry, x.inside = false, false
+		return false
+	}
+	if x.inside && exceptionFrame.Match(line) || exceptionStart.Match(line) {
+		x.inside = true
+		return true
+	}
+	x.afterEntry, x.inside = false, false
+	return false
+}
+
+func writeExceptionLine(dst io.Writer, opts *HandlerOptions, line []byte) {
+	io.WriteString(dst, "      ")
+	io.WriteString(dst, opts.TracebackColor.Sprint(string(line)))
+	dst.Write(eol[:])
+}
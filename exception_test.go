@@ -0,0 +1,37 @@
+package humanlog
+
+import "testing"
+
+func TestExceptions(t *testing.T) {
+	lines := []struct {
+		line       string
+		structured bool
+		want       bool
+	}{
+		{line: "Traceback (most recent call last):", want: false},
+		{line: `{"msg": "failed"}`, structured: true},
+		{line: "Traceback (most recent call last):", want: true},
+		{line: `  File "app.py", line 3, in <module>`, want: true},
+		{line: "ValueError: bad", want: true},
+		{line: "listening on :8080", want: false},
+		{line: "\tat com.foo.Bar.baz(Bar.java:12)", want: false},
+		{line: `{"msg": "failed"}`, structured: true},
+		{line: "java.lang.IllegalStateException: nope", want: true},
+		{line: "\tat com.foo.Bar.baz(Bar.java:12)", want: true},
+		{line: "Caused by: java.io.IOException: closed", want: true},
+		{line: "\t... 3 more", want: true},
+		{line: `{"msg": "failed"}`, structured: true},
+		{line: "just some text", want: false},
+		{line: "    indented text", want: false},
+	}
+	var x exceptions
+	for i, l := range lines {
+		if l.structured {
+			x.entry()
+			continue
+		}
+		if got := x.continues([]byte(l.line)); got != l.want {
+			t.Errorf("line %d %q: want %v, got %v", i, l.line, l.want, got)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHookEvery is how often a hook runs at most, unless it says.
+const defaultHookEvery = 10 * time.Second
+
+// ExecHook runs Command with the shell when an entry matches all of its
+// conditions, written like those of level rules. The entry is passed on
+// stdin, as the JSON object --output ndjson-normalized writes. A hook runs
+// at most once every Every, and not while it's still running.
+type ExecHook struct {
+	Text    string
+	Command string
+	Every   time.Duration
+
+	conds []fieldCondition
+}
+
+// ParseExecHook reads hooks like:
+//
+//	level=fatal: ./page-me.sh
+//	msg~deadlock every 5m: curl -s localhost:6060/debug/pprof/goroutine?debug=2 > dump.txt
+//
+// The conditions end at the first colon followed by a space, so that they
+// can hold colons themselves, like url~https://example.com or time~10:30.
+func ParseExecHook(s string) (ExecHook, error) {
+	i := strings.Index(s, ": ")
+	if i < 0 {
+		return ExecHook{}, fmt.Errorf("missing : command")
+	}
+	hook := ExecHook{Text: s, Command: strings.TrimSpace(s[i+2:]), Every: defaultHookEvery}
+	if hook.Command == "" {
+		return hook, fmt.Errorf("missing command")
+	}
+	words := strings.Fields(s[:i])
+	for j := 0; j < len(words); j++ {
+		if words[j] == "every" {
+			if j+1 == len(words) {
+				return hook, fmt.Errorf("missing duration after every")
+			}
+			j++
+			every, err := time.ParseDuration(words[j])
+			if err != nil || every < 0 {
+				return hook, fmt.Errorf("invalid duration %q", words[j])
+			}
+			hook.Every = every
+			continue
+		}
+		cond, err := parseFieldCondition(words[j])
+		if err != nil {
+			return hook, err
+		}
+		hook.conds = append(hook.conds, cond)
+	}
+	if len(hook.conds) == 0 {
+		return hook, fmt.Errorf("missing conditions")
+	}
+	return hook, nil
+}
+
+func (h *ExecHook) matches(ev *Event) bool {
+	for i := range h.conds {
+		if !h.conds[i].matches(ev) {
+			return false
+		}
+	}
+	return true
+}
+
+// hookRunner rate limits the runs of a hook, counting the matches it
+// skipped.
+type hookRunner struct {
+	hook ExecHook
+
+	mu      sync.Mutex
+	last    time.Time
+	running bool
+	skipped int
+}
+
+// due tells whether the hook can run at now, counting a skipped match if it
+// can't.
+func (r *hookRunner) due(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running || (!r.last.IsZero() && now.Sub(r.last) < r.hook.Every) {
+		r.skipped++
+		return false
+	}
+	r.last, r.running = now, true
+	return true
+}
+
+// run starts the command, with the entry on its stdin. The matches skipped
+// since the last run are in $HUMANLOG_HOOK_SKIPPED.
+func (r *hookRunner) run(entry []byte) error {
+	r.mu.Lock()
+	skipped := r.skipped
+	r.skipped = 0
+	r.mu.Unlock()
+
+	cmd := exec.Command("sh", "-c", r.hook.Command)
+	cmd.Env = append(os.Environ(),
+		"HUMANLOG_HOOK="+r.hook.Text,
+		"HUMANLOG_HOOK_SKIPPED="+strconv.Itoa(skipped),
+	)
+	cmd.Stdin = bytes.NewReader(entry)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	if err := cmd.Start(); err != nil {
+		r.done()
+		return err
+	}
+	go func() {
+		cmd.Wait()
+		r.done()
+	}()
+	return nil
+}
+
+func (r *hookRunner) done() {
+	r.mu.Lock()
+	r.running = false
+	r.mu.Unlock()
+}
+
+// hookStage runs the hooks the parsed entries match. Hooks that can't be
+// started are reported onto dst.
+func hookStage(dst io.Writer, opts *HandlerOptions) Stage {
+	runners := make([]*hookRunner, len(opts.Hooks))
+	for i, hook := range opts.Hooks {
+		runners[i] = &hookRunner{hook: hook}
+	}
+	return func(e *Entry, next Next) error {
+		if e.Event != nil {
+			var entry []byte
+			for _, r := range runners {
+				if !r.hook.matches(e.Event) || !r.due(time.Now()) {
+					continue
+				}
+				if entry == nil {
+					entry, _ = json.Marshal(newNDJSONRecord(e, opts))
+					entry = append(entry, '\n')
+				}
+				if err := r.run(entry); err != nil {
+					opts.DiagnosticColor.Fprintf(dst, "── can't run the hook %q: %v\n", r.hook.Text, err)
+				}
+			}
+		}
+		return next(e)
+	}
+}
@@ -0,0 +1,139 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseExecHook(t *testing.T) {
+	hook, err := ParseExecHook("level=fatal service=api: ./page-me.sh --now")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.Command != "./page-me.sh --now" || hook.Every != defaultHookEvery || len(hook.conds) != 2 {
+		t.Errorf("got %+v", hook)
+	}
+	hook, err = ParseExecHook("msg~deadlock every 5m: ./dump.sh")
+	if err != nil || hook.Every != 5*time.Minute {
+		t.Errorf("want every 5m, got %v (%v)", hook.Every, err)
+	}
+
+	hook, err = ParseExecHook("url~https://example.com/api time~^10:30: ./x.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := []string{"url", "time"}, conditionKeys(hook.conds); strings.Join(want, ",") != strings.Join(got, ",") || hook.Command != "./x.sh" {
+		t.Errorf("want conditions on %q and ./x.sh, got %q and %q", want, got, hook.Command)
+	}
+
+	for _, s := range []string{"level=fatal", "level=fatal:", "level=fatal:./x.sh", ": ./x.sh", "level=fatal every: ./x.sh", "level=fatal every soon: ./x.sh", "level: ./x.sh"} {
+		if _, err := ParseExecHook(s); err == nil {
+			t.Errorf("%q: want an error", s)
+		}
+	}
+}
+
+func TestExecHook_Matches(t *testing.T) {
+	hook, err := ParseExecHook("level=error status>=500: true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hook.matches(&Event{Level: "ERROR", Fields: map[string]string{"status": "503"}}) {
+		t.Error("want a match")
+	}
+	if hook.matches(&Event{Level: "error", Fields: map[string]string{"status": "404"}}) {
+		t.Error("want no match")
+	}
+}
+
+func TestHookRunner_Due(t *testing.T) {
+	r := &hookRunner{hook: ExecHook{Every: time.Minute}}
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	if !r.due(start) {
+		t.Fatal("want the first match to run")
+	}
+	r.done()
+	if r.due(start.Add(30 * time.Second)) {
+		t.Error("want the match within the minute skipped")
+	}
+	if !r.due(start.Add(time.Minute)) {
+		t.Error("want it to run once the minute passed")
+	}
+	if r.due(start.Add(3 * time.Minute)) {
+		t.Error("want it skipped while still running")
+	}
+	if r.skipped != 2 {
+		t.Errorf("want 2 skipped, got %d", r.skipped)
+	}
+}
+
+func TestHookStage(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	dir, err := ioutil.TempDir("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "entry.json")
+
+	hook, err := ParseExecHook("level=fatal: cat > " + out + ".tmp && mv " + out + ".tmp " + out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := *DefaultOptions
+	opts.Hooks = []ExecHook{hook}
+	src := strings.Join([]string{
+		`{"level":"info","msg":"fine"}`,
+		`{"level":"fatal","msg":"on fire","port":8080}`,
+	}, "\n")
+	if err := Process(strings.NewReader(src), &opts, Chain(append(builtinStages(&opts), hookStage(ioutil.Discard, &opts))...)); err != nil {
+		t.Fatal(err)
+	}
+
+	var data []byte
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if data, err = ioutil.ReadFile(out); err == nil {
+			break
+		}
+	}
+	var rec ndjsonRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		t.Fatalf("want the entry as JSON, got %q: %v", data, err)
+	}
+	if rec.Message != "on fire" || string(rec.Fields["port"]) != "8080" {
+		t.Errorf("got %+v", rec)
+	}
+}
+
+func TestDroppedKeys_Stages(t *testing.T) {
+	opts := *DefaultOptions
+	opts.SetSkip([]string{"user", "request_id", "step", "region", "latency", "size", "port", "pid"})
+	hook, err := ParseExecHook("user=bob: cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	span, err := ParseSpanRule("request_id: step=start .. step=end")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Hooks = []ExecHook{hook}
+	opts.Spans = []SpanRule{span}
+	opts.Baseline = Baseline{"region": {"us-east-1"}}
+	opts.Budgets = map[string]Budget{"latency": {}}
+	opts.Units = map[string]FieldUnit{"size": {}}
+	opts.Types = map[string]string{"port": "int"}
+
+	// only pid is read by nothing
+	dropped := opts.droppedKeys()
+	if _, ok := dropped["pid"]; !ok || len(dropped) != 1 {
+		t.Errorf("want only pid dropped, got %v", dropped)
+	}
+}
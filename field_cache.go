@@ -0,0 +1,99 @@
+package humanlog
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// fieldCacheSize is how many field names the cache remembers.
+	fieldCacheSize = 500
+	// fieldCacheSample is how many entries of a run the cache learns from,
+	// the fields of the ones after them being mostly the same.
+	fieldCacheSample = 1000
+)
+
+// FieldCache remembers the names of the fields seen recently, for shell
+// completion to suggest them.
+type FieldCache struct {
+	// seen orders the names, the most recent ones having the highest
+	// numbers
+	seen    map[string]int
+	n       int
+	entries int
+}
+
+// DefaultFieldCachePath is where the field names are cached.
+func DefaultFieldCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "humanlog", "fields")
+}
+
+// LoadFieldCache reads the names cached at path, one per line and the most
+// recent first. A missing file is an empty cache.
+func LoadFieldCache(path string) (*FieldCache, error) {
+	c := &FieldCache{seen: make(map[string]int)}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" {
+			names = append(names, name)
+		}
+	}
+	for i := len(names) - 1; i >= 0; i-- {
+		c.add(names[i])
+	}
+	return c, scanner.Err()
+}
+
+func (c *FieldCache) add(name string) {
+	c.n++
+	c.seen[name] = c.n
+}
+
+// Stage learns the fields of the first entries going through it.
+func (c *FieldCache) Stage(e *Entry, next Next) error {
+	if e.Event != nil && c.entries < fieldCacheSample {
+		c.entries++
+		for k := range e.Event.Fields {
+			c.add(k)
+		}
+	}
+	return next(e)
+}
+
+// Names lists the names cached, the most recent first.
+func (c *FieldCache) Names() []string {
+	names := make([]string, 0, len(c.seen))
+	for name := range c.seen {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return c.seen[names[i]] > c.seen[names[j]] })
+	if len(names) > fieldCacheSize {
+		names = names[:fieldCacheSize]
+	}
+	return names
+}
+
+// Save writes the names cached to path.
+func (c *FieldCache) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(strings.Join(c.Names(), "\n")+"\n"), 0644)
+}
@@ -0,0 +1,45 @@
+package humanlog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFieldCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cache", "fields")
+
+	cache, err := LoadFieldCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.Names()) != 0 {
+		t.Errorf("want an empty cache, got %q", cache.Names())
+	}
+
+	opts := *DefaultOptions
+	opts.Stages = []Stage{cache.Stage}
+	if err := ScanEvents(strings.NewReader(`{"msg": "a", "user_id": 1}`), &opts, func(*Event) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err = LoadFieldCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.add("status")
+	if want := []string{"status", "user_id"}; !reflect.DeepEqual(cache.Names(), want) {
+		t.Errorf("want %q, got %q", want, cache.Names())
+	}
+}
@@ -0,0 +1,81 @@
+package humanlog
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FieldTypes are the types fields can be told to be treated as, whatever
+// their values look like: strings keep numbers as they were written, like
+// the leading zeros or all the digits of an ID, and times are shown like
+// the time of entries, even when they were seconds since the epoch.
+var FieldTypes = []string{"string", "int", "float", "bool", "time"}
+
+// ParseFieldType reads the type of a field, like user_id=string.
+func ParseFieldType(text string) (string, string, error) {
+	i := strings.LastIndex(text, "=")
+	if i <= 0 {
+		return "", "", fmt.Errorf("want field=type, like user_id=string")
+	}
+	key, typ := text[:i], text[i+1:]
+	if !validFieldType(typ) {
+		return "", "", fmt.Errorf("unknown type %q, want one of %s", typ, strings.Join(FieldTypes, ", "))
+	}
+	return key, typ, nil
+}
+
+func validFieldType(typ string) bool {
+	for _, t := range FieldTypes {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
+
+// typeValue converts the text of a field whose type is in Types, JSON text
+// for JSON entries, and returns how to show it and its kind. Values that
+// aren't of the type are kept as they were, of the invalid kind. ok is
+// false for the fields without a type.
+func (h *HandlerOptions) typeValue(key, text string) (v string, kind valueKind, ok bool) {
+	typ, ok := h.Types[key]
+	if !ok {
+		return "", 0, false
+	}
+	s := text
+	if strings.HasPrefix(text, `"`) {
+		if unquoted, err := strconv.Unquote(text); err == nil {
+			s = unquoted
+		}
+	}
+	switch typ {
+	case "string":
+		return s, kindString, true
+	case "int":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return strconv.FormatInt(n, 10), kindNumber, true
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil && f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+			return strconv.FormatInt(int64(f), 10), kindNumber, true
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return strconv.FormatFloat(f, 'g', -1, 64), kindNumber, true
+		}
+	case "bool":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return strconv.FormatBool(b), kindBool, true
+		}
+	case "time":
+		var value interface{} = s
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			value = f
+		}
+		if t, ok := tryParseTime(value); ok {
+			return t.Format(h.TimeFormat), kindTime, true
+		}
+	}
+	return text, kindInvalid, true
+}
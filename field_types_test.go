@@ -0,0 +1,86 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFieldType(t *testing.T) {
+	key, typ, err := ParseFieldType("user_id=string")
+	if err != nil || key != "user_id" || typ != "string" {
+		t.Errorf("got %q %q (%v)", key, typ, err)
+	}
+	for _, text := range []string{"user_id", "=int", "port=integer"} {
+		if _, _, err := ParseFieldType(text); err == nil {
+			t.Errorf("%q: want an error", text)
+		}
+	}
+}
+
+func TestTypeValue(t *testing.T) {
+	opts := &HandlerOptions{
+		TimeFormat: "2006-01-02 15:04:05",
+		Types:      map[string]string{"id": "string", "port": "int", "ratio": "float", "ok": "bool", "at": "time"},
+	}
+	tests := []struct {
+		key, text string
+		want      string
+		kind      valueKind
+	}{
+		{key: "id", text: "000123", want: "000123", kind: kindString},
+		{key: "id", text: "123456789012345678901", want: "123456789012345678901", kind: kindString},
+		{key: "port", text: `"8080"`, want: "8080", kind: kindNumber},
+		{key: "port", text: "8080.0", want: "8080", kind: kindNumber},
+		{key: "port", text: "eighty", want: "eighty", kind: kindInvalid},
+		{key: "ratio", text: `"0.5"`, want: "0.5", kind: kindNumber},
+		{key: "ok", text: "1", want: "true", kind: kindBool},
+		// seconds since the epoch are shown in the local time zone
+		{key: "at", text: "1704189600", want: time.Unix(1704189600, 0).Format("2006-01-02 15:04:05"), kind: kindTime},
+		{key: "at", text: `"2024-01-02T10:00:00Z"`, want: "2024-01-02 10:00:00", kind: kindTime},
+		{key: "at", text: `"soon"`, want: `"soon"`, kind: kindInvalid},
+	}
+	for _, test := range tests {
+		got, kind, ok := opts.typeValue(test.key, test.text)
+		if !ok || got != test.want || kind != test.kind {
+			t.Errorf("%s=%s: want %q of kind %d, got %q of kind %d", test.key, test.text, test.want, test.kind, got, kind)
+		}
+	}
+	if _, _, ok := opts.typeValue("other", "1"); ok {
+		t.Error("want no conversion for a field without a type")
+	}
+}
+
+func TestJSONHandler_Types(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Types = map[string]string{"user_id": "string", "port": "int"}
+	h := JSONHandler{Opts: &opts}
+	if !h.TryHandle([]byte(`{"msg":"hi","user_id":123456789012345678,"port":"8080","count":123456789012345678}`)) {
+		t.Fatal("not handled")
+	}
+	if got := h.Fields["user_id"]; got != `"123456789012345678"` {
+		t.Errorf("want all the digits of the ID, got %s", got)
+	}
+	if got := h.Fields["port"]; got != "8080" || h.kinds["port"] != kindNumber {
+		t.Errorf("want the port as a number, got %s", got)
+	}
+	if got := h.Fields["count"]; strings.Contains(got, "123456789012345678") {
+		t.Errorf("want untyped fields decoded as before, got %s", got)
+	}
+}
+
+func TestConfigTypes(t *testing.T) {
+	if _, err := ReadConfig(writeConfig(t, `{"types": {"port": "integer"}}`)); err == nil {
+		t.Error("want an error for an unknown type")
+	}
+	cfg, err := ReadConfig(writeConfig(t, `{"types": {"port": "int"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := *DefaultOptions
+	opts.Types = nil
+	cfg.Apply(&opts)
+	if opts.Types["port"] != "int" {
+		t.Errorf("got %v", opts.Types)
+	}
+}
@@ -0,0 +1,47 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// fieldsJSON writes the fields shown as a compact JSON object, for the
+// FieldsJSON modes. kinds are the types the values had, if known.
+func (h *HandlerOptions) fieldsJSON(fields map[string]string, kinds map[string]valueKind) string {
+	keys := make([]string, 0, len(fields))
+	for k, v := range fields {
+		if !h.shouldShowKey(k) {
+			continue
+		}
+		if h.HideNull && h.kindOf(k, v, kinds) == kindNull {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(jsonValue(fields[k]))
+	}
+	buf.WriteByte('}')
+	return h.ContextColor.Sprint(buf.String())
+}
+
+func (h *HandlerOptions) kindOf(k, v string, kinds map[string]valueKind) valueKind {
+	if kind, ok := kinds[k]; ok {
+		return kind
+	}
+	return kindOfText(v)
+}
@@ -0,0 +1,53 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestFieldsJSON(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	src := strings.Join([]string{
+		`{"time": "2024-01-02T10:00:00Z", "level": "info", "msg": "a", "user": "al\"ice", "n": 1.5, "ok": true, "parent": null}`,
+		`time=2024-01-02T10:00:01Z level=warn msg=b user=bob n=2 cached=false`,
+		`{"time": "2024-01-02T10:00:02Z", "level": "info", "msg": "c"}`,
+	}, "\n")
+
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{mode: "end", want: []string{
+			`10:00:00 |INFO| a {"n":1.5,"ok":true,"parent":null,"user":"al\"ice"}`,
+			`10:00:01 |WARN| b {"cached":false,"n":2,"user":"bob"}`,
+			`10:00:02 |INFO| c `,
+			``,
+		}},
+		{mode: "line", want: []string{
+			`10:00:00 |INFO| a `,
+			`      {"n":1.5,"ok":true,"parent":null,"user":"al\"ice"}`,
+			`10:00:01 |WARN| b `,
+			`      {"cached":false,"n":2,"user":"bob"}`,
+			`10:00:02 |INFO| c `,
+			``,
+		}},
+	}
+	for _, test := range tests {
+		opts := *DefaultOptions
+		opts.TimeFormat = "15:04:05"
+		opts.FieldsJSON = test.mode
+
+		var out bytes.Buffer
+		if err := Scanner(strings.NewReader(src), &out, &opts); err != nil {
+			t.Fatal(err)
+		}
+		if want := strings.Join(test.want, "\n"); out.String() != want {
+			t.Errorf("%s: want\n%s\ngot\n%s", test.mode, want, out.String())
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package humanlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FilterExpr is a boolean expression of conditions on the entries, like
+// those of LevelRule, composed with && (and), || (or), ! (not) and
+// parentheses:
+//
+//	(level>=warn && service=api) || msg~timeout
+//	!(status<400) && path!=/healthz
+//
+// Levels are compared by their rank, so that level>=warn matches errors too.
+// Values holding spaces or operators are written between double quotes, like
+// msg~"connection (reset|refused)".
+type FilterExpr struct {
+	Expr string
+
+	root filterNode
+	// keys are the fields the conditions of the filter read.
+	keys []string
+}
+
+type filterNode interface {
+	matches(ev *Event) bool
+}
+
+type (
+	andFilter  []filterNode
+	orFilter   []filterNode
+	notFilter  struct{ filterNode }
+	condFilter struct{ cond fieldCondition }
+	// levelFilter compares the rank of the level of entries with rank.
+	levelFilter struct {
+		op   string
+		rank int
+		opts *HandlerOptions
+	}
+)
+
+func (f andFilter) matches(ev *Event) bool {
+	for _, n := range f {
+		if !n.matches(ev) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f orFilter) matches(ev *Event) bool {
+	for _, n := range f {
+		if n.matches(ev) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f notFilter) matches(ev *Event) bool { return !f.filterNode.matches(ev) }
+
+func (f condFilter) matches(ev *Event) bool { return f.cond.matches(ev) }
+
+func (f levelFilter) matches(ev *Event) bool {
+	rank, ok := f.opts.LevelRank(ev.Level)
+	if !ok {
+		return false
+	}
+	switch f.op {
+	case "<":
+		return rank < f.rank
+	case "<=":
+		return rank <= f.rank
+	case ">":
+		return rank > f.rank
+	default:
+		return rank >= f.rank
+	}
+}
+
+// ParseFilterExpr reads a filter expression, whose levels are ranked by opts.
+func ParseFilterExpr(expr string, opts *HandlerOptions) (*FilterExpr, error) {
+	p := filterParser{s: expr, opts: opts}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.i < len(p.s) {
+		return nil, fmt.Errorf("unexpected %q at %d in %q", p.s[p.i:], p.i, expr)
+	}
+	return &FilterExpr{Expr: expr, root: root, keys: p.keys}, nil
+}
+
+// Matches tells whether the entry matches the filter.
+func (f *FilterExpr) Matches(ev *Event) bool {
+	return f.root.matches(ev)
+}
+
+// filterParser reads a filter by recursive descent, && binding tighter
+// than ||.
+type filterParser struct {
+	s    string
+	i    int
+	opts *HandlerOptions
+	keys []string
+}
+
+func (p *filterParser) skipSpace() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+// consume skips tok, if it's next.
+func (p *filterParser) consume(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.s[p.i:], tok) {
+		p.i += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) or() (filterNode, error) {
+	var nodes orFilter
+	for {
+		n, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+		if !p.consume("||") {
+			break
+		}
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+func (p *filterParser) and() (filterNode, error) {
+	var nodes andFilter
+	for {
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+		if !p.consume("&&") {
+			break
+		}
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+func (p *filterParser) unary() (filterNode, error) {
+	switch {
+	case p.consume("!"):
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{n}, nil
+	case p.consume("("):
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, fmt.Errorf("missing ) at %d in %q", p.i, p.s)
+		}
+		return n, nil
+	}
+	return p.condition()
+}
+
+// condition reads key, operator and value, the value ending at a space, a
+// parenthesis it didn't open, or an && or ||, unless it's quoted.
+func (p *filterParser) condition() (filterNode, error) {
+	p.skipSpace()
+	start := p.i
+	for p.i < len(p.s) && !strings.ContainsRune("!=~<>()&| \t", rune(p.s[p.i])) {
+		p.i++
+	}
+	key := p.s[start:p.i]
+	var op string
+	for _, o := range conditionOps {
+		if strings.HasPrefix(p.s[p.i:], o) {
+			op = o
+			break
+		}
+	}
+	if key == "" || op == "" {
+		return nil, fmt.Errorf("want key, operator and value at %d in %q", start, p.s)
+	}
+	p.i += len(op)
+
+	var value string
+	if p.i < len(p.s) && p.s[p.i] == '"' {
+		end := p.i + 1
+		for end < len(p.s) && p.s[end] != '"' {
+			if p.s[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.s) {
+			return nil, fmt.Errorf("unterminated quote at %d in %q", p.i, p.s)
+		}
+		v, err := strconv.Unquote(p.s[p.i : end+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted value at %d in %q", p.i, p.s)
+		}
+		value, p.i = v, end+1
+	} else {
+		vstart, depth := p.i, 0
+		for ; p.i < len(p.s); p.i++ {
+			c := p.s[p.i]
+			if c == ' ' || c == '\t' || c == ')' && depth == 0 ||
+				strings.HasPrefix(p.s[p.i:], "&&") || strings.HasPrefix(p.s[p.i:], "||") {
+				break
+			}
+			// the groups of regexps, like msg~(?i)timeout
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				depth--
+			}
+		}
+		value = p.s[vstart:p.i]
+	}
+
+	if key == "level" && strings.ContainsAny(op, "<>") {
+		rank, ok := p.opts.LevelRank(value)
+		if !ok {
+			return nil, fmt.Errorf("unknown level %q in %q", value, p.s)
+		}
+		return levelFilter{op: op, rank: rank, opts: p.opts}, nil
+	}
+	cond, err := parseFieldCondition(key + op + value)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = append(p.keys, cond.key)
+	return condFilter{cond}, nil
+}
+
+// matchesWhere tells whether the entry matches all of Where.
+func (h *HandlerOptions) matchesWhere(ev *Event) bool {
+	for _, f := range h.Where {
+		if !f.Matches(ev) {
+			return false
+		}
+	}
+	return true
+}
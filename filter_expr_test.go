@@ -0,0 +1,122 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilterExpr(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Levels = []Level{{Name: "audit", Rank: 45}}
+	events := []*Event{
+		{Level: "info", Message: "listening", Fields: map[string]string{"service": `"api"`}},
+		{Level: "warn", Message: "slow query", Fields: map[string]string{"service": `"api"`, "duration_ms": "1200"}},
+		{Level: "error", Message: "request timeout", Fields: map[string]string{"service": `"worker"`}},
+		{Level: "audit", Message: "login", Fields: map[string]string{"service": `"auth"`}},
+		{Level: "info", Message: "connection reset by peer", Fields: map[string]string{"service": `"worker"`}},
+	}
+	for _, tt := range []struct {
+		expr string
+		want string
+	}{
+		{`(level>=warn && service=api) || msg~timeout`, "slow query,request timeout"},
+		{`level>=warn && !(service=api)`, "request timeout,login"},
+		{`level>warn || level<info`, "request timeout,login"},
+		{`service=worker&&msg~(?i)^(REQUEST|connection)`, "request timeout,connection reset by peer"},
+		{`msg~"connection reset" || duration_ms>1000`, "slow query,connection reset by peer"},
+		{`!!(service!=api)`, "request timeout,login,connection reset by peer"},
+		{`service=auth || service=api && level=info`, "listening,login"},
+	} {
+		f, err := ParseFilterExpr(tt.expr, &opts)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		var got []string
+		for _, ev := range events {
+			if f.Matches(ev) {
+				got = append(got, ev.Message)
+			}
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("%s: want %s, got %s", tt.expr, tt.want, strings.Join(got, ","))
+		}
+	}
+
+	for _, expr := range []string{
+		"", "level>=loud", "(service=api", "service=api)", "service", `msg~"open`,
+		"service=api &&", "status>high", "msg~(", "service=api service=worker",
+	} {
+		if _, err := ParseFilterExpr(expr, &opts); err == nil {
+			t.Errorf("%q: want an error", expr)
+		}
+	}
+}
+
+func TestFilterExpr_Where(t *testing.T) {
+	opts := *DefaultOptions
+	f, err := ParseFilterExpr("level>=error || msg~boot", &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Where = []*FilterExpr{f}
+	src := strings.Join([]string{
+		`{"level":"info","msg":"booting"}`,
+		`{"level":"info","msg":"ready"}`,
+		`{"level":"error","msg":"db down"}`,
+	}, "\n")
+	var got []string
+	err = ScanEvents(strings.NewReader(src), &opts, func(ev *Event) error {
+		got = append(got, ev.Message)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "booting,db down" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestReadConfig_Filters(t *testing.T) {
+	cfg, err := ReadConfig(writeConfig(t, `{"levels": [{"name": "audit", "rank": 45}], "filters": {"prod-errors": "env=prod && level>=error", "audits": "level=audit || level>audit"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := *DefaultOptions
+	cfg.Apply(&opts)
+	if opts.NamedFilters["prod-errors"] != "env=prod && level>=error" || len(opts.NamedFilters) != 2 {
+		t.Errorf("got %v", opts.NamedFilters)
+	}
+
+	_, err = ReadConfig(writeConfig(t, `{"filters": {"broken": "(env=prod"}}`))
+	if err == nil || !strings.Contains(err.Error(), "filter broken") {
+		t.Errorf("want the broken filter reported, got %v", err)
+	}
+}
+
+func TestFilterExpr_WhereSkipped(t *testing.T) {
+	opts := *DefaultOptions
+	opts.SetSkip([]string{"user"})
+	f, err := ParseFilterExpr("user=bob", &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Where = []*FilterExpr{f}
+	src := strings.Join([]string{
+		`{"msg":"hi","user":"bob"}`,
+		`{"msg":"bye","user":"ann"}`,
+	}, "\n")
+	var got []string
+	err = ScanEvents(strings.NewReader(src), &opts, func(ev *Event) error {
+		got = append(got, ev.Message)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the filter sees the field it's hidden from the output
+	if strings.Join(got, ",") != "hi" {
+		t.Errorf("want hi, got %v", got)
+	}
+}
@@ -0,0 +1,54 @@
+package humanlog
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+// FingerprintField holds the fingerprint of the entries, when they're shown.
+const FingerprintField = "fingerprint"
+
+// messageVariables matches the parts of a message that change from one
+// occurrence of a log line to the next: quoted strings, UUIDs, IPs, hex
+// identifiers and numbers.
+var messageVariables = regexp.MustCompile(`"[^"]*"|\B'[^']*'\B|\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b|\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b|\b0x[0-9a-fA-F]+\b|[-+]?\d+(\.\d+)?|\b[0-9a-fA-F]*\d[0-9a-fA-F]*\b`)
+
+// messageTemplate returns the message with its variable parts replaced by *.
+func messageTemplate(msg string) string {
+	return messageVariables.ReplaceAllString(msg, "*")
+}
+
+// Fingerprint returns a short hash of the level and the template of the
+// message of ev, which is the same for every occurrence of a log line,
+// whatever the values in its message.
+func Fingerprint(ev *Event) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(strings.ToLower(ev.Level)))
+	_, _ = hash.Write([]byte{0})
+	_, _ = hash.Write([]byte(messageTemplate(ev.Message)))
+	return fmt.Sprintf("%06x", hash.Sum32()&0xffffff)
+}
+
+// fingerprintStage shows the fingerprint of the entries as a field, and drops
+// those without one of OnlyFingerprints.
+func fingerprintStage(opts *HandlerOptions) Stage {
+	only := make(map[string]bool, len(opts.OnlyFingerprints))
+	for _, fp := range opts.OnlyFingerprints {
+		only[strings.ToLower(fp)] = true
+	}
+	return func(e *Entry, next Next) error {
+		if e.Event == nil {
+			return next(e)
+		}
+		fp := Fingerprint(e.Event)
+		if len(only) != 0 && !only[fp] {
+			return nil
+		}
+		if opts.Fingerprints {
+			e.Set(FingerprintField, fp)
+		}
+		return next(e)
+	}
+}
@@ -0,0 +1,50 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMessageTemplate(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"user 42 logged in from 10.0.0.1:8080", "user * logged in from *"},
+		{`cache miss for "abc"`, "cache miss for *"},
+		{"request 6f1c0a2e-8d3b-4c1e-9f00-1234567890ab took 1.5s", "request * took *s"},
+		{"commit deadbeef1 pushed", "commit * pushed"},
+		{"server started", "server started"},
+		{"can't find 'app.yaml', won't start", "can't find *, won't start"},
+	}
+	for _, tt := range tests {
+		if got := messageTemplate(tt.msg); got != tt.want {
+			t.Errorf("%q: want %q, got %q", tt.msg, tt.want, got)
+		}
+	}
+}
+
+func TestFingerprintStage(t *testing.T) {
+	src := strings.Join([]string{
+		`{"level": "info", "msg": "user 42 logged in"}`,
+		`{"level": "info", "msg": "user 7 logged in"}`,
+		`{"level": "error", "msg": "user 7 logged in"}`,
+	}, "\n")
+	fp := Fingerprint(&Event{Level: "INFO", Message: "user 1 logged in"})
+
+	opts := *DefaultOptions
+	opts.Fingerprints = true
+	opts.OnlyFingerprints = []string{fp}
+	var got []string
+	chain := Chain(append(builtinStages(&opts), func(e *Entry, next Next) error {
+		got = append(got, e.Event.Fields[FingerprintField])
+		return next(e)
+	})...)
+	if err := Process(strings.NewReader(src), &opts, chain); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 || got[0] != fp || got[1] != fp {
+		t.Errorf("want the 2 info entries with fingerprint %s, got %q", fp, got)
+	}
+}
@@ -0,0 +1,102 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"time"
+)
+
+// fluentSink forwards events using the message mode of the Fluentd forward
+// protocol: a msgpack array of [tag, time, record].
+// https://github.com/fluent/fluentd/wiki/Forward-Protocol-Specification-v1
+type fluentSink struct {
+	dialer *conn
+	tag    string
+	buf    bytes.Buffer
+}
+
+func (s *fluentSink) Send(ev *Event) error {
+	rec := sinkRecord(ev)
+	ts := ev.Time
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	s.buf.Reset()
+	msgpackArrayHeader(&s.buf, 3)
+	msgpackString(&s.buf, s.tag)
+	msgpackUint(&s.buf, uint64(ts.Unix()))
+	msgpackMapHeader(&s.buf, len(rec))
+
+	keys := make([]string, 0, len(rec))
+	for k := range rec {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		msgpackString(&s.buf, k)
+		msgpackString(&s.buf, rec[k])
+	}
+	_, err := s.dialer.Write(s.buf.Bytes())
+	return err
+}
+
+func (s *fluentSink) Close() error { return s.dialer.Close() }
+
+func msgpackArrayHeader(b *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		b.WriteByte(0x90 | byte(n))
+	case n < 1<<16:
+		b.WriteByte(0xdc)
+		_ = binary.Write(b, binary.BigEndian, uint16(n))
+	default:
+		b.WriteByte(0xdd)
+		_ = binary.Write(b, binary.BigEndian, uint32(n))
+	}
+}
+
+func msgpackMapHeader(b *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		b.WriteByte(0x80 | byte(n))
+	case n < 1<<16:
+		b.WriteByte(0xde)
+		_ = binary.Write(b, binary.BigEndian, uint16(n))
+	default:
+		b.WriteByte(0xdf)
+		_ = binary.Write(b, binary.BigEndian, uint32(n))
+	}
+}
+
+func msgpackString(b *bytes.Buffer, s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		b.WriteByte(0xa0 | byte(n))
+	case n < 1<<8:
+		b.WriteByte(0xd9)
+		b.WriteByte(byte(n))
+	case n < 1<<16:
+		b.WriteByte(0xda)
+		_ = binary.Write(b, binary.BigEndian, uint16(n))
+	default:
+		b.WriteByte(0xdb)
+		_ = binary.Write(b, binary.BigEndian, uint32(n))
+	}
+	b.WriteString(s)
+}
+
+func msgpackUint(b *bytes.Buffer, v uint64) {
+	switch {
+	case v < 128:
+		b.WriteByte(byte(v))
+	case v < 1<<32:
+		b.WriteByte(0xce)
+		_ = binary.Write(b, binary.BigEndian, uint32(v))
+	default:
+		b.WriteByte(0xcf)
+		_ = binary.Write(b, binary.BigEndian, v)
+	}
+}
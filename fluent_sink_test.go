@@ -0,0 +1,48 @@
+package humanlog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestFluentSink(t *testing.T) {
+	c := &recordingConn{}
+	s := &fluentSink{dialer: recordingDialer(c), tag: "app"}
+	ev := &Event{
+		Time:    time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+		Level:   "info",
+		Message: "hi",
+		Fields:  map[string]string{"status": "200"},
+	}
+	if err := s.Send(ev); err != nil {
+		t.Fatal(err)
+	}
+	want := "\x93" + // [tag, time, record]
+		"\xa3app" +
+		"\xce\x65\x93\xde\xa0" + // 1704189600
+		"\x83" + "\xa5level\xa4info" + "\xa7message\xa2hi" + "\xa6status\xa3200"
+	if len(c.written) != 1 || c.written[0] != want {
+		t.Errorf("want %q, got %q", want, c.written)
+	}
+}
+
+func TestMsgpackString(t *testing.T) {
+	for _, tt := range []struct {
+		n      int
+		header string
+	}{
+		{31, "\xbf"},
+		{32, "\xd9\x20"},
+		{255, "\xd9\xff"},
+		{256, "\xda\x01\x00"},
+		{1 << 16, "\xdb\x00\x01\x00\x00"},
+	} {
+		var b bytes.Buffer
+		s := string(make([]byte, tt.n))
+		msgpackString(&b, s)
+		if got := b.String(); got != tt.header+s {
+			t.Errorf("%d bytes: want header %q, got %q", tt.n, tt.header, got[:len(got)-tt.n])
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package humanlog
+
+// InputFormat describes a format of log lines humanlog recognizes, with a
+// line of it as an example.
+type InputFormat struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Example     string `json:"example"`
+}
+
+// InputFormats are the formats humanlog recognizes, in the order it tries
+// them on the lines it can't tell the format of at a glance. Lines in none
+// of them are shown as they are.
+var InputFormats = []InputFormat{
+	{
+		Name:        "envoy",
+		Description: "access logs of Envoy and Istio, in their default text format or as JSON",
+		Example:     `[2016-04-15T20:17:00.310Z] "POST /api/v1/locations HTTP/2" 204 - 154 0 226 100 "10.0.35.28" "nsq2http" "cc21d9b0-cf5c-432b-8c7e-98aeb7988cd2" "locations" "tcp://10.0.2.1:80"`,
+	},
+	{
+		Name:        "json",
+		Description: "a JSON object per line, or spanning several with --multiline-json, with the time, level and message under the keys of --time-fields, --level-fields and --message-fields",
+		Example:     `{"time":"2024-01-02T10:00:00Z","level":"info","msg":"listening","port":8080}`,
+	},
+	{
+		Name:        "cri",
+		Description: "the lines of the container runtime interface under /var/log/pods, around JSON, logfmt or plain text",
+		Example:     `2024-01-02T10:00:00.123456789Z stdout F {"level":"info","msg":"listening"}`,
+	},
+	{
+		Name:        "postgres",
+		Description: "the logs of Postgres servers, in the stderr or csvlog format, with the DETAIL, HINT and STATEMENT lines after an entry folded into it with --preset postgres",
+		Example:     `2024-01-02 10:00:00.123 UTC [12345] ERROR:  duplicate key value violates unique constraint "users_pkey"`,
+	},
+	{
+		Name:        "mysql",
+		Description: "the error log of MySQL and MariaDB servers",
+		Example:     `2024-01-02T10:00:00.123456Z 0 [Warning] [MY-010068] [Server] CA certificate ca.pem is self signed.`,
+	},
+	{
+		Name:        "docker",
+		Description: "the lines of docker logs --timestamps, around JSON or logfmt",
+		Example:     `2024-01-02T10:00:00.123456789Z {"level":"info","msg":"listening"}`,
+	},
+	{
+		Name:        "w3c",
+		Description: "W3C extended log files, like those of IIS, after their #Fields directive",
+		Example:     "#Fields: date time cs-method cs-uri-stem sc-status\n2024-01-02 10:00:00 GET /index.html 200",
+	},
+	{
+		Name:        "logrus",
+		Description: "the text output of logrus, written to files or to terminals",
+		Example:     `time="2024-01-02T10:00:00Z" level=info msg="listening on port" port=8080`,
+	},
+	{
+		Name:        "logfmt",
+		Description: "key=value pairs",
+		Example:     `ts=2024-01-02T10:00:00Z level=info msg=listening port=8080`,
+	},
+	{
+		Name:        "docker-compose",
+		Description: "the lines of docker-compose logs, JSON or logfmt behind the name of their service",
+		Example:     `web_1  | {"level":"info","msg":"listening"}`,
+	},
+	{
+		Name:        "zap",
+		Description: "the development encoder of zap, tab separated with the fields as JSON",
+		Example:     "2024-01-02T10:00:00.000-0700\tINFO\tmain.go:12\tlistening\t{\"port\": 8080}",
+	},
+}
@@ -0,0 +1,22 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInputFormats_Examples(t *testing.T) {
+	for _, f := range InputFormats {
+		var got []*Event
+		err := ScanEvents(strings.NewReader(f.Example), DefaultOptions, func(ev *Event) error {
+			got = append(got, ev)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].Message == "" {
+			t.Errorf("%s: want the example parsed, got %v", f.Name, got)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+//go:build go1.18
+// +build go1.18
+
+package humanlog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func FuzzJSONHandler(f *testing.F) {
+	f.Add([]byte(`{"time": "2012-11-01T22:08:41+00:00", "level": "info", "msg": "hello", "port": 8080}`))
+	f.Add([]byte(`{"data": {"l2": {"message": "nested", "level": 30}}}`))
+	f.Add([]byte(`{"level": "ıııı", "msg": "\ud800", "n": -1e300}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		opts := *DefaultOptions
+		opts.MessageFields = append(opts.MessageFields, "data.l2.message")
+		opts.LevelFields = append(opts.LevelFields, "data.l2.level")
+		h := JSONHandler{Opts: &opts}
+		if !h.TryHandle(data) {
+			return
+		}
+		out := h.Prettify(false)
+		if utf8.Valid(data) && !utf8.Valid(out) {
+			t.Errorf("valid input %q rendered as invalid UTF-8 %q", data, out)
+		}
+	})
+}
+
+func FuzzLogfmtHandler(f *testing.F) {
+	f.Add([]byte(`time="2012-11-01T22:08:41+00:00" level=info msg="hello" port=8080`))
+	f.Add([]byte(`a= b="\"" =c level=ıııı`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		opts := *DefaultOptions
+		h := LogfmtHandler{Opts: &opts}
+		if !h.TryHandle(data) {
+			return
+		}
+		out := h.Prettify(false)
+		if utf8.Valid(data) && !utf8.Valid(out) {
+			t.Errorf("valid input %q rendered as invalid UTF-8 %q", data, out)
+		}
+	})
+}
+
+func FuzzSearchJSON(f *testing.F) {
+	f.Add([]byte(`{"data": {"message": "hi"}}`), "data.message")
+	f.Add([]byte(`{"a": {"b": {"c": 1}}, "a.b": 2}`), "a.b.c")
+	f.Add([]byte(`{"": {"": ""}}`), ".")
+	f.Fuzz(func(t *testing.T, data []byte, field string) {
+		raw := make(map[string]interface{})
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return
+		}
+		var found bool
+		searchJSON(raw, []string{field}, func(key string, value interface{}) bool {
+			found = true
+			return true
+		})
+		deleteJSONKey(field, raw)
+		if !found || strings.Contains(field, ".") {
+			return
+		}
+		if _, ok := raw[field]; ok {
+			t.Errorf("%q was found but not deleted", field)
+		}
+	})
+}
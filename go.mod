@@ -1,6 +1,6 @@
 module github.com/zbartl/humanlog
 
-go 1.15
+go 1.16
 
 require (
 	github.com/aybabtme/rgbterm v0.0.0-20170906152045-cc83f3b3ce59
@@ -8,7 +8,8 @@ require (
 	github.com/go-logfmt/logfmt v0.4.0
 	github.com/kr/logfmt v0.0.0-20140226030751-b84e30acd515
 	github.com/mattn/go-colorable v0.1.0
-	github.com/mattn/go-isatty v0.0.4 // indirect
+	github.com/mattn/go-isatty v0.0.4
 	github.com/urfave/cli v1.20.1-0.20180226030253-8e01ec4cd3e2
 	golang.org/x/sys v0.0.0-20170407050850-f3918c30c5c2 // indirect
+	golang.org/x/text v0.3.6
 )
@@ -0,0 +1,35 @@
+package humanlog
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// grepFilter drops the lines that contain none of the Grep strings and
+// don't match GrepRegexp, looking at their bytes as they were read.
+type grepFilter struct {
+	needles [][]byte
+	re      *regexp.Regexp
+}
+
+// newGrepFilter returns the filter the options ask for, or nil if they
+// don't.
+func newGrepFilter(opts *HandlerOptions) *grepFilter {
+	if len(opts.Grep) == 0 && opts.GrepRegexp == nil {
+		return nil
+	}
+	g := &grepFilter{re: opts.GrepRegexp}
+	for _, s := range opts.Grep {
+		g.needles = append(g.needles, []byte(s))
+	}
+	return g
+}
+
+func (g *grepFilter) match(line []byte) bool {
+	for _, needle := range g.needles {
+		if bytes.Contains(line, needle) {
+			return true
+		}
+	}
+	return g.re != nil && g.re.Match(line)
+}
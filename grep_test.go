@@ -0,0 +1,46 @@
+package humanlog
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	src := strings.Join([]string{
+		`{"msg": "request", "status": 200}`,
+		`{"msg": "request", "status": 503}`,
+		`{"msg": "slow query", "ms": 1200}`,
+		`{"msg": "request", "status": 404}`,
+	}, "\n")
+
+	tests := []struct {
+		grep []string
+		re   string
+		want []int
+	}{
+		{grep: []string{`"status": 503`}, want: []int{2}},
+		{grep: []string{"503", "404"}, want: []int{2, 4}},
+		{re: `"ms": \d{4}`, want: []int{3}},
+		{grep: []string{"404"}, re: `slow`, want: []int{3, 4}},
+	}
+	for _, test := range tests {
+		opts := *DefaultOptions
+		opts.Grep = test.grep
+		if test.re != "" {
+			opts.GrepRegexp = regexp.MustCompile(test.re)
+		}
+		var got []int
+		err := Process(strings.NewReader(src), &opts, func(e *Entry, next Next) error {
+			got = append(got, int(e.Line))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q %q: want lines %v, got %v", test.grep, test.re, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GrokFormat parses the lines Pattern, a grok expression, matches from
+// their start. Its named patterns, like %{LOGLEVEL:level}, are the time,
+// level, message and fields of the entries, numbers with :int or :float.
+// Patterns defines patterns of its own, to use in Pattern or each other.
+//
+//	{"pattern": "%{TIMESTAMP_ISO8601:time} \\[%{DATA:thread}\\] %{LOGLEVEL:level} %{GREEDYDATA:msg}"}
+type GrokFormat struct {
+	Pattern  string            `json:"pattern"`
+	Patterns map[string]string `json:"patterns,omitempty"`
+
+	re     *regexp.Regexp
+	fields []grokField
+}
+
+// grokField is what a group of the compiled pattern captures.
+type grokField struct {
+	name, kind string
+}
+
+// grokReference matches %{SYNTAX}, %{SYNTAX:name} and %{SYNTAX:name:type}.
+var grokReference = regexp.MustCompile(`%\{(\w+)(?::([^:}]+))?(?::(int|float|string))?\}`)
+
+// maxGrokDepth is how deep patterns can refer to each other, deeper than
+// the standard library ever does, before a cycle is assumed.
+const maxGrokDepth = 32
+
+// ParseGrokFormat compiles a grok expression with the standard patterns.
+func ParseGrokFormat(pattern string) (*GrokFormat, error) {
+	g := &GrokFormat{Pattern: pattern}
+	return g, g.compile()
+}
+
+func (g *GrokFormat) compile() error {
+	g.fields = g.fields[:0]
+	expr, err := g.expand(g.Pattern, 0)
+	if err != nil {
+		return err
+	}
+	g.re, err = regexp.Compile("^(?:" + expr + ")")
+	return err
+}
+
+// expand replaces the references of expr with the patterns they refer to,
+// the named ones as a group whose name is the number of the field.
+func (g *GrokFormat) expand(expr string, depth int) (string, error) {
+	if depth > maxGrokDepth {
+		return "", fmt.Errorf("patterns referring to each other endlessly in %q", expr)
+	}
+	var err error
+	out := grokReference.ReplaceAllStringFunc(expr, func(ref string) string {
+		if err != nil {
+			return ""
+		}
+		m := grokReference.FindStringSubmatch(ref)
+		def, ok := g.Patterns[m[1]]
+		if !ok {
+			def, ok = grokPatterns[m[1]]
+		}
+		if !ok {
+			err = fmt.Errorf("unknown pattern %s", m[1])
+			return ""
+		}
+		var sub string
+		if sub, err = g.expand(def, depth+1); err != nil {
+			return ""
+		}
+		if m[2] == "" {
+			return "(?:" + sub + ")"
+		}
+		g.fields = append(g.fields, grokField{name: grokFieldName(m[2]), kind: m[3]})
+		return "(?P<f" + strconv.Itoa(len(g.fields)-1) + ">" + sub + ")"
+	})
+	return out, err
+}
+
+// grokFieldName writes logstash's nested field references, like
+// [http][status], as dotted keys.
+func grokFieldName(name string) string {
+	if !strings.HasPrefix(name, "[") {
+		return name
+	}
+	return strings.Trim(strings.Replace(name, "][", ".", -1), "[]")
+}
+
+// Rewrite turns the lines the pattern matches into JSON entries.
+func (g *GrokFormat) Rewrite(line []byte) []byte {
+	m := g.re.FindSubmatchIndex(line)
+	if m == nil {
+		return nil
+	}
+	entry := make(map[string]interface{})
+	for i, name := range g.re.SubexpNames() {
+		if !strings.HasPrefix(name, "f") || m[2*i] < 0 {
+			continue
+		}
+		n, err := strconv.Atoi(name[1:])
+		if err != nil || n >= len(g.fields) {
+			continue
+		}
+		f, v := g.fields[n], string(line[m[2*i]:m[2*i+1]])
+		if _, seen := entry[f.name]; seen && v == "" {
+			continue
+		}
+		entry[f.name] = grokValue(v, f.kind)
+	}
+	out, err := json.Marshal(entry)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+func grokValue(v, kind string) interface{} {
+	switch kind {
+	case "int":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "float":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return v
+}
+
+// grokPatterns are the standard patterns of logstash, written for RE2, from
+// builtin/grok-patterns.
+var grokPatterns, grokPatternNames = parseGrokPatterns(mustReadBuiltin("grok-patterns"))
+
+// parseGrokPatterns reads patterns written like logstash's, a name and a
+// regexp per line, and returns them along with their names in order.
+func parseGrokPatterns(data []byte) (map[string]string, []string) {
+	patterns := make(map[string]string)
+	var names []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line == "" || line[0] == '#' {
+			continue
+		}
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			panic(fmt.Sprintf("builtin grok pattern %s has no regexp", line))
+		}
+		name := line[:i]
+		patterns[name] = strings.TrimLeft(line[i:], " \t")
+		names = append(names, name)
+	}
+	return patterns, names
+}
+
+// GrokPatternNames lists the grok patterns humanlog ships, in the order of
+// their file.
+func GrokPatternNames() []string {
+	return append([]string(nil), grokPatternNames...)
+}
+
+// GrokPattern returns the regexp of the grok pattern humanlog ships with
+// that name.
+func GrokPattern(name string) (string, bool) {
+	def, ok := grokPatterns[name]
+	return def, ok
+}
+
+// GrokPatternsSource returns the file of the grok patterns humanlog ships,
+// in logstash's format.
+func GrokPatternsSource() []byte {
+	return mustReadBuiltin("grok-patterns")
+}
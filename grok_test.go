@@ -0,0 +1,70 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGrokFormat(t *testing.T) {
+	config := writeConfig(t, `{"grok": [{
+		"pattern": "%{TIMESTAMP_ISO8601:time} %{LOGLEVEL:level} \\[%{APP:app}\\] took %{NUMBER:[took][ms]:int}ms %{GREEDYDATA:msg}",
+		"patterns": {"APP": "[a-z-]+"}
+	}]}`)
+	cfg, err := ReadConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := *DefaultOptions
+	cfg.Apply(&opts)
+
+	src := strings.Join([]string{
+		`2024-01-02T03:04:05Z WARNING [billing-api] took 35ms to charge the card`,
+		`something else`,
+	}, "\n")
+	var events []*Event
+	if err := ScanEvents(strings.NewReader(src), &opts, func(ev *Event) error {
+		events = append(events, ev)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("want 1 event, got %d", len(events))
+	}
+	ev := events[0]
+	if !ev.Time.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) || ev.Level != "WARNING" || ev.Message != "to charge the card" {
+		t.Errorf("got %+v", ev)
+	}
+	if ev.Fields["app"] != `"billing-api"` || ev.Fields["took.ms"] != "35" {
+		t.Errorf("want the other captures as fields, got %v", ev.Fields)
+	}
+}
+
+func TestGrokStandardPatterns(t *testing.T) {
+	f, err := ParseGrokFormat("%{COMBINEDAPACHELOG}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := `10.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`
+	got := string(f.Rewrite([]byte(line)))
+	for _, want := range []string{`"clientip":"10.0.0.1"`, `"auth":"frank"`, `"verb":"GET"`, `"response":"200"`, `"timestamp":"10/Oct/2000:13:55:36 -0700"`, `"agent":"\"Mozilla/4.08\""`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %s in %s", want, got)
+		}
+	}
+	if f.Rewrite([]byte("not an access log")) != nil {
+		t.Error("want no entry for a line the pattern doesn't match")
+	}
+}
+
+func TestGrokErrors(t *testing.T) {
+	if _, err := ParseGrokFormat("%{NOPE:x}"); err == nil {
+		t.Error("want an error for an unknown pattern")
+	}
+	g := &GrokFormat{Pattern: "%{A}", Patterns: map[string]string{"A": "x%{B}", "B": "%{A}"}}
+	if err := g.compile(); err == nil {
+		t.Error("want an error for patterns referring to each other")
+	}
+}
@@ -0,0 +1,62 @@
+package humanlog
+
+import (
+	"hash/fnv"
+	"io"
+
+	"github.com/fatih/color"
+)
+
+var gutterPalette = []*color.Color{
+	color.New(color.FgRed),
+	color.New(color.FgGreen),
+	color.New(color.FgYellow),
+	color.New(color.FgBlue),
+	color.New(color.FgMagenta),
+	color.New(color.FgCyan),
+	color.New(color.FgHiRed),
+	color.New(color.FgHiGreen),
+	color.New(color.FgHiYellow),
+	color.New(color.FgHiBlue),
+	color.New(color.FgHiMagenta),
+	color.New(color.FgHiCyan),
+}
+
+// sourceOf returns the first of the source fields the event has.
+func (h *HandlerOptions) sourceOf(ev *Event) string {
+	if ev == nil {
+		return ""
+	}
+	for _, field := range h.SourceFields {
+		if v, ok := ev.Fields[field]; ok && v != "" {
+			return unquoteValue(v)
+		}
+	}
+	return ""
+}
+
+// writeGutter draws a narrow bar whose color is always the same for a given
+// source, so interleaved streams can be told apart at a glance, unless the
+// source's profile picks one.
+func writeGutter(dst io.Writer, opts *HandlerOptions, source string) {
+	if source == "" {
+		_, _ = io.WriteString(dst, "  ")
+		return
+	}
+	_, _ = opts.SourceColor(source).Fprint(dst, "▌ ")
+}
+
+// SourceColor is the color the gutter has for a source, for anything else
+// telling sources apart to use the same one.
+func (h *HandlerOptions) SourceColor(source string) *color.Color {
+	if profile := h.profileOf(source); profile != nil && profile.color != nil {
+		return profile.color
+	}
+	return gutterColor(source)
+}
+
+func gutterColor(source string) *color.Color {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(source))
+	return gutterPalette[hash.Sum32()%uint32(len(gutterPalette))]
+}
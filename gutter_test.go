@@ -0,0 +1,81 @@
+package humanlog
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+func TestGutter(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	src := strings.Join([]string{
+		`{"msg": "first", "service": "api"}`,
+		`{"msg": "second", "service": "db"}`,
+		`{"msg": "third", "service": "api"}`,
+		`{"msg": "no service"}`,
+		`not parsed`,
+	}, "\n")
+	opts := *DefaultOptions
+	opts.Gutter = true
+	opts.SourceFields = []string{"service"}
+
+	var out bytes.Buffer
+	if err := Scanner(strings.NewReader(src), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("want 5 lines, got %q", lines)
+	}
+
+	gutter := func(line string) string {
+		var b bytes.Buffer
+		writeGutter(&b, &opts, "")
+		if strings.HasPrefix(line, b.String()) {
+			return b.String()
+		}
+		end := strings.Index(line, "▌ ")
+		if end < 0 {
+			t.Fatalf("want a gutter, got %q", line)
+		}
+		end += len("▌ ")
+		if strings.HasPrefix(line[end:], "\x1b[0m") {
+			end += len("\x1b[0m")
+		}
+		return line[:end]
+	}
+	// the gutter is the same for each line of a source, and takes two
+	// columns whatever the source, or lack of one
+	if want, got := gutter(lines[0]), gutter(lines[2]); want != got {
+		t.Errorf("want %q, got %q, want != got", want, got)
+	}
+	if want, got := gutterColor("api").Sprint("▌ "), gutter(lines[0]); want != got {
+		t.Errorf("want %q, got %q, want != got", want, got)
+	}
+	if want, got := gutterColor("db").Sprint("▌ "), gutter(lines[1]); want != got {
+		t.Errorf("want %q, got %q, want != got", want, got)
+	}
+	escapes := regexp.MustCompile("\x1b\\[[0-9;]*m")
+	for _, line := range lines {
+		if want, got := 2, utf8.RuneCountInString(escapes.ReplaceAllString(gutter(line), "")); want != got {
+			t.Errorf("%q: want a gutter %d columns wide, got %d, want != got", line, want, got)
+		}
+	}
+}
+
+func TestGutterColor_Stable(t *testing.T) {
+	for _, source := range []string{"api", "db", "worker-1", "worker-2"} {
+		if want, got := gutterColor(source), gutterColor(source); want != got {
+			t.Errorf("%s: want %v, got %v, want != got", source, want, got)
+		}
+	}
+	if gutterColor("api") == gutterColor("db") {
+		t.Error("want api and db told apart")
+	}
+}
@@ -1,6 +1,7 @@
 package humanlog
 
 import (
+	"regexp"
 	"time"
 
 	"github.com/fatih/color"
@@ -14,6 +15,18 @@ type Handler interface {
 	logfmt.Handler
 }
 
+// MultilineHandler is implemented by the handlers whose entries can span
+// several lines, like pretty-printed JSON. Once a handler recognized the
+// first line of an entry, the lines after it are passed to Append for as
+// long as NeedsMore tells the entry isn't complete. Append returns false if
+// a line isn't part of the entry, which is then parsed on its own. Entries
+// still incomplete when a line is refused or the input ends are shown as
+// they were read.
+type MultilineHandler interface {
+	NeedsMore() bool
+	Append(line []byte) bool
+}
+
 var DefaultOptions = &HandlerOptions{
 	SortLongest:    true,
 	SkipUnchanged:  true,
@@ -21,10 +34,27 @@ var DefaultOptions = &HandlerOptions{
 	LightBg:        false,
 	TruncateLength: 15,
 	TimeFormat:     time.Stamp,
+	SparkWidth:     20,
+	QueryMinLength: 40,
+	TracebackLines: 12,
+	CallerWidth:    24,
+	TableSample:    20,
+	Exceptions:     true,
+	Batches:        true,
+	ClockSkew:      true,
+	HideEncoded:    true,
+	UnnestMessages: true,
+
+	MultilineTimeout: 500 * time.Millisecond,
 
 	TimeFields:    []string{"time", "ts", "@timestamp", "timestamp"},
 	MessageFields: []string{"message", "msg"},
 	LevelFields:   []string{"level", "lvl", "loglevel", "severity"},
+	SourceFields:  []string{"service", "pod", "container", "file", "source"},
+	QueryFields:   []string{"query", "statement", "sql", "gql", "graphql"},
+
+	CallerFields:     []string{"caller", "source", "file"},
+	CallerLineFields: []string{"line", "lineno"},
 
 	KeyColor:              color.New(color.FgGreen),
 	ValColor:              color.New(color.FgHiWhite),
@@ -41,15 +71,143 @@ var DefaultOptions = &HandlerOptions{
 	PanicLevelColor:       color.New(color.BgRed),
 	FatalLevelColor:       color.New(color.BgHiRed, color.FgHiWhite),
 	UnknownLevelColor:     color.New(color.FgMagenta),
+	LineNumberColor:       color.New(color.FgHiBlack),
+	DiagnosticColor:       color.New(color.FgYellow),
+	SeparatorColor:        color.New(color.FgHiBlack),
+	QueryKeywordColor:     color.New(color.FgHiBlue),
+	NumberColor:           color.New(color.FgHiCyan),
+	BoolColor:             color.New(color.FgHiGreen),
+	FalseColor:            color.New(color.FgHiRed),
+	NullColor:             color.New(color.FgHiBlack),
+	TimeValueColor:        color.New(color.FgHiMagenta),
+	URLColor:              color.New(color.FgHiBlue, color.Underline),
+	LoggerColor:           color.New(color.FgHiBlack),
+	TracebackColor:        color.New(color.FgHiBlack),
+	CallerColor:           color.New(color.FgHiBlack),
+	AlertColor:            color.New(color.BgYellow, color.FgBlack),
+	ContextColor:          color.New(color.FgHiBlack),
+	DeviationColor:        color.New(color.BgRed, color.FgHiWhite),
+	WithinBudgetColor:     color.New(color.FgGreen),
 }
 
 type HandlerOptions struct {
+	// Skip hides keys, unless they're in Keep. Skipped keys that no other
+	// option looks at are dropped as entries are parsed, so stages never see
+	// them. Both can hold globs, like kubernetes.* or *_id, and JSON
+	// Pointers, like /labels/app.kubernetes.io~1name, when they're set with
+	// SetSkip and SetKeep. Skipped pointers into nested objects remove what
+	// they point to from JSON entries.
 	Skip map[string]struct{}
 	Keep map[string]struct{}
 
+	skip, keep   *keyMatcher
+	skipPointers [][]string
+	// needed are the keys read by stages that aren't set up by options,
+	// like the field Count counts by, which can't be dropped.
+	needed []string
+
 	TimeFields    []string
 	MessageFields []string
 	LevelFields   []string
+	SourceFields  []string
+
+	// UnnestMessages parses the messages of JSON entries that are JSON
+	// documents themselves, encoded as strings by pipelines: their time,
+	// level and message are those of the entry, and their other fields are
+	// added, prefixed with the field of the message, like msg.user_id.
+	UnnestMessages bool
+
+	// QueryFields holding SQL or GraphQL queries of at least QueryMinLength
+	// are reflowed and highlighted on lines of their own.
+	QueryFields    []string
+	QueryMinLength int
+
+	// HideEncoded shows the values that look like base64 or hex encoded
+	// payloads as a placeholder with the size of what they hold, like
+	// base64[182B]. Those of DecodeFields are decoded on lines of their own.
+	HideEncoded  bool
+	DecodeFields []string
+
+	// Arrays are shown as their length and first item, like list[5](web,…),
+	// or item by item on lines of their own with ExpandArrays, or for the
+	// keys of ExpandArrayFields.
+	ExpandArrays      bool
+	ExpandArrayFields []string
+
+	// LoggerFields name the logger that emitted the entry, which is shown in
+	// front of the message. TracebackFields hold multi-line tracebacks shown
+	// on lines of their own, folded down to TracebackLines.
+	LoggerFields    []string
+	TracebackFields []string
+	TracebackLines  int
+
+	// CallerFields hold the source location the entry was logged from,
+	// joined with the first of CallerLineFields when it's apart. It's shown
+	// in a column of CallerWidth in front of the message, or among the other
+	// fields if CallerWidth is zero.
+	CallerFields     []string
+	CallerLineFields []string
+	CallerWidth      int
+
+	// Numeric values are rendered with ThousandsSeparator between groups of
+	// digits, DecimalSeparator in place of the decimal point, and
+	// FloatPrecision digits after it when it's positive.
+	ThousandsSeparator string
+	DecimalSeparator   string
+	FloatPrecision     int
+
+	// Fingerprints shows the fingerprint of each entry in FingerprintField.
+	// When OnlyFingerprints isn't empty, the entries with other fingerprints
+	// are dropped.
+	Fingerprints     bool
+	OnlyFingerprints []string
+
+	// Levels define levels on top of, or instead of, the ones humanlog knows
+	// about. When MinLevel isn't empty, the entries whose level ranks below
+	// it are dropped.
+	Levels   []Level
+	MinLevel string
+	// LevelRules change the level of the entries matching their conditions,
+	// before it's compared with MinLevel.
+	LevelRules []LevelRule
+	// Where drops the parsed entries that don't match all of its filters.
+	// NamedFilters are the expressions of filters saved under a name, to be
+	// picked from.
+	Where        []*FilterExpr
+	NamedFilters map[string]string
+
+	// Units are the units of the numbers of fields, keyed by the fields'
+	// name, appended to them and converted as asked.
+	Units map[string]FieldUnit
+
+	// Budgets color the values of fields timing requests against their
+	// thresholds, keyed by the fields' name.
+	Budgets map[string]Budget
+
+	// Baseline is the values fields are expected to have. The values that
+	// deviate from it are shown in DeviationColor.
+	Baseline Baseline
+
+	// TailBuffer keeps the last entries filters dropped, up to this many, to
+	// show them in ContextColor above the next error.
+	TailBuffer int
+
+	// MemoryBudget caps the memory the tail buffer and the truncated values
+	// take, the lines past it going to a temporary file. Nil doesn't cap it.
+	MemoryBudget *MemoryBudget
+
+	// Table renders the entries as rows of a table, whose columns are the
+	// fields all of the first TableSample entries had.
+	Table       bool
+	TableSample int
+
+	// Batches of entries, JSON arrays of objects or fields holding newline
+	// delimited JSON, are shown as separate entries.
+	Batches bool
+
+	// Exceptions following a structured entry on lines of their own, like
+	// Java stack traces, are shown indented under the entry.
+	Exceptions bool
 
 	SortLongest    bool
 	SkipUnchanged  bool
@@ -58,6 +216,155 @@ type HandlerOptions struct {
 	TruncateLength int
 	TimeFormat     string
 
+	// HideNull hides the fields whose value is null.
+	HideNull bool
+
+	// LineWidth keeps each entry on a line this wide, dropping the fields
+	// that don't fit, those of lowest FieldPriorities first, for a marker
+	// telling how many were. Messages too long for it are wrapped, going on
+	// under where they start behind a marker.
+	LineWidth       int
+	FieldPriorities map[string]int
+
+	// LinkLabels shows the links to artifacts matching LinkRules, or
+	// DefaultLinkRules, as a short label linking to them.
+	LinkLabels bool
+	LinkRules  []LinkRule
+
+	// FieldsJSON shows the fields as a JSON object in ContextColor, at the
+	// end of the line with "end" or on the line below with "line", instead
+	// of key=value pairs.
+	FieldsJSON string
+
+	// StickyHeader reprints the fields SkipUnchanged hides every this many
+	// entries, for whoever starts reading mid stream.
+	StickyHeader int
+
+	// Coalesce drops the entries with the same message and level as the one
+	// before them, if they came within this long of it, and writes how many
+	// there were once their burst is over.
+	Coalesce time.Duration
+
+	// Truncations numbers the values that were truncated, so that they can
+	// be shown in full after the entries.
+	Truncations *Truncations
+
+	// SparkField names a numeric field whose recent values are drawn as a
+	// sparkline at the end of each line, over the last SparkWidth values.
+	SparkField string
+	SparkWidth int
+
+	// Sinks are sent every parsed event, with the skipped keys removed.
+	Sinks []Sink
+
+	// LineNumbers prefixes each line with its line number in the input, and
+	// with ByteOffsets, the offset at which that line starts.
+	LineNumbers bool
+	ByteOffsets bool
+
+	// Strict annotates lines with why they couldn't be parsed, or what was
+	// missing from them, and summarizes those reasons at the end.
+	Strict bool
+
+	// Gutter draws a colored bar at the start of each line, whose color
+	// depends on the first of SourceFields found in the entry.
+	Gutter bool
+
+	// Grep keeps the lines containing one of these strings, or matching
+	// GrepRegexp, and drops the others before they're parsed, which is much
+	// cheaper than filtering parsed entries. Neither is used when empty.
+	Grep       []string
+	GrepRegexp *regexp.Regexp
+
+	// Rewrites turn lines humanlog can't parse into ones it can, like JSON.
+	// The first one returning a line is used.
+	Rewrites []func(line []byte) []byte
+
+	// Enrich rules add fields captured out of the message or other fields,
+	// before anything else looks at the entry.
+	Enrich []EnrichRule
+	// Transforms rewrite fields once the entry is parsed and enriched.
+	Transforms []FieldTransform
+
+	// Sources override options for the entries of some sources.
+	Sources []SourceProfile
+
+	// Separators draws a rule between entries whenever their time crosses
+	// into a new period of this duration.
+	Separators time.Duration
+
+	// Encoding of the input, which is converted to UTF-8 before being
+	// parsed. When empty, it's guessed from the start of the input.
+	Encoding string
+
+	// DuplicateKeys is what to do with the keys JSON entries have more than
+	// once, which encoding/json silently resolves to their last value and can
+	// be used to forge entries: one of DuplicateKeyPolicies, "last" when
+	// empty.
+	DuplicateKeys string
+
+	// MultilineStart matches the first line of records spanning multiple
+	// lines: lines that don't match are appended to the record before them.
+	// With MultilineJSON, JSON documents are assembled until their brackets
+	// balance. Either way, a record is complete once no line came in for
+	// MultilineTimeout.
+	MultilineStart   *regexp.Regexp
+	MultilineJSON    bool
+	MultilineTimeout time.Duration
+
+	// Since and Until drop the entries whose time is outside of them, when
+	// they're not zero.
+	Since time.Time
+	Until time.Time
+	// SortedInput tells the input is sorted by time, so that reading can
+	// stop at the first entry after Until.
+	SortedInput bool
+
+	// ClockOffsets correct the time of the entries of sources whose clock is
+	// off, keyed by source. ClockSkew warns about the sources whose clock
+	// looks off compared to the others in the same stream.
+	ClockOffsets map[string]time.Duration
+	ClockSkew    bool
+
+	// Head stops reading after this many parsed entries, among those the
+	// filters kept, when it's not zero.
+	Head int
+
+	// Stages run on every entry between parsing and rendering, in order. They
+	// can change, drop or add to the entries.
+	Stages []Stage
+
+	// Alerts are evaluated on every parsed entry. When one triggers, the
+	// NotifyCommand is run by the shell, or a banner is printed if it's empty.
+	Alerts        []AlertRule
+	NotifyCommand string
+
+	// Hooks run a command on the parsed entries matching their conditions.
+	Hooks []ExecHook
+
+	// Bell rings when entries at or above its level appear, if it's set.
+	Bell *Bell
+
+	// Panes renders the entries in a pane per source rather than one after
+	// the other, if it's set.
+	Panes *Panes
+
+	// Types are the types of fields, among FieldTypes, their values are
+	// parsed as and shown as, whatever they look like.
+	Types map[string]string
+
+	// LevelBar draws, below the entries, a bar of the share of each level
+	// among the entries of the last LevelBar, redrawn as entries come.
+	LevelBar time.Duration
+
+	// Proto reads the input as a stream of these protobuf messages, each
+	// after its length, rather than as lines.
+	Proto *ProtoMessage
+
+	// Spans pair the entries starting and ending spans, to tell how long
+	// the spans took.
+	Spans []SpanRule
+
 	KeyColor              *color.Color
 	ValColor              *color.Color
 	TimeLightBgColor      *color.Color
@@ -73,29 +380,134 @@ type HandlerOptions struct {
 	PanicLevelColor       *color.Color
 	FatalLevelColor       *color.Color
 	UnknownLevelColor     *color.Color
+	LineNumberColor       *color.Color
+	DiagnosticColor       *color.Color
+	SeparatorColor        *color.Color
+	QueryKeywordColor     *color.Color
+
+	// Values are colored by their type, falling back to ValColor for
+	// strings and the types that have no color. BoolColor colors true, and
+	// FalseColor false.
+	NumberColor    *color.Color
+	BoolColor      *color.Color
+	FalseColor     *color.Color
+	NullColor      *color.Color
+	TimeValueColor *color.Color
+	URLColor       *color.Color
+
+	LoggerColor    *color.Color
+	TracebackColor *color.Color
+	CallerColor    *color.Color
+	AlertColor     *color.Color
+	ContextColor   *color.Color
+	DeviationColor *color.Color
+	// WithinBudgetColor colors the values within their budget.
+	WithinBudgetColor *color.Color
 }
 
 func (h *HandlerOptions) shouldShowKey(key string) bool {
-	if len(h.Keep) != 0 {
-		if _, keep := h.Keep[key]; keep {
-			return true
-		}
+	if len(h.Keep) != 0 && matchKey(h.Keep, h.keep, key) {
+		return true
 	}
-	if len(h.Skip) != 0 {
-		if _, skip := h.Skip[key]; skip {
-			return false
-		}
+	if len(h.Skip) != 0 && matchKey(h.Skip, h.skip, key) {
+		return false
 	}
 	return true
 }
 
-func (h *HandlerOptions) shouldShowUnchanged(key string) bool {
-	if len(h.Keep) != 0 {
+// matchKey tells if key is in set, or matches one of its globs when they
+// were compiled into m.
+func matchKey(set map[string]struct{}, m *keyMatcher, key string) bool {
+	if m != nil {
+		return m.match(key)
+	}
+	_, ok := set[key]
+	return ok
+}
+
+// droppedKeys returns the skipped keys that can be dropped as entries are
+// parsed, because no option needs them once they're hidden.
+func (h *HandlerOptions) droppedKeys() map[string]struct{} {
+	if len(h.Skip) == 0 {
+		return nil
+	}
+	needed := make(map[string]struct{})
+	need := func(keys ...string) {
+		for _, key := range keys {
+			needed[key] = struct{}{}
+		}
+	}
+	if h.Gutter || len(h.Sources) != 0 {
+		need(h.SourceFields...)
+	}
+	if h.CallerWidth > 0 {
+		need(h.CallerFields...)
+		need(h.CallerLineFields...)
+	}
+	need(h.LoggerFields...)
+	need(h.TracebackFields...)
+	need(h.QueryFields...)
+	need(h.DecodeFields...)
+	need(h.ExpandArrayFields...)
+	need(h.SparkField)
+	need(h.needed...)
+	for _, rule := range h.Enrich {
+		need(rule.Field)
+	}
+	for _, t := range h.Transforms {
+		need(t.Field)
+	}
+	// the stages matching conditions, and those using fields even when
+	// they're hidden
+	for _, rule := range h.Alerts {
+		for _, cond := range rule.Conditions {
+			need(cond.Key)
+		}
+	}
+	for _, rule := range h.LevelRules {
+		need(conditionKeys(rule.conds)...)
+	}
+	for _, f := range h.Where {
+		need(f.keys...)
+	}
+	for _, hook := range h.Hooks {
+		need(conditionKeys(hook.conds)...)
+	}
+	for _, rule := range h.Spans {
+		need(rule.Key)
+		need(conditionKeys(rule.start)...)
+		need(conditionKeys(rule.end)...)
+	}
+	for key := range h.Baseline {
+		need(key)
+	}
+	for key := range h.Budgets {
+		need(key)
+	}
+	for key := range h.Units {
+		need(key)
+	}
+	for key := range h.Types {
+		need(key)
+	}
+
+	dropped := make(map[string]struct{})
+	for key := range h.Skip {
 		if _, keep := h.Keep[key]; keep {
-			return true
+			continue
 		}
+		if _, ok := needed[key]; !ok {
+			dropped[key] = struct{}{}
+		}
+	}
+	return dropped
+}
+
+func (h *HandlerOptions) shouldShowUnchanged(key string) bool {
+	if h.Fingerprints && key == FingerprintField {
+		return true
 	}
-	return false
+	return len(h.Keep) != 0 && matchKey(h.Keep, h.keep, key)
 }
 
 func (h *HandlerOptions) SetSkip(skip []string) {
@@ -105,6 +517,18 @@ func (h *HandlerOptions) SetSkip(skip []string) {
 	for _, key := range skip {
 		h.Skip[key] = struct{}{}
 	}
+	// pointers into nested objects remove what they point to as JSON
+	// entries are parsed, the others name keys
+	keys := make(map[string]struct{}, len(h.Skip))
+	h.skipPointers = nil
+	for key := range h.Skip {
+		if segs, ok := parsePointer(key); ok && len(segs) > 1 {
+			h.skipPointers = append(h.skipPointers, segs)
+			continue
+		}
+		keys[key] = struct{}{}
+	}
+	h.skip = newKeyMatcher(keys)
 }
 
 func (h *HandlerOptions) SetKeep(keep []string) {
@@ -114,4 +538,17 @@ func (h *HandlerOptions) SetKeep(keep []string) {
 	for _, key := range keep {
 		h.Keep[key] = struct{}{}
 	}
+	h.keep = newKeyMatcher(h.Keep)
+}
+
+// visibleEvent returns a copy of ev without the keys that shouldn't be shown.
+func (h *HandlerOptions) visibleEvent(ev *Event) *Event {
+	out := *ev
+	out.Fields = make(map[string]string, len(ev.Fields))
+	for k, v := range ev.Fields {
+		if h.shouldShowKey(k) {
+			out.Fields[k] = v
+		}
+	}
+	return &out
 }
@@ -0,0 +1,102 @@
+package humanlog
+
+import (
+	"bytes"
+	"io"
+)
+
+// headStage passes on the first n parsed entries, and the lines between
+// them, then stops right away, without waiting for the entry after them.
+func headStage(n int) Stage {
+	seen := 0
+	return func(e *Entry, next Next) error {
+		if e.Event == nil {
+			return next(e)
+		}
+		seen++
+		if err := next(e); err != nil || seen < n {
+			return err
+		}
+		return ErrStop
+	}
+}
+
+// SeekTail finds where the last n parsed entries of f, a file of size
+// bytes, start. It reads the file backwards from its end, so only the
+// lines of those entries are read however large the file is.
+func SeekTail(f io.ReaderAt, size int64, n int, opts *HandlerOptions) (int64, error) {
+	if n <= 0 {
+		return size, nil
+	}
+	end := size
+	if size > 0 {
+		var b [1]byte
+		if _, err := f.ReadAt(b[:], size-1); err != nil {
+			return 0, err
+		}
+		if b[0] == '\n' {
+			end--
+		}
+	}
+
+	w := &tailWindow{f: f, start: end}
+	p := newParser(opts)
+	found := 0
+	for {
+		nl, err := w.lastNewline(end)
+		if err != nil {
+			return 0, err
+		}
+		start := nl + 1
+		line := bytes.TrimSuffix(w.slice(start, end), []byte{'\r'})
+		if res := p.parse(trimSyslog(line)); res.entry != nil {
+			res.entry.clear()
+			found++
+			if found == n {
+				return start, nil
+			}
+		}
+		if nl < 0 {
+			return 0, nil
+		}
+		end = nl
+	}
+}
+
+// tailChunk is how much of the file is read at once going backwards.
+const tailChunk = 64 * 1024
+
+// tailWindow holds the part of a file starting at start that's being read
+// backwards.
+type tailWindow struct {
+	f     io.ReaderAt
+	start int64
+	data  []byte
+}
+
+// lastNewline returns where the last newline before end is, or -1 if
+// there's none. It reads the file before the window if it has to, keeping
+// what's between the window's start and end.
+func (w *tailWindow) lastNewline(end int64) (int64, error) {
+	for {
+		if i := bytes.LastIndexByte(w.data[:end-w.start], '\n'); i >= 0 {
+			return w.start + int64(i), nil
+		}
+		if w.start == 0 {
+			return -1, nil
+		}
+		from := w.start - tailChunk
+		if from < 0 {
+			from = 0
+		}
+		buf := make([]byte, w.start-from, end-from)
+		if n, err := w.f.ReadAt(buf, from); n < len(buf) {
+			return 0, err
+		}
+		w.start, w.data = from, append(buf, w.data[:end-w.start]...)
+	}
+}
+
+func (w *tailWindow) slice(from, to int64) []byte {
+	return w.data[from-w.start : to-w.start]
+}
@@ -0,0 +1,106 @@
+package humanlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHead(t *testing.T) {
+	src := "{\"msg\": \"one\"}\nnot an entry\n{\"msg\": \"two\"}\n{\"msg\": \"three\"}\n"
+	opts := *DefaultOptions
+	opts.Head = 2
+	var got []string
+	err := ScanEvents(strings.NewReader(src), &opts, func(ev *Event) error {
+		got = append(got, ev.Message)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "one,two"; strings.Join(got, ",") != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestHead_Quiet(t *testing.T) {
+	// the stream goes quiet after the entries, without ending
+	r, w := io.Pipe()
+	defer w.Close()
+	go io.WriteString(w, "{\"msg\": \"one\"}\n{\"msg\": \"two\"}\n")
+
+	opts := *DefaultOptions
+	opts.Head = 2
+	done := make(chan error, 1)
+	go func() { done <- Scanner(r, ioutil.Discard, &opts) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("want the scanner to stop after the second entry")
+	}
+}
+
+func TestHead_Filtered(t *testing.T) {
+	src := "{\"msg\": \"one\", \"level\": \"debug\"}\n{\"msg\": \"two\", \"level\": \"error\"}\n{\"msg\": \"three\", \"level\": \"info\"}\n{\"msg\": \"four\", \"level\": \"error\"}\n"
+	opts := *DefaultOptions
+	opts.Head = 2
+	opts.MinLevel = "info"
+	var got []string
+	err := ScanEvents(strings.NewReader(src), &opts, func(ev *Event) error {
+		got = append(got, ev.Message)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "two,three"; strings.Join(got, ",") != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestSeekTail(t *testing.T) {
+	var src bytes.Buffer
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&src, "{\"msg\": \"entry %d\", \"pad\": %q}\n", i, strings.Repeat("x", i%50))
+		if i%100 == 0 {
+			src.WriteString("\tat some.Frame(Frame.java:1)\r\n")
+		}
+	}
+	data := src.Bytes()
+
+	tests := []struct {
+		n     int
+		first string
+	}{
+		{n: 1, first: "entry 4999"},
+		{n: 3, first: "entry 4997"},
+		{n: 1000, first: "entry 4000"},
+		{n: 5000, first: "entry 0"},
+		{n: 9000, first: "entry 0"},
+	}
+	for _, test := range tests {
+		off, err := SeekTail(bytes.NewReader(data), int64(len(data)), test.n, DefaultOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if off > 0 && data[off-1] != '\n' {
+			t.Errorf("%d: want the start of a line, got %d", test.n, off)
+		}
+		if want := fmt.Sprintf("{\"msg\": %q", test.first); !bytes.HasPrefix(data[off:], []byte(want)) {
+			t.Errorf("%d: want to start at %q, got %.30q", test.n, test.first, data[off:])
+		}
+	}
+
+	// without a newline at the end
+	data = []byte("{\"msg\": \"a\"}\n{\"msg\": \"b\"}")
+	if off, err := SeekTail(bytes.NewReader(data), int64(len(data)), 1, DefaultOptions); err != nil || off != 13 {
+		t.Errorf("want 13, got %d, %v", off, err)
+	}
+}
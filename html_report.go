@@ -0,0 +1,165 @@
+package humanlog
+
+import (
+	"bufio"
+	"html"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HTMLReport reads the log lines of src and writes them onto dst as a
+// standalone HTML page, that can be filtered by text and level in the
+// browser. Rows are written as they're read, so src must be finite for the
+// page to be complete.
+func HTMLReport(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+	w := bufio.NewWriter(dst)
+	err := htmlReportHeader.Execute(w, struct{ Generated string }{time.Now().Format(time.RFC1123)})
+	if err != nil {
+		return err
+	}
+
+	stages := builtinStages(opts)
+	if len(opts.Sinks) != 0 {
+		stages = append(stages, sinkStage(opts))
+	}
+	stages = append(stages, func(e *Entry, next Next) error {
+		writeHTMLRow(w, opts, e)
+		return next(e)
+	})
+	err = Process(src, opts, Chain(stages...))
+
+	_, _ = io.WriteString(w, htmlReportFooter)
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+var htmlKindClasses = map[valueKind]string{
+	kindString: "str",
+	kindNumber: "num",
+	kindBool:   "bool",
+	kindNull:   "null",
+	kindTime:   "time",
+	kindURL:    "url",
+	kindObject: "obj",
+}
+
+func writeHTMLRow(w io.StringWriter, opts *HandlerOptions, e *Entry) {
+	if e.Event == nil {
+		w.WriteString(`<tr class="raw"><td colspan="4">`)
+		w.WriteString(html.EscapeString(string(e.Raw)))
+		w.WriteString("</td></tr>\n")
+		return
+	}
+	ev := opts.visibleEvent(e.Event)
+
+	level := strings.ToLower(ev.Level)
+	w.WriteString(`<tr class="lvl-` + html.EscapeString(htmlLevelClass(level)) + `">`)
+	w.WriteString(`<td class="ts">`)
+	if !ev.Time.IsZero() {
+		w.WriteString(html.EscapeString(ev.Time.Format(opts.TimeFormat)))
+	}
+	w.WriteString(`</td><td class="lvl">` + html.EscapeString(shortLevel(ev.Level)) + `</td>`)
+	w.WriteString(`<td class="msg">` + html.EscapeString(ev.Message) + `</td><td class="kv">`)
+
+	keys := make([]string, 0, len(ev.Fields))
+	for k := range ev.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := ev.Fields[k]
+		w.WriteString(`<span class="k">` + html.EscapeString(k) + `</span>=`)
+		w.WriteString(`<span class="` + htmlKindClasses[kindOfText(unquoteValue(v))] + `">`)
+		w.WriteString(html.EscapeString(v))
+		w.WriteString("</span> ")
+	}
+	w.WriteString("</td></tr>\n")
+}
+
+// htmlLevelClass groups the levels the way the terminal colors them.
+func htmlLevelClass(level string) string {
+	switch level {
+	case "debug", "trace":
+		return "debug"
+	case "info":
+		return "info"
+	case "warn", "warning":
+		return "warn"
+	case "error":
+		return "error"
+	case "fatal", "panic", "critical":
+		return "fatal"
+	default:
+		return "unknown"
+	}
+}
+
+var htmlReportHeader = template.Must(template.New("header").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>humanlog report</title>
+<style>
+body { background: #1e1e1e; color: #ddd; font: 13px monospace; margin: 0; }
+header { position: sticky; top: 0; background: #2a2a2a; padding: 8px; display: flex; gap: 12px; align-items: center; }
+header input[type=search] { flex: 1; font: inherit; padding: 2px 4px; }
+table { border-collapse: collapse; width: 100%; }
+td { padding: 1px 6px; vertical-align: top; white-space: pre-wrap; word-break: break-all; }
+.ts { color: #aaa; white-space: nowrap; }
+.lvl { font-weight: bold; }
+.msg { color: #fff; }
+.k { color: #5c5; }
+.num { color: #5dd; } .bool { color: #ee5; } .null { color: #777; } .time { color: #d7d; } .url { color: #79f; text-decoration: underline; }
+.lvl-debug .lvl { color: #c6c; } .lvl-info .lvl { color: #5cc; } .lvl-warn .lvl { color: #dd5; }
+.lvl-error .lvl { color: #e55; } .lvl-fatal .lvl { background: #e33; color: #fff; } .lvl-unknown .lvl { color: #c6c; }
+.raw td { color: #999; }
+tr.hidden { display: none; }
+footer { color: #777; padding: 8px; }
+</style>
+</head>
+<body>
+<header>
+<input type="search" id="q" placeholder="filter">
+<label><input type="checkbox" class="lv" value="debug" checked>debug</label>
+<label><input type="checkbox" class="lv" value="info" checked>info</label>
+<label><input type="checkbox" class="lv" value="warn" checked>warn</label>
+<label><input type="checkbox" class="lv" value="error" checked>error</label>
+<label><input type="checkbox" class="lv" value="fatal" checked>fatal</label>
+<label><input type="checkbox" class="lv" value="unknown" checked>other</label>
+<span id="n"></span>
+<span>{{.Generated}}</span>
+</header>
+<table id="log">
+`))
+
+const htmlReportFooter = `</table>
+<footer>generated by humanlog</footer>
+<script>
+(function () {
+  var rows = document.querySelectorAll("#log tr");
+  var q = document.getElementById("q");
+  var boxes = document.querySelectorAll("input.lv");
+  function apply() {
+    var text = q.value.toLowerCase(), levels = {}, shown = 0;
+    boxes.forEach(function (b) { levels[b.value] = b.checked; });
+    rows.forEach(function (row) {
+      var lvl = (row.className.match(/lvl-(\w+)/) || [])[1];
+      var ok = (!lvl || levels[lvl]) && (!text || row.textContent.toLowerCase().indexOf(text) >= 0);
+      row.classList.toggle("hidden", !ok);
+      if (ok) shown++;
+    });
+    document.getElementById("n").textContent = shown + " / " + rows.length;
+  }
+  q.addEventListener("input", apply);
+  boxes.forEach(function (b) { b.addEventListener("change", apply); });
+  apply();
+})();
+</script>
+</body>
+</html>
+`
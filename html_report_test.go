@@ -0,0 +1,36 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHTMLReport(t *testing.T) {
+	src := strings.Join([]string{
+		`{"level": "error", "msg": "<script>alert(1)</script>", "status": 500}`,
+		`plain & simple`,
+	}, "\n")
+
+	var out bytes.Buffer
+	opts := *DefaultOptions
+	if err := HTMLReport(strings.NewReader(src), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+
+	for _, want := range []string{
+		`<tr class="lvl-error">`,
+		`&lt;script&gt;alert(1)&lt;/script&gt;`,
+		`<span class="k">status</span>=<span class="num">500</span>`,
+		`plain &amp; simple`,
+		`</html>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want report to contain %q", want)
+		}
+	}
+	if strings.Contains(got, "<script>alert") {
+		t.Error("message wasn't escaped")
+	}
+}
@@ -0,0 +1,162 @@
+package humanlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SQLiteShell is the sqlite3 command line shell used to write and query
+// indexes, which keeps humanlog free of cgo.
+var SQLiteShell = "sqlite3"
+
+const indexSchema = `CREATE TABLE IF NOT EXISTS events (
+	id      INTEGER PRIMARY KEY,
+	time    TEXT,
+	level   TEXT,
+	message TEXT,
+	fields  TEXT
+);
+CREATE INDEX IF NOT EXISTS events_time ON events(time);
+CREATE INDEX IF NOT EXISTS events_level ON events(level);
+`
+
+// Index is a sink writing events in the events table of a SQLite database.
+// Fields are stored as a JSON object, which can be queried with the json1
+// functions, i.e. json_extract(fields, '$.user_id').
+type Index struct {
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	w       *bufio.Writer
+	pending int
+	done    chan struct{}
+}
+
+// OpenIndex creates the SQLite database at path if needed, and returns an
+// index appending to it.
+func OpenIndex(path string) (*Index, error) {
+	cmd := exec.Command(SQLiteShell, "-batch", path)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("can't start %s: %v", SQLiteShell, err)
+	}
+	x := &Index{
+		cmd:   cmd,
+		stdin: stdin,
+		w:     bufio.NewWriter(stdin),
+		done:  make(chan struct{}),
+	}
+	if _, err := x.w.WriteString(indexSchema); err != nil {
+		return nil, err
+	}
+	go x.commitEvery(time.Second)
+	return x, nil
+}
+
+// Send appends the event to the index. Events are committed in batches, at
+// least every second, and by Close: if the process is killed before, the
+// events of the last second are lost.
+func (x *Index) Send(ev *Event) error {
+	fields := make(map[string]string, len(ev.Fields))
+	for k, v := range ev.Fields {
+		fields[k] = unquoteValue(v)
+	}
+	blob, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	var t string
+	if !ev.Time.IsZero() {
+		t = ev.Time.UTC().Format(time.RFC3339Nano)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.pending == 0 {
+		if _, err := x.w.WriteString("BEGIN;\n"); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(x.w, "INSERT INTO events (time, level, message, fields) VALUES (%s, %s, %s, %s);\n",
+		sqlString(t), sqlString(ev.Level), sqlString(ev.Message), sqlString(string(blob)))
+	if err != nil {
+		return err
+	}
+	x.pending++
+	if x.pending >= 1000 {
+		return x.commit()
+	}
+	return nil
+}
+
+func (x *Index) commitEvery(d time.Duration) {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-x.done:
+			return
+		case <-ticker.C:
+			x.mu.Lock()
+			_ = x.commit()
+			x.mu.Unlock()
+		}
+	}
+}
+
+func (x *Index) commit() error {
+	if x.pending == 0 {
+		return nil
+	}
+	x.pending = 0
+	if _, err := x.w.WriteString("COMMIT;\n"); err != nil {
+		return err
+	}
+	return x.w.Flush()
+}
+
+// Close commits what's pending and waits for the database to be written.
+func (x *Index) Close() error {
+	close(x.done)
+	x.mu.Lock()
+	err := x.commit()
+	if ferr := x.w.Flush(); err == nil {
+		err = ferr
+	}
+	x.mu.Unlock()
+	if cerr := x.stdin.Close(); err == nil {
+		err = cerr
+	}
+	if werr := x.cmd.Wait(); err == nil {
+		err = werr
+	}
+	return err
+}
+
+// QueryIndex runs query against the SQLite database at path, writing the
+// results as a table onto dst.
+func QueryIndex(path, query string, dst io.Writer) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	cmd := exec.Command(SQLiteShell, "-batch", "-header", "-column", "-readonly", path, query)
+	cmd.Stdout = dst
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func sqlString(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
@@ -0,0 +1,143 @@
+package humanlog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSQLString(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"", "''"},
+		{"plain", "'plain'"},
+		{"it's", "'it''s'"},
+		{"'); DROP TABLE events; --", "'''); DROP TABLE events; --'"},
+		{"two\nlines", "'two\nlines'"},
+	} {
+		if got := sqlString(tt.in); got != tt.want {
+			t.Errorf("%q: want %q, got %q", tt.in, tt.want, got)
+		}
+	}
+}
+
+// fakeSQLiteShell makes SQLiteShell a script writing the statements it's
+// given into the database file, as they come.
+func fakeSQLiteShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell")
+	}
+	dir, err := ioutil.TempDir("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "sqlite3")
+	if err := ioutil.WriteFile(script, []byte("#!/bin/sh\nexec cat > \"$2\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	shell := SQLiteShell
+	SQLiteShell = script
+	t.Cleanup(func() {
+		SQLiteShell = shell
+		os.RemoveAll(dir)
+	})
+}
+
+func TestIndex_Flush(t *testing.T) {
+	fakeSQLiteShell(t)
+	path := filepath.Join(filepath.Dir(SQLiteShell), "index.sql")
+
+	x, err := OpenIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		if err := x.Send(&Event{Level: "info", Message: "batched"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a full batch is committed right away
+	var got []byte
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if got, _ = ioutil.ReadFile(path); bytes.Contains(got, []byte("COMMIT;")) {
+			break
+		}
+	}
+	if n := bytes.Count(got, []byte("INSERT INTO")); n != 1000 || !bytes.HasSuffix(got, []byte("COMMIT;\n")) {
+		t.Fatalf("want the batch of 1000 committed, got %d inserts", n)
+	}
+
+	ev := &Event{Time: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), Level: "warn", Message: "it's late", Fields: map[string]string{"user": `"o'brien"`}}
+	if err := x.Send(ev); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = ioutil.ReadFile(path)
+	want := "BEGIN;\nINSERT INTO events (time, level, message, fields) VALUES ('2024-01-02T10:00:00Z', 'warn', 'it''s late', '{\"user\":\"o''brien\"}');\nCOMMIT;\n"
+	if !strings.HasSuffix(string(got), want) {
+		t.Errorf("want the rest committed on close, ending with\n%s\ngot\n%s", want, got[len(got)-len(want):])
+	}
+}
+
+func TestIndex_Every(t *testing.T) {
+	fakeSQLiteShell(t)
+	path := filepath.Join(filepath.Dir(SQLiteShell), "index.sql")
+
+	x, err := OpenIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if err := x.Send(&Event{Message: "alone"}); err != nil {
+		t.Fatal(err)
+	}
+	// what's pending is committed within a second, without closing
+	var got []byte
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if got, _ = ioutil.ReadFile(path); bytes.Contains(got, []byte("COMMIT;")) {
+			break
+		}
+	}
+	if !bytes.Contains(got, []byte("'alone'")) || !bytes.HasSuffix(got, []byte("COMMIT;\n")) {
+		t.Errorf("want the entry committed, got\n%s", got)
+	}
+}
+
+func TestIndex_SQLite(t *testing.T) {
+	if _, err := exec.LookPath(SQLiteShell); err != nil {
+		t.Skip(err)
+	}
+	dir, err := ioutil.TempDir("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "index.db")
+
+	x, err := OpenIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Send(&Event{Level: "error", Message: "it's down", Fields: map[string]string{"host": `"web'1"`}})
+	x.Send(&Event{Level: "info", Message: "fine"})
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := QueryIndex(path, "SELECT message, json_extract(fields, '$.host') AS host FROM events WHERE level = 'error'", &out); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "it's down") || !strings.Contains(got, "web'1") || strings.Contains(got, "fine") {
+		t.Errorf("want the error entry, got\n%s", got)
+	}
+}
@@ -0,0 +1,243 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kr/logfmt"
+)
+
+// Inference is what a sample of log lines tells about their schema: which
+// keys hold the time, level and message of the entries, and how.
+type Inference struct {
+	// Lines is how many lines were read, and Parsed how many of those were
+	// JSON or logfmt.
+	Lines  int
+	Parsed int
+
+	TimeField    string
+	MessageField string
+	LevelField   string
+	// TimeFormat describes the timestamps of TimeField, a Go layout or the
+	// unit of Unix timestamps.
+	TimeFormat string
+	// LevelScheme tells how the levels are written: "names", or the numbers
+	// of "bunyan" or "syslog".
+	LevelScheme string
+}
+
+// keyStats is what was seen of a key across the sample.
+type keyStats struct {
+	key      string
+	seen     int
+	strings  int
+	words    int
+	times    map[string]int
+	levels   map[string]int
+	distinct map[string]struct{}
+}
+
+// Infer reads up to limit lines of src and guesses their schema. Keys found
+// in at least half of the structured lines are candidates, and most of their
+// values have to look like a time or a level for them to be picked as one.
+func Infer(src io.Reader, limit int) (*Inference, error) {
+	inf := new(Inference)
+	stats := make(map[string]*keyStats)
+	observe := func(key string, value interface{}) {
+		s, ok := stats[key]
+		if !ok {
+			s = &keyStats{key: key, times: make(map[string]int), levels: make(map[string]int), distinct: make(map[string]struct{})}
+			stats[key] = s
+		}
+		s.observe(value)
+	}
+
+	sc := bufio.NewScanner(src)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for inf.Lines < limit && sc.Scan() {
+		inf.Lines++
+		line := bytes.TrimSpace(sc.Bytes())
+		if inferLine(line, observe) {
+			inf.Parsed++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	var candidates []*keyStats
+	for _, s := range stats {
+		if s.seen*2 >= inf.Parsed {
+			candidates = append(candidates, s)
+		}
+	}
+	// most frequent first, so that ties go to the key seen the most
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].seen != candidates[j].seen {
+			return candidates[i].seen > candidates[j].seen
+		}
+		return candidates[i].key < candidates[j].key
+	})
+
+	if s, format := pickMajority(candidates, func(s *keyStats) map[string]int { return s.times }); s != nil {
+		inf.TimeField, inf.TimeFormat = s.key, format
+	}
+	if s, scheme := pickMajority(candidates, func(s *keyStats) map[string]int { return s.levels }); s != nil && s.key != inf.TimeField && len(s.distinct) <= 10 {
+		inf.LevelField, inf.LevelScheme = s.key, scheme
+	}
+	var best float64
+	for _, s := range candidates {
+		if s.key == inf.TimeField || s.key == inf.LevelField || s.strings*2 < s.seen {
+			continue
+		}
+		// messages are sentences, unlike identifiers and enums
+		if score := float64(s.words) / float64(s.seen); score > best {
+			inf.MessageField, best = s.key, score
+		}
+	}
+	return inf, nil
+}
+
+// inferLine calls observe with each key of line, flattening nested objects
+// into dotted keys. It tells whether line was JSON or logfmt.
+func inferLine(line []byte, observe func(key string, value interface{})) bool {
+	if bytes.HasPrefix(line, []byte("{")) {
+		raw := make(map[string]interface{})
+		if err := json.Unmarshal(line, &raw); err != nil {
+			return false
+		}
+		flattenInto("", raw, observe)
+		return true
+	}
+	n := 0
+	err := logfmt.Unmarshal(line, logfmt.HandlerFunc(func(key, val []byte) error {
+		if len(val) == 0 {
+			return nil
+		}
+		n++
+		if f, err := strconv.ParseFloat(string(val), 64); err == nil {
+			observe(string(key), f)
+		} else {
+			observe(string(key), string(val))
+		}
+		return nil
+	}))
+	return err == nil && n > 1
+}
+
+func flattenInto(prefix string, obj map[string]interface{}, observe func(key string, value interface{})) {
+	for k, v := range obj {
+		if sub, ok := v.(map[string]interface{}); ok {
+			flattenInto(prefix+k+".", sub, observe)
+			continue
+		}
+		observe(prefix+k, v)
+	}
+}
+
+func (s *keyStats) observe(value interface{}) {
+	s.seen++
+	if len(s.distinct) <= 100 {
+		s.distinct[inferString(value)] = struct{}{}
+	}
+	switch v := value.(type) {
+	case string:
+		s.strings++
+		s.words += len(strings.Fields(v))
+		if format := timeLayoutOf(v); format != "" {
+			s.times[format]++
+		}
+		if _, ok := builtinLevelRanks[strings.ToLower(v)]; ok {
+			s.levels["names"]++
+		}
+	case float64:
+		if format := epochUnitOf(v); format != "" {
+			s.times[format]++
+		}
+		if scheme := levelSchemeOf(v); scheme != "" {
+			s.levels[scheme]++
+		}
+	}
+}
+
+// pickMajority returns the first of candidates for which at least 90% of
+// the values fall in one of the classes counted by classes, and that class.
+func pickMajority(candidates []*keyStats, classes func(*keyStats) map[string]int) (*keyStats, string) {
+	for _, s := range candidates {
+		for class, n := range classes(s) {
+			if n*10 >= s.seen*9 {
+				return s, class
+			}
+		}
+	}
+	return nil, ""
+}
+
+// timeLayoutOf returns the layout v is written in, among those humanlog
+// recognizes.
+func timeLayoutOf(v string) string {
+	for _, layout := range formats {
+		if t, err := time.Parse(layout, v); err == nil && (t.Year() > 1970 || layout == time.Kitchen || strings.HasPrefix(layout, "Jan")) {
+			return layout
+		}
+	}
+	return ""
+}
+
+// epochUnitOf tells the unit of v, if it's a Unix timestamp from this
+// century.
+func epochUnitOf(v float64) string {
+	switch {
+	case v >= 946684800e9 && v < 4102444800e9:
+		return "unix nanoseconds"
+	case v >= 946684800e6 && v < 4102444800e6:
+		return "unix microseconds"
+	case v >= 946684800e3 && v < 4102444800e3:
+		return "unix milliseconds"
+	case v >= 946684800 && v < 4102444800:
+		return "unix seconds"
+	default:
+		return ""
+	}
+}
+
+// levelSchemeOf tells which numbering of levels v belongs to.
+func levelSchemeOf(v float64) string {
+	switch {
+	case v >= 10 && v <= 60 && v == float64(int(v/10)*10):
+		return "bunyan"
+	case v >= 0 && v <= 7 && v == float64(int(v)):
+		return "syslog"
+	default:
+		return ""
+	}
+}
+
+func inferString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// Config returns a configuration setting the fields that were inferred.
+func (inf *Inference) Config() *Config {
+	options := make(map[string]interface{})
+	if inf.TimeField != "" {
+		options["time-fields"] = []string{inf.TimeField}
+	}
+	if inf.MessageField != "" {
+		options["message-fields"] = []string{inf.MessageField}
+	}
+	if inf.LevelField != "" {
+		options["level-fields"] = []string{inf.LevelField}
+	}
+	return &Config{Options: options}
+}
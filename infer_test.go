@@ -0,0 +1,62 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInfer(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []string
+		want Inference
+	}{
+		{
+			name: "json",
+			src: []string{
+				`{"ts": 1700000000.5, "severity": "INFO", "body": "user logged in", "ctx": {"user": "a"}, "id": "x1"}`,
+				`{"ts": 1700000001.5, "severity": "WARN", "body": "disk is nearly full", "ctx": {"user": "b"}, "id": "x2"}`,
+				`not structured`,
+			},
+			want: Inference{Lines: 3, Parsed: 2, TimeField: "ts", TimeFormat: "unix seconds", LevelField: "severity", LevelScheme: "names", MessageField: "body"},
+		},
+		{
+			name: "bunyan",
+			src: []string{
+				`{"time": "2024-01-02T03:04:05Z", "level": 30, "event": "started the server", "pid": 1}`,
+				`{"time": "2024-01-02T03:04:06Z", "level": 50, "event": "lost the database", "pid": 1}`,
+			},
+			want: Inference{Lines: 2, Parsed: 2, TimeField: "time", TimeFormat: "2006-01-02T15:04:05Z07:00", LevelField: "level", LevelScheme: "bunyan", MessageField: "event"},
+		},
+		{
+			name: "logfmt",
+			src: []string{
+				`at=1700000000123 sev=3 text="connection refused by peer" host=a`,
+				`at=1700000000456 sev=6 text="connection established" host=b`,
+			},
+			want: Inference{Lines: 2, Parsed: 2, TimeField: "at", TimeFormat: "unix milliseconds", LevelField: "sev", LevelScheme: "syslog", MessageField: "text"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Infer(strings.NewReader(strings.Join(tt.src, "\n")), 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Errorf("want %+v, got %+v", tt.want, *got)
+			}
+		})
+	}
+}
+
+func TestInferLimit(t *testing.T) {
+	src := strings.Repeat(`{"msg": "hello there"}`+"\n", 10)
+	got, err := Infer(strings.NewReader(src), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Lines != 4 {
+		t.Errorf("want 4 lines read, got %d", got.Lines)
+	}
+}
@@ -0,0 +1,66 @@
+package humanlog
+
+import "io"
+
+// Interruptible reads from r until stop is closed, then reports the end of
+// the input, even if a read from r is still blocked. What was already read
+// is returned first, so entries in flight make it through.
+func Interruptible(r io.Reader, stop <-chan struct{}) io.Reader {
+	if m, ok := r.(*MappedFile); ok {
+		// reading a mapping never blocks, it only has to stop
+		m.stop = stop
+		return m
+	}
+	ir := &interruptible{reads: make(chan readResult), stop: stop}
+	go ir.readFrom(r)
+	return ir
+}
+
+type readResult struct {
+	data []byte
+	err  error
+}
+
+type interruptible struct {
+	reads chan readResult
+	stop  <-chan struct{}
+	buf   []byte
+	err   error
+}
+
+func (ir *interruptible) readFrom(r io.Reader) {
+	for {
+		buf := make([]byte, 32*1024)
+		n, err := r.Read(buf)
+		select {
+		case ir.reads <- readResult{data: buf[:n], err: err}:
+		case <-ir.stop:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (ir *interruptible) Read(p []byte) (int, error) {
+	for len(ir.buf) == 0 {
+		if ir.err != nil {
+			return 0, ir.err
+		}
+		select {
+		case <-ir.stop:
+			return 0, io.EOF
+		default:
+		}
+		select {
+		case res := <-ir.reads:
+			ir.buf, ir.err = res.data, res.err
+		case <-ir.stop:
+			return 0, io.EOF
+		}
+	}
+	n := copy(p, ir.buf)
+	ir.buf = ir.buf[n:]
+	return n, nil
+}
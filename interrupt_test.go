@@ -0,0 +1,35 @@
+package humanlog
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestInterruptible(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	stop := make(chan struct{})
+	r := Interruptible(pr, stop)
+
+	go func() {
+		pw.Write([]byte("first line\nsecond "))
+		time.Sleep(50 * time.Millisecond)
+		close(stop)
+	}()
+
+	done := make(chan []byte)
+	go func() {
+		b, _ := ioutil.ReadAll(r)
+		done <- b
+	}()
+	select {
+	case b := <-done:
+		if string(b) != "first line\nsecond " {
+			t.Errorf("want what was written before stopping, got %q", b)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("reading didn't stop")
+	}
+}
@@ -0,0 +1,67 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// journalLevels are the levels of the journal's priorities, the syslog
+// severities.
+var journalLevels = []string{"fatal", "critical", "critical", "error", "warn", "notice", "info", "debug"}
+
+// rewriteJournal turns the entries of the systemd journal, as exported by
+// journalctl -o json, into entries with a time to the microsecond, a level
+// and a msg. The other fields of the journal are kept as they are.
+func rewriteJournal(line []byte) []byte {
+	if !bytes.HasPrefix(line, []byte(`{`)) || !bytes.Contains(line, []byte(`"__REALTIME_TIMESTAMP"`)) {
+		return nil
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return nil
+	}
+	entry := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		entry[k] = journalValue(v)
+	}
+
+	if us, err := strconv.ParseInt(journalString(raw["__REALTIME_TIMESTAMP"]), 10, 64); err == nil {
+		entry["time"] = time.Unix(0, us*int64(time.Microsecond)).UTC().Format(time.RFC3339Nano)
+		delete(entry, "__REALTIME_TIMESTAMP")
+	}
+	if p, err := strconv.Atoi(journalString(raw["PRIORITY"])); err == nil && p >= 0 && p < len(journalLevels) {
+		entry["level"] = journalLevels[p]
+		delete(entry, "PRIORITY")
+	}
+	if msg, ok := raw["MESSAGE"]; ok {
+		entry["msg"] = journalValue(msg)
+		delete(entry, "MESSAGE")
+	}
+
+	out, err := json.Marshal(entry)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// journalValue decodes a field of the journal. Fields that aren't valid
+// UTF-8 are exported as arrays of bytes, and are turned back into strings.
+func journalValue(v json.RawMessage) interface{} {
+	var b []byte
+	if bytes.HasPrefix(v, []byte(`[`)) && json.Unmarshal(v, &b) == nil {
+		return string(b)
+	}
+	var out interface{}
+	if err := json.Unmarshal(v, &out); err != nil {
+		return string(v)
+	}
+	return out
+}
+
+func journalString(v json.RawMessage) string {
+	s, _ := journalValue(v).(string)
+	return s
+}
@@ -0,0 +1,52 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJournalPreset(t *testing.T) {
+	src := strings.Join([]string{
+		`{"__CURSOR": "s=1;i=2", "__REALTIME_TIMESTAMP": "1700000000123456", "PRIORITY": "3", "_SYSTEMD_UNIT": "api.service", "_PID": "42", "MESSAGE": "listen failed"}`,
+		`{"__REALTIME_TIMESTAMP": "1700000001000001", "PRIORITY": "6", "_SYSTEMD_UNIT": "api.service", "MESSAGE": [104, 105, 255]}`,
+		`{"__REALTIME_TIMESTAMP": "1700000002000000", "SYSLOG_IDENTIFIER": "kernel", "MESSAGE": "no priority"}`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	p, _ := LookupPreset("journal")
+	p.Apply(&opts)
+
+	var got []*Event
+	err := ScanEvents(strings.NewReader(src), &opts, func(ev *Event) error {
+		got = append(got, ev)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("want 3 entries, got %d", len(got))
+	}
+
+	if want := time.Unix(1700000000, 123456000); !got[0].Time.Equal(want) {
+		t.Errorf("want the time to the microsecond, got %v", got[0].Time)
+	}
+	if got[0].Level != "error" || got[0].Message != "listen failed" {
+		t.Errorf("want the priority as level and the message, got %q %q", got[0].Level, got[0].Message)
+	}
+	for _, key := range []string{"__CURSOR", "_SYSTEMD_UNIT", "_PID"} {
+		if _, ok := got[0].Fields[key]; !ok {
+			t.Errorf("want %s kept, got %v", key, got[0].Fields)
+		}
+	}
+	if got[1].Message != "hi\uFFFD" || got[1].Level != "info" {
+		t.Errorf("want binary messages as text, got %q %q", got[1].Level, got[1].Message)
+	}
+	if got[2].Level != "" || got[2].Message != "no priority" {
+		t.Errorf("want no level without a priority, got %q", got[2].Level)
+	}
+	if src := opts.sourceOf(got[2]); src != "kernel" {
+		t.Errorf("want the identifier as source without a unit, got %q", src)
+	}
+}
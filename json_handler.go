@@ -13,7 +13,8 @@ import (
 	"github.com/fatih/color"
 )
 
-// JSONHandler can handle logs emitted by logrus.TextFormatter loggers.
+// JSONHandler can handle logs emitted by logrus.JSONFormatter loggers, and
+// other JSON loggers.
 type JSONHandler struct {
 	buf     *bytes.Buffer
 	out     *tabwriter.Writer
@@ -26,14 +27,32 @@ type JSONHandler struct {
 	Message string
 	Fields  map[string]string
 
-	last map[string]string
+	kinds map[string]valueKind
+	// arrays are the items of the values that are arrays.
+	arrays map[string][]interface{}
+	last   map[string]string
+	// drop are the keys not to keep at all.
+	drop map[string]struct{}
+
+	// pretty holds the lines of a pretty-printed document read so far,
+	// until its brackets balance.
+	pretty   []byte
+	brackets bracketState
+	invalid  bool
 }
 
 // searchJSON searches a document for a key using the found func to determine if the value is accepted.
 // kvs is the deserialized json document.
 // fieldList is a list of field names that should be searched. Sub-documents can be searched by using the dot (.). For example, to search {"data"{"message": "<this field>"}} the item would be data.message
+// Fields can also be JSON Pointers, like /data/message, for keys holding dots.
 func searchJSON(kvs map[string]interface{}, fieldList []string, found func(key string, value interface{}) bool) bool {
 	for _, field := range fieldList {
+		if segs, ok := parsePointer(field); ok {
+			if v, ok := lookupPointer(kvs, segs); ok && found(field, v) {
+				return true
+			}
+			continue
+		}
 		splits := strings.SplitN(field, ".", 2)
 		if len(splits) > 1 {
 			name, fieldKey := splits[0], splits[1]
@@ -71,23 +90,93 @@ func (h *JSONHandler) clear() {
 	h.Level = ""
 	h.Time = time.Time{}
 	h.Message = ""
-	h.last = h.Fields
 	h.Fields = make(map[string]string)
+	h.kinds = make(map[string]valueKind)
+	h.arrays = nil
 	if h.buf != nil {
 		h.buf.Reset()
 	}
+	h.pretty, h.brackets, h.invalid = nil, bracketState{}, false
+}
+
+// sniffJSON tells the lines holding a document, which start with a brace.
+func sniffJSON(d []byte) confidence {
+	if bytes.HasPrefix(trimJSONPrefix(d), []byte("{")) {
+		return surelyFormat
+	}
+	return notFormat
+}
+
+// sniffPrettyJSON tells the lone opening braces of pretty-printed documents.
+func sniffPrettyJSON(d []byte) confidence {
+	if bytes.Equal(bytes.TrimSpace(trimJSONPrefix(d)), []byte("{")) {
+		return surelyFormat
+	}
+	return notFormat
+}
+
+// tryStartPretty tells whether the line starts a pretty-printed document,
+// whose first line is a lone opening brace.
+func (h *JSONHandler) tryStartPretty(d []byte) bool {
+	d = trimJSONPrefix(d)
+	if !bytes.Equal(bytes.TrimSpace(d), []byte("{")) {
+		return false
+	}
+	h.pretty = append(h.pretty[:0], d...)
+	h.brackets = bracketState{open: true}
+	h.brackets.feed(d)
+	return true
+}
+
+// NeedsMore tells whether a pretty-printed document is still incomplete.
+func (h *JSONHandler) NeedsMore() bool { return h.pretty != nil }
+
+// Append adds a line to the pretty-printed document, which is parsed once
+// its brackets balance.
+func (h *JSONHandler) Append(line []byte) bool {
+	if h.pretty == nil || h.invalid {
+		return false
+	}
+	h.pretty = append(append(h.pretty, '\n'), line...)
+	h.brackets.feed(line)
+	if h.brackets.unbalanced() {
+		return true
+	}
+	if err := h.UnmarshalJSON(h.pretty); err != nil {
+		// not a document after all, it stays incomplete
+		h.invalid = true
+		return true
+	}
+	h.pretty = nil
+	return true
 }
 
 // TryHandle tells if this line was handled by this handler.
 func (h *JSONHandler) TryHandle(d []byte) bool {
-	if !h.UnmarshalJSON(d) {
+	if err := h.UnmarshalJSON(trimJSONPrefix(d)); err != nil {
 		h.clear()
 		return false
 	}
 	return true
 }
 
+// trimJSONPrefix removes the whitespace and byte order mark in front of a
+// document, which some Windows tools put on every line they export, and
+// which encoding/json won't skip. Streams are only rid of the mark they
+// start with, by decodeInput.
+func trimJSONPrefix(d []byte) []byte {
+	d = bytes.TrimLeft(d, " \t\r\n")
+	if bytes.HasPrefix(d, bomUTF8) {
+		d = bytes.TrimLeft(d[len(bomUTF8):], " \t\r\n")
+	}
+	return d
+}
+
 func deleteJSONKey(key string, jsonData map[string]interface{}) {
+	if segs, ok := parsePointer(key); ok {
+		deletePointer(jsonData, segs)
+		return
+	}
 	if _, ok := jsonData[key]; ok {
 		// found the key at the root
 		delete(jsonData, key)
@@ -110,21 +199,111 @@ func deleteJSONKey(key string, jsonData map[string]interface{}) {
 }
 
 // UnmarshalJSON sets the fields of the handler.
-func (h *JSONHandler) UnmarshalJSON(data []byte) bool {
+func (h *JSONHandler) UnmarshalJSON(data []byte) error {
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
+	}
+
 	raw := make(map[string]interface{})
-	err := json.Unmarshal(data, &raw)
-	if err != nil {
-		return false
+	switch policy := h.Opts.DuplicateKeys; policy {
+	case "", "last":
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+	default:
+		var dups []string
+		var err error
+		raw, dups, err = decodeJSONObject(data, policy)
+		if err != nil {
+			return err
+		}
+		flagDuplicates(raw, dups, policy)
 	}
 
-	if h.Opts == nil {
-		h.Opts = DefaultOptions
+	msgField := h.takeTimeLevelMessage(raw)
+	if doc, ok := nestedDocument(h.Message); ok && h.Opts.UnnestMessages {
+		// the message is a document of its own, encoded as a string by a
+		// pipeline, whose time, level and message take over those around it
+		h.Message = ""
+		h.takeTimeLevelMessage(doc)
+		prefix := msgField
+		if strings.HasPrefix(prefix, "/") {
+			prefix = strings.Replace(prefix[1:], "/", ".", -1)
+		}
+		for k, v := range doc {
+			raw[prefix+"."+k] = v
+		}
+	}
+
+	for _, segs := range h.Opts.skipPointers {
+		deletePointer(raw, segs)
+	}
+
+	if h.Fields == nil {
+		h.Fields = make(map[string]string)
+	}
+	if h.kinds == nil {
+		h.kinds = make(map[string]valueKind)
+	}
+
+	// the text of the values of typed fields, before encoding/json made
+	// floats of their numbers
+	var texts map[string]json.RawMessage
+	if len(h.Opts.Types) != 0 {
+		_ = json.Unmarshal(data, &texts)
+	}
+
+	for key, val := range raw {
+		if _, drop := h.drop[key]; drop {
+			continue
+		}
+		if _, typed := h.Opts.Types[key]; typed {
+			text, ok := texts[key]
+			if !ok {
+				text, _ = json.Marshal(val)
+			}
+			v, kind, _ := h.Opts.typeValue(key, string(text))
+			if kind == kindString {
+				v = fmt.Sprintf("%q", v)
+			}
+			h.Fields[key], h.kinds[key] = v, kind
+			continue
+		}
+		h.kinds[key] = kindOfJSON(val)
+		switch v := val.(type) {
+		case float64:
+			if v-math.Floor(v) < 0.000001 && math.Abs(v) < 1e9 {
+				// looks like an integer that's not too large
+				h.Fields[key] = fmt.Sprintf("%d", int(v))
+			} else {
+				h.Fields[key] = fmt.Sprintf("%g", v)
+			}
+		case string:
+			h.Fields[key] = fmt.Sprintf("%q", v)
+		case nil:
+			h.Fields[key] = "null"
+		case []interface{}:
+			if h.arrays == nil {
+				h.arrays = make(map[string][]interface{})
+			}
+			h.arrays[key] = v
+			h.Fields[key] = fmt.Sprintf("%v", v)
+		default:
+			h.Fields[key] = fmt.Sprintf("%v", v)
+		}
 	}
 
+	return nil
+}
+
+// takeTimeLevelMessage sets the time, message and level of the entry from
+// the fields of a document holding them, which are removed from it, and
+// returns the field the message was in.
+func (h *JSONHandler) takeTimeLevelMessage(raw map[string]interface{}) (msgField string) {
 	searchJSON(raw, h.Opts.TimeFields, func(field string, value interface{}) bool {
-		var ok bool
-		h.Time, ok = tryParseTime(value)
+		t, ok := tryParseTime(value)
 		if ok {
+			h.Time = t
 			deleteJSONKey(field, raw)
 		}
 		return ok
@@ -134,6 +313,7 @@ func (h *JSONHandler) UnmarshalJSON(data []byte) bool {
 		var ok bool
 		h.Message, ok = value.(string)
 		if ok {
+			msgField = field
 			deleteJSONKey(field, raw)
 		}
 		return ok
@@ -151,39 +331,56 @@ func (h *JSONHandler) UnmarshalJSON(data []byte) bool {
 		}
 		return true
 	})
+	return msgField
+}
 
-	if h.Fields == nil {
-		h.Fields = make(map[string]string)
+// nestedDocument returns the JSON object msg holds, if it's one.
+func nestedDocument(msg string) (map[string]interface{}, bool) {
+	msg = strings.TrimSpace(msg)
+	if len(msg) < 2 || msg[0] != '{' || msg[len(msg)-1] != '}' {
+		return nil, false
 	}
-
-	for key, val := range raw {
-		switch v := val.(type) {
-		case float64:
-			if v-math.Floor(v) < 0.000001 && v < 1e9 {
-				// looks like an integer that's not too large
-				h.Fields[key] = fmt.Sprintf("%d", int(v))
-			} else {
-				h.Fields[key] = fmt.Sprintf("%g", v)
-			}
-		case string:
-			h.Fields[key] = fmt.Sprintf("%q", v)
-		default:
-			h.Fields[key] = fmt.Sprintf("%v", v)
-		}
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(msg), &doc); err != nil {
+		return nil, false
 	}
-
-	return true
+	return doc, true
 }
+
 func (h *JSONHandler) setField(key, val []byte) {
 	if h.Fields == nil {
 		h.Fields = make(map[string]string)
 	}
 	h.Fields[string(key)] = string(val)
+	delete(h.kinds, string(key))
+	delete(h.arrays, string(key))
+}
+
+func (h *JSONHandler) setLevel(val []byte) { h.Level = string(val) }
+
+// defaultTime sets the time of the entry, unless it had one.
+func (h *JSONHandler) defaultTime(t time.Time) {
+	if h.Time.IsZero() {
+		h.Time = t
+	}
+}
+
+// shiftTime moves the time of the entry, if it has one.
+func (h *JSONHandler) shiftTime(d time.Duration) {
+	if !h.Time.IsZero() {
+		h.Time = h.Time.Add(d)
+	}
+}
+
+func (h *JSONHandler) event() *Event {
+	return &Event{Time: h.Time, Level: h.Level, Message: h.Message, Fields: h.Fields}
 }
 
 // Prettify the output in a logrus like fashion.
 func (h *JSONHandler) Prettify(skipUnchanged bool) []byte {
 	defer h.clear()
+	// only the fields of the entries shown can be skipped when unchanged
+	defer func() { h.last = h.Fields }()
 	if h.out == nil {
 		if h.Opts == nil {
 			h.Opts = DefaultOptions
@@ -203,32 +400,8 @@ func (h *JSONHandler) Prettify(skipUnchanged bool) []byte {
 		msgColor = h.Opts.MsgDarkBgColor
 		msgAbsentColor = h.Opts.MsgAbsentDarkBgColor
 	}
-	msgColor = color.New(color.FgHiWhite)
-	msgAbsentColor = color.New(color.FgHiWhite)
 
-	var msg string
-	if h.Message == "" {
-		msg = msgAbsentColor.Sprint("<no msg>")
-	} else {
-		msg = msgColor.Sprint(h.Message)
-	}
-
-	lvl := strings.ToUpper(h.Level)[:imin(4, len(h.Level))]
-	var level string
-	switch h.Level {
-	case "debug":
-		level = h.Opts.DebugLevelColor.Sprint(lvl)
-	case "info":
-		level = h.Opts.InfoLevelColor.Sprint(lvl)
-	case "warn", "warning":
-		level = h.Opts.WarnLevelColor.Sprint(lvl)
-	case "error":
-		level = h.Opts.ErrorLevelColor.Sprint(lvl)
-	case "fatal", "panic":
-		level = h.Opts.FatalLevelColor.Sprint(lvl)
-	default:
-		level = h.Opts.UnknownLevelColor.Sprint(lvl)
-	}
+	level := h.Opts.levelColor(h.Level).Sprint(shortLevel(h.Level))
 
 	var timeColor *color.Color
 	if h.Opts.LightBg {
@@ -236,23 +409,65 @@ func (h *JSONHandler) Prettify(skipUnchanged bool) []byte {
 	} else {
 		timeColor = h.Opts.TimeDarkBgColor
 	}
-	_, _ = fmt.Fprintf(h.out, "%s |%s| %s\t %s",
+	var caller string
+	var callerKeys []string
+	if h.Opts.CallerWidth > 0 {
+		caller, callerKeys = h.Opts.callerOf(h.Fields)
+	}
+	kvs, blocks := h.joinKVs(skipUnchanged, "=", callerKeys)
+	var trailer string
+	if h.Opts.FieldsJSON != "" {
+		trailer, kvs = h.Opts.fieldsJSON(h.Fields, h.kinds), nil
+		if h.Opts.FieldsJSON == "end" && trailer != "" {
+			kvs = []string{trailer}
+		}
+	}
+	columns := fmt.Sprintf("%s |%s| %s",
 		timeColor.Sprint(h.Time.Format(h.Opts.TimeFormat)),
 		level,
-		msg,
-		strings.Join(h.joinKVs(skipUnchanged, "="), "\t "),
+		h.Opts.callerColumn(caller),
 	)
+	var logger string
+	if name := h.Opts.loggerName(h.Fields); name != "" {
+		logger = "[" + name + "] "
+	}
+	column := displayWidth(ansiEscape.ReplaceAllString(columns, ""))
+	var msg string
+	var msgEnd int
+	if h.Message == "" {
+		msg = msgAbsentColor.Sprint("<no msg>")
+		msgEnd = column + displayWidth(logger) + len("<no msg>")
+	} else {
+		msg, msgEnd = h.Opts.wrapMessage(h.Message, msgColor, column, displayWidth(logger))
+	}
+	if logger != "" {
+		msg = h.Opts.LoggerColor.Sprint(logger) + msg
+	}
+	head := columns + msg
+	if h.Opts.LineWidth > 0 && h.Opts.FieldsJSON == "" {
+		kvs = h.Opts.fitFields(msgEnd, kvs)
+	}
+	_, _ = fmt.Fprintf(h.out, "%s\t %s", head, strings.Join(kvs, "\t "))
 
 	_ = h.out.Flush()
 
+	if h.Opts.FieldsJSON == "line" && trailer != "" {
+		h.buf.WriteString("\n      ")
+		h.buf.WriteString(trailer)
+	}
+	for _, block := range blocks {
+		h.buf.WriteString("\n")
+		h.buf.WriteString(block)
+	}
+
 	return h.buf.Bytes()
 }
 
-func (h *JSONHandler) joinKVs(skipUnchanged bool, sep string) []string {
+func (h *JSONHandler) joinKVs(skipUnchanged bool, sep string, hidden []string) (kv []string, blocks []string) {
 
-	kv := make([]string, 0, len(h.Fields))
+	kv = make([]string, 0, len(h.Fields))
 	for k, v := range h.Fields {
-		if !h.Opts.shouldShowKey(k) {
+		if !h.Opts.shouldShowKey(k) || containsField(h.Opts.LoggerFields, k) || containsField(hidden, k) {
 			continue
 		}
 
@@ -261,15 +476,42 @@ func (h *JSONHandler) joinKVs(skipUnchanged bool, sep string) []string {
 				continue
 			}
 		}
+		if block, ok := h.Opts.queryBlock(k, v); ok {
+			blocks = append(blocks, block)
+			continue
+		}
+		if block, ok := h.Opts.tracebackBlock(k, v); ok {
+			blocks = append(blocks, block)
+			continue
+		}
+		if block, ok := h.Opts.decodedBlock(k, v); ok {
+			blocks = append(blocks, block)
+			continue
+		}
+		if items, ok := h.arrays[k]; ok {
+			if block, ok := h.Opts.arrayBlock(k, items); ok {
+				blocks = append(blocks, block)
+			} else {
+				kv = append(kv, h.Opts.KeyColor.Sprint(k)+sep+h.Opts.arraySummary(k, items))
+			}
+			continue
+		}
+
 		kstr := h.Opts.KeyColor.Sprint(k)
 
-		var vstr string
-		if h.Opts.Truncates && len(v) > h.Opts.TruncateLength {
-			vstr = v[:h.Opts.TruncateLength] + "..."
-		} else {
-			vstr = v
+		kind, ok := h.kinds[k]
+		if !ok {
+			kind = kindOfText(v)
 		}
-		vstr = h.Opts.ValColor.Sprint(vstr)
+		if kind == kindNull && h.Opts.HideNull {
+			continue
+		}
+		vcolor := h.Opts.fieldColor(k, v, kind)
+		if kind == kindNumber {
+			v = h.Opts.formatQuantity(k, v)
+		}
+
+		vstr := h.Opts.formatValue(k, v, vcolor)
 		kv = append(kv, kstr+sep+vstr)
 	}
 
@@ -278,8 +520,9 @@ func (h *JSONHandler) joinKVs(skipUnchanged bool, sep string) []string {
 	if h.Opts.SortLongest {
 		sort.Stable(byLongest(kv))
 	}
+	sort.Strings(blocks)
 
-	return kv
+	return kv, blocks
 }
 
 // convertBunyanLogLevel returns a human readable log level given a numerical bunyan level
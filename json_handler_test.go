@@ -2,6 +2,7 @@ package humanlog_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -151,3 +152,106 @@ func TestJSONHandler_UnmarshalJSON_ParsesCustomMultiNestedFields(t *testing.T) {
 		t.Fatalf("not equal: expected %q, got %q", tm, h.Time)
 	}
 }
+
+func TestJSONHandler_UnmarshalJSON_PythonPreset(t *testing.T) {
+	raw := []byte(`{"asctime": "2024-01-02 10:00:00,123", "levelname": "WARNING", "name": "app.db", "message": "slow query"}`)
+
+	opts := *humanlog.DefaultOptions
+	preset, ok := humanlog.LookupPreset("python")
+	if !ok {
+		t.Fatal("no python preset")
+	}
+	preset.Apply(&opts)
+
+	h := humanlog.JSONHandler{Opts: &opts}
+	if !h.TryHandle(raw) {
+		t.Fatalf("failed to handle log")
+	}
+
+	if h.Level != "WARNING" {
+		t.Fatalf("not equal: expected %q, got %q", "WARNING", h.Level)
+	}
+	if h.Message != "slow query" {
+		t.Fatalf("not equal: expected %q, got %q", "slow query", h.Message)
+	}
+	tm := time.Date(2024, 1, 2, 10, 0, 0, 123e6, time.UTC)
+	if !h.Time.Equal(tm) {
+		t.Fatalf("not equal: expected %q, got %q", tm, h.Time)
+	}
+}
+
+func TestJSONHandler_TryHandle_Prefixes(t *testing.T) {
+	for _, line := range []string{
+		"\xef\xbb\xbf{\"msg\": \"started\", \"level\": \"info\"}",
+		"   {\"msg\": \"started\", \"level\": \"info\"}",
+		"\t\xef\xbb\xbf {\"msg\": \"started\", \"level\": \"info\"}",
+		"{\"msg\": \"started\", \"level\": \"info\"}\r",
+		"\xef\xbb\xbf  {\"msg\": \"started\", \"level\": \"info\"}\r",
+	} {
+		h := humanlog.JSONHandler{Opts: humanlog.DefaultOptions}
+		if !h.TryHandle([]byte(line)) {
+			t.Errorf("%q: want it handled", line)
+			continue
+		}
+		if h.Message != "started" || h.Level != "info" {
+			t.Errorf("%q: got %q at %q", line, h.Message, h.Level)
+		}
+	}
+}
+
+func TestJSONHandler_BOMMidStream(t *testing.T) {
+	// the files of two exports concatenated, each starting with a mark,
+	// one of them pretty-printed
+	src := "\xef\xbb\xbf{\"msg\": \"one\"}\r\n{\"msg\": \"two\"}\r\n\xef\xbb\xbf{\r\n  \"msg\": \"three\"\r\n}\r\n"
+	var got []string
+	err := humanlog.ScanEvents(strings.NewReader(src), humanlog.DefaultOptions, func(ev *humanlog.Event) error {
+		got = append(got, ev.Message)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "one,two,three" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestJSONHandler_UnmarshalJSON_NestedMessage(t *testing.T) {
+	line := []byte(`{"time": "2024-01-02T10:00:00Z", "level": "info", "host": "web-1", "msg": "{\"time\": \"2024-01-02T09:59:58Z\", \"level\": \"error\", \"msg\": \"db down\", \"user_id\": 42, \"tags\": [\"a\"]}"}`)
+	h := humanlog.JSONHandler{Opts: humanlog.DefaultOptions}
+	if !h.TryHandle(line) {
+		t.Fatal("want the line handled")
+	}
+	if h.Message != "db down" || h.Level != "error" || !h.Time.Equal(time.Date(2024, 1, 2, 9, 59, 58, 0, time.UTC)) {
+		t.Errorf("want the inner message, level and time, got %q at %q, %s", h.Message, h.Level, h.Time)
+	}
+	if h.Fields["msg.user_id"] != "42" || h.Fields["host"] != `"web-1"` || h.Fields["msg.tags"] != "[a]" {
+		t.Errorf("want the inner fields prefixed along the outer ones, got %v", h.Fields)
+	}
+
+	// what the inner document doesn't have is kept from the entry
+	h = humanlog.JSONHandler{Opts: humanlog.DefaultOptions}
+	if !h.TryHandle([]byte(`{"level": "warn", "message": "{\"order\": 7}"}`)) {
+		t.Fatal("want the line handled")
+	}
+	if h.Message != "" || h.Level != "warn" || h.Fields["message.order"] != "7" {
+		t.Errorf("got %q at %q, %v", h.Message, h.Level, h.Fields)
+	}
+
+	for _, msg := range []string{`{not json}`, `{\"a\": 1} and more`} {
+		h = humanlog.JSONHandler{Opts: humanlog.DefaultOptions}
+		if !h.TryHandle([]byte(`{"msg": "` + msg + `"}`)) {
+			t.Fatal("want the line handled")
+		}
+		if len(h.Fields) != 0 || h.Message == "" {
+			t.Errorf("%s: want the message left alone, got %q %v", msg, h.Message, h.Fields)
+		}
+	}
+
+	opts := *humanlog.DefaultOptions
+	opts.UnnestMessages = false
+	h = humanlog.JSONHandler{Opts: &opts}
+	if !h.TryHandle([]byte(`{"msg": "{\"a\": 1}"}`)) || h.Message != `{"a": 1}` {
+		t.Errorf("want the message as it is, got %q", h.Message)
+	}
+}
@@ -0,0 +1,127 @@
+package humanlog
+
+import (
+	"path"
+	"strings"
+)
+
+// keyMatcher tells if keys match any of a set of patterns: keys, globs like
+// kubernetes.* or *_id, any pattern path.Match understands, or JSON
+// Pointers. Prefix and suffix globs are looked up in tries, so matching a
+// key takes as long as the key whatever the number of patterns.
+type keyMatcher struct {
+	exact    map[string]struct{}
+	prefixes *keyTrie
+	suffixes *keyTrie
+	globs    []string
+}
+
+// newKeyMatcher compiles the patterns, the keys of a Skip or Keep set.
+func newKeyMatcher(patterns map[string]struct{}) *keyMatcher {
+	m := &keyMatcher{exact: make(map[string]struct{})}
+	for p := range patterns {
+		if segs, ok := parsePointer(p); ok {
+			// pointers name keys as they are, dots and stars included, and
+			// the ones into nested objects the key holding them
+			m.exact[segs[0]] = struct{}{}
+			continue
+		}
+		star := strings.IndexByte(p, '*')
+		switch {
+		case !strings.ContainsAny(p, `*?[\`):
+			m.exact[p] = struct{}{}
+		case star == len(p)-1 && !strings.ContainsAny(p[:star], `*?[\`):
+			if m.prefixes == nil {
+				m.prefixes = new(keyTrie)
+			}
+			m.prefixes.add(p[:star])
+		case star == 0 && !strings.ContainsAny(p[1:], `*?[\`):
+			if m.suffixes == nil {
+				m.suffixes = new(keyTrie)
+			}
+			m.suffixes.addReversed(p[1:])
+		default:
+			m.globs = append(m.globs, p)
+		}
+	}
+	return m
+}
+
+func (m *keyMatcher) match(key string) bool {
+	if m == nil {
+		return false
+	}
+	if _, ok := m.exact[key]; ok {
+		return true
+	}
+	if m.prefixes.hasPrefixOf(key) || m.suffixes.hasSuffixOf(key) {
+		return true
+	}
+	for _, glob := range m.globs {
+		if ok, _ := path.Match(glob, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// keyTrie holds strings byte by byte, to find which of them start a key, or
+// end it when they were added reversed.
+type keyTrie struct {
+	end  bool
+	next map[byte]*keyTrie
+}
+
+func (t *keyTrie) add(s string) {
+	for i := 0; i < len(s); i++ {
+		t = t.child(s[i])
+	}
+	t.end = true
+}
+
+func (t *keyTrie) addReversed(s string) {
+	for i := len(s) - 1; i >= 0; i-- {
+		t = t.child(s[i])
+	}
+	t.end = true
+}
+
+func (t *keyTrie) child(b byte) *keyTrie {
+	if t.next == nil {
+		t.next = make(map[byte]*keyTrie)
+	}
+	n, ok := t.next[b]
+	if !ok {
+		n = new(keyTrie)
+		t.next[b] = n
+	}
+	return n
+}
+
+// hasPrefixOf tells if one of the strings is a prefix of s.
+func (t *keyTrie) hasPrefixOf(s string) bool {
+	for i := 0; t != nil; i++ {
+		if t.end {
+			return true
+		}
+		if i == len(s) {
+			return false
+		}
+		t = t.next[s[i]]
+	}
+	return false
+}
+
+// hasSuffixOf tells if one of the strings added reversed is a suffix of s.
+func (t *keyTrie) hasSuffixOf(s string) bool {
+	for i := len(s) - 1; t != nil; i-- {
+		if t.end {
+			return true
+		}
+		if i < 0 {
+			return false
+		}
+		t = t.next[s[i]]
+	}
+	return false
+}
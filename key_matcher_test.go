@@ -0,0 +1,53 @@
+package humanlog
+
+import "testing"
+
+func TestKeyMatcher(t *testing.T) {
+	m := newKeyMatcher(map[string]struct{}{
+		"pid":           {},
+		"kubernetes.*":  {},
+		"labels.app.*":  {},
+		"*_id":          {},
+		"req.h[ae]ad*r": {},
+	})
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "pid", want: true},
+		{key: "pids", want: false},
+		{key: "kubernetes.pod", want: true},
+		{key: "kubernetes", want: false},
+		{key: "labels.app.name", want: true},
+		{key: "labels.team", want: false},
+		{key: "user_id", want: true},
+		{key: "_id", want: true},
+		{key: "id", want: false},
+		{key: "req.header", want: true},
+		{key: "req.body", want: false},
+	}
+	for _, tt := range tests {
+		if got := m.match(tt.key); got != tt.want {
+			t.Errorf("%s: want %v, got %v", tt.key, tt.want, got)
+		}
+	}
+	if len(m.globs) != 1 {
+		t.Errorf("want only the bracket pattern matched as a glob, got %q", m.globs)
+	}
+}
+
+func TestShouldShowKey_Globs(t *testing.T) {
+	opts := *DefaultOptions
+	opts.SetSkip([]string{"kubernetes.*", "*_id"})
+	opts.SetKeep([]string{"kubernetes.pod"})
+	for key, want := range map[string]bool{
+		"kubernetes.pod":       true,
+		"kubernetes.namespace": false,
+		"trace_id":             false,
+		"msg":                  true,
+	} {
+		if got := opts.shouldShowKey(key); got != want {
+			t.Errorf("%s: want %v, got %v", key, want, got)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// lambdaPlatformLine matches the START, END and REPORT lines of the
+	// Lambda platform, in the text log format.
+	lambdaPlatformLine = regexp.MustCompile(`^(START|END|REPORT) RequestId: ([\w-]+)\s*(.*)$`)
+	// lambdaMetric matches the metrics of a REPORT line, like "Duration: 2.25 ms".
+	lambdaMetric = regexp.MustCompile(`([A-Z][\w ]*?): ([\d.]+) (ms|MB)`)
+	// lambdaTextLine matches the lines of the runtimes, in the text log
+	// format: time, request ID, level and message separated by tabs.
+	lambdaTextLine = regexp.MustCompile(`^(\d{4}-\d\d-\d\dT[\d:.]+Z)\t([\w-]+)\t([A-Z]+)\t(.*)$`)
+)
+
+// lambdaReportMetrics maps the metrics of REPORT lines, in the text and JSON
+// log formats, to the fields they're shown as.
+var lambdaReportMetrics = map[string]string{
+	"Duration":         "duration_ms",
+	"durationMs":       "duration_ms",
+	"Billed Duration":  "billed_ms",
+	"billedDurationMs": "billed_ms",
+	"Memory Size":      "memory_mb",
+	"memorySizeMB":     "memory_mb",
+	"Max Memory Used":  "max_memory_mb",
+	"maxMemoryUsedMB":  "max_memory_mb",
+	"Init Duration":    "init_ms",
+	"initDurationMs":   "init_ms",
+}
+
+// rewriteLambda turns the lines of the Lambda platform and runtimes into JSON
+// entries with a request ID, a level and a message. REPORT lines get a
+// summary of their metrics as their message.
+func rewriteLambda(line []byte) []byte {
+	if m := lambdaTextLine.FindSubmatch(line); m != nil {
+		return lambdaEntry(map[string]interface{}{
+			"timestamp": string(m[1]),
+			"requestId": string(m[2]),
+			"level":     string(m[3]),
+			"message":   string(m[4]),
+		})
+	}
+	if m := lambdaPlatformLine.FindSubmatch(line); m != nil {
+		entry := map[string]interface{}{"requestId": string(m[2]), "level": "info", "message": string(m[1])}
+		switch string(m[1]) {
+		case "START":
+			if v := bytes.TrimPrefix(m[3], []byte("Version: ")); len(v) != len(m[3]) {
+				entry["version"] = string(v)
+			}
+		case "REPORT":
+			metrics := make(map[string]float64)
+			for _, metric := range lambdaMetric.FindAllSubmatch(m[3], -1) {
+				if v, err := strconv.ParseFloat(string(metric[2]), 64); err == nil {
+					metrics[string(metric[1])] = v
+				}
+			}
+			lambdaReport(entry, metrics)
+		}
+		return lambdaEntry(entry)
+	}
+	if bytes.HasPrefix(line, []byte(`{`)) && bytes.Contains(line, []byte(`"platform.`)) {
+		return rewriteLambdaPlatformJSON(line)
+	}
+	return nil
+}
+
+// rewriteLambdaPlatformJSON rewrites the events of the platform in the JSON
+// log format, whose details are in a record.
+func rewriteLambdaPlatformJSON(line []byte) []byte {
+	var ev struct {
+		Time   string `json:"time"`
+		Type   string `json:"type"`
+		Record struct {
+			RequestID string             `json:"requestId"`
+			Version   string             `json:"version"`
+			Status    string             `json:"status"`
+			Metrics   map[string]float64 `json:"metrics"`
+		} `json:"record"`
+	}
+	if err := json.Unmarshal(line, &ev); err != nil || !strings.HasPrefix(ev.Type, "platform.") {
+		return nil
+	}
+	entry := map[string]interface{}{"time": ev.Time, "level": "info"}
+	if ev.Record.RequestID != "" {
+		entry["requestId"] = ev.Record.RequestID
+	}
+	if ev.Record.Version != "" {
+		entry["version"] = ev.Record.Version
+	}
+	if ev.Record.Status != "" && ev.Record.Status != "success" {
+		entry["status"] = ev.Record.Status
+		entry["level"] = "error"
+	}
+	switch ev.Type {
+	case "platform.start":
+		entry["message"] = "START"
+	case "platform.runtimeDone":
+		entry["message"] = "END"
+	case "platform.report":
+		entry["message"] = "REPORT"
+		lambdaReport(entry, ev.Record.Metrics)
+	default:
+		entry["message"] = ev.Type
+	}
+	return lambdaEntry(entry)
+}
+
+// lambdaReport sums up the metrics of a REPORT in the message of the entry:
+//
+//	REPORT 2.25ms (billed 3ms, init 110.36ms) 75/128MB
+func lambdaReport(entry map[string]interface{}, metrics map[string]float64) {
+	fields := make(map[string]float64)
+	for name, v := range metrics {
+		if field, ok := lambdaReportMetrics[name]; ok {
+			fields[field] = v
+		}
+	}
+	msg := "REPORT"
+	if d, ok := fields["duration_ms"]; ok {
+		msg += fmt.Sprintf(" %gms", d)
+	}
+	var details []string
+	if b, ok := fields["billed_ms"]; ok {
+		details = append(details, fmt.Sprintf("billed %gms", b))
+	}
+	if i, ok := fields["init_ms"]; ok {
+		details = append(details, fmt.Sprintf("init %gms", i))
+	}
+	if len(details) != 0 {
+		msg += " (" + strings.Join(details, ", ") + ")"
+	}
+	if used, ok := fields["max_memory_mb"]; ok {
+		msg += fmt.Sprintf(" %g", used)
+		if size, ok := fields["memory_mb"]; ok {
+			msg += fmt.Sprintf("/%g", size)
+		}
+		msg += "MB"
+	}
+	entry["message"] = msg
+}
+
+func lambdaEntry(entry map[string]interface{}) []byte {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil
+	}
+	return line
+}
@@ -0,0 +1,43 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLambdaPreset(t *testing.T) {
+	src := strings.Join([]string{
+		`START RequestId: 8f507cfc-1 Version: $LATEST`,
+		"2024-01-02T10:00:00.123Z\t8f507cfc-1\tINFO\thandling order 42",
+		`{"timestamp": "2024-01-02T10:00:00.200Z", "level": "WARN", "message": "slow db", "requestId": "8f507cfc-1"}`,
+		"REPORT RequestId: 8f507cfc-1\tDuration: 2.25 ms\tBilled Duration: 3 ms\tMemory Size: 128 MB\tMax Memory Used: 75 MB\tInit Duration: 110.36 ms",
+		`{"time": "2024-01-02T10:00:01Z", "type": "platform.report", "record": {"requestId": "aa-2", "metrics": {"durationMs": 5.1, "billedDurationMs": 6, "memorySizeMB": 128, "maxMemoryUsedMB": 80}, "status": "timeout"}}`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	preset, _ := LookupPreset("lambda")
+	preset.Apply(&opts)
+	if !opts.Gutter || opts.SourceFields[0] != "requestId" {
+		t.Errorf("want the lines colored by requestId, got gutter=%v source fields %q", opts.Gutter, opts.SourceFields)
+	}
+
+	var got []string
+	err := ScanEvents(strings.NewReader(src), &opts, func(ev *Event) error {
+		got = append(got, ev.Level+" "+ev.Message+" "+opts.sourceOf(ev))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"info START 8f507cfc-1",
+		"INFO handling order 42 8f507cfc-1",
+		"WARN slow db 8f507cfc-1",
+		"info REPORT 2.25ms (billed 3ms, init 110.36ms) 75/128MB 8f507cfc-1",
+		"error REPORT 5.1ms (billed 6ms) 80/128MB aa-2",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("want\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
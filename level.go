@@ -0,0 +1,128 @@
+package humanlog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Level defines a level entries can have, on top of the ones humanlog knows
+// about, or overrides one of those. Rank orders the levels by severity, to
+// compare them with MinLevel.
+//
+//	{"name": "audit", "aliases": ["aud"], "rank": 35, "color": "hi-blue"}
+type Level struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	Rank    int      `json:"rank"`
+	// Color of the level, like "red" or "hi-cyan". Levels without one are
+	// shown in UnknownLevelColor.
+	Color string `json:"color,omitempty"`
+
+	color *color.Color
+}
+
+// builtinLevelRanks are the ranks of the levels humanlog knows about, spaced
+// out so that custom levels can go in between.
+var builtinLevelRanks = map[string]int{
+	"trace":    10,
+	"debug":    20,
+	"info":     30,
+	"notice":   35,
+	"warn":     40,
+	"warning":  40,
+	"error":    50,
+	"critical": 55,
+	"fatal":    60,
+	"panic":    60,
+}
+
+// compile checks the name and the color of the level.
+func (l *Level) compile() error {
+	if l.Name == "" {
+		return fmt.Errorf("level without a name")
+	}
+	if l.Color == "" {
+		return nil
+	}
+	c, err := parseColor(l.Color)
+	if err != nil {
+		return err
+	}
+	l.color = c
+	return nil
+}
+
+func (l *Level) is(level string) bool {
+	if strings.EqualFold(l.Name, level) {
+		return true
+	}
+	for _, alias := range l.Aliases {
+		if strings.EqualFold(alias, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// customLevel returns the definition of level among Levels, the last one
+// winning.
+func (h *HandlerOptions) customLevel(level string) (*Level, bool) {
+	for i := len(h.Levels) - 1; i >= 0; i-- {
+		if h.Levels[i].is(level) {
+			return &h.Levels[i], true
+		}
+	}
+	return nil, false
+}
+
+// LevelRank returns the rank of level, and whether it's a level humanlog
+// knows about or one of Levels.
+func (h *HandlerOptions) LevelRank(level string) (int, bool) {
+	if l, ok := h.customLevel(level); ok {
+		return l.Rank, true
+	}
+	rank, ok := builtinLevelRanks[strings.ToLower(level)]
+	return rank, ok
+}
+
+// levelColor returns the color of the level.
+func (h *HandlerOptions) levelColor(level string) *color.Color {
+	if l, ok := h.customLevel(level); ok {
+		if l.color == nil {
+			return h.UnknownLevelColor
+		}
+		return l.color
+	}
+	switch strings.ToLower(level) {
+	case "trace", "debug":
+		return h.DebugLevelColor
+	case "info", "notice":
+		return h.InfoLevelColor
+	case "warn", "warning":
+		return h.WarnLevelColor
+	case "error":
+		return h.ErrorLevelColor
+	case "fatal", "panic", "critical":
+		return h.FatalLevelColor
+	default:
+		return h.UnknownLevelColor
+	}
+}
+
+// minLevelStage drops the entries whose level ranks below MinLevel. Entries
+// without a level, or with one that isn't known, are kept since there's no
+// telling.
+func minLevelStage(opts *HandlerOptions) Stage {
+	min, _ := opts.LevelRank(opts.MinLevel)
+	return func(e *Entry, next Next) error {
+		if e.Event == nil {
+			return next(e)
+		}
+		if rank, ok := opts.LevelRank(e.Event.Level); ok && rank < min {
+			return nil
+		}
+		return next(e)
+	}
+}
@@ -0,0 +1,140 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// levelBarWidth is how many columns the bar of LevelBar takes.
+const levelBarWidth = 40
+
+// The groups of levels the bar of LevelBar tells apart, from the least
+// severe.
+var levelBarGroups = []string{"debug", "info", "warn", "error", "fatal", "other"}
+
+// levelBar draws, on the line below the entries, a bar made of the share
+// of each level among the entries of the last window, redrawn as entries
+// come.
+type levelBar struct {
+	// buckets count the entries of each second of the window, by group
+	buckets []levelBucket
+	drawn   bool
+}
+
+type levelBucket struct {
+	second int64
+	counts [6]int
+}
+
+func newLevelBar(window time.Duration) *levelBar {
+	n := int(window / time.Second)
+	if n < 1 {
+		n = 1
+	}
+	return &levelBar{buckets: make([]levelBucket, n)}
+}
+
+// levelGroup tells which group of the bar a level falls in, by its rank.
+func (h *HandlerOptions) levelGroup(level string) int {
+	rank, ok := h.LevelRank(level)
+	switch {
+	case !ok:
+		return 5
+	case rank < builtinLevelRanks["info"]:
+		return 0
+	case rank < builtinLevelRanks["warn"]:
+		return 1
+	case rank < builtinLevelRanks["error"]:
+		return 2
+	case rank < builtinLevelRanks["critical"]:
+		return 3
+	}
+	return 4
+}
+
+func (b *levelBar) observe(opts *HandlerOptions, ev *Event, now time.Time) {
+	sec := now.Unix()
+	bucket := &b.buckets[sec%int64(len(b.buckets))]
+	if bucket.second != sec {
+		*bucket = levelBucket{second: sec}
+	}
+	bucket.counts[opts.levelGroup(ev.Level)]++
+}
+
+// counts sums the entries of the window ending at now, by group.
+func (b *levelBar) counts(now time.Time) (counts [6]int, total int) {
+	oldest := now.Unix() - int64(len(b.buckets))
+	for _, bucket := range b.buckets {
+		if bucket.second <= oldest {
+			continue
+		}
+		for i, n := range bucket.counts {
+			counts[i] += n
+			total += n
+		}
+	}
+	return counts, total
+}
+
+// cells splits width columns between the groups, in proportion of their
+// counts, each group that had entries getting at least one.
+func levelBarCells(counts [6]int, total, width int) [6]int {
+	var cells [6]int
+	if total == 0 {
+		return cells
+	}
+	used, largest := 0, 0
+	for i, n := range counts {
+		if n == 0 {
+			continue
+		}
+		cells[i] = n * width / total
+		if cells[i] == 0 {
+			cells[i] = 1
+		}
+		used += cells[i]
+		if n > counts[largest] {
+			largest = i
+		}
+	}
+	// what rounding left over, or took too much, goes to the largest group
+	cells[largest] += width - used
+	return cells
+}
+
+func (b *levelBar) draw(dst io.Writer, opts *HandlerOptions, window time.Duration, now time.Time) {
+	counts, total := b.counts(now)
+	if total == 0 {
+		return
+	}
+	var line strings.Builder
+	for i, n := range levelBarCells(counts, total, levelBarWidth) {
+		if n > 0 {
+			line.WriteString(opts.levelColor(levelBarGroups[i]).Sprint(strings.Repeat("█", n)))
+		}
+	}
+	fmt.Fprintf(&line, " %s", opts.ContextColor.Sprintf("%d entries in the last %s", total, window))
+	io.WriteString(dst, line.String())
+	b.drawn = true
+}
+
+// clear erases the bar, for what comes next to be written in its place.
+func (b *levelBar) clear(dst io.Writer) {
+	if b.drawn {
+		io.WriteString(dst, "\r\x1b[K")
+		b.drawn = false
+	}
+}
+
+// levelBarStage erases the bar before anything about an entry is written,
+// and redraws it once the entry was.
+func levelBarStage(dst io.Writer, opts *HandlerOptions, b *levelBar) Stage {
+	return func(e *Entry, next Next) error {
+		b.clear(dst)
+		err := next(e)
+		b.draw(dst, opts, opts.LevelBar, time.Now())
+		return err
+	}
+}
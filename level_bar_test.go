@@ -0,0 +1,68 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+func TestLevelBarCells(t *testing.T) {
+	tests := []struct {
+		counts [6]int
+		want   [6]int
+	}{
+		{counts: [6]int{0, 90, 8, 2}, want: [6]int{0, 36, 3, 1}},
+		{counts: [6]int{0, 1000, 0, 0, 1}, want: [6]int{0, 39, 0, 0, 1}},
+		{counts: [6]int{0, 0, 0, 5}, want: [6]int{0, 0, 0, 40}},
+	}
+	for _, test := range tests {
+		total := 0
+		for _, n := range test.counts {
+			total += n
+		}
+		if got := levelBarCells(test.counts, total, 40); got != test.want {
+			t.Errorf("%v: want %v, got %v", test.counts, test.want, got)
+		}
+	}
+}
+
+func TestLevelBarWindow(t *testing.T) {
+	opts := *DefaultOptions
+	b := newLevelBar(10 * time.Second)
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	b.observe(&opts, &Event{Level: "error"}, start)
+	b.observe(&opts, &Event{Level: "info"}, start.Add(5*time.Second))
+	b.observe(&opts, &Event{Level: "WARNING"}, start.Add(9*time.Second))
+	b.observe(&opts, &Event{Level: "verbose"}, start.Add(9*time.Second))
+
+	if counts, total := b.counts(start.Add(9 * time.Second)); total != 4 || counts != [6]int{0, 1, 1, 1, 0, 1} {
+		t.Errorf("got %v, %d", counts, total)
+	}
+	// the error is out of the window
+	if counts, total := b.counts(start.Add(12 * time.Second)); total != 3 || counts[3] != 0 {
+		t.Errorf("got %v, %d", counts, total)
+	}
+}
+
+func TestLevelBarScanner(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	opts := *DefaultOptions
+	opts.LevelBar = time.Minute
+	var out bytes.Buffer
+	src := `{"level":"info","msg":"a"}` + "\n" + `{"level":"error","msg":"b"}` + "\n"
+	if err := Scanner(strings.NewReader(src), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "1 entries in the last 1m0s\r\x1b[K") || !strings.Contains(got, "2 entries in the last 1m0s") {
+		t.Errorf("want the bar drawn after each entry and cleared before the next, got %q", got)
+	}
+	if !strings.HasSuffix(got, "\r\x1b[K") {
+		t.Errorf("want the bar cleared at the end, got %q", got)
+	}
+}
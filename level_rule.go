@@ -0,0 +1,172 @@
+package humanlog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LevelRule sets the level of the entries matching all of its conditions,
+// whatever level they were logged at. Conditions are separated by spaces,
+// and compare a field, or the level and msg, with one of =, !=, <, <=, >,
+// >=, ~ (matches a regexp) and !~:
+//
+//	{"when": "status>=500", "level": "error"}
+//	{"when": "msg~(?i)health.?check", "level": "trace"}
+type LevelRule struct {
+	When  string `json:"when"`
+	Level string `json:"level"`
+
+	conds []fieldCondition
+}
+
+// ParseLevelRule reads rules like `status>=500 method!=HEAD => error`.
+func ParseLevelRule(s string) (LevelRule, error) {
+	i := strings.LastIndex(s, "=>")
+	if i < 0 {
+		return LevelRule{}, fmt.Errorf("missing => level")
+	}
+	rule := LevelRule{When: strings.TrimSpace(s[:i]), Level: strings.TrimSpace(s[i+2:])}
+	return rule, rule.compile()
+}
+
+// compile parses the conditions of the rule.
+func (r *LevelRule) compile() error {
+	if r.Level == "" {
+		return fmt.Errorf("missing level")
+	}
+	r.conds = r.conds[:0]
+	for _, word := range strings.Fields(r.When) {
+		cond, err := parseFieldCondition(word)
+		if err != nil {
+			return err
+		}
+		r.conds = append(r.conds, cond)
+	}
+	if len(r.conds) == 0 {
+		return fmt.Errorf("missing conditions")
+	}
+	return nil
+}
+
+func (r *LevelRule) matches(ev *Event) bool {
+	for i := range r.conds {
+		if !r.conds[i].matches(ev) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldCondition compares the value of a field with a string, a number or a
+// regexp.
+type fieldCondition struct {
+	key, op, value string
+	num            float64
+	re             *regexp.Regexp
+}
+
+// conditionOps are the operators of conditions, the longer ones first so
+// that >= isn't read as >.
+var conditionOps = []string{"!=", "!~", "<=", ">=", "=", "~", "<", ">"}
+
+func parseFieldCondition(s string) (fieldCondition, error) {
+	i := strings.IndexAny(s, "!=~<>")
+	if i <= 0 {
+		return fieldCondition{}, fmt.Errorf("want key, operator and value in %q", s)
+	}
+	cond := fieldCondition{key: s[:i]}
+	for _, op := range conditionOps {
+		if strings.HasPrefix(s[i:], op) {
+			cond.op, cond.value = op, s[i+len(op):]
+			break
+		}
+	}
+	if cond.key == "level" {
+		// levels are compared whatever their case
+		cond.value = strings.ToLower(cond.value)
+	}
+	var err error
+	switch cond.op {
+	case "":
+		return cond, fmt.Errorf("unknown operator in %q", s)
+	case "<", "<=", ">", ">=":
+		if cond.num, err = strconv.ParseFloat(cond.value, 64); err != nil {
+			return cond, fmt.Errorf("want a number after %s in %q", cond.op, s)
+		}
+	case "~", "!~":
+		if cond.re, err = regexp.Compile(cond.value); err != nil {
+			return cond, fmt.Errorf("%q: %v", s, err)
+		}
+	}
+	return cond, nil
+}
+
+// conditionKeys returns the fields conds read.
+func conditionKeys(conds []fieldCondition) []string {
+	keys := make([]string, len(conds))
+	for i := range conds {
+		keys[i] = conds[i].key
+	}
+	return keys
+}
+
+func (c *fieldCondition) matches(ev *Event) bool {
+	var (
+		v  string
+		ok = true
+	)
+	switch c.key {
+	case "level":
+		v = strings.ToLower(ev.Level)
+	case "msg", "message":
+		v = ev.Message
+	default:
+		v, ok = ev.Fields[c.key]
+		v = unquoteValue(v)
+	}
+	switch c.op {
+	case "=":
+		return ok && v == c.value
+	case "!=":
+		return !ok || v != c.value
+	case "~":
+		return ok && c.re.MatchString(v)
+	case "!~":
+		return !ok || !c.re.MatchString(v)
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if !ok || err != nil {
+		return false
+	}
+	switch c.op {
+	case "<":
+		return n < c.num
+	case "<=":
+		return n <= c.num
+	case ">":
+		return n > c.num
+	default:
+		return n >= c.num
+	}
+}
+
+// levelRuleStage sets the level of the entries the rules match, the last
+// matching rule winning.
+func levelRuleStage(rules []LevelRule) Stage {
+	return func(e *Entry, next Next) error {
+		if e.Event == nil {
+			return next(e)
+		}
+		for i := len(rules) - 1; i >= 0; i-- {
+			if rules[i].matches(e.Event) {
+				if e.Event.Level != rules[i].Level {
+					e.SetLevel(rules[i].Level)
+				}
+				break
+			}
+		}
+		return next(e)
+	}
+}
@@ -0,0 +1,75 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestLevelRules(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	var rules []LevelRule
+	for _, text := range []string{
+		"status>=500 => error",
+		"msg~(?i)health.?check => trace",
+		"level=WARN user!=root => info",
+	} {
+		rule, err := ParseLevelRule(text)
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	src := strings.Join([]string{
+		`{"level": "info", "msg": "request", "status": 503}`,
+		`{"level": "info", "msg": "GET /healthcheck", "status": 200}`,
+		`{"level": "info", "msg": "request", "status": "oops"}`,
+		`level=warn msg="cache miss" user=ann`,
+		`level=warn msg="sudo" user=root`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.LevelRules = rules
+	opts.MinLevel = "debug"
+	opts.SkipUnchanged = false
+
+	var (
+		out    bytes.Buffer
+		levels []string
+	)
+	stages := append(builtinStages(&opts), func(e *Entry, next Next) error {
+		levels = append(levels, e.Event.Level)
+		return next(e)
+	}, Render(&out, &opts))
+	if err := Process(strings.NewReader(src), &opts, Chain(stages...)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"error", "info", "info", "warn"}
+	if strings.Join(levels, " ") != strings.Join(want, " ") {
+		t.Errorf("want levels %v, got %v", want, levels)
+	}
+	if got := out.String(); !strings.Contains(got, "|ERRO| request") {
+		t.Errorf("want the escalated level rendered, got %q", got)
+	}
+}
+
+func TestParseLevelRule(t *testing.T) {
+	for _, bad := range []string{
+		"status>=500",
+		"=> error",
+		"status>=abc => error",
+		"msg~( => trace",
+		"status => error",
+		"status>=500 =>",
+	} {
+		if _, err := ParseLevelRule(bad); err == nil {
+			t.Errorf("want an error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package humanlog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMinLevel(t *testing.T) {
+	path := writeConfig(t, `{"levels": [
+		{"name": "audit", "rank": 38, "color": "hi-blue"},
+		{"name": "security", "aliases": ["sec"], "rank": 55}
+	]}`)
+	cfg, err := ReadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := strings.Join([]string{
+		`{"level": "trace", "msg": "a"}`,
+		`{"level": "info", "msg": "b"}`,
+		`{"level": "notice", "msg": "c"}`,
+		`{"level": "AUDIT", "msg": "d"}`,
+		`{"level": "warning", "msg": "e"}`,
+		`{"level": "sec", "msg": "f"}`,
+		`{"level": "custom", "msg": "g"}`,
+		`{"msg": "h"}`,
+	}, "\n")
+
+	tests := []struct {
+		min  string
+		want []string
+	}{
+		{min: "trace", want: []string{"a", "b", "c", "d", "e", "f", "g", "h"}},
+		{min: "notice", want: []string{"c", "d", "e", "f", "g", "h"}},
+		{min: "audit", want: []string{"d", "e", "f", "g", "h"}},
+		{min: "error", want: []string{"f", "g", "h"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.min, func(t *testing.T) {
+			opts := *DefaultOptions
+			cfg.Apply(&opts)
+			opts.MinLevel = tt.min
+			var got []string
+			chain := Chain(append(builtinStages(&opts), func(e *Entry, next Next) error {
+				got = append(got, e.Event.Message)
+				return next(e)
+			})...)
+			if err := Process(strings.NewReader(src), &opts, chain); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLevelColor(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Levels = []Level{{Name: "audit", Color: "hi-blue"}, {Name: "security"}}
+	for i := range opts.Levels {
+		if err := opts.Levels[i].compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if c := opts.levelColor("Audit"); c != opts.Levels[0].color {
+		t.Errorf("want the color of the custom level, got %v", c)
+	}
+	if c := opts.levelColor("security"); c != opts.UnknownLevelColor {
+		t.Errorf("want a custom level without a color to be unknown, got %v", c)
+	}
+	if c := opts.levelColor("trace"); c != opts.DebugLevelColor {
+		t.Errorf("want trace in the debug color, got %v", c)
+	}
+
+	bad := Level{Name: "audit", Color: "mauve"}
+	if err := bad.compile(); err == nil {
+		t.Error("want an error for an unknown color")
+	}
+}
@@ -0,0 +1,136 @@
+package humanlog
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// ParseFieldPriority reads the priority of a field, like user_id=10. The
+// fields without one have priority 0, and those of lowest priority are the
+// first dropped from lines longer than LineWidth.
+func ParseFieldPriority(text string) (string, int, error) {
+	i := strings.LastIndex(text, "=")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("want field=priority, like user_id=10")
+	}
+	n, err := strconv.Atoi(text[i+1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("want a number as priority, like user_id=10")
+	}
+	return text[:i], n, nil
+}
+
+// fitFields drops fields out of kvs, the key=value pairs written after the
+// used columns of a line, until the line fits in LineWidth columns, the
+// fields of lowest priority first. A marker telling how many were dropped
+// takes their place.
+func (h *HandlerOptions) fitFields(used int, kvs []string) []string {
+	widths := make([]int, len(kvs))
+	total := used
+	for i, kv := range kvs {
+		widths[i] = displayWidth(ansiEscape.ReplaceAllString(kv, ""))
+		total += 1 + widths[i]
+	}
+	if total <= h.LineWidth {
+		return kvs
+	}
+
+	priorities := make([]int, len(kvs))
+	for i, kv := range kvs {
+		key := ansiEscape.ReplaceAllString(kv, "")
+		if j := strings.IndexByte(key, '='); j >= 0 {
+			key = key[:j]
+		}
+		priorities[i] = h.FieldPriorities[key]
+	}
+	order := make([]int, len(kvs))
+	for i := range order {
+		order[i] = i
+	}
+	// among fields of the same priority, the last ones go first
+	sort.SliceStable(order, func(a, b int) bool {
+		if pa, pb := priorities[order[a]], priorities[order[b]]; pa != pb {
+			return pa < pb
+		}
+		return order[a] > order[b]
+	})
+
+	dropped := make([]bool, len(kvs))
+	n := 0
+	for _, i := range order {
+		dropped[i] = true
+		total -= 1 + widths[i]
+		n++
+		if total+1+len(moreMarker(n)) <= h.LineWidth {
+			break
+		}
+	}
+
+	kept := make([]string, 0, len(kvs)-n+1)
+	for i, kv := range kvs {
+		if !dropped[i] {
+			kept = append(kept, kv)
+		}
+	}
+	return append(kept, h.ContextColor.Sprint(moreMarker(n)))
+}
+
+func moreMarker(n int) string {
+	return "+" + strconv.Itoa(n) + " more"
+}
+
+// continuationMarker starts the lines a wrapped message goes on, right
+// before where its first line starts.
+const continuationMarker = "↪ "
+
+// wrapMessage colors msg, the message of an entry starting at column behind
+// a prefix this wide. When it doesn't fit in LineWidth, it goes on on lines
+// indented to start under it, behind a marker telling they continue it
+// rather than being entries of their own. It also returns the column the
+// message ends at.
+func (h *HandlerOptions) wrapMessage(msg string, c *color.Color, column, prefix int) (string, int) {
+	width := h.LineWidth - column
+	if h.LineWidth <= 0 || width-prefix < minWrapWidth || displayWidth(msg)+prefix <= width {
+		return c.Sprint(isolateBidi(msg)), column + prefix + displayWidth(msg)
+	}
+	lines := wrapText(msg, width-prefix, width)
+	indent := column - displayWidth(continuationMarker)
+	if indent < 0 {
+		indent = 0
+	}
+	marker := "\n" + strings.Repeat(" ", indent) + h.ContextColor.Sprint(continuationMarker)
+	colored := make([]string, len(lines))
+	for i, line := range lines {
+		colored[i] = c.Sprint(isolateBidi(line))
+	}
+	return strings.Join(colored, marker), column + displayWidth(lines[len(lines)-1])
+}
+
+// minWrapWidth is the fewest columns worth wrapping a message in, rather
+// than letting it overflow.
+const minWrapWidth = 10
+
+// wrapText splits s into lines no wider than first columns for the first,
+// and rest for the others, at spaces when there are some.
+func wrapText(s string, first, rest int) []string {
+	var lines []string
+	for width := first; displayWidth(s) > width; width = rest {
+		cut := truncateWidth(s, width)
+		if i := strings.LastIndexByte(cut, ' '); i > 0 {
+			lines = append(lines, cut[:i])
+			s = s[i+1:]
+			continue
+		}
+		if cut == "" {
+			size, _ := nextCluster(s)
+			cut = s[:size]
+		}
+		lines = append(lines, cut)
+		s = strings.TrimPrefix(s[len(cut):], " ")
+	}
+	return append(lines, s)
+}
@@ -0,0 +1,80 @@
+package humanlog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestParseFieldPriority(t *testing.T) {
+	key, n, err := ParseFieldPriority("user_id=10")
+	if err != nil || key != "user_id" || n != 10 {
+		t.Errorf("got %q %d (%v)", key, n, err)
+	}
+	if key, n, _ := ParseFieldPriority("a=b=-1"); key != "a=b" || n != -1 {
+		t.Errorf("got %q %d", key, n)
+	}
+	for _, text := range []string{"user_id", "=1", "user_id=high"} {
+		if _, _, err := ParseFieldPriority(text); err == nil {
+			t.Errorf("%q: want an error", text)
+		}
+	}
+}
+
+func TestFitFields(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	opts := &HandlerOptions{
+		ContextColor:    color.New(),
+		LineWidth:       40,
+		FieldPriorities: map[string]int{"user": 10, "debug": -1},
+	}
+	kvs := []string{"a=1", "debug=xyz", "path=/api/things", "user=42"}
+
+	tests := []struct {
+		used int
+		want []string
+	}{
+		// 10 + 4 + 10 + 17 + 8 = 49: debug goes first, then the last of
+		// the others
+		{used: 10, want: []string{"a=1", "user=42", "+2 more"}},
+		{used: 0, want: kvs},
+		{used: 35, want: []string{"+4 more"}},
+	}
+	for _, test := range tests {
+		if got := opts.fitFields(test.used, kvs); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d columns used: want %q, got %q", test.used, test.want, got)
+		}
+	}
+}
+
+func TestWrapMessage(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	opts := &HandlerOptions{ContextColor: color.New(), LineWidth: 30}
+	msg, end := opts.wrapMessage("the quick brown fox jumps over the lazy dog", color.New(), 10, 0)
+	want := "the quick brown fox\n        ↪ jumps over the lazy\n        ↪ dog"
+	if msg != want || end != 13 {
+		t.Errorf("want %q ending at 13, got %q ending at %d", want, msg, end)
+	}
+
+	if msg, end := opts.wrapMessage("short", color.New(), 10, 4); msg != "short" || end != 19 {
+		t.Errorf("want the message as it is, got %q ending at %d", msg, end)
+	}
+	opts.LineWidth = 0
+	if msg, _ := opts.wrapMessage("the quick brown fox jumps over the lazy dog", color.New(), 10, 0); strings.Contains(msg, "\n") {
+		t.Errorf("want no wrapping without a line width, got %q", msg)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	got := wrapText("abcdefghij klm", 4, 6)
+	want := []string{"abcd", "efghij", "klm"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
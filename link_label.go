@@ -0,0 +1,93 @@
+package humanlog
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/fatih/color"
+)
+
+// LinkRule shortens the values matching Pattern, links to artifacts like
+// Sentry issues or Grafana dashboards, to Label, a hyperlink to URL. Both
+// can refer to the groups of the match, like $1 or ${name}. URL defaults to
+// the value.
+//
+//	{"pattern": "^https://sentry\\.io/organizations/[^/]+/issues/(\\d+)", "label": "sentry:$1"}
+type LinkRule struct {
+	Pattern string `json:"pattern"`
+	Label   string `json:"label"`
+	URL     string `json:"url,omitempty"`
+
+	re *regexp.Regexp
+}
+
+func (r *LinkRule) compile() error {
+	if r.Label == "" {
+		return fmt.Errorf("missing label")
+	}
+	var err error
+	r.re, err = regexp.Compile(r.Pattern)
+	return err
+}
+
+// DefaultLinkRules label the links to the artifacts of well known services,
+// after the rules of the configuration file.
+var DefaultLinkRules = []LinkRule{
+	{Pattern: `^https://(?:[\w-]+\.)?sentry\.io/(?:organizations/[\w-]+/)?issues/(\d+)/?(?:\?.*)?$`, Label: "sentry:$1"},
+	{Pattern: `^https?://[^/]+/d/[\w-]+/([\w-]+)(?:\?.*)?$`, Label: "grafana:$1"},
+	{Pattern: `^https://([a-z0-9.-]+)\.s3(?:[.-][a-z0-9-]+)?\.amazonaws\.com/(.+)$`, Label: "s3:$1/$2"},
+	{Pattern: `^https://s3(?:[.-][a-z0-9-]+)?\.amazonaws\.com/([a-z0-9.-]+)/(.+)$`, Label: "s3:$1/$2"},
+	{Pattern: `^s3://([a-z0-9.-]+)/(.+)$`, Label: "s3:$1/$2", URL: "https://s3.console.aws.amazon.com/s3/object/$1?prefix=$2"},
+}
+
+func init() {
+	for i := range DefaultLinkRules {
+		if err := DefaultLinkRules[i].compile(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// linkLabel returns the label of v and where it links to, if a rule
+// matches it.
+func (h *HandlerOptions) linkLabel(v string) (label, url string, ok bool) {
+	if !h.LinkLabels {
+		return "", "", false
+	}
+	for _, rules := range [][]LinkRule{h.LinkRules, DefaultLinkRules} {
+		for _, r := range rules {
+			m := r.re.FindStringSubmatchIndex(v)
+			if m == nil {
+				continue
+			}
+			label = string(r.re.ExpandString(nil, r.Label, v, m))
+			url = v
+			if r.URL != "" {
+				url = string(r.re.ExpandString(nil, r.URL, v, m))
+			}
+			return label, url, true
+		}
+	}
+	return "", "", false
+}
+
+// formatValue colors the value of the field key, truncated as asked, or
+// its label if it's a link one of the LinkRules matches, or a placeholder
+// if it's an encoded payload.
+func (h *HandlerOptions) formatValue(key, v string, c *color.Color) string {
+	if placeholder, ok := h.encodedPlaceholder(v); ok {
+		return h.ContextColor.Sprint(placeholder)
+	}
+	// without colors, the output goes to a file or a pipe where the link
+	// itself is more useful than its label
+	if label, url, ok := h.linkLabel(v); ok && !color.NoColor {
+		return hyperlink(url, c.Sprint(label))
+	}
+	return c.Sprint(isolateBidi(h.truncateValue(key, v)))
+}
+
+// hyperlink makes text a link to url, for the terminals supporting OSC 8,
+// the others showing text alone.
+func hyperlink(url, text string) string {
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
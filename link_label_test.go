@@ -0,0 +1,83 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestLinkLabel(t *testing.T) {
+	opts := &HandlerOptions{LinkLabels: true}
+	tests := []struct {
+		in, label, url string
+	}{
+		{in: "https://sentry.io/organizations/acme/issues/4123/", label: "sentry:4123"},
+		{in: "https://acme.sentry.io/issues/4123/?project=2", label: "sentry:4123"},
+		{in: "https://grafana.example.com/d/abc123/api-latency?orgId=1", label: "grafana:api-latency"},
+		{in: "https://my-bucket.s3.us-east-1.amazonaws.com/reports/1.csv", label: "s3:my-bucket/reports/1.csv"},
+		{in: "https://s3.amazonaws.com/my-bucket/reports/1.csv", label: "s3:my-bucket/reports/1.csv"},
+		{
+			in: "s3://my-bucket/reports/1.csv", label: "s3:my-bucket/reports/1.csv",
+			url: "https://s3.console.aws.amazon.com/s3/object/my-bucket?prefix=reports/1.csv",
+		},
+	}
+	for _, test := range tests {
+		label, url, ok := opts.linkLabel(test.in)
+		want := test.url
+		if want == "" {
+			want = test.in
+		}
+		if !ok || label != test.label || url != want {
+			t.Errorf("%q: want %q to %q, got %q to %q (%v)", test.in, test.label, want, label, url, ok)
+		}
+	}
+	for _, in := range []string{"https://example.com/d", "sentry", "https://sentry.io/organizations/acme/"} {
+		if label, _, ok := opts.linkLabel(in); ok {
+			t.Errorf("%q: want no label, got %q", in, label)
+		}
+	}
+	if _, _, ok := (&HandlerOptions{}).linkLabel(tests[0].in); ok {
+		t.Error("want no label without LinkLabels")
+	}
+}
+
+func TestLinkLabel_Config(t *testing.T) {
+	path := writeConfig(t, `{"links": [{"pattern": "^https://ci\\.example\\.com/builds/(?P<id>\\d+)$", "label": "build:${id}"}]}`)
+	cfg, err := ReadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &HandlerOptions{LinkLabels: true}
+	cfg.Apply(opts)
+	if label, _, ok := opts.linkLabel("https://ci.example.com/builds/77"); !ok || label != "build:77" {
+		t.Errorf("want build:77, got %q", label)
+	}
+
+	if _, err := ReadConfig(writeConfig(t, `{"links": [{"pattern": "("}]}`)); err == nil {
+		t.Error("want an error for a rule without a label")
+	}
+}
+
+func TestLinkLabel_Rendered(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	opts := *DefaultOptions
+	opts.LinkLabels = true
+	opts.Truncates = false
+	link := "https://sentry.io/organizations/acme/issues/4123/"
+
+	color.NoColor = false
+	h := LogfmtHandler{Opts: &opts}
+	h.setField([]byte("issue"), []byte(link))
+	out := string(h.Prettify(false))
+	if !strings.Contains(out, "\x1b]8;;"+link+"\x1b\\") || !strings.Contains(out, "sentry:4123") {
+		t.Errorf("want a hyperlink labeled sentry:4123, got %q", out)
+	}
+
+	// written to a file, the link is kept
+	color.NoColor = true
+	h.setField([]byte("issue"), []byte(link))
+	if out := string(h.Prettify(false)); !strings.Contains(out, "issue="+link) {
+		t.Errorf("want the link as it was, got %q", out)
+	}
+}
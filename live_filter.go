@@ -0,0 +1,96 @@
+package humanlog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// LiveFilters are conditions on the entries that can change while they're
+// being read, from an interactive session. Only the entries matching all of
+// them go through their stage.
+type LiveFilters struct {
+	mu    sync.Mutex
+	conds []AlertCondition
+	undo  [][]AlertCondition
+}
+
+// Exec changes the filters with a command:
+//
+//	+key=value  only let the entries where key is value through
+//	-key=value  drop the entries where key is value
+//	undo        revert the last change
+//	clear       remove all the filters
+//
+// The key=value pairs can be pasted as they're shown, quotes included. The
+// level and message can be filtered on with the keys level and msg.
+func (f *LiveFilters) Exec(cmd string) error {
+	cmd = strings.TrimSpace(cmd)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch cmd {
+	case "undo":
+		if len(f.undo) == 0 {
+			return fmt.Errorf("nothing to undo")
+		}
+		f.conds, f.undo = f.undo[len(f.undo)-1], f.undo[:len(f.undo)-1]
+		return nil
+	case "clear":
+		f.save()
+		f.conds = nil
+		return nil
+	}
+	if len(cmd) < 2 || cmd[0] != '+' && cmd[0] != '-' {
+		return fmt.Errorf("unknown command %q, want +key=value, -key=value, undo or clear", cmd)
+	}
+	kv := strings.SplitN(cmd[1:], "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return fmt.Errorf("want key=value after %c, got %q", cmd[0], cmd[1:])
+	}
+	f.save()
+	f.conds = append(f.conds, AlertCondition{Key: kv[0], Value: unquoteValue(kv[1]), Negate: cmd[0] == '-'})
+	return nil
+}
+
+// save remembers the filters as they are, to undo what comes next.
+func (f *LiveFilters) save() {
+	f.undo = append(f.undo, append([]AlertCondition(nil), f.conds...))
+}
+
+// String lists the active filters, the way they were given.
+func (f *LiveFilters) String() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.conds) == 0 {
+		return "no filters"
+	}
+	parts := make([]string, len(f.conds))
+	for i, c := range f.conds {
+		op := "+"
+		if c.Negate {
+			op = "-"
+		}
+		parts[i] = op + c.Key + "=" + c.Value
+	}
+	return "filters: " + strings.Join(parts, " ")
+}
+
+// Stage drops the parsed entries that don't match the filters. Entries that
+// couldn't be parsed go through while there are no filters.
+func (f *LiveFilters) Stage(e *Entry, next Next) error {
+	f.mu.Lock()
+	conds := f.conds
+	f.mu.Unlock()
+	if len(conds) == 0 {
+		return next(e)
+	}
+	if e.Event == nil {
+		return nil
+	}
+	for i := range conds {
+		if !conds[i].matches(e.Event) {
+			return nil
+		}
+	}
+	return next(e)
+}
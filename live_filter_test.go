@@ -0,0 +1,62 @@
+package humanlog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLiveFilters(t *testing.T) {
+	src := strings.Join([]string{
+		`{"msg": "a", "service": "api", "level": "info"}`,
+		`{"msg": "b", "service": "db", "level": "info"}`,
+		`{"msg": "c", "service": "api", "level": "debug", "user": "jo doe"}`,
+		`not structured`,
+	}, "\n")
+	filters := new(LiveFilters)
+	messages := func() []string {
+		var got []string
+		stages := append(builtinStages(DefaultOptions), filters.Stage, func(e *Entry, next Next) error {
+			if e.Event == nil {
+				got = append(got, string(e.Raw))
+			} else {
+				got = append(got, e.Event.Message)
+			}
+			return next(e)
+		})
+		if err := Process(strings.NewReader(src), DefaultOptions, Chain(stages...)); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	steps := []struct {
+		cmd    string
+		want   []string
+		status string
+	}{
+		{cmd: "+service=api", want: []string{"a", "c"}, status: "filters: +service=api"},
+		{cmd: "-level=debug", want: []string{"a"}, status: "filters: +service=api -level=debug"},
+		{cmd: "undo", want: []string{"a", "c"}, status: "filters: +service=api"},
+		{cmd: `+user="jo doe"`, want: []string{"c"}, status: "filters: +service=api +user=jo doe"},
+		{cmd: "clear", want: []string{"a", "b", "c", "not structured"}, status: "no filters"},
+		{cmd: "undo", want: []string{"c"}, status: "filters: +service=api +user=jo doe"},
+	}
+	for _, step := range steps {
+		if err := filters.Exec(step.cmd); err != nil {
+			t.Fatalf("%s: %v", step.cmd, err)
+		}
+		if got := messages(); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("after %s, want %v, got %v", step.cmd, step.want, got)
+		}
+		if got := filters.String(); got != step.status {
+			t.Errorf("after %s, want %q, got %q", step.cmd, step.status, got)
+		}
+	}
+
+	for _, bad := range []string{"service=api", "+service", "+=x"} {
+		if err := filters.Exec(bad); err == nil {
+			t.Errorf("want an error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LnavFormat is a log format defined for lnav, matching lines with regexps
+// whose named captures are the time, level, message and fields of the
+// entries. See https://docs.lnav.org/en/latest/formats.html.
+type LnavFormat struct {
+	Name string
+
+	patterns       []*regexp.Regexp
+	timestampField string
+	bodyField      string
+	levelField     string
+	levels         []lnavLevel
+	layouts        []string
+}
+
+type lnavLevel struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// lnavFormatFile is the part of lnav's format definitions humanlog uses.
+type lnavFormatFile struct {
+	JSON  bool `json:"json"`
+	Regex map[string]struct {
+		Pattern string `json:"pattern"`
+	} `json:"regex"`
+	TimestampField  string            `json:"timestamp-field"`
+	BodyField       string            `json:"body-field"`
+	LevelField      string            `json:"level-field"`
+	Level           map[string]string `json:"level"`
+	TimestampFormat []string          `json:"timestamp-format"`
+}
+
+// lnavLevelNames maps lnav's levels to humanlog's.
+var lnavLevelNames = map[string]string{
+	"trace":    "trace",
+	"debug5":   "debug",
+	"debug4":   "debug",
+	"debug3":   "debug",
+	"debug2":   "debug",
+	"debug":    "debug",
+	"info":     "info",
+	"stats":    "info",
+	"notice":   "notice",
+	"warning":  "warn",
+	"error":    "error",
+	"critical": "critical",
+	"fatal":    "fatal",
+}
+
+// LoadLnavFormats reads the formats defined in an lnav format file. Formats
+// of JSON logs are left out, since humanlog reads those already.
+func LoadLnavFormats(path string) ([]*LnavFormat, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var defs map[string]json.RawMessage
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		if !strings.HasPrefix(name, "$") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var formats []*LnavFormat
+	for _, name := range names {
+		var def lnavFormatFile
+		if err := json.Unmarshal(defs[name], &def); err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", path, name, err)
+		}
+		if def.JSON {
+			continue
+		}
+		f, err := newLnavFormat(name, &def)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", path, name, err)
+		}
+		formats = append(formats, f)
+	}
+	return formats, nil
+}
+
+func newLnavFormat(name string, def *lnavFormatFile) (*LnavFormat, error) {
+	f := &LnavFormat{
+		Name:           name,
+		timestampField: def.TimestampField,
+		bodyField:      def.BodyField,
+		levelField:     def.LevelField,
+	}
+	if f.timestampField == "" {
+		f.timestampField = "timestamp"
+	}
+	if f.bodyField == "" {
+		f.bodyField = "body"
+	}
+
+	// the patterns are tried in the order of their names
+	patterns := make([]string, 0, len(def.Regex))
+	for name := range def.Regex {
+		patterns = append(patterns, name)
+	}
+	sort.Strings(patterns)
+	for _, name := range patterns {
+		re, err := regexp.Compile(pcreToRE2(def.Regex[name].Pattern))
+		if err != nil {
+			return nil, fmt.Errorf("regex %s: %v", name, err)
+		}
+		f.patterns = append(f.patterns, re)
+	}
+	if len(f.patterns) == 0 {
+		return nil, fmt.Errorf("no regex")
+	}
+
+	levels := make([]string, 0, len(def.Level))
+	for level := range def.Level {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+	for _, level := range levels {
+		re, err := regexp.Compile(pcreToRE2(def.Level[level]))
+		if err != nil {
+			return nil, fmt.Errorf("level %s: %v", level, err)
+		}
+		name, ok := lnavLevelNames[level]
+		if !ok {
+			name = level
+		}
+		f.levels = append(f.levels, lnavLevel{name: name, re: re})
+	}
+	sort.SliceStable(f.levels, func(i, j int) bool {
+		return builtinLevelRanks[f.levels[i].name] > builtinLevelRanks[f.levels[j].name]
+	})
+
+	for _, format := range def.TimestampFormat {
+		layout, err := strftimeLayout(format)
+		if err != nil {
+			return nil, fmt.Errorf("timestamp-format %q: %v", format, err)
+		}
+		f.layouts = append(f.layouts, layout)
+	}
+	return f, nil
+}
+
+// pcreToRE2 rewrites the named groups of PCRE, which RE2 only learned
+// lately.
+func pcreToRE2(pattern string) string {
+	return strings.Replace(pattern, "(?<", "(?P<", -1)
+}
+
+// Rewrite turns the lines matching one of the format's regexps into JSON
+// entries, with a time, level and msg, and the other captures as fields.
+func (f *LnavFormat) Rewrite(line []byte) []byte {
+	for _, re := range f.patterns {
+		m := re.FindSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		entry := make(map[string]interface{})
+		for i, name := range re.SubexpNames() {
+			if name == "" || m[2*i] < 0 {
+				continue
+			}
+			v := string(line[m[2*i]:m[2*i+1]])
+			switch name {
+			case f.timestampField:
+				entry["time"] = f.parseTime(v)
+			case f.bodyField:
+				entry["msg"] = v
+			case f.levelField:
+				entry["level"] = f.level(v)
+			default:
+				entry[name] = v
+			}
+		}
+		out, err := json.Marshal(entry)
+		if err != nil {
+			return nil
+		}
+		return out
+	}
+	return nil
+}
+
+// parseTime returns the time as RFC 3339 if it's in one of the format's
+// layouts, or leaves it to the handlers to recognize otherwise.
+func (f *LnavFormat) parseTime(v string) string {
+	for _, layout := range f.layouts {
+		if t, ok := parseLnavTime(layout, v); ok {
+			return t.Format(time.RFC3339Nano)
+		}
+	}
+	return v
+}
+
+func parseLnavTime(layout, v string) (time.Time, bool) {
+	var unit time.Duration
+	switch layout {
+	case "%s":
+		unit = time.Second
+	case "%i":
+		unit = time.Millisecond
+	case "%6":
+		unit = time.Microsecond
+	default:
+		t, err := time.ParseInLocation(layout, v, time.Local)
+		return t, err == nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, n*int64(unit)), true
+}
+
+// level maps the value of the level field to a level with the format's
+// patterns, the most severe level matching winning.
+func (f *LnavFormat) level(v string) string {
+	for _, level := range f.levels {
+		if level.re.MatchString(v) {
+			return level.name
+		}
+	}
+	return v
+}
+
+// strftimeDirectives are the Go layouts of the strftime directives used in
+// lnav's timestamp formats.
+var strftimeDirectives = map[byte]string{
+	'Y': "2006", 'y': "06", 'm': "01", 'd': "02", 'e': "_2", 'j': "002",
+	'H': "15", 'I': "03", 'M': "04", 'S': "05", 'p': "PM",
+	'b': "Jan", 'h': "Jan", 'B': "January", 'a': "Mon", 'A': "Monday",
+	'z': "-0700", 'Z': "MST", 'T': "15:04:05", 'F': "2006-01-02",
+	'L': "000", 'f': "000000", 'N': "000000000", '%': "%",
+}
+
+// strftimeLayout converts a strftime format to a Go layout. Timestamps
+// since the epoch, %s in seconds, %i in milliseconds and %6 in
+// microseconds, are returned as they are.
+func strftimeLayout(format string) (string, error) {
+	switch format {
+	case "%s", "%i", "%6":
+		return format, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		if i == len(format) {
+			return "", fmt.Errorf("trailing %%")
+		}
+		layout, ok := strftimeDirectives[format[i]]
+		if !ok {
+			return "", fmt.Errorf("unsupported directive %%%c", format[i])
+		}
+		b.WriteString(layout)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,80 @@
+package humanlog
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const lnavExample = `{
+	"$schema": "https://lnav.org/schemas/format-v1.schema.json",
+	"myapp_log": {
+		"title": "My app",
+		"regex": {
+			"std": {"pattern": "^(?<timestamp>\\d{4}-\\d\\d-\\d\\d \\d\\d:\\d\\d:\\d\\d\\.\\d{3}) \\[(?<thread>[^\\]]+)\\] (?<level>\\S+) (?<body>.*)$"}
+		},
+		"level-field": "level",
+		"level": {"error": "ERR|ERROR", "warning": "WARN", "info": "INFO", "fatal": "FATAL|ERROR!"},
+		"timestamp-format": ["%Y-%m-%d %H:%M:%S.%L"]
+	},
+	"other_json": {"json": true}
+}`
+
+func TestLnavFormat(t *testing.T) {
+	config := writeConfig(t, `{"lnav-formats": ["myapp.json"]}`)
+	if err := ioutil.WriteFile(filepath.Join(filepath.Dir(config), "myapp.json"), []byte(lnavExample), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := ReadConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := *DefaultOptions
+	cfg.Apply(&opts)
+
+	src := strings.Join([]string{
+		`2024-01-02 03:04:05.678 [main] WARN disk is nearly full`,
+		`2024-01-02 03:04:06.000 [worker-1] ERROR! lost the database`,
+		`something else`,
+	}, "\n")
+	var events []*Event
+	if err := ScanEvents(strings.NewReader(src), &opts, func(ev *Event) error {
+		events = append(events, ev)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("want 2 events, got %d", len(events))
+	}
+	want := time.Date(2024, 1, 2, 3, 4, 5, 678e6, time.Local)
+	if ev := events[0]; !ev.Time.Equal(want) || ev.Level != "warn" || ev.Message != "disk is nearly full" || ev.Fields["thread"] != `"main"` {
+		t.Errorf("got %+v", ev)
+	}
+	if ev := events[1]; ev.Level != "fatal" || ev.Message != "lost the database" {
+		t.Errorf("want the most severe matching level, got %+v", ev)
+	}
+}
+
+func TestStrftimeLayout(t *testing.T) {
+	tests := []struct {
+		format, want string
+	}{
+		{format: "%Y-%m-%dT%H:%M:%S.%f%z", want: "2006-01-02T15:04:05.000000-0700"},
+		{format: "%b %e %T", want: "Jan _2 15:04:05"},
+		{format: "%i", want: "%i"},
+		{format: "100%%", want: "100%"},
+	}
+	for _, tt := range tests {
+		got, err := strftimeLayout(tt.format)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: want %q, got %q (%v)", tt.format, tt.want, got, err)
+		}
+	}
+	if _, err := strftimeLayout("%Q"); err == nil {
+		t.Error("want an error for an unknown directive")
+	}
+}
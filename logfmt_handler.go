@@ -13,7 +13,8 @@ import (
 	"github.com/go-logfmt/logfmt"
 )
 
-// LogfmtHandler can handle logs emmited by logrus.TextFormatter loggers.
+// LogfmtHandler can handle logfmt lines, and those of the other text formats
+// humanlog knows, like logrus.TextFormatter's.
 type LogfmtHandler struct {
 	buf     *bytes.Buffer
 	out     *tabwriter.Writer
@@ -27,19 +28,28 @@ type LogfmtHandler struct {
 	Fields  map[string]string
 
 	last map[string]string
+	// drop are the keys not to keep at all.
+	drop map[string]struct{}
 }
 
 func (h *LogfmtHandler) clear() {
 	h.Level = ""
 	h.Time = time.Time{}
 	h.Message = ""
-	h.last = h.Fields
 	h.Fields = make(map[string]string)
 	if h.buf != nil {
 		h.buf.Reset()
 	}
 }
 
+// sniffLogfmt tells the lines that may hold key=value pairs.
+func sniffLogfmt(d []byte) confidence {
+	if bytes.IndexByte(d, '=') >= 0 {
+		return mayBeFormat
+	}
+	return notFormat
+}
+
 // CanHandle tells if this line can be handled by this handler.
 func (h *LogfmtHandler) TryHandle(d []byte) bool {
 	if !bytes.ContainsRune(d, '=') {
@@ -76,7 +86,7 @@ func (h *LogfmtHandler) UnmarshalLogfmt(data []byte) bool {
 
 			if len(h.Message) == 0 {
 				foundMessage := checkEachUntilFound(h.Opts.MessageFields, func(field string) bool {
-					if !bytes.Equal(key, []byte(field)) {
+					if !bytes.Equal(key, []byte(fieldKey(field))) {
 						return false
 					}
 					h.Message = string(val)
@@ -89,7 +99,7 @@ func (h *LogfmtHandler) UnmarshalLogfmt(data []byte) bool {
 
 			if len(h.Level) == 0 {
 				foundLevel := checkEachUntilFound(h.Opts.LevelFields, func(field string) bool {
-					if !bytes.Equal(key, []byte(field)) {
+					if !bytes.Equal(key, []byte(fieldKey(field))) {
 						return false
 					}
 					h.Level = string(val)
@@ -100,6 +110,9 @@ func (h *LogfmtHandler) UnmarshalLogfmt(data []byte) bool {
 				}
 			}
 
+			if _, drop := h.drop[string(key)]; drop {
+				continue
+			}
 			h.setField(key, val)
 		}
 	}
@@ -109,6 +122,8 @@ func (h *LogfmtHandler) UnmarshalLogfmt(data []byte) bool {
 // Prettify the output in a logrus like fashion.
 func (h *LogfmtHandler) Prettify(skipUnchanged bool) []byte {
 	defer h.clear()
+	// only the fields of the entries shown can be skipped when unchanged
+	defer func() { h.last = h.Fields }()
 	if h.out == nil {
 		if h.Opts == nil {
 			h.Opts = DefaultOptions
@@ -129,29 +144,7 @@ func (h *LogfmtHandler) Prettify(skipUnchanged bool) []byte {
 		msgAbsentColor = h.Opts.MsgAbsentDarkBgColor
 	}
 
-	var msg string
-	if h.Message == "" {
-		msg = msgAbsentColor.Sprint("<no msg>")
-	} else {
-		msg = msgColor.Sprint(h.Message)
-	}
-
-	lvl := strings.ToUpper(h.Level)[:imin(4, len(h.Level))]
-	var level string
-	switch h.Level {
-	case "debug":
-		level = h.Opts.DebugLevelColor.Sprint(lvl)
-	case "info":
-		level = h.Opts.InfoLevelColor.Sprint(lvl)
-	case "warn", "warning":
-		level = h.Opts.WarnLevelColor.Sprint(lvl)
-	case "error":
-		level = h.Opts.ErrorLevelColor.Sprint(lvl)
-	case "fatal", "panic":
-		level = h.Opts.FatalLevelColor.Sprint(lvl)
-	default:
-		level = h.Opts.UnknownLevelColor.Sprint(lvl)
-	}
+	level := h.Opts.levelColor(h.Level).Sprint(shortLevel(h.Level))
 
 	var timeColor *color.Color
 	if h.Opts.LightBg {
@@ -159,15 +152,57 @@ func (h *LogfmtHandler) Prettify(skipUnchanged bool) []byte {
 	} else {
 		timeColor = h.Opts.TimeDarkBgColor
 	}
-	_, _ = fmt.Fprintf(h.out, "%s |%s| %s\t %s",
+	var caller string
+	var callerKeys []string
+	if h.Opts.CallerWidth > 0 {
+		caller, callerKeys = h.Opts.callerOf(h.Fields)
+	}
+	kvs, blocks := h.joinKVs(skipUnchanged, "=", callerKeys)
+	var trailer string
+	if h.Opts.FieldsJSON != "" {
+		trailer, kvs = h.Opts.fieldsJSON(h.Fields, nil), nil
+		if h.Opts.FieldsJSON == "end" && trailer != "" {
+			kvs = []string{trailer}
+		}
+	}
+	columns := fmt.Sprintf("%s |%s| %s",
 		timeColor.Sprint(h.Time.Format(h.Opts.TimeFormat)),
 		level,
-		msg,
-		strings.Join(h.joinKVs(skipUnchanged, "="), "\t "),
+		h.Opts.callerColumn(caller),
 	)
+	var logger string
+	if name := h.Opts.loggerName(h.Fields); name != "" {
+		logger = "[" + name + "] "
+	}
+	column := displayWidth(ansiEscape.ReplaceAllString(columns, ""))
+	var msg string
+	var msgEnd int
+	if h.Message == "" {
+		msg = msgAbsentColor.Sprint("<no msg>")
+		msgEnd = column + displayWidth(logger) + len("<no msg>")
+	} else {
+		msg, msgEnd = h.Opts.wrapMessage(h.Message, msgColor, column, displayWidth(logger))
+	}
+	if logger != "" {
+		msg = h.Opts.LoggerColor.Sprint(logger) + msg
+	}
+	head := columns + msg
+	if h.Opts.LineWidth > 0 && h.Opts.FieldsJSON == "" {
+		kvs = h.Opts.fitFields(msgEnd, kvs)
+	}
+	_, _ = fmt.Fprintf(h.out, "%s\t %s", head, strings.Join(kvs, "\t "))
 
 	_ = h.out.Flush()
 
+	if h.Opts.FieldsJSON == "line" && trailer != "" {
+		h.buf.WriteString("\n      ")
+		h.buf.WriteString(trailer)
+	}
+	for _, block := range blocks {
+		h.buf.WriteString("\n")
+		h.buf.WriteString(block)
+	}
+
 	return h.buf.Bytes()
 }
 
@@ -190,11 +225,29 @@ func (h *LogfmtHandler) setField(key, val []byte) {
 	h.Fields[string(key)] = string(val)
 }
 
-func (h *LogfmtHandler) joinKVs(skipUnchanged bool, sep string) []string {
+// defaultTime sets the time of the entry, unless it had one.
+func (h *LogfmtHandler) defaultTime(t time.Time) {
+	if h.Time.IsZero() {
+		h.Time = t
+	}
+}
 
-	kv := make([]string, 0, len(h.Fields))
+// shiftTime moves the time of the entry, if it has one.
+func (h *LogfmtHandler) shiftTime(d time.Duration) {
+	if !h.Time.IsZero() {
+		h.Time = h.Time.Add(d)
+	}
+}
+
+func (h *LogfmtHandler) event() *Event {
+	return &Event{Time: h.Time, Level: h.Level, Message: h.Message, Fields: h.Fields}
+}
+
+func (h *LogfmtHandler) joinKVs(skipUnchanged bool, sep string, hidden []string) (kv []string, blocks []string) {
+
+	kv = make([]string, 0, len(h.Fields))
 	for k, v := range h.Fields {
-		if !h.Opts.shouldShowKey(k) {
+		if !h.Opts.shouldShowKey(k) || containsField(h.Opts.LoggerFields, k) || containsField(hidden, k) {
 			continue
 		}
 
@@ -204,15 +257,34 @@ func (h *LogfmtHandler) joinKVs(skipUnchanged bool, sep string) []string {
 			}
 		}
 
+		if block, ok := h.Opts.queryBlock(k, v); ok {
+			blocks = append(blocks, block)
+			continue
+		}
+		if block, ok := h.Opts.tracebackBlock(k, v); ok {
+			blocks = append(blocks, block)
+			continue
+		}
+		if block, ok := h.Opts.decodedBlock(k, v); ok {
+			blocks = append(blocks, block)
+			continue
+		}
+
 		kstr := h.Opts.KeyColor.Sprint(k)
 
-		var vstr string
-		if h.Opts.Truncates && len(v) > h.Opts.TruncateLength {
-			vstr = v[:h.Opts.TruncateLength] + "..."
-		} else {
-			vstr = v
+		kind := kindOfText(v)
+		if typed, typedKind, ok := h.Opts.typeValue(k, v); ok {
+			v, kind = typed, typedKind
 		}
-		vstr = h.Opts.ValColor.Sprint(vstr)
+		if kind == kindNull && h.Opts.HideNull {
+			continue
+		}
+		vcolor := h.Opts.fieldColor(k, v, kind)
+		if kind == kindNumber {
+			v = h.Opts.formatQuantity(k, v)
+		}
+
+		vstr := h.Opts.formatValue(k, v, vcolor)
 		kv = append(kv, kstr+sep+vstr)
 	}
 
@@ -221,8 +293,9 @@ func (h *LogfmtHandler) joinKVs(skipUnchanged bool, sep string) []string {
 	if h.Opts.SortLongest {
 		sort.Stable(byLongest(kv))
 	}
+	sort.Strings(blocks)
 
-	return kv
+	return kv, blocks
 }
 
 type byLongest []string
@@ -231,9 +304,7 @@ func (s byLongest) Len() int           { return len(s) }
 func (s byLongest) Less(i, j int) bool { return len(s[i]) < len(s[j]) }
 func (s byLongest) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
-func imin(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+// shortLevel returns the level upper cased, cut down to 4 columns.
+func shortLevel(level string) string {
+	return truncateWidth(strings.ToUpper(level), 4)
 }
@@ -0,0 +1,181 @@
+package humanlog
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// logrusTTYLine matches the lines logrus' TextFormatter writes to terminals,
+// once their colors are removed: the level cut to 4 letters, the seconds
+// since the program started or the full timestamp, and the message padded
+// to 44 columns before the fields:
+//
+//	INFO[0003] listening                                     addr=":8080"
+//	WARN[2024-01-02T10:00:00Z] slow query                    took=1.2s
+var logrusTTYLine = regexp.MustCompile(`^(PANI|FATA|ERRO|WARN|INFO|DEBU|TRAC)\[([^\]]*)\] ?(.*)$`)
+
+// logrusTTYFields finds where the fields start after the message, logrus
+// leaving at least two spaces between them.
+var logrusTTYFields = regexp.MustCompile(`\s{2,}[^\s="]+=`)
+
+var logrusTTYLevels = map[string]string{
+	"PANI": "panic", "FATA": "fatal", "ERRO": "error", "WARN": "warning",
+	"INFO": "info", "DEBU": "debug", "TRAC": "trace",
+}
+
+// logrusClashes are the keys logrus gives the fields named like its own
+// keys, for them not to overwrite the time, level or message of the entry.
+var logrusClashes = map[string]string{
+	"fields.time": "time", "fields.level": "level", "fields.msg": "msg",
+	"fields.func": "func", "fields.file": "file", "fields.logrus_error": "logrus_error",
+}
+
+// sniffLogrusText tells the lines of logrus' TextFormatter by their first
+// key, or by the level they start with on terminals.
+func sniffLogrusText(d []byte) confidence {
+	switch {
+	case bytes.HasPrefix(d, []byte("time=")), bytes.HasPrefix(d, []byte("level=")):
+		return likelyFormat
+	case bytes.HasPrefix(d, []byte("\x1b[")), len(d) > 4 && d[4] == '[':
+		return mayBeFormat
+	}
+	return notFormat
+}
+
+// tryLogrusText handles the lines of logrus' TextFormatter, either as it
+// writes them to files, time="..." level=info msg="..." followed by the
+// fields, or to terminals. Its values are quoted the way Go quotes strings.
+// The fields it renamed because they clashed with its own keys get their
+// name back.
+func tryLogrusText(d []byte, h *LogfmtHandler) bool {
+	line := string(d)
+	if strings.HasPrefix(line, "time=") || strings.HasPrefix(line, "level=") {
+		return tryLogrusPlain(line, h)
+	}
+	if strings.HasPrefix(line, "\x1b[") {
+		line = ansiEscape.ReplaceAllString(line, "")
+	}
+	return tryLogrusTTY(line, h)
+}
+
+func tryLogrusPlain(line string, h *LogfmtHandler) bool {
+	pairs, ok := logrusPairs(line)
+	if !ok {
+		return false
+	}
+	var level, msg, ts string
+	var hasLevel, hasMsg bool
+	fields := make([][2]string, 0, len(pairs))
+	for _, kv := range pairs {
+		switch kv[0] {
+		case "time":
+			ts = kv[1]
+		case "level":
+			level, hasLevel = kv[1], true
+		case "msg":
+			msg, hasMsg = kv[1], true
+		default:
+			fields = append(fields, kv)
+		}
+	}
+	// logrus always writes both, other logfmt lines are left to the logfmt
+	// handler and the fields it was told about
+	if !hasLevel || !hasMsg {
+		return false
+	}
+	if t, ok := tryParseTime(ts); ok {
+		h.Time = t
+	} else if ts != "" {
+		// a TimestampFormat humanlog doesn't know, kept not to lose it
+		fields = append(fields, [2]string{"time", ts})
+	}
+	h.Level, h.Message = level, msg
+	setLogrusFields(fields, h)
+	return true
+}
+
+func tryLogrusTTY(line string, h *LogfmtHandler) bool {
+	m := logrusTTYLine.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	msg, rest := m[3], ""
+	if loc := logrusTTYFields.FindStringIndex(msg); loc != nil {
+		msg, rest = msg[:loc[0]], msg[loc[0]:]
+	}
+	pairs, ok := logrusPairs(rest)
+	if !ok {
+		return false
+	}
+	// the seconds since the program started don't tell when the entry was
+	// logged, only a full timestamp does
+	if t, ok := tryParseTime(m[2]); ok {
+		h.Time = t
+	}
+	h.Level, h.Message = logrusTTYLevels[m[1]], strings.TrimRight(msg, " ")
+	setLogrusFields(pairs, h)
+	return true
+}
+
+func setLogrusFields(pairs [][2]string, h *LogfmtHandler) {
+	for _, kv := range pairs {
+		key := kv[0]
+		if original, ok := logrusClashes[key]; ok {
+			key = original
+		}
+		if _, drop := h.drop[key]; !drop {
+			h.setField([]byte(key), []byte(kv[1]))
+		}
+	}
+}
+
+// logrusPairs reads the key=value pairs of s, separated by spaces, the
+// values being quoted with Go's escapes when they need to.
+func logrusPairs(s string) ([][2]string, bool) {
+	var pairs [][2]string
+	for s = strings.TrimLeft(s, " "); s != ""; s = strings.TrimLeft(s, " ") {
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || strings.ContainsAny(s[:eq], " \"") {
+			return nil, false
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := quotedEnd(s)
+			if end < 0 {
+				return nil, false
+			}
+			v, err := strconv.Unquote(s[:end])
+			if err != nil {
+				return nil, false
+			}
+			value, s = v, s[end:]
+		} else {
+			end := strings.IndexByte(s, ' ')
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+		}
+		pairs = append(pairs, [2]string{key, value})
+	}
+	return pairs, true
+}
+
+// quotedEnd returns where the quoted string s starts with ends, -1 if it
+// doesn't.
+func quotedEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,83 @@
+package humanlog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogrusText(t *testing.T) {
+	src := strings.Join([]string{
+		`time="2024-01-02T10:00:00Z" level=info msg="listening on port" addr=":8080" pid=42`,
+		`time="2024-01-02T10:00:01Z" level=warning msg="quote \"this\"\n\tand that" fields.time="yesterday" fields.msg=hi empty=`,
+		`level=error msg=boom error="dial tcp: i/o timeout"`,
+		"\x1b[36mINFO\x1b[0m[0003] starting up                                   \x1b[36mversion\x1b[0m=1.2.3 \x1b[36mregion\x1b[0m=\"us east\"",
+		`WARN[2024-01-02T10:00:02Z] slow query  took=1.2s`,
+		`ERRO[0012] no fields at all`,
+	}, "\n")
+
+	var got []*Event
+	err := ScanEvents(strings.NewReader(src), DefaultOptions, func(ev *Event) error {
+		got = append(got, ev)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Event{
+		{
+			Time: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), Level: "info", Message: "listening on port",
+			Fields: map[string]string{"addr": ":8080", "pid": "42"},
+		},
+		{
+			Time: time.Date(2024, 1, 2, 10, 0, 1, 0, time.UTC), Level: "warning", Message: "quote \"this\"\n\tand that",
+			Fields: map[string]string{"time": "yesterday", "msg": "hi", "empty": ""},
+		},
+		{
+			Level: "error", Message: "boom",
+			Fields: map[string]string{"error": "dial tcp: i/o timeout"},
+		},
+		{
+			Level: "info", Message: "starting up",
+			Fields: map[string]string{"version": "1.2.3", "region": "us east"},
+		},
+		{
+			Time: time.Date(2024, 1, 2, 10, 0, 2, 0, time.UTC), Level: "warning", Message: "slow query",
+			Fields: map[string]string{"took": "1.2s"},
+		},
+		{
+			Level: "error", Message: "no fields at all",
+			Fields: map[string]string{},
+		},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %d entries, got %d", len(want), len(got))
+	}
+	for i, w := range want {
+		g := got[i]
+		if !g.Time.Equal(w.Time) || g.Level != w.Level || g.Message != w.Message {
+			t.Errorf("%d: want %v %q %q, got %v %q %q", i, w.Time, w.Level, w.Message, g.Time, g.Level, g.Message)
+		}
+		if len(g.Fields) != 0 || len(w.Fields) != 0 {
+			if !reflect.DeepEqual(g.Fields, w.Fields) {
+				t.Errorf("%d: want fields %v, got %v", i, w.Fields, g.Fields)
+			}
+		}
+	}
+}
+
+func TestLogrusText_LeavesLogfmt(t *testing.T) {
+	var h LogfmtHandler
+	for _, line := range []string{
+		`level=info message=hi`,
+		`time="2024-01-02T10:00:00Z" msg=hi`,
+		`INFO something happened`,
+		`level=info msg="unterminated`,
+	} {
+		if tryLogrusText([]byte(line), &h) {
+			t.Errorf("%q: want it left to the other handlers", line)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package humanlog
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MemoryBudget caps the memory the buffers remembering lines, like the tail
+// buffer, the truncated values and the snapshot's scrollback, take together.
+// Past it, each buffer moves its oldest lines to a temporary file, and reads
+// them back from there when they're needed.
+type MemoryBudget struct {
+	limit int64
+
+	mu      sync.Mutex
+	used    int64
+	spilled int64
+	files   []*os.File
+}
+
+// NewMemoryBudget returns a budget of limit bytes.
+func NewMemoryBudget(limit int64) *MemoryBudget {
+	return &MemoryBudget{limit: limit}
+}
+
+// ParseSize reads a number of bytes, with a unit or not, like 64MiB or 1GB.
+func ParseSize(text string) (int64, error) {
+	text = strings.TrimSpace(text)
+	i := strings.IndexFunc(text, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(text)
+	}
+	n, err := strconv.ParseFloat(text[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("want a size like 64MiB or 1GB")
+	}
+	scale := 1.0
+	if unit := strings.TrimSpace(text[i:]); unit != "" {
+		s, ok := unitScales[unit]
+		if !ok || s.dimension != "size" {
+			return 0, fmt.Errorf("unknown size unit %q", unit)
+		}
+		scale = s.scale
+	}
+	return int64(n * scale), nil
+}
+
+// Used returns how many bytes the buffers hold in memory.
+func (b *MemoryBudget) Used() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Spilled returns how many bytes were moved to temporary files since the
+// start.
+func (b *MemoryBudget) Spilled() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spilled
+}
+
+// Close removes the temporary files the buffers spilled into.
+func (b *MemoryBudget) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var err error
+	for _, f := range b.files {
+		f.Close()
+		if rerr := os.Remove(f.Name()); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	b.files = nil
+	return err
+}
+
+func (b *MemoryBudget) charge(n int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.used += n
+	b.mu.Unlock()
+}
+
+func (b *MemoryBudget) over() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used > b.limit
+}
+
+func (b *MemoryBudget) spill(n int64) {
+	b.mu.Lock()
+	b.used -= n
+	b.spilled += n
+	b.mu.Unlock()
+}
+
+func (b *MemoryBudget) tempFile() (*os.File, error) {
+	f, err := ioutil.TempFile("", "humanlog-spill-")
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	b.files = append(b.files, f)
+	b.mu.Unlock()
+	return f, nil
+}
+
+// spillRing remembers the last lines pushed, up to max. With a budget, the
+// oldest lines go to a temporary file while the budget is exceeded, the
+// newest staying in memory.
+type spillRing struct {
+	max    int
+	budget *MemoryBudget
+
+	// mem holds the newest lines, the oldest first
+	mem []string
+	// spans are where the older lines are in file, the oldest first, the
+	// ones before first having been dropped
+	file  *os.File
+	spans []span
+	first int
+}
+
+type span struct {
+	off, n int64
+}
+
+func (r *spillRing) len() int {
+	return len(r.spans) - r.first + len(r.mem)
+}
+
+func (r *spillRing) push(line string) {
+	if r.max <= 0 {
+		return
+	}
+	if r.len() == r.max {
+		r.drop()
+	}
+	r.mem = append(r.mem, line)
+	r.budget.charge(int64(len(line)))
+	for len(r.mem) != 0 && r.budget.over() {
+		if err := r.spillOldest(); err != nil {
+			// it stays in memory then
+			break
+		}
+	}
+}
+
+func (r *spillRing) drop() {
+	if r.first < len(r.spans) {
+		r.first++
+		r.compact()
+		return
+	}
+	r.budget.charge(-int64(len(r.mem[0])))
+	r.mem[0] = ""
+	r.mem = r.mem[1:]
+}
+
+func (r *spillRing) spillOldest() error {
+	if r.file == nil {
+		f, err := r.budget.tempFile()
+		if err != nil {
+			return err
+		}
+		r.file = f
+	}
+	line := r.mem[0]
+	s := span{off: r.end(), n: int64(len(line))}
+	if _, err := r.file.WriteAt([]byte(line), s.off); err != nil {
+		return err
+	}
+	r.spans = append(r.spans, s)
+	r.budget.spill(s.n)
+	r.mem[0] = ""
+	r.mem = r.mem[1:]
+	return nil
+}
+
+func (r *spillRing) end() int64 {
+	if len(r.spans) == 0 {
+		return 0
+	}
+	last := r.spans[len(r.spans)-1]
+	return last.off + last.n
+}
+
+// compact moves the lines still in the file to its start once the dropped
+// ones take more room than them, for the file not to keep growing.
+func (r *spillRing) compact() {
+	if r.first == len(r.spans) {
+		r.spans, r.first = r.spans[:0], 0
+		r.file.Truncate(0)
+		return
+	}
+	dead, end := r.spans[r.first].off, r.end()
+	if dead < end-dead {
+		return
+	}
+	buf := make([]byte, 32<<10)
+	for from, to := dead, int64(0); from < end; {
+		n, err := r.file.ReadAt(buf[:min64(int64(len(buf)), end-from)], from)
+		if n > 0 {
+			if _, werr := r.file.WriteAt(buf[:n], to); werr != nil {
+				return
+			}
+		}
+		if err != nil && n == 0 {
+			return
+		}
+		from, to = from+int64(n), to+int64(n)
+	}
+	r.file.Truncate(end - dead)
+	live := r.spans[r.first:]
+	for i := range live {
+		live[i].off -= dead
+	}
+	r.spans = append(r.spans[:0], live...)
+	r.first = 0
+}
+
+// get returns the i-th line remembered, the oldest being the 0-th.
+func (r *spillRing) get(i int) (string, bool) {
+	if i < 0 || i >= r.len() {
+		return "", false
+	}
+	if spilled := len(r.spans) - r.first; i >= spilled {
+		return r.mem[i-spilled], true
+	}
+	s := r.spans[r.first+i]
+	buf := make([]byte, s.n)
+	if _, err := r.file.ReadAt(buf, s.off); err != nil {
+		return "", false
+	}
+	return string(buf), true
+}
+
+// each calls fn with the lines remembered, the oldest first.
+func (r *spillRing) each(fn func(string)) {
+	for i := 0; i < r.len(); i++ {
+		if line, ok := r.get(i); ok {
+			fn(line)
+		}
+	}
+}
+
+// reset forgets the lines remembered.
+func (r *spillRing) reset() {
+	for _, line := range r.mem {
+		r.budget.charge(-int64(len(line)))
+	}
+	r.mem = nil
+	r.spans, r.first = r.spans[:0], 0
+	if r.file != nil {
+		r.file.Truncate(0)
+	}
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
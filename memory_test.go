@@ -0,0 +1,102 @@
+package humanlog
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"1024":     1024,
+		"64MiB":    64 << 20,
+		"1GB":      1e9,
+		"1.5 KiB":  1536,
+		"100bytes": 100,
+	}
+	for in, want := range tests {
+		got, err := ParseSize(in)
+		if err != nil || got != want {
+			t.Errorf("%q: want %d, got %d (%v)", in, want, got, err)
+		}
+	}
+	for _, in := range []string{"", "MiB", "10ms", "10 parsecs"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("%q: want an error", in)
+		}
+	}
+}
+
+func ringLines(r *spillRing) []string {
+	var lines []string
+	r.each(func(line string) { lines = append(lines, line) })
+	return lines
+}
+
+func TestSpillRing(t *testing.T) {
+	budget := NewMemoryBudget(10)
+	defer budget.Close()
+	r := &spillRing{max: 4, budget: budget}
+	for i := 0; i < 10; i++ {
+		r.push("line" + strconv.Itoa(i))
+	}
+
+	if want := []string{"line6", "line7", "line8", "line9"}; !reflect.DeepEqual(ringLines(r), want) {
+		t.Errorf("want %q, got %q", want, ringLines(r))
+	}
+	if used := budget.Used(); used > 10 {
+		t.Errorf("want at most 10 bytes in memory, got %d", used)
+	}
+	if budget.Spilled() == 0 {
+		t.Error("want lines moved to disk")
+	}
+	if line, ok := r.get(0); !ok || line != "line6" {
+		t.Errorf("want the oldest line read back from disk, got %q", line)
+	}
+	// the dropped lines don't pile up in the file
+	if fi, err := r.file.Stat(); err != nil || fi.Size() > int64(len("line0")*4) {
+		t.Errorf("want the file compacted, got %v (%v)", fi.Size(), err)
+	}
+
+	r.reset()
+	if r.len() != 0 || budget.Used() != 0 {
+		t.Errorf("want nothing left, got %d lines and %d bytes", r.len(), budget.Used())
+	}
+	r.push("again")
+	if want := []string{"again"}; !reflect.DeepEqual(ringLines(r), want) {
+		t.Errorf("want %q, got %q", want, ringLines(r))
+	}
+}
+
+func TestSpillRing_NoBudget(t *testing.T) {
+	r := &spillRing{max: 2}
+	for _, line := range []string{"a", "b", "c"} {
+		r.push(line)
+	}
+	if want := []string{"b", "c"}; !reflect.DeepEqual(ringLines(r), want) {
+		t.Errorf("want %q, got %q", want, ringLines(r))
+	}
+	if r.file != nil {
+		t.Error("want nothing on disk")
+	}
+}
+
+func TestTruncations_Budget(t *testing.T) {
+	budget := NewMemoryBudget(100)
+	defer budget.Close()
+	truncs := NewTruncations(budget)
+	long := strings.Repeat("x", 60)
+	for i := 0; i < 5; i++ {
+		truncs.add("k"+strconv.Itoa(i), long)
+	}
+	for i := 0; i < 5; i++ {
+		key, value, ok := truncs.Get(i + 1)
+		if !ok || key != "k"+strconv.Itoa(i) || value != long {
+			t.Errorf("%d: got %q=%q, %v", i+1, key, value, ok)
+		}
+	}
+	if budget.Used() > 100 {
+		t.Errorf("want at most 100 bytes in memory, got %d", budget.Used())
+	}
+}
@@ -0,0 +1,161 @@
+package humanlog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrMapUnsupported is returned by MapFile on the platforms where files
+// can't be mapped in memory.
+var ErrMapUnsupported = errors.New("mapping files in memory isn't supported on this platform")
+
+// MappedFile is a file mapped in memory, from the offset it was at when it
+// was mapped. Reading entries out of it scans the mapping for newlines
+// instead of copying the file in small reads, which is much faster on huge
+// files.
+type MappedFile struct {
+	data   []byte
+	base   int64
+	pos    int
+	unmap  func() error
+	stop   <-chan struct{}
+	status *progress
+}
+
+// MapFile maps f in memory, from its current offset to its end. The file
+// must not change while it's mapped: reading what was truncated from it
+// kills the process with SIGBUS.
+func MapFile(f *os.File) (*MappedFile, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s isn't a regular file", f.Name())
+	}
+	base, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if base > fi.Size() {
+		// what the file had up to the offset was truncated since
+		return nil, fmt.Errorf("%s is read from %d, past its end at %d", f.Name(), base, fi.Size())
+	}
+	m := &MappedFile{base: base, unmap: func() error { return nil }}
+	if fi.Size() == 0 {
+		return m, nil
+	}
+	data, unmap, err := mapFile(f, fi.Size())
+	if err != nil {
+		return nil, err
+	}
+	m.data, m.unmap = data[base:], unmap
+	if bytes.HasPrefix(m.data, bomUTF8) {
+		m.data, m.base = m.data[len(bomUTF8):], m.base+int64(len(bomUTF8))
+	}
+	return m, nil
+}
+
+// ShowProgress draws a progress bar onto w, a terminal, as the file is read.
+func (m *MappedFile) ShowProgress(w io.Writer) {
+	m.status = newProgress(w, int64(len(m.data)))
+}
+
+// Read copies the rest of the file into p, for the readers that don't know
+// about mappings.
+func (m *MappedFile) Read(p []byte) (int, error) {
+	if m.stopped() || m.pos >= len(m.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[m.pos:])
+	m.pos += n
+	return n, nil
+}
+
+// Close unmaps the file. Nothing read out of it can be used afterwards.
+func (m *MappedFile) Close() error {
+	m.status.done()
+	m.data = nil
+	return m.unmap()
+}
+
+func (m *MappedFile) stopped() bool {
+	select {
+	case <-m.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// isUTF8 tells whether the lines can be used as they're mapped, without
+// converting them to UTF-8 first.
+func (m *MappedFile) isUTF8(encoding string) bool {
+	switch strings.ToLower(strings.Replace(encoding, "_", "-", -1)) {
+	case "", "auto":
+		head := m.data
+		if len(head) > 4 {
+			head = head[:4]
+		}
+		if bytes.HasPrefix(head, bomUTF16LE) || bytes.HasPrefix(head, bomUTF16BE) ||
+			len(head) == 4 && (head[1] == 0 && head[3] == 0 || head[0] == 0 && head[2] == 0) {
+			return false
+		}
+		// the same guess decodeInput makes from its first read
+		sniffed := m.data
+		if len(sniffed) > sniffLen {
+			sniffed = sniffed[:sniffLen]
+		}
+		return !isLatin1(sniffed)
+	case "utf-8", "utf8":
+		return true
+	default:
+		return false
+	}
+}
+
+// isWrapped tells whether the file is compressed or multiplexed, and has to
+// be read like a stream.
+func (m *MappedFile) isWrapped() bool {
+	head := m.data
+	if len(head) > 8 {
+		head = head[:8]
+	}
+	gzipped, multiplexed := isWrapped(head)
+	return gzipped || multiplexed
+}
+
+// mappedLines returns the lines of the mapping, as slices of it.
+type mappedLines struct {
+	m    *MappedFile
+	line uint64
+}
+
+func (r *mappedLines) next() (record, bool) {
+	m := r.m
+	if m.pos >= len(m.data) || r.line&0xfff == 0 && m.stopped() {
+		return record{}, false
+	}
+	start := m.pos
+	data := m.data[start:]
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data = data[:i]
+		m.pos += i + 1
+	} else {
+		m.pos = len(m.data)
+	}
+	data = bytes.TrimSuffix(data, []byte{'\r'})
+	r.line++
+	if m.status != nil && r.line&0xfff == 0 {
+		m.status.update(int64(m.pos))
+	}
+	return record{data: data, line: r.line, offset: m.base + int64(start)}, true
+}
+
+func (r *mappedLines) err() error { return nil }
+
+func (r *mappedLines) stop() {}
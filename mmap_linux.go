@@ -0,0 +1,17 @@
+package humanlog
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps the size bytes of f in memory, read only.
+func mapFile(f *os.File, size int64) ([]byte, func() error, error) {
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, nil, &os.PathError{Op: "mmap", Path: f.Name(), Err: err}
+	}
+	// the file is read once from start to end
+	_ = syscall.Madvise(data, syscall.MADV_SEQUENTIAL)
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
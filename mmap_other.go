@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package humanlog
+
+import "os"
+
+func mapFile(f *os.File, size int64) ([]byte, func() error, error) {
+	return nil, nil, ErrMapUnsupported
+}
@@ -0,0 +1,144 @@
+package humanlog
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMappedFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	content := "\xef\xbb\xbfskipped\r\n{\"msg\": \"one\"}\r\nplain\n{\"msg\": \"two\"}"
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(int64(len("\xef\xbb\xbfskipped\r\n")), io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := MapFile(f)
+	if err == ErrMapUnsupported {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	var got []string
+	err = Process(m, DefaultOptions, func(e *Entry, next Next) error {
+		got = append(got, string(e.Raw)+"@"+strconv.FormatInt(e.Offset, 10))
+		return next(e)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`{"msg": "one"}@12`, `plain@28`, `{"msg": "two"}@34`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("want\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestMappedFile_Latin1(t *testing.T) {
+	f, err := ioutil.TempFile("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	content := "{\"msg\": \"h\xe9llo\"}\nbye\n"
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := MapFile(f)
+	if err == ErrMapUnsupported {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if m.isUTF8("") {
+		t.Error("want Latin-1 input to be decoded rather than used as mapped")
+	}
+
+	// the same lines as when the input is read rather than mapped
+	raw := func(src io.Reader) []string {
+		var got []string
+		err := Process(src, DefaultOptions, func(e *Entry, next Next) error {
+			got = append(got, string(e.Raw))
+			return next(e)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	want, got := raw(strings.NewReader(content)), raw(m)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("want\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+	if want := `{"msg": "héllo"}`; len(got) == 0 || got[0] != want {
+		t.Errorf("want %q first, got %q", want, got)
+	}
+}
+
+func TestMappedFile_PastEnd(t *testing.T) {
+	f, err := ioutil.TempFile("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := f.WriteString("hello world\n"); err != nil {
+		t.Fatal(err)
+	}
+	// like stdin left past the end of a file that was truncated since
+	if _, err := f.Seek(100, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if m, err := MapFile(f); err == nil {
+		m.Close()
+		t.Error("want an error for an offset past the end")
+	}
+}
+
+func TestMappedFile_Read(t *testing.T) {
+	m := &MappedFile{data: []byte("a\nb\n"), unmap: func() error { return nil }}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "a\nb\n" {
+		t.Errorf("want the whole mapping, got %q", buf.String())
+	}
+
+	stop := make(chan struct{})
+	close(stop)
+	m = &MappedFile{data: []byte("a\nb\n"), stop: stop, unmap: func() error { return nil }}
+	if n, err := m.Read(make([]byte, 10)); n != 0 || err != io.EOF {
+		t.Errorf("want EOF once stopped, got %d, %v", n, err)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	var buf bytes.Buffer
+	p := &progress{w: &buf, total: 4 << 20}
+	p.update(1 << 20)
+	p.done()
+	want := "\r[=======                       ]  25% 1.0MiB / 4.0MiB \r\x1b[K"
+	if buf.String() != want {
+		t.Errorf("want %q, got %q", want, buf.String())
+	}
+}
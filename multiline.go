@@ -0,0 +1,251 @@
+package humanlog
+
+import (
+	"bytes"
+	"time"
+)
+
+// maxRecordLines caps how many lines are assembled into a single record, in
+// case a start pattern never matches again or brackets never balance.
+const maxRecordLines = 1000
+
+// assembler groups lines into records, either because they continue a JSON
+// document whose brackets aren't balanced yet, or because they don't match
+// the pattern records start with. A record is also complete when no line
+// came in for a while.
+type assembler struct {
+	opts  *HandlerOptions
+	lines chan record
+	out   chan record
+	src   recordSource
+	// done is closed when the records aren't read anymore, for the
+	// goroutines not to wait for a reader that's gone.
+	done chan struct{}
+}
+
+func newAssembler(src recordSource, opts *HandlerOptions) *assembler {
+	a := &assembler{
+		opts:  opts,
+		lines: make(chan record),
+		out:   make(chan record),
+		src:   src,
+		done:  make(chan struct{}),
+	}
+	go a.readLines()
+	go a.assemble()
+	return a
+}
+
+func (a *assembler) next() (record, bool) {
+	rec, ok := <-a.out
+	return rec, ok
+}
+
+// err is only meaningful once next returned false.
+func (a *assembler) err() error { return a.src.err() }
+
+func (a *assembler) stop() {
+	close(a.done)
+	a.src.stop()
+}
+
+// send passes rec on to the lines, unless they aren't read anymore.
+func (a *assembler) send(rec record) bool {
+	select {
+	case a.lines <- rec:
+		return true
+	case <-a.done:
+		return false
+	}
+}
+
+func (a *assembler) readLines() {
+	defer close(a.lines)
+	var array arrayLines
+	for {
+		rec, ok := a.src.next()
+		if !ok {
+			return
+		}
+		rec.data = append([]byte(nil), rec.data...)
+		if !a.opts.MultilineJSON {
+			if !a.send(rec) {
+				return
+			}
+			continue
+		}
+		for _, rec := range array.split(rec) {
+			if !a.send(rec) {
+				return
+			}
+		}
+	}
+}
+
+// arrayLines reads a stream that's a JSON array of objects, printed a line
+// per field, like log stream --style json writes on macOS, as the objects
+// it holds: without the brackets of the array, and with the lines between
+// objects split.
+type arrayLines struct {
+	started, inArray bool
+}
+
+func (l *arrayLines) split(rec record) []record {
+	trimmed := bytes.TrimSpace(rec.data)
+	if !l.started {
+		if len(trimmed) == 0 {
+			return []record{rec}
+		}
+		l.started = true
+		if !bytes.Equal(trimmed, []byte("[")) && !bytes.Equal(trimmed, []byte("[{")) {
+			return []record{rec}
+		}
+		l.inArray = true
+		rec.data = trimmed[1:]
+		if len(rec.data) == 0 {
+			return nil
+		}
+		return []record{rec}
+	}
+	if !l.inArray {
+		return []record{rec}
+	}
+	switch string(trimmed) {
+	case "},{":
+		next := rec
+		rec.data, next.data = []byte("}"), []byte("{")
+		return []record{rec, next}
+	case "}]", "},":
+		rec.data = []byte("}")
+	case "]", ",":
+		return nil
+	}
+	return []record{rec}
+}
+
+func (a *assembler) assemble() {
+	defer close(a.out)
+
+	timeout := a.opts.MultilineTimeout
+	if timeout <= 0 {
+		timeout = DefaultOptions.MultilineTimeout
+	}
+	timer := time.NewTimer(timeout)
+	stopTimer := func() {
+		// drain what the timer may have sent already, for the next Reset
+		// not to be followed by a stale tick
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+	stopTimer()
+
+	var (
+		pending  *record
+		count    int
+		brackets bracketState
+	)
+	flush := func() bool {
+		stopTimer()
+		if pending != nil {
+			select {
+			case a.out <- *pending:
+			case <-a.done:
+				return false
+			}
+		}
+		pending, count, brackets = nil, 0, bracketState{}
+		return true
+	}
+	start := func(rec record) {
+		pending, count = &rec, 1
+		if a.opts.MultilineJSON {
+			trimmed := bytes.TrimSpace(rec.data)
+			if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+				brackets.open = true
+				brackets.feed(trimmed)
+			}
+		}
+	}
+
+	for {
+		select {
+		case rec, ok := <-a.lines:
+			if !ok {
+				flush()
+				return
+			}
+			switch {
+			case pending == nil:
+				start(rec)
+			case brackets.unbalanced():
+				pending.data = append(append(pending.data, '\n'), rec.data...)
+				brackets.feed(rec.data)
+				count++
+			case a.opts.MultilineStart != nil && !a.opts.MultilineStart.Match(rec.data) && !brackets.open:
+				pending.data = append(append(pending.data, '\n'), rec.data...)
+				count++
+			default:
+				if !flush() {
+					return
+				}
+				start(rec)
+			}
+
+			flushed := true
+			switch {
+			case count >= maxRecordLines:
+				flushed = flush()
+			case brackets.open && !brackets.unbalanced():
+				// a complete JSON document doesn't wait for what follows
+				flushed = flush()
+			case a.opts.MultilineStart == nil && !brackets.unbalanced():
+				flushed = flush()
+			default:
+				stopTimer()
+				timer.Reset(timeout)
+			}
+			if !flushed {
+				return
+			}
+
+		case <-timer.C:
+			if !flush() {
+				return
+			}
+		case <-a.done:
+			return
+		}
+	}
+}
+
+// bracketState tracks the nesting of a JSON document fed to it in pieces.
+type bracketState struct {
+	open     bool
+	depth    int
+	inString bool
+	escaped  bool
+}
+
+func (b *bracketState) unbalanced() bool { return b.open && b.depth > 0 }
+
+func (b *bracketState) feed(data []byte) {
+	for _, c := range data {
+		switch {
+		case b.escaped:
+			b.escaped = false
+		case b.inString && c == '\\':
+			b.escaped = true
+		case c == '"':
+			b.inString = !b.inString
+		case b.inString:
+		case c == '{' || c == '[':
+			b.depth++
+		case c == '}' || c == ']':
+			b.depth--
+		}
+	}
+}
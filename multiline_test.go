@@ -0,0 +1,163 @@
+package humanlog
+
+import (
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readRecords(t *testing.T, input string, opts *HandlerOptions) []string {
+	t.Helper()
+	src := newRecordSource(newLineReader(strings.NewReader(input)), opts)
+	var got []string
+	for {
+		rec, ok := src.next()
+		if !ok {
+			break
+		}
+		got = append(got, string(rec.data))
+	}
+	if err := src.err(); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestAssembler_JSON(t *testing.T) {
+	opts := *DefaultOptions
+	opts.MultilineJSON = true
+
+	got := readRecords(t, "{\n  \"msg\": \"a } in a string\",\n  \"list\": [1,\n2]\n}\n{\"msg\": \"b\"}\nplain\n", &opts)
+	want := []string{
+		"{\n  \"msg\": \"a } in a string\",\n  \"list\": [1,\n2]\n}",
+		`{"msg": "b"}`,
+		"plain",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %q, got %q, want != got", want, got)
+	}
+}
+
+func TestAssembler_JSONArray(t *testing.T) {
+	opts := *DefaultOptions
+	opts.MultilineJSON = true
+
+	got := readRecords(t, "[{\n  \"msg\" : \"a\"\n},{\n  \"msg\" : \"b\"\n}]\n", &opts)
+	want := []string{"{\n  \"msg\" : \"a\"\n}", "{\n  \"msg\" : \"b\"\n}"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %q, got %q, want != got", want, got)
+	}
+
+	got = readRecords(t, "[1,\n2]\n", &opts)
+	if want := []string{"[1,\n2]"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want an array of values kept whole, got %q", got)
+	}
+}
+
+func TestAssembler_StartPattern(t *testing.T) {
+	opts := *DefaultOptions
+	opts.MultilineStart = regexp.MustCompile(`^\d{4}-`)
+
+	got := readRecords(t, "2021-01-01 first\n  at foo\n  at bar\n2021-01-02 second\n", &opts)
+	want := []string{
+		"2021-01-01 first\n  at foo\n  at bar",
+		"2021-01-02 second",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %q, got %q, want != got", want, got)
+	}
+}
+
+func TestMultilineHandler(t *testing.T) {
+	src := strings.Join([]string{
+		`{`,
+		`  "msg": "pretty",`,
+		`  "ctx": {"user": "alice"}`,
+		`}`,
+		`{"msg": "compact"}`,
+		`{`,
+		`  "msg": oops`,
+		`}`,
+		`{"msg": "after"}`,
+		`{`,
+		`  "msg": "cut short",`,
+	}, "\n")
+
+	type entry struct {
+		raw string
+		msg string
+	}
+	var got []entry
+	err := Process(strings.NewReader(src), DefaultOptions, Chain(Parse(DefaultOptions), func(e *Entry, next Next) error {
+		en := entry{raw: string(e.Raw)}
+		if e.Event != nil {
+			en.msg = e.Event.Message
+		}
+		got = append(got, en)
+		return next(e)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []entry{
+		{raw: "{\n  \"msg\": \"pretty\",\n  \"ctx\": {\"user\": \"alice\"}\n}", msg: "pretty"},
+		{raw: `{"msg": "compact"}`, msg: "compact"},
+		{raw: "{\n  \"msg\": oops\n}"},
+		{raw: `{"msg": "after"}`, msg: "after"},
+		{raw: "{\n  \"msg\": \"cut short\","},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %q, got %q, want != got", want, got)
+	}
+}
+
+func TestAssembler_Timeout(t *testing.T) {
+	opts := *DefaultOptions
+	opts.MultilineStart = regexp.MustCompile(`^\d{4}-`)
+	opts.MultilineTimeout = 10 * time.Millisecond
+
+	r, w := io.Pipe()
+	defer w.Close()
+	src := newRecordSource(newLineReader(r), &opts)
+	defer src.stop()
+
+	// with nothing after them, the records wait for the timeout, each time
+	for _, line := range []string{"2021-01-01 first", "2021-01-02 second"} {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			t.Fatal(err)
+		}
+		rec, ok := src.next()
+		if !ok || string(rec.data) != line {
+			t.Fatalf("want %q, got %q, want != got", line, rec.data)
+		}
+	}
+}
+
+func TestAssembler_Stop(t *testing.T) {
+	opts := *DefaultOptions
+	opts.MultilineJSON = true
+
+	src := newRecordSource(newLineReader(strings.NewReader(strings.Repeat("{\"msg\": \"a\"}\n", 100))), &opts)
+	if _, ok := src.next(); !ok {
+		t.Fatal("want a record")
+	}
+	src.stop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, ok := src.next(); !ok {
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("want the records to end once the source is stopped")
+	}
+}
@@ -0,0 +1,54 @@
+package humanlog
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mysqlLine matches the lines of the error log of MySQL and MariaDB, with
+// the error code and subsystem MySQL 8 adds:
+//
+//	2024-01-02T10:00:00.123456Z 0 [Warning] [MY-010068] [Server] CA certificate ca.pem is self signed.
+//	2024-01-02 10:00:00 140234 [Note] InnoDB: Buffer pool(s) load completed
+var mysqlLine = regexp.MustCompile(`^(\d{4}-\d\d-\d\d[T ]\d\d:\d\d:\d\d(?:\.\d+)?(?:Z|[+-]\d\d:\d\d)?) +(\d+) \[(System|Note|Warning|ERROR|Error)\](?: \[(MY-\d+)\])?(?: \[(\w+)\])? (.*)$`)
+
+// sniffMySQL tells the lines of MySQL servers by the time they start with.
+func sniffMySQL(d []byte) confidence {
+	if hasDatePrefix(d, 0) {
+		return likelyFormat
+	}
+	return notFormat
+}
+
+// tryMySQL handles the error log of MySQL and MariaDB servers. The thread,
+// error code and subsystem are fields, and the lines continuing an entry
+// are kept with its message.
+func tryMySQL(d []byte, h *LogfmtHandler) bool {
+	if len(d) < len("2006-01-02 15:04:05") || d[0] < '0' || d[0] > '9' {
+		return false
+	}
+	lines := strings.SplitN(string(d), "\n", 2)
+	m := mysqlLine.FindStringSubmatch(lines[0])
+	if m == nil {
+		return false
+	}
+	if t, ok := tryParseTime(m[1]); ok {
+		h.Time = t
+	}
+	switch m[3] {
+	case "System", "Note":
+		h.Level = "info"
+	case "Warning":
+		h.Level = "warn"
+	default:
+		h.Level = "error"
+	}
+	h.Message = m[6]
+	if len(lines) > 1 {
+		h.Message += "\n" + lines[1]
+	}
+	h.setNonEmptyField("thread", m[2])
+	h.setNonEmptyField("code", m[4])
+	h.setNonEmptyField("subsystem", m[5])
+	return true
+}
@@ -0,0 +1,51 @@
+package humanlog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMySQLLogs(t *testing.T) {
+	opts := *DefaultOptions
+	opts.MultilineTimeout = time.Minute
+	p, _ := LookupPreset("mysql")
+	p.Apply(&opts)
+
+	var got []*Event
+	err := ScanEvents(strings.NewReader(strings.Join([]string{
+		`2024-01-02T10:00:00.123456Z 0 [Warning] [MY-010068] [Server] CA certificate ca.pem is self signed.`,
+		`2024-01-02T10:00:01.000000Z 8 [ERROR] [MY-012592] [InnoDB] Operating system error number 2 in a file operation.`,
+		`2024-01-02 10:00:02 140234 [Note] InnoDB: Buffer pool(s) dump:`,
+		`  page 1`,
+		`  page 2`,
+	}, "\n")), &opts, func(ev *Event) error {
+		got = append(got, ev)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		level, message string
+		fields         map[string]string
+	}{
+		{level: "warn", message: "CA certificate ca.pem is self signed.", fields: map[string]string{"thread": "0", "code": "MY-010068", "subsystem": "Server"}},
+		{level: "error", message: "Operating system error number 2 in a file operation.", fields: map[string]string{"thread": "8", "code": "MY-012592", "subsystem": "InnoDB"}},
+		{level: "info", message: "InnoDB: Buffer pool(s) dump:\n  page 1\n  page 2", fields: map[string]string{"thread": "140234"}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %d entries, got %d", len(want), len(got))
+	}
+	for i, w := range want {
+		ev := got[i]
+		if ev.Level != w.level || ev.Message != w.message || !reflect.DeepEqual(ev.Fields, w.fields) {
+			t.Errorf("%d: want %s %q %v, got %s %q %v", i, w.level, w.message, w.fields, ev.Level, ev.Message, ev.Fields)
+		}
+	}
+	if want := time.Date(2024, 1, 2, 10, 0, 0, 123456e3, time.UTC); !got[0].Time.Equal(want) {
+		t.Errorf("want %s, got %s", want, got[0].Time)
+	}
+}
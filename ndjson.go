@@ -0,0 +1,71 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ndjsonRecord is the normalized shape of the entries, whatever the schema
+// they were logged with.
+type ndjsonRecord struct {
+	Time    string                     `json:"ts,omitempty"`
+	Level   string                     `json:"level,omitempty"`
+	Message string                     `json:"msg"`
+	Fields  map[string]json.RawMessage `json:"fields"`
+	Source  string                     `json:"source,omitempty"`
+}
+
+// NDJSON reads the log lines of src and writes each entry onto dst as a JSON
+// object on its own line, with the same keys whatever the input looked like:
+//
+//	{"ts":"2024-01-02T03:04:05Z","level":"info","msg":"started","fields":{"port":8080},"source":"api"}
+//
+// Numbers, booleans and null keep their JSON type. Lines that couldn't be parsed are written as
+// their msg, so that nothing is lost.
+func NDJSON(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+	enc := json.NewEncoder(dst)
+	enc.SetEscapeHTML(false)
+
+	stages := builtinStages(opts)
+	if len(opts.Sinks) != 0 {
+		stages = append(stages, sinkStage(opts))
+	}
+	stages = append(stages, func(e *Entry, next Next) error {
+		if err := enc.Encode(newNDJSONRecord(e, opts)); err != nil {
+			return err
+		}
+		return next(e)
+	})
+	return Process(src, opts, Chain(stages...))
+}
+
+func newNDJSONRecord(e *Entry, opts *HandlerOptions) *ndjsonRecord {
+	if e.Event == nil {
+		return &ndjsonRecord{Message: string(e.Raw), Fields: map[string]json.RawMessage{}}
+	}
+	ev := opts.visibleEvent(e.Event)
+	rec := &ndjsonRecord{
+		Level:   ev.Level,
+		Message: ev.Message,
+		Fields:  make(map[string]json.RawMessage, len(ev.Fields)),
+		Source:  opts.sourceOf(ev),
+	}
+	if !ev.Time.IsZero() {
+		rec.Time = ev.Time.Format(time.RFC3339Nano)
+	}
+	for k, v := range ev.Fields {
+		rec.Fields[k] = jsonValue(v)
+	}
+	return rec
+}
+
+// jsonValue returns v as it is if it's JSON, like the values of JSON entries
+// are, or as a JSON string otherwise.
+func jsonValue(v string) json.RawMessage {
+	if v != "" && json.Valid([]byte(v)) {
+		return json.RawMessage(v)
+	}
+	b, _ := json.Marshal(v)
+	return b
+}
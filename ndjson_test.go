@@ -0,0 +1,33 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNDJSON(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time": "2024-01-02T03:04:05Z", "level": "info", "msg": "started", "port": 8080, "service": "api", "pid": 1}`,
+		`level=warn msg="slow <query>" took=1.5 user=ann flag=true`,
+		`not structured`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.SetSkip([]string{"pid"})
+
+	var out bytes.Buffer
+	if err := NDJSON(strings.NewReader(src), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		`{"ts":"2024-01-02T03:04:05Z","level":"info","msg":"started","fields":{"port":8080,"service":"api"},"source":"api"}`,
+		`{"level":"warn","msg":"slow <query>","fields":{"flag":true,"took":1.5,"user":"ann"}}`,
+		`{"msg":"not structured","fields":{}}`,
+		``,
+	}, "\n")
+	if got := out.String(); got != want {
+		t.Errorf("want\n%s\ngot\n%s", want, got)
+	}
+}
@@ -0,0 +1,100 @@
+package humanlog
+
+import (
+	"strconv"
+	"strings"
+)
+
+// localeSeparators are the thousands and decimal separators of a few
+// languages and regions, by the first part of their locale name.
+var localeSeparators = map[string][2]string{
+	"en": {",", "."},
+	"us": {",", "."},
+	"uk": {",", "."},
+	"ja": {",", "."},
+	"zh": {",", "."},
+	"de": {".", ","},
+	"es": {".", ","},
+	"it": {".", ","},
+	"nl": {".", ","},
+	"pt": {".", ","},
+	"da": {".", ","},
+	"id": {".", ","},
+	"tr": {".", ","},
+	"fr": {"\u202f", ","},
+	"ru": {" ", ","},
+	"pl": {" ", ","},
+	"cs": {" ", ","},
+	"sv": {" ", ","},
+	"fi": {" ", ","},
+	"nb": {" ", ","},
+	"ch": {"'", "."},
+	"si": {"\u2009", "."},
+}
+
+// LocaleSeparators returns the thousands and decimal separators used by a
+// locale, like en_US.UTF-8 or de.
+func LocaleSeparators(locale string) (thousands, decimal string, ok bool) {
+	locale = strings.ToLower(locale)
+	if i := strings.IndexAny(locale, "_-.@"); i >= 0 {
+		if region := locale[i+1:]; strings.HasPrefix(region, "ch") {
+			locale = "ch"
+		} else {
+			locale = locale[:i]
+		}
+	}
+	seps, ok := localeSeparators[locale]
+	return seps[0], seps[1], ok
+}
+
+// formatsNumbers tells if numbers are rendered differently than they're
+// read.
+func (h *HandlerOptions) formatsNumbers() bool {
+	return h.ThousandsSeparator != "" || h.DecimalSeparator != "" || h.FloatPrecision > 0
+}
+
+// formatNumber renders a numeric value with the separators and precision of
+// the options. Values it doesn't understand are returned as they are.
+func (h *HandlerOptions) formatNumber(v string) string {
+	if !h.formatsNumbers() {
+		return v
+	}
+	if strings.ContainsAny(v, "eEnNiI") {
+		// exponents, NaN and Inf are left alone
+		return v
+	}
+	if h.FloatPrecision > 0 && strings.Contains(v, ".") {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return v
+		}
+		v = strconv.FormatFloat(f, 'f', h.FloatPrecision, 64)
+	}
+
+	sign := ""
+	if strings.HasPrefix(v, "-") || strings.HasPrefix(v, "+") {
+		sign, v = v[:1], v[1:]
+	}
+	intPart, frac := v, ""
+	if i := strings.IndexByte(v, '.'); i >= 0 {
+		intPart, frac = v[:i], v[i+1:]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteString(h.ThousandsSeparator)
+		}
+		sb.WriteRune(c)
+	}
+	if frac != "" || strings.HasSuffix(v, ".") {
+		if h.DecimalSeparator != "" {
+			sb.WriteString(h.DecimalSeparator)
+		} else {
+			sb.WriteString(".")
+		}
+		sb.WriteString(frac)
+	}
+	return sb.String()
+}
@@ -0,0 +1,53 @@
+package humanlog
+
+import "testing"
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		in        string
+		thousands string
+		decimal   string
+		precision int
+		want      string
+	}{
+		{in: "104857600", thousands: ",", want: "104,857,600"},
+		{in: "-1234567", thousands: ",", want: "-1,234,567"},
+		{in: "123", thousands: ",", want: "123"},
+		{in: "1234.5678", thousands: ".", decimal: ",", want: "1.234,5678"},
+		{in: "1234.5678", precision: 2, want: "1234.57"},
+		{in: "0.1", thousands: ",", precision: 3, want: "0.100"},
+		{in: "1.5e+10", thousands: ",", want: "1.5e+10"},
+		{in: "1234", want: "1234"},
+	}
+	for _, test := range tests {
+		opts := HandlerOptions{
+			ThousandsSeparator: test.thousands,
+			DecimalSeparator:   test.decimal,
+			FloatPrecision:     test.precision,
+		}
+		if got := opts.formatNumber(test.in); got != test.want {
+			t.Errorf("%q: want %q, got %q", test.in, test.want, got)
+		}
+	}
+}
+
+func TestLocaleSeparators(t *testing.T) {
+	tests := []struct {
+		locale             string
+		thousands, decimal string
+	}{
+		{locale: "en_US.UTF-8", thousands: ",", decimal: "."},
+		{locale: "de_DE", thousands: ".", decimal: ","},
+		{locale: "de_CH.UTF-8", thousands: "'", decimal: "."},
+		{locale: "fr", thousands: " ", decimal: ","},
+	}
+	for _, test := range tests {
+		thousands, decimal, ok := LocaleSeparators(test.locale)
+		if !ok || thousands != test.thousands || decimal != test.decimal {
+			t.Errorf("%s: want %q and %q, got %q and %q", test.locale, test.thousands, test.decimal, thousands, decimal)
+		}
+	}
+	if _, _, ok := LocaleSeparators("xx"); ok {
+		t.Error("want an unknown locale to be reported")
+	}
+}
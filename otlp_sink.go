@@ -0,0 +1,235 @@
+package humanlog
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	otlpTraceIDFields = []string{"trace_id", "traceId", "trace.id", "traceid"}
+	otlpSpanIDFields  = []string{"span_id", "spanId", "span.id", "spanid"}
+	otlpServiceFields = []string{"service.name", "service"}
+)
+
+// otlpSink turns the error entries into spans, posted to an OpenTelemetry
+// collector with OTLP over HTTP/JSON. Entries carrying a trace and span ID
+// show up as a child of that span, so the trace they belong to is flagged as
+// failing.
+type otlpSink struct {
+	endpoint string
+	service  string
+	client   *http.Client
+	retrier
+}
+
+func newOTLPSink(u *url.URL) *otlpSink {
+	scheme := "http"
+	if u.Scheme == "otlps" {
+		scheme = "https"
+	}
+	path := u.Path
+	if path == "" || path == "/" {
+		path = "/v1/traces"
+	}
+	service := u.Query().Get("service")
+	if service == "" {
+		service = "humanlog"
+	}
+	return &otlpSink{
+		endpoint: scheme + "://" + hostPort(u, "4318") + path,
+		service:  service,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		retrier:  retrier{backoff: DefaultBackoff},
+	}
+}
+
+func (s *otlpSink) Send(ev *Event) error {
+	if !isErrorLevel(ev.Level) {
+		return nil
+	}
+	if err := s.wait(); err != nil {
+		return err
+	}
+	body, err := json.Marshal(s.request(ev))
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return s.failed(err)
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return s.failed(fmt.Errorf("collector answered %s", resp.Status))
+	}
+	s.succeeded()
+	return nil
+}
+
+func (s *otlpSink) Close() error { return nil }
+
+func isErrorLevel(level string) bool {
+	switch strings.ToLower(level) {
+	case "error", "err", "fatal", "panic", "critical", "crit", "alert", "emerg":
+		return true
+	}
+	return false
+}
+
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID      string          `json:"traceId"`
+		SpanID       string          `json:"spanId"`
+		ParentSpanID string          `json:"parentSpanId,omitempty"`
+		Name         string          `json:"name"`
+		Kind         int             `json:"kind"`
+		Start        string          `json:"startTimeUnixNano"`
+		End          string          `json:"endTimeUnixNano"`
+		Attributes   []otlpAttribute `json:"attributes"`
+		Events       []otlpEvent     `json:"events"`
+		Status       otlpStatus      `json:"status"`
+	}
+	otlpEvent struct {
+		Time       string          `json:"timeUnixNano"`
+		Name       string          `json:"name"`
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+)
+
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusError      = 2
+)
+
+func (s *otlpSink) request(ev *Event) *otlpRequest {
+	rec := sinkRecord(ev)
+
+	service := s.service
+	if v, ok := firstField(rec, otlpServiceFields); ok {
+		service = v
+	}
+
+	traceID, ok := firstField(rec, otlpTraceIDFields)
+	if !ok || !isHexID(traceID, 16) {
+		traceID = randomHexID(16)
+	}
+	var parent string
+	if v, ok := firstField(rec, otlpSpanIDFields); ok && isHexID(v, 8) {
+		parent = v
+	}
+
+	ts := ev.Time
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	nanos := strconv.FormatInt(ts.UnixNano(), 10)
+
+	name := ev.Message
+	if name == "" {
+		name = "log"
+	}
+	attrs := otlpAttributes(rec)
+	return &otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpValue{StringValue: service}},
+		}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "humanlog"},
+			Spans: []otlpSpan{{
+				TraceID:      strings.ToLower(traceID),
+				SpanID:       randomHexID(8),
+				ParentSpanID: strings.ToLower(parent),
+				Name:         name,
+				Kind:         otlpSpanKindInternal,
+				Start:        nanos,
+				End:          nanos,
+				Attributes:   attrs,
+				Events:       []otlpEvent{{Time: nanos, Name: "log", Attributes: attrs}},
+				Status:       otlpStatus{Code: otlpStatusError, Message: ev.Message},
+			}},
+		}},
+	}}}
+}
+
+func otlpAttributes(rec map[string]string) []otlpAttribute {
+	keys := make([]string, 0, len(rec))
+	for k := range rec {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]otlpAttribute, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, otlpAttribute{Key: k, Value: otlpValue{StringValue: rec[k]}})
+	}
+	return attrs
+}
+
+func firstField(rec map[string]string, fields []string) (string, bool) {
+	for _, field := range fields {
+		if v, ok := rec[field]; ok && v != "" {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// isHexID tells if s is the hex encoding of an ID of n bytes, that isn't all
+// zeroes.
+func isHexID(s string, n int) bool {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != n {
+		return false
+	}
+	for _, c := range b {
+		if c != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func randomHexID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
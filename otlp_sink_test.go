@@ -0,0 +1,61 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestOTLPSink(t *testing.T) {
+	var got []otlpRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("want a post to /v1/traces, got %q", r.URL.Path)
+		}
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		got = append(got, req)
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	sink, err := NewSink("otlp://" + u.Host + "?service=api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	spanID := "00f067aa0ba902b7"
+	events := []*Event{
+		{Level: "info", Message: "fine", Fields: map[string]string{}},
+		{Level: "ERROR", Message: "boom", Fields: map[string]string{
+			"trace_id": `"` + traceID + `"`,
+			"span_id":  `"` + spanID + `"`,
+		}},
+	}
+	for _, ev := range events {
+		if err := sink.Send(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("want only the error sent, got %d requests", len(got))
+	}
+	rs := got[0].ResourceSpans[0]
+	if svc := rs.Resource.Attributes[0].Value.StringValue; svc != "api" {
+		t.Errorf("want service api, got %q", svc)
+	}
+	span := rs.ScopeSpans[0].Spans[0]
+	if span.TraceID != traceID || span.ParentSpanID != spanID {
+		t.Errorf("want span in trace %s under %s, got %s under %s", traceID, spanID, span.TraceID, span.ParentSpanID)
+	}
+	if span.Name != "boom" || span.Status.Code != otlpStatusError {
+		t.Errorf("want a failed span named boom, got %q with status %d", span.Name, span.Status.Code)
+	}
+}
@@ -0,0 +1,319 @@
+package humanlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPaneScrollback is how many lines a pane keeps, unless told.
+	DefaultPaneScrollback = 1000
+	// panesRedraw is how often the panes are redrawn at most.
+	panesRedraw = 100 * time.Millisecond
+)
+
+// Panes renders the entries in a grid of panes filling the terminal, one
+// per source, by SourceFields, rather than interleaved. Each pane keeps its
+// own lines, renders its entries on its own, and has its own filters, and
+// can be scrolled back. Lines that aren't entries, like those of a stack
+// trace, go to the pane of the entry before them.
+type Panes struct {
+	dst        io.Writer
+	opts       *HandlerOptions
+	scrollback int
+
+	mu            sync.Mutex
+	width, height int
+	panes         []*pane
+	bySource      map[string]*pane
+	last          *pane
+	dirty         bool
+
+	start   sync.Once
+	started bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+type pane struct {
+	source   string
+	r        *renderer
+	buf      bytes.Buffer
+	lines    []string
+	filters  LiveFilters
+	scrolled int
+}
+
+// NewPanes returns Panes drawing onto a terminal of width by height
+// characters, each keeping up to scrollback lines.
+func NewPanes(dst io.Writer, opts *HandlerOptions, width, height, scrollback int) *Panes {
+	if scrollback <= 0 {
+		scrollback = DefaultPaneScrollback
+	}
+	return &Panes{
+		dst:        dst,
+		opts:       opts,
+		scrollback: scrollback,
+		width:      width,
+		height:     height,
+		bySource:   make(map[string]*pane),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Resize redraws the panes for a terminal that's now width by height.
+func (p *Panes) Resize(width, height int) {
+	p.mu.Lock()
+	p.width, p.height = width, height
+	p.dirty = true
+	p.mu.Unlock()
+}
+
+// paneOf returns the pane of a source, adding it if it's new. It's called
+// with mu held.
+func (p *Panes) paneOf(source string) *pane {
+	pn, ok := p.bySource[source]
+	if !ok {
+		pn = &pane{source: source}
+		pn.r = newRenderer(&pn.buf, p.opts)
+		// a pane has no line below it for a bar, nor room for the lines
+		// before an entry
+		pn.r.bar, pn.r.tail = nil, nil
+		p.bySource[source] = pn
+		p.panes = append(p.panes, pn)
+	}
+	return pn
+}
+
+// Exec runs a command on the pane of a source:
+//
+//	api +key=value   change the filters of the pane, like LiveFilters.Exec
+//	api scroll 20    show the lines from 20 lines back
+//	api scroll 0     follow the lines as they come again
+//
+// The filters only apply to the entries that come next.
+func (p *Panes) Exec(cmd string) (string, error) {
+	fields := strings.Fields(cmd)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("want a source and a command, like api +level=error or api scroll 20")
+	}
+	source := fields[0]
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pn := p.paneOf(source)
+	p.dirty = true
+	if fields[1] == "scroll" {
+		if len(fields) != 3 {
+			return "", fmt.Errorf("want how many lines back to scroll")
+		}
+		n, err := strconv.Atoi(fields[2])
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("invalid number of lines %q", fields[2])
+		}
+		pn.scrolled = n
+		return fmt.Sprintf("%s scrolled %d lines back", source, n), nil
+	}
+	if err := pn.filters.Exec(strings.Join(fields[1:], " ")); err != nil {
+		return "", err
+	}
+	return source + " " + pn.filters.String(), nil
+}
+
+// render renders the entry into the pane of its source, if it goes through
+// the pane's filters.
+func (p *Panes) render(e *Entry, next Next) error {
+	p.start.Do(func() {
+		// clear the screen once, the panes are drawn over what follows
+		io.WriteString(p.dst, "\x1b[2J")
+		p.started = true
+		go p.loop()
+	})
+
+	p.mu.Lock()
+	pn := p.last
+	if e.Event != nil || pn == nil {
+		pn = p.paneOf(p.opts.sourceOf(e.Event))
+	}
+	p.last = pn
+	err := pn.filters.Stage(e, func(e *Entry) error {
+		pn.buf.Reset()
+		err := pn.r.render(e, endOfChain)
+		pn.addOutput(p.scrollback)
+		p.dirty = true
+		return err
+	})
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return next(e)
+}
+
+// addOutput adds the lines the renderer of the pane wrote, keeping up to
+// max of them.
+func (pn *pane) addOutput(max int) {
+	if pn.buf.Len() == 0 {
+		return
+	}
+	lines := strings.Split(strings.TrimSuffix(pn.buf.String(), "\n"), "\n")
+	pn.lines = append(pn.lines, lines...)
+	if over := len(pn.lines) - max; over > 0 {
+		// dropped in chunks, not to copy the lines on every one
+		if over < max/4 {
+			over = max / 4
+		}
+		pn.lines = append(pn.lines[:0], pn.lines[over:]...)
+	}
+	if pn.scrolled > 0 {
+		// a pane scrolled back keeps showing the same lines
+		pn.scrolled += len(lines)
+	}
+}
+
+func (p *Panes) loop() {
+	defer close(p.done)
+	tick := time.NewTicker(panesRedraw)
+	defer tick.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-tick.C:
+			p.mu.Lock()
+			if p.dirty {
+				p.draw()
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// finish draws the panes one last time, with what the renderers of the
+// panes only write once the entries ended, and leaves the cursor below them.
+func (p *Panes) finish() {
+	if !p.started {
+		return
+	}
+	close(p.stop)
+	<-p.done
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, pn := range p.panes {
+		pn.buf.Reset()
+		pn.r.finish()
+		pn.addOutput(p.scrollback)
+	}
+	p.draw()
+	fmt.Fprintf(p.dst, "\x1b[%d;1H\n", p.height)
+}
+
+// paneGrid is how many columns and rows of panes n panes are laid out in,
+// as square as can be.
+func paneGrid(n int) (cols, rows int) {
+	if n == 0 {
+		return 0, 0
+	}
+	cols = int(math.Ceil(math.Sqrt(float64(n))))
+	rows = (n + cols - 1) / cols
+	return cols, rows
+}
+
+// draw writes the panes over the screen, each framed by a title line with
+// its source. It's called with mu held.
+func (p *Panes) draw() {
+	p.dirty = false
+	cols, rows := paneGrid(len(p.panes))
+	if cols == 0 {
+		return
+	}
+	// the columns of panes are separated by a column of their own
+	paneWidth := (p.width - (cols - 1)) / cols
+	paneHeight := p.height / rows
+	if paneWidth < 1 || paneHeight < 2 {
+		return
+	}
+
+	var screen bytes.Buffer
+	for i, pn := range p.panes {
+		top, left := (i/cols)*paneHeight+1, (i%cols)*(paneWidth+1)+1
+		sep := ""
+		if i%cols != cols-1 {
+			sep = "│"
+		}
+
+		title := pn.source
+		if title == "" {
+			title = "(no source)"
+		}
+		if pn.scrolled > 0 {
+			title += fmt.Sprintf(" ↑%d", pn.scrolled)
+		}
+		title = truncateWidth(" "+title+" ", paneWidth)
+		fmt.Fprintf(&screen, "\x1b[%d;%dH%s%s%s", top, left,
+			p.opts.SourceColor(pn.source).Sprint(title),
+			p.opts.ContextColor.Sprint(strings.Repeat("─", paneWidth-displayWidth(title))), sep)
+
+		end := len(pn.lines) - pn.scrolled
+		if end < 0 {
+			end = 0
+		}
+		start := end - (paneHeight - 1)
+		if start < 0 {
+			start = 0
+		}
+		for row := 1; row < paneHeight; row++ {
+			var line string
+			if j := start + row - 1; j < end {
+				line = pn.lines[j]
+			}
+			clipped, w := clipANSI(line, paneWidth)
+			fmt.Fprintf(&screen, "\x1b[%d;%dH%s%s%s", top+row, left, clipped, strings.Repeat(" ", paneWidth-w), sep)
+		}
+	}
+	p.dst.Write(screen.Bytes())
+}
+
+// clipANSI returns the start of s that fits in width columns, with its
+// escape sequences kept but not counted, and the colors reset after it,
+// along with how many columns it takes.
+func clipANSI(s string, width int) (string, int) {
+	var out strings.Builder
+	used, colored := 0, false
+	for len(s) > 0 {
+		if loc := ansiEscape.FindStringIndex(s); loc != nil && loc[0] == 0 {
+			out.WriteString(s[:loc[1]])
+			s, colored = s[loc[1]:], true
+			continue
+		}
+		// the text up to the next escape sequence, which mustn't be taken
+		// for a part of its last character
+		text := s
+		if i := strings.IndexByte(s[1:], '\x1b'); i >= 0 {
+			text = s[:i+1]
+		}
+		for len(text) > 0 {
+			size, w := nextCluster(text)
+			if used+w > width {
+				if colored {
+					out.WriteString("\x1b[0m")
+				}
+				return out.String(), used
+			}
+			out.WriteString(text[:size])
+			used += w
+			text, s = text[size:], s[size:]
+		}
+	}
+	if colored {
+		out.WriteString("\x1b[0m")
+	}
+	return out.String(), used
+}
@@ -0,0 +1,85 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestPanes(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	opts := *DefaultOptions
+	var out bytes.Buffer
+	p := NewPanes(&out, &opts, 60, 8, 3)
+	opts.Panes = p
+	if _, err := p.Exec("api -msg=listening"); err != nil {
+		t.Fatal(err)
+	}
+
+	src := strings.Join([]string{
+		`{"level":"info","msg":"listening","service":"api"}`,
+		`{"level":"error","msg":"db down","service":"worker"}`,
+		`  at db.connect`,
+		`{"level":"debug","msg":"retrying","service":"worker"}`,
+		`{"level":"info","msg":"tick","service":"cron"}`,
+		`{"level":"info","msg":"one","service":"api"}`,
+		`{"level":"info","msg":"two","service":"api"}`,
+		`{"level":"info","msg":"three","service":"api"}`,
+	}, "\n")
+	if err := Scanner(strings.NewReader(src), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := func(source string) string {
+		var msgs []string
+		for _, line := range p.bySource[source].lines {
+			if i := strings.Index(line, "| "); i >= 0 {
+				line = line[i+2:]
+			}
+			msgs = append(msgs, strings.Fields(line)[0])
+		}
+		return strings.Join(msgs, ",")
+	}
+	// the line after the error goes with it, the pane of api filters out
+	// what it was told to and only keeps its last lines
+	if got := messages("worker"); got != "db,at,retrying" {
+		t.Errorf("worker: got %q", got)
+	}
+	if got := messages("api"); got != "one,two,three" {
+		t.Errorf("api: got %q", got)
+	}
+	if len(p.panes) != 3 || p.panes[0].source != "api" {
+		t.Errorf("want the panes in the order they came, got %d", len(p.panes))
+	}
+	if !strings.Contains(out.String(), " cron ") {
+		t.Error("want the title of the cron pane drawn")
+	}
+
+	for _, cmd := range []string{"api", "api scroll", "api scroll -1", "api frobnicate"} {
+		if _, err := p.Exec(cmd); err == nil {
+			t.Errorf("%q: want an error", cmd)
+		}
+	}
+}
+
+func TestPaneGrid(t *testing.T) {
+	for n, want := range map[int][2]int{0: {0, 0}, 1: {1, 1}, 2: {2, 1}, 3: {2, 2}, 4: {2, 2}, 5: {3, 2}, 10: {4, 3}} {
+		if cols, rows := paneGrid(n); cols != want[0] || rows != want[1] {
+			t.Errorf("%d panes: want %dx%d, got %dx%d", n, want[0], want[1], cols, rows)
+		}
+	}
+}
+
+func TestClipANSI(t *testing.T) {
+	got, w := clipANSI("\x1b[31mERRO\x1b[0m db down", 7)
+	if got != "\x1b[31mERRO\x1b[0m db\x1b[0m" || w != 7 {
+		t.Errorf("got %q %d", got, w)
+	}
+	if got, w := clipANSI("日本語", 5); got != "日本" || w != 4 {
+		t.Errorf("got %q %d", got, w)
+	}
+}
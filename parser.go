@@ -0,0 +1,130 @@
+package humanlog
+
+import (
+	"bytes"
+)
+
+// confidence is how likely a line is in a format, as told by the format's
+// sniff from a glance at the line, without parsing it.
+type confidence int
+
+const (
+	// notFormat lines can't be in the format, which isn't tried on them.
+	notFormat confidence = iota
+	// mayBeFormat lines have what the format needs, like logfmt's '='.
+	mayBeFormat
+	// likelyFormat lines start the way the format's lines do.
+	likelyFormat
+	// surelyFormat lines have the signature of the format.
+	surelyFormat
+)
+
+// lineFormat is a format of the registry of the parser. Its sniff is cheap,
+// so that lines are only parsed in the formats they may be in.
+type lineFormat struct {
+	name  string
+	entry handler
+	// last tells whether the previous line was handled by the same entry.
+	last  *bool
+	sniff func(d []byte) confidence
+	try   func(d []byte) bool
+}
+
+// parser tries the formats humanlog knows about on a line, remembering which
+// entry handled the previous line so unchanged keys can be skipped.
+type parser struct {
+	opts        *HandlerOptions
+	jsonEntry   JSONHandler
+	logfmtEntry LogfmtHandler
+	w3c         w3cFields
+
+	// formats are tried in this order among those sniffed as confidently.
+	formats []lineFormat
+	sniffed []confidence
+
+	lastJSON   bool
+	lastLogfmt bool
+}
+
+func newParser(opts *HandlerOptions) *parser {
+	drop := opts.droppedKeys()
+	p := &parser{
+		opts:        opts,
+		jsonEntry:   JSONHandler{Opts: opts, drop: drop},
+		logfmtEntry: LogfmtHandler{Opts: opts, drop: drop},
+	}
+	js, lf := &p.jsonEntry, &p.logfmtEntry
+	p.formats = []lineFormat{
+		{"envoy", lf, &p.lastLogfmt, sniffEnvoy, func(d []byte) bool { return tryEnvoy(d, lf) }},
+		{"json", js, &p.lastJSON, sniffPrettyJSON, js.tryStartPretty},
+		{"json", js, &p.lastJSON, sniffJSON, js.TryHandle},
+		{"cri json", js, &p.lastJSON, sniffCRI, func(d []byte) bool { return tryCRIPrefix(d, js) }},
+		{"cri logfmt", lf, &p.lastLogfmt, sniffCRI, func(d []byte) bool { return tryCRIPrefix(d, lf) }},
+		{"postgres", lf, &p.lastLogfmt, sniffPostgres, func(d []byte) bool { return tryPostgres(d, lf) }},
+		{"mysql", lf, &p.lastLogfmt, sniffMySQL, func(d []byte) bool { return tryMySQL(d, lf) }},
+		{"docker json", js, &p.lastJSON, sniffDockerTimestamp, func(d []byte) bool { return tryDockerTimestamp(d, js) }},
+		{"docker logfmt", lf, &p.lastLogfmt, sniffDockerTimestamp, func(d []byte) bool { return tryDockerTimestamp(d, lf) }},
+		{"cri", js, &p.lastJSON, sniffCRI, func(d []byte) bool { return tryCRIPlainText(d, js) }},
+		{"w3c", lf, &p.lastLogfmt, p.w3c.sniff, func(d []byte) bool { return p.w3c.tryHandle(d, lf) }},
+		{"logrus", lf, &p.lastLogfmt, sniffLogrusText, func(d []byte) bool { return tryLogrusText(d, lf) }},
+		{"logfmt", lf, &p.lastLogfmt, sniffLogfmt, lf.TryHandle},
+		{"docker-compose json", js, &p.lastJSON, sniffDockerComposePrefix, func(d []byte) bool { return tryDockerComposePrefix(d, js) }},
+		{"docker-compose logfmt", lf, &p.lastLogfmt, sniffDockerComposePrefix, func(d []byte) bool { return tryDockerComposePrefix(d, lf) }},
+		{"zap", js, &p.lastJSON, sniffZapDevPrefix, func(d []byte) bool { return tryZapDevPrefix(d, js) }},
+	}
+	p.sniffed = make([]confidence, len(p.formats))
+	return p
+}
+
+// trimSyslog removes that pesky syslog crap.
+func trimSyslog(lineData []byte) []byte {
+	lineData = bytes.TrimPrefix(lineData, []byte("@cee: "))
+	return bytes.TrimPrefix(lineData, []byte("@cee:"))
+}
+
+// hasDatePrefix tells whether d starts with a date like 2006-01-02, followed
+// by sep, or by a T or a space if sep is 0.
+func hasDatePrefix(d []byte, sep byte) bool {
+	if len(d) < len("2006-01-02 15:04:05") || d[4] != '-' || d[7] != '-' || d[13] != ':' {
+		return false
+	}
+	for _, i := range []int{0, 1, 2, 3, 5, 6, 8, 9} {
+		if d[i] < '0' || d[i] > '9' {
+			return false
+		}
+	}
+	if sep == 0 {
+		return d[10] == 'T' || d[10] == ' '
+	}
+	return d[10] == sep
+}
+
+// parseResult tells which handler recognized a line, if any did. skip tells
+// whether the previous line was handled by the same handler.
+type parseResult struct {
+	entry handler
+	name  string
+	skip  bool
+}
+
+// parse tries the formats the line was sniffed in, the most confidently
+// sniffed first, and the formats sniffed as confidently in their order.
+func (p *parser) parse(lineData []byte) parseResult {
+	for i := range p.formats {
+		p.sniffed[i] = p.formats[i].sniff(lineData)
+	}
+	for c := surelyFormat; c > notFormat; c-- {
+		for i := range p.formats {
+			f := &p.formats[i]
+			if p.sniffed[i] != c || !f.try(lineData) {
+				continue
+			}
+			skip := *f.last
+			*f.last = true
+			return parseResult{entry: f.entry, name: f.name, skip: skip}
+		}
+	}
+	p.lastLogfmt = false
+	p.lastJSON = false
+	return parseResult{}
+}
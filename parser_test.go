@@ -0,0 +1,94 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParser_FormatOfExamples(t *testing.T) {
+	names := map[string]string{
+		"envoy":          "envoy",
+		"json":           "json",
+		"cri":            "cri json",
+		"postgres":       "postgres",
+		"mysql":          "mysql",
+		"docker":         "docker json",
+		"w3c":            "w3c",
+		"logrus":         "logrus",
+		"logfmt":         "logfmt",
+		"docker-compose": "docker-compose json",
+		"zap":            "zap",
+	}
+	for _, f := range InputFormats {
+		p := newParser(DefaultOptions)
+		var res parseResult
+		for _, line := range strings.Split(f.Example, "\n") {
+			res = p.parse([]byte(line))
+		}
+		if res.name != names[f.Name] {
+			t.Errorf("%s: want the example parsed as %q, got %q", f.Name, names[f.Name], res.name)
+		}
+	}
+}
+
+func TestParser_Sniff(t *testing.T) {
+	p := newParser(DefaultOptions)
+	for _, tt := range []struct {
+		line string
+		want map[string]confidence
+	}{
+		{
+			line: `{"level":"info","msg":"listening"}`,
+			want: map[string]confidence{"json": surelyFormat, "logfmt": notFormat, "cri json": notFormat},
+		},
+		{
+			line: `2024-01-02T10:00:00.123456789Z stdout F level=info msg=listening`,
+			want: map[string]confidence{"cri logfmt": surelyFormat, "docker logfmt": likelyFormat, "postgres": notFormat, "logfmt": mayBeFormat},
+		},
+		{
+			line: `2024-01-02 10:00:00.123 UTC [12345] LOG:  checkpoint starting: time`,
+			want: map[string]confidence{"postgres": likelyFormat, "cri": notFormat, "docker json": notFormat, "json": notFormat},
+		},
+		{
+			line: `time="2024-01-02T10:00:00Z" level=info msg=listening`,
+			want: map[string]confidence{"logrus": likelyFormat, "logfmt": mayBeFormat, "envoy": notFormat},
+		},
+	} {
+		// the formats sharing a name, like json's, are sniffed at their best
+		got := make(map[string]confidence)
+		for _, f := range p.formats {
+			if c := f.sniff([]byte(tt.line)); c > got[f.name] {
+				got[f.name] = c
+			}
+		}
+		for name, want := range tt.want {
+			if got[name] != want {
+				t.Errorf("%s: want %s sniffed at %d, got %d", tt.line, name, want, got[name])
+			}
+		}
+	}
+}
+
+func TestParser_MostConfidentFirst(t *testing.T) {
+	p := newParser(DefaultOptions)
+
+	// the message has a pair, but the line is zap's rather than logfmt's
+	line := "2024-01-02T10:00:00.000-0700\tINFO\tmain.go:12\tretrying with limit=5\t{\"port\": 8080}"
+	if res := p.parse([]byte(line)); res.name != "zap" {
+		t.Errorf("want the line parsed as zap, got %q", res.name)
+	}
+
+	for _, line := range []string{"", "plain text, in no format at all", "#Software: Microsoft Internet Information Services 10.0"} {
+		for i := range p.formats {
+			if p.formats[i].name == "w3c" {
+				continue
+			}
+			if c := p.formats[i].sniff([]byte(line)); c != notFormat {
+				t.Errorf("%q: want it in no format, got %s at %d", line, p.formats[i].name, c)
+			}
+		}
+		if res := p.parse([]byte(line)); res.entry != nil {
+			t.Errorf("%q: want it unparsed, got %q", line, res.name)
+		}
+	}
+}
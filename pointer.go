@@ -0,0 +1,70 @@
+package humanlog
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parsePointer splits a JSON Pointer (RFC 6901), like /data/items/0/message,
+// into the keys it goes through. Unlike dotted paths, pointers can name keys
+// holding dots, like /labels/app.kubernetes.io~1name. It tells whether field
+// is a pointer at all.
+func parsePointer(field string) ([]string, bool) {
+	if !strings.HasPrefix(field, "/") {
+		return nil, false
+	}
+	segs := strings.Split(field[1:], "/")
+	for i, seg := range segs {
+		segs[i] = strings.Replace(strings.Replace(seg, "~1", "/", -1), "~0", "~", -1)
+	}
+	return segs, true
+}
+
+// lookupPointer returns the value the keys lead to in doc, going through
+// objects by key and arrays by index.
+func lookupPointer(doc interface{}, segs []string) (interface{}, bool) {
+	for _, seg := range segs {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			next, ok := v[seg]
+			if !ok {
+				return nil, false
+			}
+			doc = next
+		case []interface{}:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			doc = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// deletePointer removes the key the keys lead to from the object holding
+// it. Items of arrays are left where they are, for the indexes of the ones
+// after them not to change.
+func deletePointer(doc map[string]interface{}, segs []string) {
+	if len(segs) == 0 {
+		return
+	}
+	parent, ok := lookupPointer(doc, segs[:len(segs)-1])
+	if !ok {
+		return
+	}
+	if m, ok := parent.(map[string]interface{}); ok {
+		delete(m, segs[len(segs)-1])
+	}
+}
+
+// fieldKey returns the key a field names at the top of the entries: the
+// field itself, or the key of a pointer to a top-level key.
+func fieldKey(field string) string {
+	if segs, ok := parsePointer(field); ok && len(segs) == 1 {
+		return segs[0]
+	}
+	return field
+}
@@ -0,0 +1,66 @@
+package humanlog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePointer(t *testing.T) {
+	segs, ok := parsePointer("/labels/app.kubernetes.io~1name/~0x")
+	if want := []string{"labels", "app.kubernetes.io/name", "~x"}; !ok || !reflect.DeepEqual(segs, want) {
+		t.Errorf("want %q, got %q", want, segs)
+	}
+	if _, ok := parsePointer("data.message"); ok {
+		t.Error("want dotted paths not to be pointers")
+	}
+}
+
+func TestJSONPointerFields(t *testing.T) {
+	opts := *DefaultOptions
+	opts.TimeFields = []string{"/@timestamp"}
+	opts.MessageFields = []string{"/data/items/0/message"}
+	opts.LevelFields = []string{"/log.level"}
+	opts.SetSkip([]string{"/service.name", "/labels/app.kubernetes.io~1name"})
+
+	src := `{"@timestamp": "2024-01-02T03:04:05Z", "log.level": "warn", "log": {"level": "nested"}, "service.name": "api", "host": "web-1",` +
+		` "data": {"items": [{"message": "first"}, {"message": "second"}]}, "labels": {"app.kubernetes.io/name": "api", "team": "core"}}`
+	var got []*Event
+	err := ScanEvents(strings.NewReader(src), &opts, func(ev *Event) error {
+		got = append(got, opts.visibleEvent(ev))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := got[0]
+	if ev.Time.IsZero() || ev.Level != "warn" || ev.Message != "first" {
+		t.Errorf("want the time, level and message the pointers point to, got %v %q %q", ev.Time, ev.Level, ev.Message)
+	}
+	if _, ok := ev.Fields["service.name"]; ok {
+		t.Errorf("want the key with a dot skipped, got %v", ev.Fields)
+	}
+	if labels := ev.Fields["labels"]; labels != "map[team:core]" {
+		t.Errorf("want the nested label skipped, got %q", labels)
+	}
+	if ev.Fields["host"] != `"web-1"` || ev.Fields["log"] == "" {
+		t.Errorf("want the other fields kept, got %v", ev.Fields)
+	}
+}
+
+func TestLogfmtPointerFields(t *testing.T) {
+	opts := *DefaultOptions
+	opts.MessageFields = []string{"/event.original"}
+	opts.LevelFields = []string{"/log.level"}
+	var got []*Event
+	err := ScanEvents(strings.NewReader(`log.level=error event.original="disk full" host=a`), &opts, func(ev *Event) error {
+		got = append(got, ev)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Level != "error" || got[0].Message != "disk full" {
+		t.Errorf("want the level and message of keys with dots, got %q %q", got[0].Level, got[0].Message)
+	}
+}
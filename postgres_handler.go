@@ -0,0 +1,177 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// postgresLine matches the lines of Postgres's stderr log, with the default
+// log_line_prefix or with the user and database after the process ID:
+//
+//	2024-01-02 10:00:00.123 UTC [12345] ERROR:  duplicate key value violates unique constraint "users_pkey"
+//	2024-01-02 10:00:00.123 UTC [12345] app@shop DETAIL:  Key (id)=(1) already exists.
+var postgresLine = regexp.MustCompile(`^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d(?:\.\d+)?(?: [A-Za-z]{2,5}| [+-]\d\d(?::?\d\d)?)?) \[(\d+)\](?:-\d+)? (?:(\S*)@(\S*) )?(DEBUG[1-5]|INFO|NOTICE|WARNING|ERROR|LOG|FATAL|PANIC|DETAIL|HINT|QUERY|CONTEXT|LOCATION|STATEMENT):  (.*)$`)
+
+// postgresFolded are the lines Postgres writes after an entry to tell more
+// about it, by the field they're folded into.
+var postgresFolded = map[string]string{
+	"DETAIL":    "detail",
+	"HINT":      "hint",
+	"QUERY":     "query",
+	"CONTEXT":   "context",
+	"LOCATION":  "location",
+	"STATEMENT": "statement",
+}
+
+// postgresCSVColumns are the columns of Postgres's csvlog, up to the ones
+// every version has, named like the fields of the stderr log.
+var postgresCSVColumns = []string{
+	"time", "user", "database", "pid", "connection_from", "session_id", "session_line",
+	"command_tag", "session_start", "virtual_transaction_id", "transaction_id", "severity",
+	"sql_state", "message", "detail", "hint", "query", "query_pos", "context", "statement",
+	"statement_pos", "location", "application",
+}
+
+// sniffPostgres tells the lines of Postgres servers by the date they start
+// with, which is followed by a space in both of its formats.
+func sniffPostgres(d []byte) confidence {
+	if hasDatePrefix(d, ' ') {
+		return likelyFormat
+	}
+	return notFormat
+}
+
+// tryPostgres handles the logs of Postgres servers, in the stderr format or
+// in the csvlog one. The lines telling more about an entry, like its DETAIL
+// or STATEMENT, are fields of the entry when they're assembled with it, as
+// the postgres preset does, and the lines continuing a multi-line message
+// or statement are kept with it.
+func tryPostgres(d []byte, h *LogfmtHandler) bool {
+	if len(d) < len("2006-01-02 15:04:05") || d[0] < '0' || d[0] > '9' {
+		return false
+	}
+	lines := strings.Split(string(d), "\n")
+	m := postgresLine.FindStringSubmatch(lines[0])
+	if m == nil {
+		return tryPostgresCSV(d, h)
+	}
+	if t, ok := parsePostgresTime(m[1]); ok {
+		h.Time = t
+	}
+	h.Level = postgresLevel(m[5])
+	h.setNonEmptyField("pid", m[2])
+	h.setNonEmptyField("user", m[3])
+	h.setNonEmptyField("database", m[4])
+
+	message := []string{m[6]}
+	// the lines after the first continue its message, or the last part
+	// folded into a field, until another part starts
+	part, field := &message, ""
+	var folded []string
+	for _, line := range lines[1:] {
+		if m := postgresLine.FindStringSubmatch(line); m != nil {
+			if name, ok := postgresFolded[m[5]]; ok {
+				if field != "" {
+					h.setNonEmptyField(field, strings.Join(folded, "\n"))
+				}
+				folded, field = []string{m[6]}, name
+				part = &folded
+				continue
+			}
+		}
+		*part = append(*part, strings.TrimPrefix(line, "\t"))
+	}
+	if field != "" {
+		h.setNonEmptyField(field, strings.Join(folded, "\n"))
+	}
+	h.Message = strings.Join(message, "\n")
+	return true
+}
+
+// tryPostgresCSV handles the lines of Postgres's csvlog, whose quoted values
+// can span several lines.
+func tryPostgresCSV(d []byte, h *LogfmtHandler) bool {
+	r := csv.NewReader(bytes.NewReader(d))
+	r.FieldsPerRecord = -1
+	values, err := r.Read()
+	if err != nil || len(values) < len(postgresCSVColumns) {
+		return false
+	}
+	t, ok := parsePostgresTime(values[0])
+	if !ok {
+		return false
+	}
+	level := postgresLevel(values[11])
+	if level == "" {
+		return false
+	}
+	h.Time, h.Level, h.Message = t, level, values[13]
+	for i, name := range postgresCSVColumns {
+		switch name {
+		case "time", "severity", "message", "session_line", "session_start", "virtual_transaction_id":
+			continue
+		case "transaction_id", "query_pos", "statement_pos":
+			if values[i] == "0" {
+				continue
+			}
+		}
+		h.setNonEmptyField(name, values[i])
+	}
+	return true
+}
+
+// setNonEmptyField sets a field, unless its value is empty or the key is
+// dropped.
+func (h *LogfmtHandler) setNonEmptyField(key, value string) {
+	if value == "" {
+		return
+	}
+	if _, drop := h.drop[key]; !drop {
+		h.setField([]byte(key), []byte(value))
+	}
+}
+
+// postgresLevel is the level of a severity of Postgres, or empty for the
+// lines telling more about an entry.
+func postgresLevel(severity string) string {
+	switch severity {
+	case "LOG", "INFO":
+		return "info"
+	case "NOTICE":
+		return "notice"
+	case "WARNING":
+		return "warn"
+	case "ERROR":
+		return "error"
+	case "FATAL":
+		return "fatal"
+	case "PANIC":
+		return "panic"
+	}
+	if strings.HasPrefix(severity, "DEBUG") {
+		return "debug"
+	}
+	return ""
+}
+
+// postgresTimeLayouts are the ways %m and %t write the time, with the time
+// zone as an abbreviation or an offset.
+var postgresTimeLayouts = []string{
+	"2006-01-02 15:04:05 -07",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+}
+
+func parsePostgresTime(s string) (time.Time, bool) {
+	for _, layout := range postgresTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,107 @@
+package humanlog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scanPostgres(t *testing.T, src string) []*Event {
+	t.Helper()
+	opts := *DefaultOptions
+	opts.MultilineTimeout = time.Minute
+	p, _ := LookupPreset("postgres")
+	p.Apply(&opts)
+	var got []*Event
+	err := ScanEvents(strings.NewReader(src), &opts, func(ev *Event) error {
+		got = append(got, ev)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestPostgresLogs(t *testing.T) {
+	got := scanPostgres(t, strings.Join([]string{
+		`2024-01-02 10:00:00.123 UTC [12345] LOG:  database system is ready to accept connections`,
+		`2024-01-02 10:00:01.456 UTC [12346] app@shop ERROR:  duplicate key value violates unique constraint "users_pkey"`,
+		`2024-01-02 10:00:01.456 UTC [12346] app@shop DETAIL:  Key (id)=(1) already exists.`,
+		`2024-01-02 10:00:01.456 UTC [12346] app@shop STATEMENT:  INSERT INTO users (id, name)`,
+		"\tVALUES (1, 'alice')",
+		`2024-01-02 10:00:02.000 UTC [12347] WARNING:  there is no transaction in progress`,
+		`2024-01-02 10:00:02.000 UTC [12347] HINT:  Start one with BEGIN.`,
+	}, "\n"))
+
+	want := []struct {
+		level, message string
+		fields         map[string]string
+	}{
+		{level: "info", message: "database system is ready to accept connections", fields: map[string]string{"pid": "12345"}},
+		{
+			level:   "error",
+			message: `duplicate key value violates unique constraint "users_pkey"`,
+			fields: map[string]string{
+				"pid": "12346", "user": "app", "database": "shop",
+				"detail":    "Key (id)=(1) already exists.",
+				"statement": "INSERT INTO users (id, name)\nVALUES (1, 'alice')",
+			},
+		},
+		{level: "warn", message: "there is no transaction in progress", fields: map[string]string{"pid": "12347", "hint": "Start one with BEGIN."}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %d entries, got %d", len(want), len(got))
+	}
+	for i, w := range want {
+		ev := got[i]
+		if ev.Level != w.level || ev.Message != w.message || !reflect.DeepEqual(ev.Fields, w.fields) {
+			t.Errorf("%d: want %s %q %v, got %s %q %v", i, w.level, w.message, w.fields, ev.Level, ev.Message, ev.Fields)
+		}
+	}
+	if want := time.Date(2024, 1, 2, 10, 0, 1, 456e6, time.UTC); !got[1].Time.Equal(want) {
+		t.Errorf("want %s, got %s", want, got[1].Time)
+	}
+}
+
+func TestPostgresCSVLogs(t *testing.T) {
+	got := scanPostgres(t, strings.Join([]string{
+		`2024-01-02 10:00:01.456 UTC,"app","shop",12346,"10.0.0.7:51234",65940c41.303a,3,"INSERT",2024-01-02 09:58:00 UTC,3/42,0,ERROR,23505,"duplicate key value violates unique constraint ""users_pkey""","Key (id)=(1) already exists.",,,,,"INSERT INTO users (id, name)`,
+		`VALUES (1, 'alice')",,,"psql","client backend",,0`,
+	}, "\n"))
+	if len(got) != 1 {
+		t.Fatalf("want 1 entry, got %d", len(got))
+	}
+	ev := got[0]
+	want := map[string]string{
+		"user": "app", "database": "shop", "pid": "12346", "connection_from": "10.0.0.7:51234",
+		"session_id": "65940c41.303a", "command_tag": "INSERT", "sql_state": "23505",
+		"detail": "Key (id)=(1) already exists.", "statement": "INSERT INTO users (id, name)\nVALUES (1, 'alice')",
+		"application": "psql",
+	}
+	if ev.Level != "error" || ev.Message != `duplicate key value violates unique constraint "users_pkey"` || !reflect.DeepEqual(ev.Fields, want) {
+		t.Errorf("got %s %q %v", ev.Level, ev.Message, ev.Fields)
+	}
+}
+
+func TestPostgresLogs_WithoutPreset(t *testing.T) {
+	var got []*Event
+	err := ScanEvents(strings.NewReader(strings.Join([]string{
+		`2024-01-02 10:00:01.456 +01 [12346] FATAL:  password authentication failed for user "app"`,
+		`2024-01-02 10:00:01.456 +01 [12346] DETAIL:  Connection matched pg_hba.conf line 99`,
+	}, "\n")), DefaultOptions, func(ev *Event) error {
+		got = append(got, ev)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// each line is an entry of its own
+	if len(got) != 2 || got[0].Level != "fatal" || got[1].Message != "Connection matched pg_hba.conf line 99" {
+		t.Fatalf("got %v", got)
+	}
+	if _, offset := got[0].Time.Zone(); offset != 3600 {
+		t.Errorf("want the offset of the line, got %s", got[0].Time)
+	}
+}
@@ -0,0 +1,175 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+)
+
+// Preset configures humanlog for the logs emitted by a library or platform.
+// The presets humanlog ships are JSON files under builtin/presets, which
+// `humanlog preset export` writes out as starting points for others.
+type Preset struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	TimeFields      []string `json:"time-fields,omitempty"`
+	MessageFields   []string `json:"message-fields,omitempty"`
+	LevelFields     []string `json:"level-fields,omitempty"`
+	LoggerFields    []string `json:"logger-fields,omitempty"`
+	TracebackFields []string `json:"traceback-fields,omitempty"`
+	SourceFields    []string `json:"source-fields,omitempty"`
+
+	// Gutter colors the lines by the first of SourceFields they have.
+	Gutter bool `json:"gutter,omitempty"`
+	// MultilineJSON assembles the entries printed on several lines.
+	MultilineJSON bool `json:"multiline-json,omitempty"`
+	// MultilineStart matches the first line of the entries of the platform,
+	// unless the options already have a pattern. It's compiled from the
+	// pattern of the file.
+	MultilineStart *regexp.Regexp `json:"-"`
+	StartPattern   string         `json:"multiline-start,omitempty"`
+	// Rewrite turns the lines of the platform humanlog can't parse into ones
+	// it can, or returns nil. The file names it, among apple, journal and
+	// lambda.
+	Rewrite     func(line []byte) []byte `json:"-"`
+	RewriteName string                   `json:"rewrite,omitempty"`
+}
+
+// presetRewrites are the rewrites presets can name, for the platforms whose
+// lines only code can turn into entries.
+var presetRewrites = map[string]func(line []byte) []byte{
+	"apple":   rewriteAppleLog,
+	"journal": rewriteJournal,
+	"lambda":  rewriteLambda,
+}
+
+// presets are the presets humanlog ships, by name.
+var presets = loadBuiltinPresets()
+
+func loadBuiltinPresets() map[string]*Preset {
+	presets := make(map[string]*Preset)
+	for _, name := range builtinNames("presets", ".json") {
+		p, err := readPreset(mustReadBuiltin("presets/" + name + ".json"))
+		if err != nil {
+			panic(fmt.Sprintf("builtin preset %s: %v", name, err))
+		}
+		presets[p.Name] = p
+	}
+	return presets
+}
+
+// readPreset reads and compiles the preset in a JSON document.
+func readPreset(data []byte) (*Preset, error) {
+	p := new(Preset)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(p); err != nil {
+		return nil, err
+	}
+	return p, p.compile()
+}
+
+// compile sets MultilineStart and Rewrite from what the file names.
+func (p *Preset) compile() error {
+	if p.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if p.StartPattern != "" {
+		re, err := regexp.Compile(p.StartPattern)
+		if err != nil {
+			return fmt.Errorf("multiline-start: %v", err)
+		}
+		p.MultilineStart = re
+	}
+	if p.RewriteName != "" {
+		rewrite, ok := presetRewrites[p.RewriteName]
+		if !ok {
+			return fmt.Errorf("unknown rewrite %q", p.RewriteName)
+		}
+		p.Rewrite = rewrite
+	}
+	return nil
+}
+
+// LookupPreset returns the preset with that name.
+func LookupPreset(name string) (*Preset, bool) {
+	p, ok := presets[name]
+	return p, ok
+}
+
+// LoadPreset returns the preset humanlog ships with that name, or reads the
+// preset in the JSON file at that path.
+func LoadPreset(nameOrPath string) (*Preset, error) {
+	if p, ok := presets[nameOrPath]; ok {
+		return p, nil
+	}
+	data, err := ioutil.ReadFile(nameOrPath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no preset named %q", nameOrPath)
+	} else if err != nil {
+		return nil, err
+	}
+	p, err := readPreset(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", nameOrPath, err)
+	}
+	return p, nil
+}
+
+// PresetSource returns the JSON file of the preset humanlog ships with that
+// name.
+func PresetSource(name string) ([]byte, bool) {
+	if _, ok := presets[name]; !ok {
+		return nil, false
+	}
+	return mustReadBuiltin("presets/" + name + ".json"), true
+}
+
+// PresetNames lists the presets humanlog has, in alphabetical order.
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply makes the fields of the preset searched before the ones opts already
+// had.
+func (p *Preset) Apply(opts *HandlerOptions) {
+	opts.TimeFields = prependFields(p.TimeFields, opts.TimeFields)
+	opts.MessageFields = prependFields(p.MessageFields, opts.MessageFields)
+	opts.LevelFields = prependFields(p.LevelFields, opts.LevelFields)
+	opts.LoggerFields = prependFields(p.LoggerFields, opts.LoggerFields)
+	opts.TracebackFields = prependFields(p.TracebackFields, opts.TracebackFields)
+	opts.SourceFields = prependFields(p.SourceFields, opts.SourceFields)
+	opts.Gutter = opts.Gutter || p.Gutter
+	opts.MultilineJSON = opts.MultilineJSON || p.MultilineJSON
+	if opts.MultilineStart == nil {
+		opts.MultilineStart = p.MultilineStart
+	}
+	if p.Rewrite != nil {
+		opts.Rewrites = append(opts.Rewrites, p.Rewrite)
+	}
+}
+
+func prependFields(first, then []string) []string {
+	out := make([]string, 0, len(first)+len(then))
+	seen := make(map[string]struct{}, len(first)+len(then))
+	for _, fields := range [][]string{first, then} {
+		for _, field := range fields {
+			if _, ok := seen[field]; ok {
+				continue
+			}
+			seen[field] = struct{}{}
+			out = append(out, field)
+		}
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// progress draws how much of the input was read as a bar, with how fast it's
+// read and when it should be done, at most every tenth of a second.
+type progress struct {
+	w     io.Writer
+	total int64
+	start time.Time
+	last  time.Time
+	drawn bool
+}
+
+func newProgress(w io.Writer, total int64) *progress {
+	return &progress{w: w, total: total, start: time.Now()}
+}
+
+func (p *progress) update(done int64) {
+	p.updateAt(done, time.Now())
+}
+
+func (p *progress) updateAt(done int64, now time.Time) {
+	if now.Sub(p.last) < 100*time.Millisecond || p.total <= 0 {
+		return
+	}
+	p.last, p.drawn = now, true
+	if done > p.total {
+		done = p.total
+	}
+
+	const width = 30
+	filled := int(done * width / p.total)
+	fmt.Fprintf(p.w, "\r[%s%s] %3d%% %s / %s",
+		strings.Repeat("=", filled), strings.Repeat(" ", width-filled),
+		done*100/p.total, byteSize(done), byteSize(p.total))
+
+	// the first moments say little about the rate
+	elapsed := now.Sub(p.start)
+	if p.start.IsZero() || elapsed < time.Second || done == 0 {
+		io.WriteString(p.w, " ")
+		return
+	}
+	rate := float64(done) / elapsed.Seconds()
+	eta := time.Duration(float64(p.total-done) / rate * float64(time.Second))
+	fmt.Fprintf(p.w, " %s/s ETA %s \x1b[K", byteSize(int64(rate)), eta.Round(time.Second))
+}
+
+// done clears the bar.
+func (p *progress) done() {
+	if p != nil && p.drawn {
+		io.WriteString(p.w, "\r\x1b[K")
+	}
+}
+
+func byteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// ProgressReader draws a progress bar onto a terminal as a file of known
+// size is read, for the files that aren't mapped in memory.
+type ProgressReader struct {
+	r      io.Reader
+	read   int64
+	status *progress
+}
+
+// NewProgressReader draws the progress of reading total bytes out of r
+// onto w.
+func NewProgressReader(r io.Reader, total int64, w io.Writer) *ProgressReader {
+	return &ProgressReader{r: r, status: newProgress(w, total)}
+}
+
+func (pr *ProgressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.read += int64(n)
+	pr.status.update(pr.read)
+	return n, err
+}
+
+// Close clears the bar.
+func (pr *ProgressReader) Close() error {
+	pr.status.done()
+	return nil
+}
@@ -0,0 +1,40 @@
+package humanlog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressRate(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := &progress{w: &buf, total: 40 << 20, start: start}
+	p.updateAt(10<<20, start.Add(2*time.Second))
+	want := "\r[=======                       ]  25% 10.0MiB / 40.0MiB 5.0MiB/s ETA 6s \x1b[K"
+	if buf.String() != want {
+		t.Errorf("want %q, got %q", want, buf.String())
+	}
+
+	// not redrawn until a tenth of a second went by
+	buf.Reset()
+	p.updateAt(11<<20, start.Add(2*time.Second+time.Millisecond))
+	if buf.Len() != 0 {
+		t.Errorf("want no redraw, got %q", buf.String())
+	}
+}
+
+func TestProgressReader(t *testing.T) {
+	var bar bytes.Buffer
+	pr := NewProgressReader(strings.NewReader("hello\nworld\n"), 12, &bar)
+	out, err := ioutil.ReadAll(pr)
+	if err != nil || string(out) != "hello\nworld\n" {
+		t.Fatalf("want the input read through, got %q, %v", out, err)
+	}
+	pr.Close()
+	if !strings.Contains(bar.String(), "100%") || !strings.HasSuffix(bar.String(), "\r\x1b[K") {
+		t.Errorf("want a full bar, cleared, got %q", bar.String())
+	}
+}
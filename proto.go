@@ -0,0 +1,470 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+	"time"
+)
+
+// ProtoMessage is a type of protobuf message, read from a descriptor set
+// like protoc --descriptor_set_out --include_imports writes, to decode the
+// entries of a stream of them.
+type ProtoMessage struct {
+	Name string
+
+	fields   map[int32]*protoField
+	mapEntry bool
+	schema   *protoSchema
+}
+
+type protoField struct {
+	name     string
+	kind     int
+	repeated bool
+	// typeName is the full name of the message or enum of the field, like
+	// .my.pkg.Level
+	typeName string
+}
+
+// protoSchema are the messages and enums of a descriptor set, by their full
+// name.
+type protoSchema struct {
+	messages map[string]*ProtoMessage
+	enums    map[string]map[int32]string
+}
+
+// The types of fields, as FieldDescriptorProto numbers them.
+const (
+	protoDouble   = 1
+	protoFloat    = 2
+	protoInt64    = 3
+	protoUint64   = 4
+	protoInt32    = 5
+	protoFixed64  = 6
+	protoFixed32  = 7
+	protoBool     = 8
+	protoString   = 9
+	protoGroup    = 10
+	protoMessage  = 11
+	protoBytes    = 12
+	protoUint32   = 13
+	protoEnum     = 14
+	protoSfixed32 = 15
+	protoSfixed64 = 16
+	protoSint32   = 17
+	protoSint64   = 18
+)
+
+// The wire types of fields.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+const (
+	// maxProtoDepth is how deep messages can be nested in each other.
+	maxProtoDepth = 64
+	// maxProtoSize is how large a message of a stream can be, anything
+	// larger meaning the stream isn't one.
+	maxProtoSize = 64 << 20
+)
+
+var errProtoTruncated = errors.New("truncated message")
+
+// LoadProtoMessage reads the descriptor set at path, and returns the message
+// with that full name, like my.pkg.LogEntry, out of it.
+func LoadProtoMessage(path, name string) (*ProtoMessage, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	schema := &protoSchema{messages: make(map[string]*ProtoMessage), enums: make(map[string]map[int32]string)}
+	err = eachProtoField(data, func(num int32, wire int, _ uint64, b []byte) error {
+		if num == 1 && wire == wireBytes {
+			return schema.addFile(b)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: not a descriptor set: %v", path, err)
+	}
+	m, ok := schema.messages["."+strings.TrimPrefix(name, ".")]
+	if !ok {
+		return nil, fmt.Errorf("%s: no message %s", path, name)
+	}
+	return m, nil
+}
+
+func (s *protoSchema) addFile(b []byte) error {
+	var pkg string
+	var messages, enums [][]byte
+	err := eachProtoField(b, func(num int32, wire int, _ uint64, b []byte) error {
+		switch {
+		case wire != wireBytes:
+		case num == 2:
+			pkg = string(b)
+		case num == 4:
+			messages = append(messages, b)
+		case num == 5:
+			enums = append(enums, b)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	scope := "."
+	if pkg != "" {
+		scope += pkg + "."
+	}
+	for _, b := range messages {
+		if err := s.addMessage(scope, b); err != nil {
+			return err
+		}
+	}
+	for _, b := range enums {
+		if err := s.addEnum(scope, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *protoSchema) addMessage(scope string, b []byte) error {
+	m := &ProtoMessage{fields: make(map[int32]*protoField), schema: s}
+	var fields, nested, enums [][]byte
+	err := eachProtoField(b, func(num int32, wire int, _ uint64, b []byte) error {
+		switch {
+		case wire != wireBytes:
+		case num == 1:
+			m.Name = string(b)
+		case num == 2:
+			fields = append(fields, b)
+		case num == 3:
+			nested = append(nested, b)
+		case num == 4:
+			enums = append(enums, b)
+		case num == 7:
+			// MessageOptions, whose map_entry tells the entries of maps
+			return eachProtoField(b, func(num int32, wire int, v uint64, _ []byte) error {
+				if num == 7 && wire == wireVarint {
+					m.mapEntry = v != 0
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	full := scope + m.Name
+	s.messages[full] = m
+	m.Name = full[1:]
+
+	for _, b := range fields {
+		f := &protoField{}
+		var number int32
+		err := eachProtoField(b, func(num int32, wire int, v uint64, b []byte) error {
+			switch num {
+			case 1:
+				f.name = string(b)
+			case 3:
+				number = int32(v)
+			case 4:
+				f.repeated = v == 3
+			case 5:
+				f.kind = int(v)
+			case 6:
+				f.typeName = string(b)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		m.fields[number] = f
+	}
+	for _, b := range nested {
+		if err := s.addMessage(full+".", b); err != nil {
+			return err
+		}
+	}
+	for _, b := range enums {
+		if err := s.addEnum(full+".", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *protoSchema) addEnum(scope string, b []byte) error {
+	var name string
+	values := make(map[int32]string)
+	err := eachProtoField(b, func(num int32, wire int, _ uint64, b []byte) error {
+		switch {
+		case wire != wireBytes:
+		case num == 1:
+			name = string(b)
+		case num == 2:
+			var valueName string
+			var number int32
+			err := eachProtoField(b, func(num int32, _ int, v uint64, b []byte) error {
+				switch num {
+				case 1:
+					valueName = string(b)
+				case 2:
+					number = int32(v)
+				}
+				return nil
+			})
+			values[number] = valueName
+			return err
+		}
+		return nil
+	})
+	s.enums[scope+name] = values
+	return err
+}
+
+// eachProtoField calls fn with each field of a message: its number, wire
+// type, and value, either a number or bytes.
+func eachProtoField(b []byte, fn func(num int32, wire int, v uint64, b []byte) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errProtoTruncated
+		}
+		b = b[n:]
+		num, wire := int32(key>>3), int(key&7)
+		var v uint64
+		var data []byte
+		switch wire {
+		case wireVarint:
+			if v, n = binary.Uvarint(b); n <= 0 {
+				return errProtoTruncated
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return errProtoTruncated
+			}
+			v, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return errProtoTruncated
+			}
+			v, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case wireBytes:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				return errProtoTruncated
+			}
+			data, b = b[n:n+int(size)], b[n+int(size):]
+		default:
+			return fmt.Errorf("unsupported wire type %d of field %d", wire, num)
+		}
+		if err := fn(num, wire, v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode turns a message into the JSON object encoding/json would marshal
+// it as, the fields under their name in the .proto. Unknown fields are
+// left out.
+func (m *ProtoMessage) decode(b []byte, depth int) (map[string]interface{}, error) {
+	if depth > maxProtoDepth {
+		return nil, fmt.Errorf("messages nested too deep")
+	}
+	obj := make(map[string]interface{})
+	err := eachProtoField(b, func(num int32, wire int, v uint64, data []byte) error {
+		f, ok := m.fields[num]
+		if !ok {
+			return nil
+		}
+		if wire == wireBytes && f.repeated && f.kind != protoString && f.kind != protoBytes && f.kind != protoMessage {
+			// packed scalars
+			var list []interface{}
+			if prev, ok := obj[f.name].([]interface{}); ok {
+				list = prev
+			}
+			for len(data) > 0 {
+				var n int
+				switch f.kind {
+				case protoDouble, protoFixed64, protoSfixed64:
+					if len(data) < 8 {
+						return errProtoTruncated
+					}
+					v, n = binary.LittleEndian.Uint64(data), 8
+				case protoFloat, protoFixed32, protoSfixed32:
+					if len(data) < 4 {
+						return errProtoTruncated
+					}
+					v, n = uint64(binary.LittleEndian.Uint32(data)), 4
+				default:
+					if v, n = binary.Uvarint(data); n <= 0 {
+						return errProtoTruncated
+					}
+				}
+				data = data[n:]
+				list = append(list, m.schema.scalar(f, v))
+			}
+			obj[f.name] = list
+			return nil
+		}
+
+		var value interface{}
+		switch {
+		case f.kind == protoMessage:
+			var err error
+			if value, err = m.schema.message(f.typeName, data, depth); err != nil {
+				return err
+			}
+		case f.kind == protoString:
+			value = string(data)
+		case f.kind == protoBytes:
+			value = append([]byte(nil), data...)
+		case f.kind == protoGroup:
+			return fmt.Errorf("field %s is a group", f.name)
+		default:
+			value = m.schema.scalar(f, v)
+		}
+
+		if !f.repeated {
+			obj[f.name] = value
+			return nil
+		}
+		if entry, ok := value.(protoMapEntry); ok {
+			dict, _ := obj[f.name].(map[string]interface{})
+			if dict == nil {
+				dict = make(map[string]interface{})
+				obj[f.name] = dict
+			}
+			dict[entry.key] = entry.value
+			return nil
+		}
+		list, _ := obj[f.name].([]interface{})
+		obj[f.name] = append(list, value)
+		return nil
+	})
+	return obj, err
+}
+
+// protoMapEntry is an entry of a map field, which are encoded as a list of
+// messages with a key and a value.
+type protoMapEntry struct {
+	key   string
+	value interface{}
+}
+
+// message decodes a message of the type named, the well known types of
+// times being turned into what humanlog can read.
+func (s *protoSchema) message(typeName string, b []byte, depth int) (interface{}, error) {
+	switch typeName {
+	case ".google.protobuf.Timestamp", ".google.protobuf.Duration":
+		var seconds, nanos int64
+		err := eachProtoField(b, func(num int32, _ int, v uint64, _ []byte) error {
+			switch num {
+			case 1:
+				seconds = int64(v)
+			case 2:
+				nanos = int64(int32(v))
+			}
+			return nil
+		})
+		if typeName == ".google.protobuf.Duration" {
+			return (time.Duration(seconds)*time.Second + time.Duration(nanos)).String(), err
+		}
+		return time.Unix(seconds, nanos).UTC().Format(time.RFC3339Nano), err
+	}
+	m, ok := s.messages[typeName]
+	if !ok {
+		return nil, fmt.Errorf("no message %s in the descriptor set", typeName)
+	}
+	obj, err := m.decode(b, depth+1)
+	if err != nil || !m.mapEntry {
+		return obj, err
+	}
+	return protoMapEntry{key: fmt.Sprint(obj["key"]), value: obj["value"]}, nil
+}
+
+// scalar decodes a number, a bool or an enum, given the bits it was encoded
+// as.
+func (s *protoSchema) scalar(f *protoField, v uint64) interface{} {
+	switch f.kind {
+	case protoDouble:
+		return math.Float64frombits(v)
+	case protoFloat:
+		return math.Float32frombits(uint32(v))
+	case protoInt64, protoSfixed64:
+		return int64(v)
+	case protoInt32, protoSfixed32:
+		return int32(v)
+	case protoUint32, protoFixed32:
+		return uint32(v)
+	case protoBool:
+		return v != 0
+	case protoSint32, protoSint64:
+		return int64(v>>1) ^ -int64(v&1)
+	case protoEnum:
+		if name, ok := s.enums[f.typeName][int32(v)]; ok {
+			return name
+		}
+		return int32(v)
+	}
+	return v
+}
+
+// protoReader reads a stream of messages, each after its length as a
+// varint, like writeDelimitedTo writes them, as JSON lines.
+type protoReader struct {
+	src  *bufio.Reader
+	msg  *ProtoMessage
+	line bytes.Buffer
+	n    int
+}
+
+func newProtoReader(src io.Reader, msg *ProtoMessage) *protoReader {
+	return &protoReader{src: bufio.NewReader(src), msg: msg}
+}
+
+func (r *protoReader) Read(p []byte) (int, error) {
+	for r.line.Len() == 0 {
+		size, err := binary.ReadUvarint(r.src)
+		if err == io.ErrUnexpectedEOF {
+			return 0, io.EOF
+		} else if err != nil {
+			return 0, err
+		}
+		if size > maxProtoSize {
+			return 0, fmt.Errorf("message %d: %d bytes long, not a stream of %s", r.n+1, size, r.msg.Name)
+		}
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(r.src, buf); err != nil {
+			return 0, fmt.Errorf("message %d: %v", r.n+1, err)
+		}
+		r.n++
+		obj, err := r.msg.decode(buf, 0)
+		if err != nil {
+			return 0, fmt.Errorf("message %d: %v", r.n, err)
+		}
+		enc := json.NewEncoder(&r.line)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(obj); err != nil {
+			return 0, err
+		}
+	}
+	return r.line.Read(p)
+}
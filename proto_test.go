@@ -0,0 +1,178 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The protobuf encoding, just enough of it to write descriptors and
+// messages.
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func pbVarint(num int32, v uint64) []byte {
+	b := appendUvarint(nil, uint64(num)<<3|wireVarint)
+	return appendUvarint(b, v)
+}
+
+func pbBytes(num int32, parts ...[]byte) []byte {
+	data := bytes.Join(parts, nil)
+	b := appendUvarint(nil, uint64(num)<<3|wireBytes)
+	b = appendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func pbString(num int32, s string) []byte { return pbBytes(num, []byte(s)) }
+
+func pbFixed64(num int32, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(appendUvarint(nil, uint64(num)<<3|wireFixed64), buf[:]...)
+}
+
+func pbField(name string, number, kind int, repeated bool, typeName string) []byte {
+	label := uint64(1)
+	if repeated {
+		label = 3
+	}
+	f := [][]byte{pbString(1, name), pbVarint(3, uint64(number)), pbVarint(4, label), pbVarint(5, uint64(kind))}
+	if typeName != "" {
+		f = append(f, pbString(6, typeName))
+	}
+	return pbBytes(2, f...)
+}
+
+// logEntrySet describes, in package app:
+//
+//	message LogEntry {
+//	  google.protobuf.Timestamp time = 1;
+//	  Level level = 2;
+//	  string msg = 3;
+//	  map<string, string> labels = 4;
+//	  repeated int32 codes = 5;
+//	  sint64 delta = 6;
+//	  double ratio = 7;
+//	  google.protobuf.Duration took = 8;
+//	}
+//	enum Level { INFO = 0; ERROR = 1; }
+func logEntrySet() []byte {
+	labelsEntry := pbBytes(3,
+		pbString(1, "LabelsEntry"),
+		pbField("key", 1, protoString, false, ""),
+		pbField("value", 2, protoString, false, ""),
+		pbBytes(7, pbVarint(7, 1)),
+	)
+	entry := pbBytes(4,
+		pbString(1, "LogEntry"),
+		pbField("time", 1, protoMessage, false, ".google.protobuf.Timestamp"),
+		pbField("level", 2, protoEnum, false, ".app.Level"),
+		pbField("msg", 3, protoString, false, ""),
+		pbField("labels", 4, protoMessage, true, ".app.LogEntry.LabelsEntry"),
+		pbField("codes", 5, protoInt32, true, ""),
+		pbField("delta", 6, protoSint64, false, ""),
+		pbField("ratio", 7, protoDouble, false, ""),
+		pbField("took", 8, protoMessage, false, ".google.protobuf.Duration"),
+		labelsEntry,
+	)
+	level := pbBytes(5,
+		pbString(1, "Level"),
+		pbBytes(2, pbString(1, "INFO"), pbVarint(2, 0)),
+		pbBytes(2, pbString(1, "ERROR"), pbVarint(2, 1)),
+	)
+	return pbBytes(1, pbString(1, "app/log.proto"), pbString(2, "app"), entry, level)
+}
+
+func TestProtoStream(t *testing.T) {
+	dir, err := ioutil.TempDir("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "set.pb")
+	if err := ioutil.WriteFile(path, logEntrySet(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := LoadProtoMessage(path, "app.LogEntry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadProtoMessage(path, "app.Nope"); err == nil {
+		t.Error("want an error for a message the set doesn't have")
+	}
+
+	when := time.Date(2024, 1, 2, 10, 0, 0, 5e8, time.UTC)
+	messages := [][]byte{
+		bytes.Join([][]byte{
+			pbBytes(1, pbVarint(1, uint64(when.Unix())), pbVarint(2, 5e8)),
+			pbVarint(2, 1),
+			pbString(3, "payment failed"),
+			pbBytes(4, pbString(1, "region"), pbString(2, "eu")),
+			pbBytes(4, pbString(1, "zone"), pbString(2, "b")),
+			pbBytes(5, appendUvarint(appendUvarint(nil, 402), 7)),
+			pbVarint(6, 5), // -3, zigzagged
+			pbFixed64(7, math.Float64bits(0.25)),
+			pbBytes(8, pbVarint(1, 1), pbVarint(2, 5e8)),
+			pbString(99, "unknown to the descriptor"),
+		}, nil),
+		pbString(3, "retrying"),
+	}
+	var stream []byte
+	for _, m := range messages {
+		stream = appendUvarint(stream, uint64(len(m)))
+		stream = append(stream, m...)
+	}
+
+	opts := *DefaultOptions
+	opts.Proto = msg
+	var events []*Event
+	if err := ScanEvents(bytes.NewReader(stream), &opts, func(ev *Event) error {
+		events = append(events, ev)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("want 2 events, got %d", len(events))
+	}
+	ev := events[0]
+	if !ev.Time.Equal(when) || ev.Level != "ERROR" || ev.Message != "payment failed" {
+		t.Errorf("got %+v", ev)
+	}
+	want := map[string]string{
+		"labels": "map[region:eu zone:b]",
+		"codes":  "[402 7]",
+		"delta":  "-3",
+		"ratio":  "0.25",
+		"took":   `"1.5s"`,
+	}
+	for k, v := range want {
+		if got := ev.Fields[k]; got != v {
+			t.Errorf("%s: want %s, got %s (%v)", k, v, got, ev.Fields)
+		}
+	}
+	if events[1].Message != "retrying" {
+		t.Errorf("got %+v", events[1])
+	}
+}
+
+func TestProtoStreamTruncated(t *testing.T) {
+	msg := &ProtoMessage{Name: "app.LogEntry", fields: map[int32]*protoField{3: {name: "msg", kind: protoString}}}
+	msg.schema = &protoSchema{}
+	stream := append(appendUvarint(nil, 10), pbString(3, "cut")...)
+	opts := *DefaultOptions
+	opts.Proto = msg
+	err := ScanEvents(bytes.NewReader(stream), &opts, func(*Event) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "message 1") {
+		t.Errorf("want an error for the truncated message, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package humanlog
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	sqlStartRe     = regexp.MustCompile(`(?i)^\s*(select|insert|update|delete|with|create|alter|drop|explain)\b`)
+	graphqlStartRe = regexp.MustCompile(`^\s*((query|mutation|subscription|fragment)\b|\{)`)
+
+	// sqlClauses start on a new line when a query is reflowed.
+	sqlClauses = []string{
+		"SELECT", "FROM", "WHERE", "AND", "OR", "GROUP BY", "ORDER BY", "HAVING",
+		"LIMIT", "OFFSET", "LEFT JOIN", "RIGHT JOIN", "INNER JOIN", "OUTER JOIN",
+		"FULL JOIN", "CROSS JOIN", "JOIN", "ON", "VALUES", "SET", "UNION",
+		"RETURNING", "INSERT INTO", "UPDATE", "DELETE FROM", "WITH",
+	}
+	sqlKeywords = append(sqlClauses,
+		"AS", "IN", "IS", "NOT", "NULL", "LIKE", "BETWEEN", "DISTINCT", "CASE",
+		"WHEN", "THEN", "ELSE", "END", "ASC", "DESC", "BY", "INTO", "EXISTS",
+	)
+	sqlClauseRe  = wordsRe(sqlClauses)
+	sqlKeywordRe = wordsRe(sqlKeywords)
+)
+
+func wordsRe(words []string) *regexp.Regexp {
+	alts := make([]string, 0, len(words))
+	for _, w := range words {
+		alts = append(alts, strings.Replace(w, " ", `\s+`, -1))
+	}
+	return regexp.MustCompile(`(?i)\b(` + strings.Join(alts, "|") + `)\b`)
+}
+
+// queryBlock returns the value of key reflowed and highlighted over multiple
+// lines, if key is one of the QueryFields and holds a long enough SQL or
+// GraphQL query.
+func (h *HandlerOptions) queryBlock(key, val string) (string, bool) {
+	if len(val) < h.QueryMinLength {
+		return "", false
+	}
+	if !containsField(h.QueryFields, key) {
+		return "", false
+	}
+
+	query := unquoteValue(val)
+	var lines []string
+	switch {
+	case graphqlStartRe.MatchString(query):
+		lines = reflowGraphQL(query, h)
+	case sqlStartRe.MatchString(query):
+		lines = reflowSQL(query, h)
+	default:
+		return "", false
+	}
+
+	var sb strings.Builder
+	sb.WriteString("    ")
+	sb.WriteString(h.KeyColor.Sprint(key))
+	sb.WriteString(":")
+	for _, line := range lines {
+		sb.WriteString("\n      ")
+		sb.WriteString(line)
+	}
+	return sb.String(), true
+}
+
+// reflowSQL puts each clause of the query on its own line, with keywords
+// highlighted. Quoted strings are left untouched.
+func reflowSQL(query string, opts *HandlerOptions) []string {
+	query = strings.Join(strings.Fields(query), " ")
+	var lines []string
+	var cur strings.Builder
+	for _, part := range splitQuoted(query) {
+		if part.quoted {
+			cur.WriteString(part.text)
+			continue
+		}
+		text := part.text
+		for {
+			loc := sqlClauseRe.FindStringIndex(text)
+			if loc == nil {
+				cur.WriteString(text)
+				break
+			}
+			cur.WriteString(text[:loc[0]])
+			if strings.TrimSpace(cur.String()) != "" {
+				lines = append(lines, strings.TrimSpace(cur.String()))
+				cur.Reset()
+			}
+			cur.WriteString(text[loc[0]:loc[1]])
+			text = text[loc[1]:]
+		}
+	}
+	if strings.TrimSpace(cur.String()) != "" {
+		lines = append(lines, strings.TrimSpace(cur.String()))
+	}
+	for i, line := range lines {
+		lines[i] = highlightSQL(line, opts)
+	}
+	return lines
+}
+
+func highlightSQL(line string, opts *HandlerOptions) string {
+	var sb strings.Builder
+	for _, part := range splitQuoted(line) {
+		if part.quoted {
+			sb.WriteString(opts.ValColor.Sprint(part.text))
+			continue
+		}
+		sb.WriteString(sqlKeywordRe.ReplaceAllStringFunc(part.text, func(kw string) string {
+			return opts.QueryKeywordColor.Sprint(strings.ToUpper(kw))
+		}))
+	}
+	return sb.String()
+}
+
+// reflowGraphQL indents the query by the depth of its braces.
+func reflowGraphQL(query string, opts *HandlerOptions) []string {
+	var lines []string
+	var cur strings.Builder
+	depth := 0
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			lines = append(lines, strings.Repeat("  ", depth)+s)
+		}
+		cur.Reset()
+	}
+	for _, part := range splitQuoted(strings.Join(strings.Fields(query), " ")) {
+		if part.quoted {
+			cur.WriteString(opts.ValColor.Sprint(part.text))
+			continue
+		}
+		for _, r := range part.text {
+			switch r {
+			case '{':
+				cur.WriteString("{")
+				flush()
+				depth++
+			case '}':
+				flush()
+				if depth > 0 {
+					depth--
+				}
+				cur.WriteString("}")
+				flush()
+			case ',':
+				flush()
+			default:
+				cur.WriteRune(r)
+			}
+		}
+	}
+	flush()
+	for i, line := range lines {
+		if trimmed := strings.TrimLeft(line, " "); graphqlStartRe.MatchString(trimmed) && !strings.HasPrefix(trimmed, "{") {
+			word := strings.Fields(trimmed)[0]
+			lines[i] = line[:len(line)-len(trimmed)] + opts.QueryKeywordColor.Sprint(word) + trimmed[len(word):]
+		}
+	}
+	return lines
+}
+
+type queryPart struct {
+	text   string
+	quoted bool
+}
+
+// splitQuoted separates the single or double quoted strings of a query from
+// the rest of it.
+func splitQuoted(s string) []queryPart {
+	var parts []queryPart
+	start := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == 0 && (c == '\'' || c == '"'):
+			if i > start {
+				parts = append(parts, queryPart{text: s[start:i]})
+			}
+			quote, start = c, i
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			parts = append(parts, queryPart{text: s[start : i+1], quoted: true})
+			quote, start = 0, i+1
+		}
+	}
+	if start < len(s) {
+		parts = append(parts, queryPart{text: s[start:], quoted: quote != 0})
+	}
+	return parts
+}
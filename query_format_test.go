@@ -0,0 +1,35 @@
+package humanlog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReflowSQL(t *testing.T) {
+	got := reflowSQL(`select id from users where name = 'a where b' and active order by id`, DefaultOptions)
+	want := []string{
+		"SELECT id",
+		"FROM users",
+		"WHERE name = 'a where b'",
+		"AND active",
+		"ORDER BY id",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %q, got %q, want != got", want, got)
+	}
+}
+
+func TestReflowGraphQL(t *testing.T) {
+	got := reflowGraphQL(`query Q { user(id: "1,2") { id, name } }`, DefaultOptions)
+	want := []string{
+		"query Q {",
+		`  user(id: "1,2") {`,
+		"    id",
+		"    name",
+		"  }",
+		"}",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %q, got %q, want != got", want, got)
+	}
+}
@@ -0,0 +1,131 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"golang.org/x/text/transform"
+)
+
+// record is a log entry as read from the input, before it's parsed. It's
+// usually one line, unless multi-line assembly is enabled.
+type record struct {
+	data   []byte
+	line   uint64
+	offset int64
+}
+
+type recordSource interface {
+	next() (record, bool)
+	err() error
+	// stop tells the source no more records will be read.
+	stop()
+}
+
+// newRecordSource reads the records out of lines, assembling them out of
+// multiple lines and exploding batches if the options ask for it.
+func newRecordSource(lines recordSource, opts *HandlerOptions) recordSource {
+	records := lines
+	if opts.MultilineStart != nil || opts.MultilineJSON {
+		records = newAssembler(lines, opts)
+	}
+	if opts.Batches {
+		records = &batchSplitter{src: records}
+	}
+	return records
+}
+
+// pushback reads the records of src, with a way to put the last one back.
+type pushback struct {
+	src  recordSource
+	held *record
+}
+
+func (p *pushback) next() (record, bool) {
+	if rec := p.held; rec != nil {
+		p.held = nil
+		return *rec, true
+	}
+	return p.src.next()
+}
+
+// unread makes next return rec again.
+func (p *pushback) unread(rec record) {
+	rec.data = append([]byte(nil), rec.data...)
+	p.held = &rec
+}
+
+func (p *pushback) err() error { return p.src.err() }
+
+func (p *pushback) stop() { p.src.stop() }
+
+// lineReader reads src one line at a time, keeping track of where each line
+// starts in src.
+type lineReader struct {
+	in   *bufio.Scanner
+	line uint64
+	// offset is where the current line starts in src, consumed is how much
+	// of src the scanner went through so far.
+	offset, consumed int64
+	// decode converts the lines to UTF-8, when src is in another encoding,
+	// into buf.
+	decode transform.Transformer
+	buf    []byte
+}
+
+func newLineReader(src io.Reader) *lineReader {
+	return newEncodedLineReader(src, 0, bufio.ScanLines, nil)
+}
+
+// newEncodedLineReader reads the lines of src as split by split, converting
+// them to UTF-8 with decode unless it's nil. skipped is how much of src was
+// read already, like its byte order mark.
+func newEncodedLineReader(src io.Reader, skipped int64, split bufio.SplitFunc, decode transform.Transformer) *lineReader {
+	r := &lineReader{in: bufio.NewScanner(src), consumed: skipped, decode: decode}
+	r.in.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := split(data, atEOF)
+		if token != nil {
+			r.offset = r.consumed
+		}
+		r.consumed += int64(advance)
+		return advance, token, err
+	})
+	return r
+}
+
+// next returns the next line. Its data is only valid until the next call.
+func (r *lineReader) next() (record, bool) {
+	if !r.in.Scan() {
+		return record{}, false
+	}
+	r.line++
+	data := r.in.Bytes()
+	if r.decode != nil {
+		data = r.decodeLine(data)
+	}
+	return record{data: data, line: r.line, offset: r.offset}, true
+}
+
+// decodeLine converts line to UTF-8. None of the encodings takes more than
+// three bytes of UTF-8 for each byte of the line, so buf is made that large
+// and the conversion never runs short of room.
+func (r *lineReader) decodeLine(line []byte) []byte {
+	if n := 3 * len(line); cap(r.buf) < n {
+		r.buf = make([]byte, n)
+	}
+	r.decode.Reset()
+	n, _, _ := r.decode.Transform(r.buf[:cap(r.buf)], line, true)
+	return bytes.TrimSuffix(r.buf[:n], []byte{'\r'})
+}
+
+func (r *lineReader) stop() {}
+
+func (r *lineReader) err() error {
+	switch err := r.in.Err(); err {
+	case nil, io.EOF:
+		return nil
+	default:
+		return err
+	}
+}
@@ -0,0 +1,169 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session is a recorded stream of lines, with how long after the previous
+// line each came, to replay through humanlog as it was received: to test
+// what it renders of the streams of real services, how much memory it
+// takes, and how it behaves when lines are slow to come, like the
+// entries assembled over several lines are.
+//
+// Sessions are written a line per line, the delay, a tab and the line:
+//
+//	0s	{"level":"info","msg":"starting"}
+//	1.5s	level=warn msg="slow start"
+//
+// Lines without a delay come right after the previous one.
+type Session struct {
+	Lines []SessionLine
+}
+
+// SessionLine is a line of a session, and how long after the previous one
+// it came.
+type SessionLine struct {
+	Delay time.Duration
+	Data  []byte
+}
+
+// ReadSession reads a session written by RecordSession, or by hand.
+func ReadSession(r io.Reader) (*Session, error) {
+	s := &Session{}
+	lines := bufio.NewScanner(r)
+	for lines.Scan() {
+		line := SessionLine{Data: append([]byte(nil), lines.Bytes()...)}
+		if i := bytes.IndexByte(line.Data, '\t'); i > 0 {
+			if d, err := time.ParseDuration(string(line.Data[:i])); err == nil && d >= 0 {
+				line.Delay, line.Data = d, line.Data[i+1:]
+			}
+		}
+		s.Lines = append(s.Lines, line)
+	}
+	return s, lines.Err()
+}
+
+// RecordSession writes the lines of src onto dst as a session, with the
+// delays they came after, until src ends.
+func RecordSession(dst io.Writer, src io.Reader) error {
+	lines := bufio.NewScanner(src)
+	w := bufio.NewWriter(dst)
+	last := time.Now()
+	for lines.Scan() {
+		now := time.Now()
+		fmt.Fprintf(w, "%s\t%s\n", now.Sub(last).Round(time.Millisecond), lines.Bytes())
+		last = now
+		// the lines are written as they come, for a session cut short to
+		// still have them
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return lines.Err()
+}
+
+// Duration is how long the session took to be recorded.
+func (s *Session) Duration() time.Duration {
+	var d time.Duration
+	for _, line := range s.Lines {
+		d += line.Delay
+	}
+	return d
+}
+
+// Reader replays the session, speed times faster than it was recorded, or
+// without waiting at all if speed isn't positive.
+func (s *Session) Reader(speed float64) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		for _, line := range s.Lines {
+			if speed > 0 && line.Delay > 0 {
+				time.Sleep(time.Duration(float64(line.Delay) / speed))
+			}
+			if _, err := pw.Write(append(line.Data[:len(line.Data):len(line.Data)], '\n')); err != nil {
+				return
+			}
+		}
+		pw.Close()
+	}()
+	return pr
+}
+
+// ReplayResult is what replaying a session gave.
+type ReplayResult struct {
+	// Output is what was rendered.
+	Output []byte
+	// Elapsed is how long the replay took.
+	Elapsed time.Duration
+	// PeakHeap is the most memory the heap held more than before the
+	// replay, sampled every few milliseconds.
+	PeakHeap uint64
+}
+
+// Lines returns the lines rendered, without their colors.
+func (r *ReplayResult) Lines() []string {
+	out := strings.TrimSuffix(ansiEscape.ReplaceAllString(string(r.Output), ""), "\n")
+	if out == "" {
+		return nil
+	}
+	return strings.Split(out, "\n")
+}
+
+// replaySampling is how often the heap is measured during a replay.
+const replaySampling = 5 * time.Millisecond
+
+// Replay runs the session through the whole of humanlog, as Scanner does,
+// speed times faster than it was recorded.
+func (s *Session) Replay(opts *HandlerOptions, speed float64) (*ReplayResult, error) {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	base := stats.HeapAlloc
+
+	var (
+		mu   sync.Mutex
+		peak uint64
+		done = make(chan struct{})
+		wg   sync.WaitGroup
+	)
+	sample := func() {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		mu.Lock()
+		if stats.HeapAlloc > base && stats.HeapAlloc-base > peak {
+			peak = stats.HeapAlloc - base
+		}
+		mu.Unlock()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tick := time.NewTicker(replaySampling)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick.C:
+				sample()
+			}
+		}
+	}()
+
+	var out bytes.Buffer
+	start := time.Now()
+	err := Scanner(s.Reader(speed), &out, opts)
+	elapsed := time.Since(start)
+	sample()
+	close(done)
+	wg.Wait()
+
+	return &ReplayResult{Output: out.Bytes(), Elapsed: elapsed, PeakHeap: peak}, err
+}
@@ -0,0 +1,148 @@
+package humanlog
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+var update = flag.Bool("update", false, "rewrite the .golden files of the sessions replayed")
+
+// replayCeiling is the most memory replaying any of the sessions may take.
+const replayCeiling = 64 << 20
+
+func replayOptions() *HandlerOptions {
+	opts := *DefaultOptions
+	opts.TimeFormat = time.RFC3339
+	opts.MultilineJSON = true
+	opts.MultilineTimeout = 100 * time.Millisecond
+	opts.ClockSkew = false
+	return &opts
+}
+
+// TestReplaySessions replays the sessions of testdata/sessions ten times
+// faster than they were recorded, and compares what's rendered with their
+// .golden file. go test -run ReplaySessions -update rewrites them.
+func TestReplaySessions(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	paths, err := filepath.Glob(filepath.Join("testdata", "sessions", "*.session"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no sessions (%v)", err)
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session, err := ReadSession(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+
+		res, err := session.Replay(replayOptions(), 10)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if res.PeakHeap > replayCeiling {
+			t.Errorf("%s: took %s, more than %s", path, byteSize(int64(res.PeakHeap)), byteSize(replayCeiling))
+		}
+		if min := session.Duration() / 10; res.Elapsed < min {
+			t.Errorf("%s: replayed in %s, faster than the %s it should take", path, res.Elapsed, min)
+		}
+
+		got := strings.Join(res.Lines(), "\n") + "\n"
+		golden := strings.TrimSuffix(path, ".session") + ".golden"
+		if *update {
+			if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != string(want) {
+			t.Errorf("%s: want\n%s\ngot\n%s", path, want, got)
+		}
+	}
+}
+
+// TestReplayOrder replays many entries as fast as they can go, to check
+// that they all come out, in the order they came in, without the memory
+// growing with their number.
+func TestReplayOrder(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	const n = 20000
+	session := &Session{}
+	for i := 0; i < n; i++ {
+		var line string
+		switch i % 3 {
+		case 0:
+			line = fmt.Sprintf(`{"level":"info","msg":"entry","seq":%d}`, i)
+		case 1:
+			line = fmt.Sprintf(`level=info msg=entry seq=%d`, i)
+		default:
+			line = fmt.Sprintf(`2024-01-02T10:00:00Z stdout F {"level":"info","msg":"entry","seq":%d}`, i)
+		}
+		session.Lines = append(session.Lines, SessionLine{Data: []byte(line)})
+	}
+
+	res, err := session.Replay(replayOptions(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := res.Lines()
+	if len(lines) != n {
+		t.Fatalf("want %d lines, got %d", n, len(lines))
+	}
+	for i, line := range lines {
+		j := strings.Index(line, "seq=")
+		if j < 0 {
+			t.Fatalf("line %d has no seq: %q", i, line)
+		}
+		seq, err := strconv.Atoi(strings.Fields(line[j+len("seq="):])[0])
+		if err != nil || seq != i {
+			t.Fatalf("line %d: want seq=%d, got %q", i, i, line)
+		}
+	}
+	if res.PeakHeap > replayCeiling {
+		t.Errorf("took %s, more than %s", byteSize(int64(res.PeakHeap)), byteSize(replayCeiling))
+	}
+}
+
+func TestRecordSession(t *testing.T) {
+	var recorded bytes.Buffer
+	if err := RecordSession(&recorded, strings.NewReader("first\nsecond\tcolumn\n")); err != nil {
+		t.Fatal(err)
+	}
+	session, err := ReadSession(&recorded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(session.Lines) != 2 || string(session.Lines[0].Data) != "first" || string(session.Lines[1].Data) != "second\tcolumn" {
+		t.Errorf("got %q", recorded.String())
+	}
+
+	session, err = ReadSession(strings.NewReader("1.5s\tlate\nno delay\n5m\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Duration() != 1500*time.Millisecond || string(session.Lines[1].Data) != "no delay" || string(session.Lines[2].Data) != "5m" {
+		t.Errorf("got %+v", session.Lines)
+	}
+}
@@ -1,9 +1,8 @@
 package humanlog
 
 import (
-	"bufio"
-	"bytes"
 	"io"
+	"time"
 )
 
 var (
@@ -14,60 +13,233 @@ var (
 // the lines aren't JSON-structured, it will simply write them out with no
 // prettification.
 func Scanner(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
-	in := bufio.NewScanner(src)
-	in.Split(bufio.ScanLines)
+	r := newRenderer(dst, opts)
 
-	var line uint64
-
-	var lastLogfmt bool
-	var lastJSON bool
-
-	logfmtEntry := LogfmtHandler{Opts: opts}
-	jsonEntry := JSONHandler{Opts: opts}
+	stages := builtinStages(opts)
+	if r.tail != nil && opts.Panes == nil {
+		// right after parsing, to see what the filters drop
+		stages = append([]Stage{stages[0], r.tail.record}, stages[1:]...)
+	}
+	if len(opts.Sinks) != 0 {
+		stages = append(stages, sinkStage(opts))
+	}
+	if len(opts.Alerts) != 0 {
+		stages = append(stages, alertStage(dst, opts))
+	}
+	if len(opts.Hooks) != 0 {
+		stages = append(stages, hookStage(dst, opts))
+	}
+	if opts.Bell != nil {
+		stages = append(stages, bellStage(dst, opts))
+	}
+	if r.bar != nil && opts.Panes == nil {
+		// first, to clear the bar before any stage writes
+		stages = append([]Stage{levelBarStage(dst, opts, r.bar)}, stages...)
+	}
+	render, finish := r.render, r.finish
+	if opts.Table {
+		t := newTable(dst, opts)
+		render, finish = t.render, t.flush
+	}
+	if opts.Panes != nil {
+		render, finish = opts.Panes.render, opts.Panes.finish
+	}
+	stages = append(stages, render)
 
-	for in.Scan() {
-		line++
-		lineData := in.Bytes()
+	err := Process(src, opts, Chain(stages...))
+	finish()
+	return err
+}
 
-		// remove that pesky syslog crap
-		lineData = bytes.TrimPrefix(lineData, []byte("@cee: "))
-		lineData = bytes.TrimPrefix(lineData, []byte("@cee:"))
+// Render writes the entries onto dst, prettified if they were parsed or as
+// they were read otherwise.
+func Render(dst io.Writer, opts *HandlerOptions) Stage {
+	return newRenderer(dst, opts).render
+}
 
-		switch {
+// renderer holds what rendering needs to remember from one entry to the next.
+type renderer struct {
+	dst  io.Writer
+	opts *HandlerOptions
+
+	diag   *diagnostics
+	spark  *sparkline
+	sep    *separators
+	exc    *exceptions
+	tail   *tailBuffer
+	sticky *stickyHeader
+	coal   *coalescer
+	skew   *clockSkew
+	bar    *levelBar
+
+	// shown is the handler of the entry on the previous line, if one was
+	// rendered there. Unchanged keys are only skipped against it, not
+	// against entries the stages dropped.
+	shown handler
+}
 
-		case jsonEntry.TryHandle(lineData):
-			dst.Write(jsonEntry.Prettify(opts.SkipUnchanged && lastJSON))
-			lastJSON = true
+func newRenderer(dst io.Writer, opts *HandlerOptions) *renderer {
+	r := &renderer{dst: dst, opts: opts}
+	if opts.Strict {
+		r.diag = newDiagnostics()
+	}
+	if opts.SparkField != "" {
+		r.spark = newSparkline(opts.SparkWidth)
+	}
+	if opts.Separators > 0 {
+		r.sep = &separators{every: opts.Separators}
+	}
+	if opts.Exceptions {
+		r.exc = &exceptions{}
+	}
+	if opts.TailBuffer > 0 {
+		r.tail = newTailBuffer(opts)
+	}
+	if opts.StickyHeader > 0 && opts.SkipUnchanged {
+		r.sticky = &stickyHeader{every: opts.StickyHeader}
+	}
+	if opts.ClockSkew {
+		r.skew = newClockSkew()
+	}
+	if opts.Coalesce > 0 {
+		r.coal = newCoalescer(opts.Coalesce)
+	}
+	if opts.LevelBar > 0 {
+		r.bar = newLevelBar(opts.LevelBar)
+	}
+	return r
+}
 
-		case logfmtEntry.TryHandle(lineData):
-			dst.Write(logfmtEntry.Prettify(opts.SkipUnchanged && lastLogfmt))
-			lastLogfmt = true
+func (r *renderer) render(e *Entry, next Next) error {
+	dst, opts, ev := r.dst, r.opts, e.Event
 
-		case tryDockerComposePrefix(lineData, &jsonEntry):
-			dst.Write(jsonEntry.Prettify(opts.SkipUnchanged && lastJSON))
-			lastJSON = true
+	if r.bar != nil && ev != nil {
+		r.bar.observe(opts, ev, time.Now())
+	}
 
-		case tryDockerComposePrefix(lineData, &logfmtEntry):
-			dst.Write(logfmtEntry.Prettify(opts.SkipUnchanged && lastLogfmt))
-			lastLogfmt = true
+	if r.coal != nil {
+		var dropped bool
+		if ev != nil {
+			dropped = r.coal.observe(dst, opts, ev)
+		} else {
+			dropped = r.coal.continues(dst, opts, r.exc != nil && r.exc.continues(e.Raw))
+		}
+		if dropped {
+			// the burst's summary stands for the entry, which the tail
+			// buffer mustn't show again
+			if e.h != nil {
+				e.h.clear()
+			}
+			if ev != nil && r.exc != nil {
+				r.exc.entry()
+			}
+			e.rendered = true
+			return next(e)
+		}
+	}
+	if r.skew != nil && ev != nil {
+		r.skew.observe(dst, opts, ev)
+	}
+	if r.sep != nil && ev != nil {
+		r.sep.observe(dst, opts, ev.Time)
+	}
+	if r.tail != nil && ev != nil {
+		r.tail.flush(dst, ev)
+	}
+	skip := e.skip && e.h != nil && e.h == r.shown
+	if r.sticky != nil && ev != nil {
+		r.sticky.observe(dst, opts, ev, skip)
+	}
 
-		case tryZapDevPrefix(lineData, &jsonEntry):
-			dst.Write(jsonEntry.Prettify(opts.SkipUnchanged && lastJSON))
-			lastJSON = true
+	if opts.Gutter {
+		writeGutter(dst, opts, opts.sourceOf(ev))
+	}
+	if opts.LineNumbers {
+		writeLineNumber(dst, opts, e.Line, e.Offset)
+	}
 
-		default:
-			lastLogfmt = false
-			lastJSON = false
-			dst.Write(lineData)
+	if ev == nil {
+		r.shown = nil
+	}
+	if ev == nil && r.exc != nil && r.exc.continues(e.Raw) {
+		writeExceptionLine(dst, opts, e.Raw)
+		return next(e)
+	}
+	if ev == nil {
+		dst.Write(e.Raw)
+		if r.diag != nil {
+			r.diag.annotate(dst, opts, r.diag.rejected(e.Raw))
 		}
 		dst.Write(eol[:])
+		return next(e)
+	}
 
+	if r.exc != nil {
+		r.exc.entry()
 	}
 
-	switch err := in.Err(); err {
-	case nil, io.EOF:
-		return nil
-	default:
-		return err
+	var missing []string
+	if r.diag != nil {
+		missing = r.diag.incomplete(e.Format, ev)
+	}
+	if r.spark != nil {
+		r.spark.observe(ev.Fields[opts.SparkField])
 	}
+
+	dst.Write(e.h.Prettify(opts.SkipUnchanged && skip))
+	e.rendered, r.shown = true, e.h
+
+	if r.spark != nil && r.spark.len() > 0 {
+		dst.Write([]byte(" "))
+		dst.Write([]byte(opts.KeyColor.Sprint(opts.SparkField)))
+		dst.Write([]byte(" "))
+		dst.Write([]byte(opts.ValColor.Sprint(r.spark.String())))
+	}
+	if r.diag != nil {
+		r.diag.annotate(dst, opts, missing)
+	}
+	dst.Write(eol[:])
+	return next(e)
+}
+
+// finish writes what's only known once all the entries were rendered.
+func (r *renderer) finish() {
+	if r.bar != nil {
+		r.bar.clear(r.dst)
+	}
+	if r.coal != nil {
+		r.coal.flush(r.dst, r.opts)
+	}
+	if r.diag != nil {
+		r.diag.summarize(r.dst, r.opts)
+	}
+	if r.opts.Truncations != nil {
+		r.opts.Truncations.writeAppendix(r.dst, r.opts)
+	}
+	if b := r.opts.MemoryBudget; b != nil && b.Spilled() > 0 {
+		r.opts.DiagnosticColor.Fprintf(r.dst, "── went over the memory budget, %s of remembered lines were moved to disk\n", byteSize(b.Spilled()))
+	}
+}
+
+func writeLineNumber(dst io.Writer, opts *HandlerOptions, line uint64, offset int64) {
+	if opts.ByteOffsets {
+		opts.LineNumberColor.Fprintf(dst, "%6d:%-9d ", line, offset)
+	} else {
+		opts.LineNumberColor.Fprintf(dst, "%6d ", line)
+	}
+}
+
+// ScanEvents reads log lines from src and calls fn with each entry that could
+// be parsed. Lines that aren't recognized are ignored.
+func ScanEvents(src io.Reader, opts *HandlerOptions, fn func(*Event) error) error {
+	stages := append(builtinStages(opts), func(e *Entry, next Next) error {
+		if e.Event == nil {
+			return next(e)
+		}
+		if err := fn(e.Event); err != nil {
+			return err
+		}
+		return next(e)
+	})
+	return Process(src, opts, Chain(stages...))
 }
@@ -0,0 +1,49 @@
+package humanlog
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// separators draws a rule whenever the time of the entries crosses into a
+// new bucket of a given duration.
+type separators struct {
+	every time.Duration
+	last  time.Time
+}
+
+func (s *separators) observe(dst io.Writer, opts *HandlerOptions, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	bucket := s.bucket(t)
+	if s.last.IsZero() {
+		s.last = bucket
+		return
+	}
+	if bucket.Equal(s.last) {
+		return
+	}
+	s.last = bucket
+	rule := strings.Repeat("─", 20)
+	opts.SeparatorColor.Fprintf(dst, "%s %s %s\n", rule, bucket.Format(opts.TimeFormat), rule)
+}
+
+// bucket returns the start of the bucket t is in. The durations that divide
+// a day are counted from midnight on the wall clock of t's location, for an
+// hour to start at :00 in zones that are half an hour off UTC and on the
+// days clocks change. Time.Truncate counts from the zero time, in UTC, which
+// is what's left for the others.
+func (s *separators) bucket(t time.Time) time.Time {
+	const day = 24 * time.Hour
+	if s.every <= 0 || day%s.every != 0 {
+		return t.Truncate(s.every)
+	}
+	hour, min, sec := t.Clock()
+	wall := time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute +
+		time.Duration(sec)*time.Second + time.Duration(t.Nanosecond())
+	wall -= wall % s.every
+	year, month, dd := t.Date()
+	return time.Date(year, month, dd, 0, 0, int(wall/time.Second), int(wall%time.Second), t.Location())
+}
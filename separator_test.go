@@ -0,0 +1,53 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+func TestSeparators_LocalBuckets(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	opts := *DefaultOptions
+	opts.TimeFormat = "15:04"
+	india := time.FixedZone("IST", 5*3600+1800)
+
+	var out bytes.Buffer
+	s := &separators{every: time.Hour}
+	for _, clock := range []string{"10:15", "10:45", "11:05", "11:55"} {
+		at, err := time.ParseInLocation("2006-01-02 15:04", "2024-01-02 "+clock, india)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.observe(&out, &opts, at)
+	}
+	// counted from UTC, the hours would start at half past, between 10:15
+	// and 10:45
+	rule := strings.Repeat("─", 20)
+	if want, got := rule+" 11:00 "+rule+"\n", out.String(); want != got {
+		t.Errorf("want %q, got %q, want != got", want, got)
+	}
+}
+
+func TestSeparators_Bucket(t *testing.T) {
+	at := time.Date(2024, 1, 2, 10, 47, 12, 5, time.FixedZone("IST", 5*3600+1800))
+	for _, tt := range []struct {
+		every time.Duration
+		want  time.Time
+	}{
+		{every: 15 * time.Minute, want: time.Date(2024, 1, 2, 10, 45, 0, 0, at.Location())},
+		{every: 6 * time.Hour, want: time.Date(2024, 1, 2, 6, 0, 0, 0, at.Location())},
+		{every: 24 * time.Hour, want: time.Date(2024, 1, 2, 0, 0, 0, 0, at.Location())},
+		{every: 7 * time.Minute, want: at.Truncate(7 * time.Minute)},
+	} {
+		s := &separators{every: tt.every}
+		if got := s.bucket(at); !got.Equal(tt.want) || got.Location() != at.Location() {
+			t.Errorf("%s: want %s, got %s, want != got", tt.every, tt.want, got)
+		}
+	}
+}
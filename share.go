@@ -0,0 +1,207 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// shareBacklog is how many of the latest rows a browser gets when it
+// connects, before the live ones.
+const shareBacklog = 1000
+
+// ShareServer serves the entries to browsers as they're rendered, as a page
+// updated live with server-sent events, and to Connect and gRPC-Web clients
+// with its Watch endpoint.
+type ShareServer struct {
+	// AllowedOrigins are the origins of the pages, like the dev tools of the
+	// app being worked on, whose scripts can watch the entries. The pages
+	// served by the server itself always can.
+	AllowedOrigins []string
+
+	opts *HandlerOptions
+
+	mu      sync.Mutex
+	backlog []*sharedEntry
+	clients map[chan *sharedEntry]struct{}
+}
+
+// sharedEntry is an entry as it's sent to each kind of client.
+type sharedEntry struct {
+	row    string
+	event  *Event
+	record []byte
+}
+
+// NewShareServer creates a server sharing the entries rendered with opts.
+// Add its Stage to the options' stages to feed it.
+func NewShareServer(opts *HandlerOptions) *ShareServer {
+	return &ShareServer{opts: opts, clients: make(map[chan *sharedEntry]struct{})}
+}
+
+// Stage publishes each entry to the connected browsers.
+func (s *ShareServer) Stage(e *Entry, next Next) error {
+	var buf bytes.Buffer
+	writeHTMLRow(&buf, s.opts, e)
+	shared := &sharedEntry{row: strings.TrimRight(buf.String(), "\n")}
+	if e.Event != nil {
+		shared.event = s.opts.visibleEvent(e.Event)
+	} else {
+		shared.event = &Event{Message: string(e.Raw)}
+	}
+	shared.record, _ = json.Marshal(newNDJSONRecord(e, s.opts))
+
+	s.mu.Lock()
+	if len(s.backlog) == shareBacklog {
+		s.backlog = s.backlog[1:]
+	}
+	s.backlog = append(s.backlog, shared)
+	for c := range s.clients {
+		select {
+		case c <- shared:
+		default:
+			// too slow to keep up, it will reconnect and start over
+			delete(s.clients, c)
+			close(c)
+		}
+	}
+	s.mu.Unlock()
+
+	return next(e)
+}
+
+func (s *ShareServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		s.servePage(w)
+	case "/events":
+		s.serveEvents(w, r)
+	case "/viewer":
+		s.serveViewer(w)
+	case watchPath:
+		s.serveWatch(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *ShareServer) servePage(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = htmlReportHeader.Execute(w, struct{ Generated string }{"live"})
+	_, _ = io.WriteString(w, sharePageFooter)
+}
+
+func (s *ShareServer) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	backlog, c, unsubscribe := s.subscribe()
+	defer unsubscribe()
+
+	for _, shared := range backlog {
+		writeEvent(w, shared.row)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case shared, ok := <-c:
+			if !ok {
+				return
+			}
+			writeEvent(w, shared.row)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// subscribe returns the backlog and a channel of the entries to come, which
+// is closed if the client falls behind.
+func (s *ShareServer) subscribe() ([]*sharedEntry, chan *sharedEntry, func()) {
+	c := make(chan *sharedEntry, 256)
+	s.mu.Lock()
+	backlog := append([]*sharedEntry(nil), s.backlog...)
+	s.clients[c] = struct{}{}
+	s.mu.Unlock()
+	return backlog, c, func() {
+		s.mu.Lock()
+		if _, ok := s.clients[c]; ok {
+			delete(s.clients, c)
+			close(c)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// writeEvent writes data as a server-sent event, which can't hold newlines
+// unless each line is sent as data.
+func writeEvent(w io.Writer, data string) {
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	io.WriteString(w, "\n")
+}
+
+const sharePageFooter = `</table>
+<script>
+(function () {
+  var table = document.getElementById("log");
+  var q = document.getElementById("q");
+  var boxes = document.querySelectorAll("input.lv");
+  var total = 0, shown = 0;
+  function visible(row) {
+    var text = q.value.toLowerCase();
+    var lvl = (row.className.match(/lvl-(\w+)/) || [])[1];
+    var level = !lvl || Array.prototype.some.call(boxes, function (b) { return b.value === lvl && b.checked; });
+    return level && (!text || row.textContent.toLowerCase().indexOf(text) >= 0);
+  }
+  function count() {
+    document.getElementById("n").textContent = shown + " / " + total;
+  }
+  function apply() {
+    shown = 0;
+    table.querySelectorAll("tr").forEach(function (row) {
+      var ok = visible(row);
+      row.classList.toggle("hidden", !ok);
+      if (ok) shown++;
+    });
+    count();
+  }
+  q.addEventListener("input", apply);
+  boxes.forEach(function (b) { b.addEventListener("change", apply); });
+
+  var events = new EventSource("events");
+  events.onopen = function () {
+    table.innerHTML = "";
+    total = shown = 0;
+  };
+  events.onmessage = function (e) {
+    var atBottom = window.innerHeight + window.scrollY >= document.body.scrollHeight - 4;
+    var body = document.createElement("tbody");
+    body.innerHTML = e.data;
+    var row = body.firstElementChild;
+    if (!row) return;
+    table.appendChild(row);
+    total++;
+    var ok = visible(row);
+    row.classList.toggle("hidden", !ok);
+    if (ok) shown++;
+    count();
+    if (atBottom) window.scrollTo(0, document.body.scrollHeight);
+  };
+})();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,53 @@
+package humanlog
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestShareServer(t *testing.T) {
+	opts := *DefaultOptions
+	srv := NewShareServer(&opts)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	feed := func(lines ...string) {
+		t.Helper()
+		err := Process(strings.NewReader(strings.Join(lines, "\n")), &opts, Chain(Parse(&opts), srv.Stage))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	feed(`{"msg": "before"}`)
+
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("want an event stream, got %q", ct)
+	}
+	events := bufio.NewScanner(resp.Body)
+	nextEvent := func() string {
+		t.Helper()
+		for events.Scan() {
+			if line := events.Text(); strings.HasPrefix(line, "data: ") {
+				return line
+			}
+		}
+		t.Fatal("stream ended")
+		return ""
+	}
+
+	if got := nextEvent(); !strings.Contains(got, "before") {
+		t.Errorf("want the backlog first, got %q", got)
+	}
+	feed(`{"msg": "after"}`)
+	if got := nextEvent(); !strings.Contains(got, "after") {
+		t.Errorf("want the live entry, got %q", got)
+	}
+}
@@ -0,0 +1,353 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// watchPath is the route of the Watch procedure, a server stream of the
+// entries, named like a gRPC method so that Connect and gRPC-Web clients can
+// call it.
+const watchPath = "/humanlog.v1.LogService/Watch"
+
+// The content types of the protocols Watch speaks. Both frame each message
+// with a flags byte and its length, and only differ in how the stream ends.
+const (
+	connectContentType = "application/connect+json"
+	grpcWebContentType = "application/grpc-web+json"
+)
+
+// The flags of the frame ending the stream, in each protocol.
+const (
+	connectEndStream = 0x02
+	grpcWebTrailers  = 0x80
+)
+
+// maxWatchRequest is how large a Watch request can be.
+const maxWatchRequest = 64 << 10
+
+// WatchRequest is what a Watch client asks for. Its filters are the ones of
+// the command line: MinLevel as --min-level, Conditions as the ones of
+// --level-rule, and Contains searches the entries for a text, whatever its
+// case.
+type WatchRequest struct {
+	MinLevel   string   `json:"minLevel,omitempty"`
+	Conditions []string `json:"conditions,omitempty"`
+	Contains   string   `json:"contains,omitempty"`
+}
+
+type watchFilter struct {
+	opts     *HandlerOptions
+	min      int
+	conds    []fieldCondition
+	contains string
+}
+
+func newWatchFilter(opts *HandlerOptions, req *WatchRequest) (*watchFilter, error) {
+	f := &watchFilter{opts: opts, contains: strings.ToLower(req.Contains)}
+	if req.MinLevel != "" {
+		min, ok := opts.LevelRank(req.MinLevel)
+		if !ok {
+			return nil, fmt.Errorf("unknown level %q", req.MinLevel)
+		}
+		f.min = min
+	}
+	for _, s := range req.Conditions {
+		for _, word := range strings.Fields(s) {
+			cond, err := parseFieldCondition(word)
+			if err != nil {
+				return nil, err
+			}
+			f.conds = append(f.conds, cond)
+		}
+	}
+	return f, nil
+}
+
+// sameOrigin tells whether origin is that of the pages of the server on
+// host.
+func sameOrigin(origin, host string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == host
+}
+
+func (f *watchFilter) matches(shared *sharedEntry) bool {
+	if rank, ok := f.opts.LevelRank(shared.event.Level); ok && rank < f.min {
+		return false
+	}
+	for i := range f.conds {
+		if !f.conds[i].matches(shared.event) {
+			return false
+		}
+	}
+	return f.contains == "" || strings.Contains(strings.ToLower(string(shared.record)), f.contains)
+}
+
+// serveWatch streams the entries matching the request, the backlog first,
+// as the JSON objects of --output ndjson-normalized. Browsers can only call
+// it from the server's own pages and from AllowedOrigins, lest any site
+// visited read the entries.
+func (s *ShareServer) serveWatch(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" && !sameOrigin(origin, r.Host) {
+		if !containsField(s.AllowedOrigins, origin) {
+			http.Error(w, fmt.Sprintf("origin %s isn't allowed", origin), http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Expose-Headers", "Grpc-Status, Grpc-Message")
+		w.Header().Add("Vary", "Origin")
+	}
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Methods", "POST")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Connect-Protocol-Version, Connect-Timeout-Ms, X-Grpc-Web, X-User-Agent")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodPost:
+	default:
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "want POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != connectContentType && contentType != grpcWebContentType {
+		http.Error(w, fmt.Sprintf("want %s or %s", connectContentType, grpcWebContentType), http.StatusUnsupportedMediaType)
+		return
+	}
+	grpcWeb := contentType == grpcWebContentType
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	var req WatchRequest
+	payload, err := readFrame(io.LimitReader(r.Body, maxWatchRequest))
+	if err == nil && len(bytes.TrimSpace(payload)) != 0 {
+		err = json.Unmarshal(payload, &req)
+	}
+	var filter *watchFilter
+	if err == nil {
+		filter, err = newWatchFilter(s.opts, &req)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err != nil {
+		endWatch(w, grpcWeb, "invalid_argument", err.Error())
+		return
+	}
+
+	backlog, c, unsubscribe := s.subscribe()
+	defer unsubscribe()
+	send := func(shared *sharedEntry) {
+		if filter.matches(shared) {
+			writeFrame(w, 0, shared.record)
+		}
+	}
+
+	for _, shared := range backlog {
+		send(shared)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case shared, ok := <-c:
+			if !ok {
+				endWatch(w, grpcWeb, "unavailable", "too slow to keep up")
+				return
+			}
+			send(shared)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// readFrame reads the message of a request, which Connect and gRPC-Web
+// clients both send framed. An empty body is an empty message.
+func readFrame(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading request: %v", err)
+	}
+	if prefix[0]&0x01 != 0 {
+		return nil, fmt.Errorf("compressed requests aren't supported")
+	}
+	payload, err := ioutil.ReadAll(io.LimitReader(r, int64(binary.BigEndian.Uint32(prefix[1:]))))
+	if err != nil {
+		return nil, fmt.Errorf("reading request: %v", err)
+	}
+	return payload, nil
+}
+
+func writeFrame(w io.Writer, flags byte, payload []byte) {
+	var prefix [5]byte
+	prefix[0] = flags
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(payload)))
+	_, _ = w.Write(prefix[:])
+	_, _ = w.Write(payload)
+}
+
+// grpcStatusCodes are the numbers gRPC gives to the codes Connect names.
+var grpcStatusCodes = map[string]int{
+	"invalid_argument": 3,
+	"unavailable":      14,
+}
+
+// endWatch ends the stream with an error, as a Connect end-of-stream message
+// or as gRPC-Web trailers.
+func endWatch(w io.Writer, grpcWeb bool, code, message string) {
+	if grpcWeb {
+		trailers := fmt.Sprintf("grpc-status: %d\r\ngrpc-message: %s\r\n", grpcStatusCodes[code], url.PathEscape(message))
+		writeFrame(w, grpcWebTrailers, []byte(trailers))
+		return
+	}
+	var end struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	end.Error.Code, end.Error.Message = code, message
+	payload, _ := json.Marshal(end)
+	writeFrame(w, connectEndStream, payload)
+}
+
+func (s *ShareServer) serveViewer(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = io.WriteString(w, watchViewerPage)
+}
+
+// watchViewerPage is a client of Watch, for watching the entries with the
+// filters of the command line from a browser tab.
+const watchViewerPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>humanlog viewer</title>
+<style>
+body { font-family: monospace; font-size: 13px; margin: 0; background: #fff; color: #222; }
+form { position: sticky; top: 0; padding: 8px; background: #eee; border-bottom: 1px solid #ccc; }
+input[name=conditions] { width: 24em; }
+table { border-collapse: collapse; width: 100%; }
+td { padding: 1px 6px; vertical-align: top; white-space: pre-wrap; }
+td.level { font-weight: bold; }
+.trace, .debug { color: #888; } .warn, .warning { color: #b80; }
+.error, .critical, .fatal, .panic { color: #c00; }
+#status { margin-left: 1em; color: #666; }
+</style>
+</head>
+<body>
+<form id="filters">
+<label>min level <input name="minLevel" size="8" placeholder="info"></label>
+<label>where <input name="conditions" placeholder="status>=500 service=api"></label>
+<label>contains <input name="contains" size="16"></label>
+<button>watch</button><span id="status"></span>
+</form>
+<table><tbody id="log"></tbody></table>
+<script>
+(function () {
+  var form = document.getElementById("filters");
+  var log = document.getElementById("log");
+  var status = document.getElementById("status");
+  var controller;
+
+  function cell(row, text, cls) {
+    var td = row.insertCell();
+    td.textContent = text;
+    if (cls) td.className = cls;
+  }
+  function show(entry) {
+    var atBottom = window.innerHeight + window.scrollY >= document.body.scrollHeight - 4;
+    var row = log.insertRow();
+    var level = (entry.level || "").toLowerCase();
+    cell(row, entry.ts || "");
+    cell(row, entry.level || "", "level " + level);
+    cell(row, entry.msg);
+    cell(row, Object.keys(entry.fields).map(function (k) {
+      return k + "=" + JSON.stringify(entry.fields[k]);
+    }).join(" "));
+    if (atBottom) window.scrollTo(0, document.body.scrollHeight);
+  }
+  function frame(message) {
+    var body = new TextEncoder().encode(JSON.stringify(message));
+    var out = new Uint8Array(5 + body.length);
+    new DataView(out.buffer).setUint32(1, body.length);
+    out.set(body, 5);
+    return out;
+  }
+  function watch() {
+    if (controller) controller.abort();
+    controller = new AbortController();
+    log.innerHTML = "";
+    var req = {
+      minLevel: form.minLevel.value.trim(),
+      conditions: form.conditions.value.trim() ? [form.conditions.value.trim()] : [],
+      contains: form.contains.value
+    };
+    status.textContent = "connecting";
+    fetch("humanlog.v1.LogService/Watch", {
+      method: "POST",
+      headers: {"Content-Type": "application/connect+json", "Connect-Protocol-Version": "1"},
+      body: frame(req),
+      signal: controller.signal
+    }).then(function (resp) {
+      status.textContent = "watching";
+      var reader = resp.body.getReader();
+      var buf = new Uint8Array(0);
+      var decoder = new TextDecoder();
+      function read() {
+        return reader.read().then(function (chunk) {
+          if (chunk.done) {
+            status.textContent = "disconnected";
+            return;
+          }
+          var joined = new Uint8Array(buf.length + chunk.value.length);
+          joined.set(buf);
+          joined.set(chunk.value, buf.length);
+          buf = joined;
+          while (buf.length >= 5) {
+            var n = new DataView(buf.buffer, buf.byteOffset).getUint32(1);
+            if (buf.length < 5 + n) break;
+            var flags = buf[0];
+            var msg = JSON.parse(decoder.decode(buf.subarray(5, 5 + n)));
+            buf = buf.slice(5 + n);
+            if (flags & 2) {
+              status.textContent = msg.error ? msg.error.message : "ended";
+              return;
+            }
+            show(msg);
+          }
+          return read();
+        });
+      }
+      return read();
+    }).catch(function (err) {
+      if (err.name !== "AbortError") status.textContent = err.message;
+    });
+  }
+  form.addEventListener("submit", function (e) {
+    e.preventDefault();
+    watch();
+  });
+  watch();
+})();
+</script>
+</body>
+</html>
+`
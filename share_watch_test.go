@@ -0,0 +1,122 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestShareServerWatch(t *testing.T) {
+	opts := *DefaultOptions
+	srv := NewShareServer(&opts)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	feed := func(lines ...string) {
+		t.Helper()
+		err := Process(strings.NewReader(strings.Join(lines, "\n")), &opts, Chain(Parse(&opts), srv.Stage))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	feed(
+		`{"level": "info", "msg": "served", "status": 200}`,
+		`{"level": "error", "msg": "failed", "status": 502}`,
+		`{"level": "warn", "msg": "slow", "status": 200}`,
+	)
+
+	watch := func(contentType string, req WatchRequest) (*http.Response, func() (byte, []byte)) {
+		t.Helper()
+		payload, _ := json.Marshal(req)
+		var body bytes.Buffer
+		writeFrame(&body, 0, payload)
+		resp, err := http.Post(ts.URL+watchPath, contentType, &body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, func() (byte, []byte) {
+			t.Helper()
+			var prefix [5]byte
+			if _, err := io.ReadFull(resp.Body, prefix[:]); err != nil {
+				t.Fatal(err)
+			}
+			msg := make([]byte, binary.BigEndian.Uint32(prefix[1:]))
+			if _, err := io.ReadFull(resp.Body, msg); err != nil {
+				t.Fatal(err)
+			}
+			return prefix[0], msg
+		}
+	}
+
+	t.Run("connect", func(t *testing.T) {
+		resp, next := watch(connectContentType, WatchRequest{MinLevel: "warn", Conditions: []string{"status>=500"}})
+		defer resp.Body.Close()
+		if ct := resp.Header.Get("Content-Type"); ct != connectContentType {
+			t.Errorf("want %s, got %q", connectContentType, ct)
+		}
+		if _, msg := next(); !strings.Contains(string(msg), `"msg":"failed"`) {
+			t.Errorf("want the matching backlog first, got %s", msg)
+		}
+		feed(`{"level": "debug", "msg": "retrying", "status": 503}`, `{"level": "fatal", "msg": "gave up", "status": 503}`)
+		flags, msg := next()
+		if flags != 0 || !strings.Contains(string(msg), `"msg":"gave up"`) {
+			t.Errorf("want the live entry matching, got %x %s", flags, msg)
+		}
+	})
+
+	t.Run("grpc-web error", func(t *testing.T) {
+		resp, next := watch(grpcWebContentType, WatchRequest{MinLevel: "loud"})
+		defer resp.Body.Close()
+		flags, trailers := next()
+		if flags != grpcWebTrailers || !strings.Contains(string(trailers), "grpc-status: 3\r\n") {
+			t.Errorf("want invalid argument trailers, got %x %q", flags, trailers)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		resp, err := http.Post(ts.URL+watchPath, "application/json", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnsupportedMediaType {
+			t.Errorf("want 415, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestShareServerWatch_Origins(t *testing.T) {
+	srv := NewShareServer(DefaultOptions)
+	srv.AllowedOrigins = []string{"http://localhost:3000"}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	for _, tt := range []struct {
+		origin string
+		status int
+		allow  string
+	}{
+		{"", http.StatusNoContent, ""},
+		{ts.URL, http.StatusNoContent, ""},
+		{"http://localhost:3000", http.StatusNoContent, "http://localhost:3000"},
+		{"https://evil.example", http.StatusForbidden, ""},
+	} {
+		req, _ := http.NewRequest(http.MethodOptions, ts.URL+watchPath, nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); resp.StatusCode != tt.status || got != tt.allow {
+			t.Errorf("%q: want %d allowing %q, got %d allowing %q", tt.origin, tt.status, tt.allow, resp.StatusCode, got)
+		}
+	}
+}
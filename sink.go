@@ -0,0 +1,193 @@
+package humanlog
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// Sink receives the events humanlog parses, alongside them being printed.
+type Sink interface {
+	Send(ev *Event) error
+	Close() error
+}
+
+// NewSink creates a sink forwarding events to the collector at rawurl. The
+// scheme selects the protocol:
+//
+//	fluent://host:24224?tag=app     Fluentd forward protocol
+//	syslog://host:514               RFC 5424 syslog over UDP
+//	syslog+tcp://host:514           RFC 5424 syslog over TCP, octet counted
+//	otlp://host:4318?service=api    OpenTelemetry spans for error entries, over HTTP
+//	otlps://host:4318               same, over HTTPS
+func NewSink(rawurl string) (Sink, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "fluent", "fluentd":
+		tag := u.Query().Get("tag")
+		if tag == "" {
+			tag = "humanlog"
+		}
+		return &fluentSink{dialer: dialer("tcp", hostPort(u, "24224")), tag: tag}, nil
+	case "syslog", "syslog+udp":
+		return &syslogSink{dialer: dialer("udp", hostPort(u, "514"))}, nil
+	case "syslog+tcp":
+		return &syslogSink{dialer: dialer("tcp", hostPort(u, "514")), stream: true}, nil
+	case "otlp", "otlps":
+		return newOTLPSink(u), nil
+	default:
+		return nil, fmt.Errorf("unsupported sink scheme %q", u.Scheme)
+	}
+}
+
+// queuedSink sends the events to a sink in the background, so that a slow or
+// unreachable collector doesn't hold up rendering. The events coming while
+// its queue is full are dropped.
+type queuedSink struct {
+	sink    Sink
+	events  chan *Event
+	done    chan struct{}
+	dropped int64
+}
+
+// Queue returns a sink sending the events to s in the background, through a
+// queue of size events. Close sends what's queued before closing s.
+func Queue(s Sink, size int) Sink {
+	q := &queuedSink{sink: s, events: make(chan *Event, size), done: make(chan struct{})}
+	go q.run()
+	return q
+}
+
+func (q *queuedSink) run() {
+	defer close(q.done)
+	for ev := range q.events {
+		// s reports its failures, and backs off while its collector is
+		// unreachable
+		_ = q.sink.Send(ev)
+	}
+}
+
+func (q *queuedSink) Send(ev *Event) error {
+	select {
+	case q.events <- ev:
+		return nil
+	default:
+		atomic.AddInt64(&q.dropped, 1)
+		return errQueueFull
+	}
+}
+
+var errQueueFull = fmt.Errorf("the queue of events to forward is full")
+
+func (q *queuedSink) Close() error {
+	close(q.events)
+	<-q.done
+	err := q.sink.Close()
+	if n := atomic.LoadInt64(&q.dropped); n != 0 && err == nil {
+		err = fmt.Errorf("dropped %d events while the queue was full", n)
+	}
+	return err
+}
+
+func hostPort(u *url.URL, defaultPort string) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), defaultPort)
+}
+
+// conn lazily (re)connects to a collector, so a collector going away doesn't
+// stop the events from being printed. While the collector is unreachable,
+// writes fail with a RetryError until it's time to try again.
+type conn struct {
+	dial func() (net.Conn, error)
+	c    net.Conn
+	retrier
+}
+
+func dialer(network, addr string) *conn {
+	return &conn{
+		dial: func() (net.Conn, error) {
+			return net.DialTimeout(network, addr, 5*time.Second)
+		},
+		retrier: retrier{backoff: DefaultBackoff},
+	}
+}
+
+func (c *conn) Write(p []byte) (int, error) {
+	if c.c == nil {
+		if err := c.wait(); err != nil {
+			return 0, err
+		}
+		nc, err := c.dial()
+		if err != nil {
+			return 0, c.failed(err)
+		}
+		c.c = nc
+		c.succeeded()
+	}
+	n, err := c.c.Write(p)
+	if err != nil {
+		_ = c.c.Close()
+		c.c = nil
+		return n, c.failed(err)
+	}
+	return n, nil
+}
+
+func (c *conn) Close() error {
+	if c.c == nil {
+		return nil
+	}
+	err := c.c.Close()
+	c.c = nil
+	return err
+}
+
+// retrier keeps track of failed attempts at reaching a collector, backing off
+// before trying again.
+type retrier struct {
+	backoff Backoff
+	retry   *RetryError
+	retryAt time.Time
+}
+
+// wait returns the last error until it's time to try again.
+func (r *retrier) wait() error {
+	if r.retry != nil && time.Now().Before(r.retryAt) {
+		return r.retry
+	}
+	return nil
+}
+
+func (r *retrier) failed(err error) error {
+	wait, attempt := r.backoff.Next()
+	r.retry = &RetryError{Attempt: attempt, Wait: wait, Err: err}
+	r.retryAt = time.Now().Add(wait)
+	return r.retry
+}
+
+func (r *retrier) succeeded() {
+	r.retry = nil
+	r.backoff.Reset()
+}
+
+// sinkRecord is the flat representation of an event sent to collectors.
+func sinkRecord(ev *Event) map[string]string {
+	rec := make(map[string]string, len(ev.Fields)+2)
+	for k, v := range ev.Fields {
+		rec[k] = unquoteValue(v)
+	}
+	if ev.Level != "" {
+		rec["level"] = ev.Level
+	}
+	if ev.Message != "" {
+		rec["message"] = ev.Message
+	}
+	return rec
+}
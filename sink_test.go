@@ -0,0 +1,78 @@
+package humanlog
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingConn keeps what's written to it.
+type recordingConn struct {
+	net.Conn
+	mu      sync.Mutex
+	written []string
+}
+
+func (c *recordingConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.written = append(c.written, string(p))
+	return len(p), nil
+}
+
+func (c *recordingConn) Close() error { return nil }
+
+func recordingDialer(c *recordingConn) *conn {
+	return &conn{dial: func() (net.Conn, error) { return c, nil }, retrier: retrier{backoff: DefaultBackoff}}
+}
+
+// blockingSink holds up every Send until it's released.
+type blockingSink struct {
+	release chan struct{}
+	mu      sync.Mutex
+	sent    []string
+	closed  bool
+}
+
+func (s *blockingSink) Send(ev *Event) error {
+	<-s.release
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, ev.Message)
+	return nil
+}
+
+func (s *blockingSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	inner := &blockingSink{release: make(chan struct{})}
+	q := Queue(inner, 2)
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for _, msg := range []string{"a", "b", "c", "d", "e"} {
+			q.Send(&Event{Message: msg})
+		}
+	}()
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("want Send not to wait for the sink")
+	}
+
+	close(inner.release)
+	err := q.Close()
+	if !inner.closed {
+		t.Error("want the sink closed")
+	}
+	// a is being sent while b and c wait in the queue
+	if len(inner.sent) < 2 || inner.sent[0] != "a" || err == nil || !strings.Contains(err.Error(), "dropped") {
+		t.Errorf("want the queued events sent and the others dropped, got %q, %v", inner.sent, err)
+	}
+}
@@ -0,0 +1,96 @@
+package humanlog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ansiEscape matches the escape sequences of colors and hyperlinks.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;?]*[A-Za-z]|\x1b\\][^\x07\x1b]*(?:\x07|\x1b\\\\)")
+
+// Scrollback writes through to a writer, remembering the last lines written
+// so that they can be saved as they were seen, whatever the terminal kept.
+type Scrollback struct {
+	w io.Writer
+
+	mu      sync.Mutex
+	lines   spillRing
+	partial []byte
+}
+
+// NewScrollback returns a Scrollback over w remembering up to n lines, those
+// over budget in a temporary file. A nil budget keeps them all in memory.
+func NewScrollback(w io.Writer, n int, budget *MemoryBudget) *Scrollback {
+	return &Scrollback{w: w, lines: spillRing{max: n, budget: budget}}
+}
+
+func (s *Scrollback) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	rest := p
+	for len(rest) != 0 {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			s.partial = append(s.partial, rest...)
+			break
+		}
+		s.lines.push(string(s.partial) + string(rest[:i+1]))
+		s.partial, rest = nil, rest[i+1:]
+	}
+	s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// Lines returns the lines remembered, the oldest first.
+func (s *Scrollback) Lines() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var lines [][]byte
+	s.lines.each(func(line string) {
+		lines = append(lines, []byte(line))
+	})
+	return lines
+}
+
+// Save writes the lines remembered into dir, in a file named after now with
+// their colors, and in another without, for pasting in tickets. It returns
+// the path of the latter.
+func (s *Scrollback) Save(dir string, now time.Time) (string, error) {
+	base := filepath.Join(dir, "humanlog-"+now.Format("20060102-150405"))
+	colored, err := os.Create(base + ".ansi.log")
+	if err != nil {
+		return "", err
+	}
+	defer colored.Close()
+	path := base + ".log"
+	plain, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer plain.Close()
+
+	// line by line, for the lines spilled to disk not to all come back in
+	// memory at once
+	s.mu.Lock()
+	var werr error
+	s.lines.each(func(line string) {
+		if werr == nil {
+			_, werr = io.WriteString(colored, line)
+		}
+		if werr == nil {
+			_, werr = io.WriteString(plain, ansiEscape.ReplaceAllString(line, ""))
+		}
+	})
+	s.mu.Unlock()
+	if werr != nil {
+		return "", werr
+	}
+	if err := colored.Close(); err != nil {
+		return "", err
+	}
+	return path, plain.Close()
+}
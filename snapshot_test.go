@@ -0,0 +1,45 @@
+package humanlog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScrollback(t *testing.T) {
+	var out bytes.Buffer
+	sb := NewScrollback(&out, 2, nil)
+	for _, s := range []string{"one\n", "\x1b[31mtw", "o\x1b[0m\nthree\n", "\x1b]8;;http://a\x1b\\four\x1b]8;;\x1b\\\nfi"} {
+		if _, err := sb.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := "one\n\x1b[31mtwo\x1b[0m\nthree\n\x1b]8;;http://a\x1b\\four\x1b]8;;\x1b\\\nfi"; out.String() != want {
+		t.Errorf("want everything written through, got %q", out.String())
+	}
+
+	dir, err := ioutil.TempDir("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path, err := sb.Save(dir, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "humanlog-20240102-030405.log"); path != want {
+		t.Errorf("want %s, got %s", want, path)
+	}
+	plain, _ := ioutil.ReadFile(path)
+	if want := "three\nfour\n"; string(plain) != want {
+		t.Errorf("want %q, got %q", want, plain)
+	}
+	colored, _ := ioutil.ReadFile(filepath.Join(dir, "humanlog-20240102-030405.ansi.log"))
+	if want := "three\n\x1b]8;;http://a\x1b\\four\x1b]8;;\x1b\\\n"; string(colored) != want {
+		t.Errorf("want %q, got %q", want, colored)
+	}
+}
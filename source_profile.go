@@ -0,0 +1,136 @@
+package humanlog
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// SourceProfile overrides options for the entries of the sources whose name
+// matches a glob pattern, for when the services interleaved in the input
+// don't log the same way. The source of an entry is the first of the
+// SourceFields it has.
+//
+//	{"match": "billing-*", "message-fields": ["event"], "level-fields": ["severity"], "color": "hi-blue"}
+type SourceProfile struct {
+	Match         string   `json:"match"`
+	MessageFields []string `json:"message-fields,omitempty"`
+	TimeFields    []string `json:"time-fields,omitempty"`
+	LevelFields   []string `json:"level-fields,omitempty"`
+	Skip          []string `json:"skip,omitempty"`
+	Keep          []string `json:"keep,omitempty"`
+	TimeFormat    string   `json:"time-format,omitempty"`
+	// Color of the source's gutter, like "red" or "hi-cyan".
+	Color string `json:"color,omitempty"`
+
+	color *color.Color
+}
+
+var colorNames = map[string]color.Attribute{
+	"black":   color.FgBlack,
+	"red":     color.FgRed,
+	"green":   color.FgGreen,
+	"yellow":  color.FgYellow,
+	"blue":    color.FgBlue,
+	"magenta": color.FgMagenta,
+	"cyan":    color.FgCyan,
+	"white":   color.FgWhite,
+}
+
+// compile checks the pattern and the color of the profile.
+func (p *SourceProfile) compile() error {
+	if _, err := path.Match(p.Match, ""); err != nil {
+		return fmt.Errorf("match %q: %v", p.Match, err)
+	}
+	if p.Color == "" {
+		return nil
+	}
+	c, err := parseColor(p.Color)
+	if err != nil {
+		return err
+	}
+	p.color = c
+	return nil
+}
+
+// parseColor returns the color with that name, like "red", or "hi-red" for
+// its bright variant.
+func parseColor(name string) (*color.Color, error) {
+	base := strings.TrimPrefix(name, "hi-")
+	attr, ok := colorNames[base]
+	if !ok {
+		return nil, fmt.Errorf("unknown color %q", name)
+	}
+	if base != name {
+		attr += color.FgHiBlack - color.FgBlack
+	}
+	return color.New(attr), nil
+}
+
+// options returns a copy of base with the profile's overrides.
+func (p *SourceProfile) options(base *HandlerOptions) *HandlerOptions {
+	opts := *base
+	if len(p.MessageFields) != 0 {
+		opts.MessageFields = p.MessageFields
+	}
+	if len(p.TimeFields) != 0 {
+		opts.TimeFields = p.TimeFields
+	}
+	if len(p.LevelFields) != 0 {
+		opts.LevelFields = p.LevelFields
+	}
+	if len(p.Skip) != 0 {
+		opts.Skip = nil
+		opts.SetSkip(p.Skip)
+	}
+	if len(p.Keep) != 0 {
+		opts.Keep = nil
+		opts.SetKeep(p.Keep)
+	}
+	if p.TimeFormat != "" {
+		opts.TimeFormat = p.TimeFormat
+	}
+	return &opts
+}
+
+// profileOf returns the first profile matching the source, if any does.
+func (h *HandlerOptions) profileOf(source string) *SourceProfile {
+	if source == "" {
+		return nil
+	}
+	for i := range h.Sources {
+		if ok, _ := path.Match(h.Sources[i].Match, source); ok {
+			return &h.Sources[i]
+		}
+	}
+	return nil
+}
+
+// profileParsers parse the entries of each profiled source again, with the
+// options of its profile.
+type profileParsers struct {
+	opts    *HandlerOptions
+	parsers map[*SourceProfile]*parser
+}
+
+// parse returns the result of parsing the line again if its source has a
+// profile, or res unchanged.
+func (pp *profileParsers) parse(line []byte, res parseResult) parseResult {
+	profile := pp.opts.profileOf(pp.opts.sourceOf(res.entry.event()))
+	if profile == nil {
+		return res
+	}
+	p, ok := pp.parsers[profile]
+	if !ok {
+		p = newParser(profile.options(pp.opts))
+		pp.parsers[profile] = p
+	}
+	again := p.parse(line)
+	if again.entry == nil {
+		return res
+	}
+	res.entry.clear()
+	return again
+}
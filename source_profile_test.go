@@ -0,0 +1,70 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestSourceProfiles(t *testing.T) {
+	path := writeConfig(t, `{"sources": [
+		{"match": "billing-*", "message-fields": ["event"], "skip": ["pid"], "color": "hi-blue"}
+	]}`)
+	cfg, err := ReadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := *DefaultOptions
+	cfg.Apply(&opts)
+
+	src := strings.Join([]string{
+		`{"service": "billing-worker", "event": "charge failed", "pid": 12}`,
+		`{"service": "api", "event": "signup", "msg": "ok", "pid": 13}`,
+	}, "\n")
+	var (
+		out    bytes.Buffer
+		events []*Event
+	)
+	chain := Chain(Parse(&opts), func(e *Entry, next Next) error {
+		events = append(events, e.Event)
+		return next(e)
+	}, Render(&out, &opts))
+	if err := Process(strings.NewReader(src), &opts, chain); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("want 2 events, got %d", len(events))
+	}
+	if events[0].Message != "charge failed" {
+		t.Errorf("want billing's message from its event field, got %q", events[0].Message)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if strings.Contains(lines[0], "pid") {
+		t.Errorf("want billing's pid skipped, got %q", lines[0])
+	}
+	if events[1].Message != "ok" || events[1].Fields["event"] == "" {
+		t.Errorf("want the api parsed with the default options, got %+v", events[1])
+	}
+	if !strings.Contains(lines[1], "pid") {
+		t.Errorf("want the api's pid kept, got %q", lines[1])
+	}
+
+	profile := opts.profileOf("billing-worker")
+	if profile == nil || profile.color == nil || !profile.color.Equals(color.New(color.FgHiBlue)) {
+		t.Errorf("want billing's gutter hi-blue, got %+v", profile)
+	}
+}
+
+func TestSourceProfile_Errors(t *testing.T) {
+	for _, p := range []SourceProfile{
+		{Match: "billing-[", Color: "red"},
+		{Match: "billing-*", Color: "teal"},
+	} {
+		if err := p.compile(); err == nil {
+			t.Errorf("%+v: want an error", p)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package humanlog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SpanDurationField is the field telling how long after the entry starting
+// its span an entry ending one came.
+const SpanDurationField = "elapsed"
+
+const (
+	// defaultSpanTTL is how long a span stays open, unless its rule says.
+	defaultSpanTTL = 10 * time.Minute
+	// maxOpenSpans is how many spans are kept open at most, the oldest
+	// being forgotten first.
+	maxOpenSpans = 10000
+)
+
+// SpanRule pairs the entries matching the start conditions with those
+// matching the end conditions that have the same value of Key, and gives
+// the end entries that don't tell their duration one in SpanDurationField.
+// Spans that don't end within TTL are forgotten.
+type SpanRule struct {
+	Text string
+	Key  string
+	TTL  time.Duration
+
+	start, end []fieldCondition
+}
+
+// ParseSpanRule reads rules like:
+//
+//	request_id: msg=started .. msg=finished
+//	job: msg~^running level=debug .. msg~^done within 1h
+func ParseSpanRule(s string) (SpanRule, error) {
+	i := strings.Index(s, ":")
+	if i <= 0 {
+		return SpanRule{}, fmt.Errorf("missing key: before the conditions")
+	}
+	rule := SpanRule{Text: s, Key: strings.TrimSpace(s[:i]), TTL: defaultSpanTTL}
+	conds := &rule.start
+	words := strings.Fields(s[i+1:])
+	for j := 0; j < len(words); j++ {
+		switch words[j] {
+		case "..":
+			if conds == &rule.end {
+				return rule, fmt.Errorf("more than one ..")
+			}
+			conds = &rule.end
+			continue
+		case "within":
+			if j+1 == len(words) {
+				return rule, fmt.Errorf("missing duration after within")
+			}
+			j++
+			ttl, err := time.ParseDuration(words[j])
+			if err != nil || ttl <= 0 {
+				return rule, fmt.Errorf("invalid duration %q", words[j])
+			}
+			rule.TTL = ttl
+			continue
+		}
+		cond, err := parseFieldCondition(words[j])
+		if err != nil {
+			return rule, err
+		}
+		*conds = append(*conds, cond)
+	}
+	if len(rule.start) == 0 || len(rule.end) == 0 {
+		return rule, fmt.Errorf("want start conditions .. end conditions")
+	}
+	return rule, nil
+}
+
+func matchesAll(conds []fieldCondition, ev *Event) bool {
+	for i := range conds {
+		if !conds[i].matches(ev) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasDuration tells whether the logger already told how long the entry
+// took, in a field like duration_ms or latency.
+func hasDuration(ev *Event) bool {
+	for k := range ev.Fields {
+		k = strings.ToLower(k)
+		for _, word := range []string{"duration", "elapsed", "latency", "took"} {
+			if strings.Contains(k, word) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// spanTable remembers when the spans of a rule that are still open started.
+type spanTable struct {
+	rule   SpanRule
+	starts map[string]time.Time
+	// order is the keys in the order their spans started, some of them
+	// ended or restarted since, to forget the oldest ones first
+	order []spanStart
+}
+
+type spanStart struct {
+	key string
+	at  time.Time
+}
+
+func newSpanTable(rule SpanRule) *spanTable {
+	return &spanTable{rule: rule, starts: make(map[string]time.Time)}
+}
+
+// observe opens the span ev starts, or returns how long the one it ends
+// took.
+func (t *spanTable) observe(ev *Event) (time.Duration, bool) {
+	key := unquoteValue(ev.Fields[t.rule.Key])
+	if key == "" || ev.Time.IsZero() {
+		return 0, false
+	}
+	if matchesAll(t.rule.end, ev) {
+		start, ok := t.starts[key]
+		if !ok {
+			return 0, false
+		}
+		delete(t.starts, key)
+		if d := ev.Time.Sub(start); d >= 0 && d <= t.rule.TTL {
+			return d, true
+		}
+		return 0, false
+	}
+	if matchesAll(t.rule.start, ev) {
+		t.expire(ev.Time)
+		t.starts[key] = ev.Time
+		t.order = append(t.order, spanStart{key: key, at: ev.Time})
+	}
+	return 0, false
+}
+
+// expire forgets the spans open longer than the TTL at now, and the oldest
+// ones while there are too many.
+func (t *spanTable) expire(now time.Time) {
+	n := 0
+	for ; n < len(t.order); n++ {
+		s := t.order[n]
+		if at, ok := t.starts[s.key]; !ok || !at.Equal(s.at) {
+			// ended or restarted since
+			continue
+		}
+		if now.Sub(s.at) <= t.rule.TTL && len(t.starts) < maxOpenSpans {
+			break
+		}
+		delete(t.starts, s.key)
+	}
+	t.order = t.order[n:]
+}
+
+// spanStage gives the entries ending spans how long after their start they
+// came.
+func spanStage(opts *HandlerOptions) Stage {
+	tables := make([]*spanTable, len(opts.Spans))
+	for i, rule := range opts.Spans {
+		tables[i] = newSpanTable(rule)
+	}
+	return func(e *Entry, next Next) error {
+		if e.Event != nil {
+			for _, t := range tables {
+				if d, ok := t.observe(e.Event); ok && !hasDuration(e.Event) {
+					e.Set(SpanDurationField, d.String())
+				}
+			}
+		}
+		return next(e)
+	}
+}
@@ -0,0 +1,74 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSpanRule(t *testing.T) {
+	rule, err := ParseSpanRule("request_id: msg=started level=info .. msg=finished")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.Key != "request_id" || rule.TTL != defaultSpanTTL || len(rule.start) != 2 || len(rule.end) != 1 {
+		t.Errorf("got %+v", rule)
+	}
+	rule, err = ParseSpanRule("job: msg~^running .. msg~^done within 1h")
+	if err != nil || rule.TTL != time.Hour {
+		t.Errorf("want within 1h, got %v (%v)", rule.TTL, err)
+	}
+
+	for _, s := range []string{"msg=started .. msg=finished", "id: msg=started", "id: .. msg=finished", "id: a=1 .. b=2 .. c=3", "id: a=1 .. b=2 within", "id: a=1 .. b=2 within soon", "id: a .. b=2"} {
+		if _, err := ParseSpanRule(s); err == nil {
+			t.Errorf("%q: want an error", s)
+		}
+	}
+}
+
+func TestSpanStage(t *testing.T) {
+	rule, err := ParseSpanRule("request_id: msg=started .. msg=finished within 1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := *DefaultOptions
+	opts.Spans = []SpanRule{rule}
+
+	src := strings.Join([]string{
+		`{"time":"2024-01-02T10:00:00Z","msg":"started","request_id":"a"}`,
+		`{"time":"2024-01-02T10:00:01Z","msg":"started","request_id":"b"}`,
+		`{"time":"2024-01-02T10:00:01.5Z","msg":"finished","request_id":"a"}`,
+		`{"time":"2024-01-02T10:00:02Z","msg":"finished","request_id":"b","duration_ms":900}`,
+		`{"time":"2024-01-02T10:00:03Z","msg":"started","request_id":"c"}`,
+		`{"time":"2024-01-02T10:05:00Z","msg":"finished","request_id":"c"}`,
+		`{"time":"2024-01-02T10:05:01Z","msg":"finished","request_id":"d"}`,
+	}, "\n")
+	var elapsed []string
+	if err := ScanEvents(strings.NewReader(src), &opts, func(ev *Event) error {
+		elapsed = append(elapsed, ev.Fields[SpanDurationField])
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// b told its duration, c took longer than the TTL and d never started
+	want := []string{"", "", "1.5s", "", "", "", ""}
+	if strings.Join(elapsed, ",") != strings.Join(want, ",") {
+		t.Errorf("want %q, got %q", want, elapsed)
+	}
+}
+
+func TestSpanTable_Expire(t *testing.T) {
+	table := newSpanTable(SpanRule{Key: "id", TTL: time.Minute})
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	table.starts["old"] = start
+	table.starts["new"] = start.Add(50 * time.Second)
+	table.order = []spanStart{{"old", start}, {"gone", start}, {"new", start.Add(50 * time.Second)}}
+
+	table.expire(start.Add(90 * time.Second))
+	if _, ok := table.starts["old"]; ok {
+		t.Error("want the span open longer than the TTL forgotten")
+	}
+	if _, ok := table.starts["new"]; !ok || len(table.order) != 1 {
+		t.Errorf("want only the recent span left, got %v", table.order)
+	}
+}
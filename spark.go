@@ -0,0 +1,85 @@
+package humanlog
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline keeps the last few values of a numeric field and draws them as a
+// row of unicode block characters.
+type sparkline struct {
+	values []float64
+	next   int
+	full   bool
+}
+
+func newSparkline(width int) *sparkline {
+	if width <= 0 {
+		width = DefaultOptions.SparkWidth
+	}
+	return &sparkline{values: make([]float64, width)}
+}
+
+// observe records the value if it looks like a number, ignoring it otherwise,
+// and ignoring NaN and infinities, which can't be drawn.
+func (s *sparkline) observe(val string) {
+	v, err := strconv.ParseFloat(strings.Trim(val, `"`), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+	s.values[s.next] = v
+	s.next++
+	if s.next == len(s.values) {
+		s.next = 0
+		s.full = true
+	}
+}
+
+func (s *sparkline) len() int {
+	if s.full {
+		return len(s.values)
+	}
+	return s.next
+}
+
+// ordered returns the recorded values, oldest first.
+func (s *sparkline) ordered() []float64 {
+	if !s.full {
+		return s.values[:s.next]
+	}
+	return append(append([]float64{}, s.values[s.next:]...), s.values[:s.next]...)
+}
+
+func (s *sparkline) String() string {
+	vals := s.ordered()
+	if len(vals) == 0 {
+		return ""
+	}
+	min, max := vals[0], vals[0]
+	for _, v := range vals {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	var sb strings.Builder
+	for _, v := range vals {
+		idx := 0
+		if max > min {
+			idx = int((v - min) / (max - min) * float64(len(sparkTicks)-1))
+		}
+		// values far enough apart overflow max - min
+		if idx < 0 {
+			idx = 0
+		} else if idx >= len(sparkTicks) {
+			idx = len(sparkTicks) - 1
+		}
+		sb.WriteRune(sparkTicks[idx])
+	}
+	return sb.String()
+}
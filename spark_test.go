@@ -0,0 +1,36 @@
+package humanlog
+
+import "testing"
+
+func TestSparkline(t *testing.T) {
+	s := newSparkline(4)
+	for _, v := range []string{"1", "not a number", "2", `"3"`, "4", "5"} {
+		s.observe(v)
+	}
+	if s.len() != 4 {
+		t.Fatalf("want %d values, got %d", 4, s.len())
+	}
+	want := "▁▃▅█"
+	if got := s.String(); got != want {
+		t.Errorf("want %q, got %q, want != got", want, got)
+	}
+}
+
+func TestSparkline_NotFinite(t *testing.T) {
+	s := newSparkline(4)
+	for _, v := range []string{"1", `"NaN"`, "Inf", "-Inf", "2"} {
+		s.observe(v)
+	}
+	if want, got := "▁█", s.String(); got != want {
+		t.Errorf("want %q, got %q, want != got", want, got)
+	}
+
+	// the range of the values overflows
+	s = newSparkline(3)
+	for _, v := range []string{"-1.7e308", "0", "1.7e308"} {
+		s.observe(v)
+	}
+	if got := s.String(); len([]rune(got)) != 3 {
+		t.Errorf("want a tick per value, got %q", got)
+	}
+}
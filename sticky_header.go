@@ -0,0 +1,48 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// stickyHeader reprints, every so many entries, the fields skip-unchanged
+// hides because they keep their value, so that whoever starts reading mid
+// stream still knows the context, like the host, version and environment.
+type stickyHeader struct {
+	every int
+	n     int
+	prev  map[string]string
+}
+
+// observe writes the header before ev, if it's time to and some of its
+// fields would be hidden. skipping tells whether the handler skips the
+// fields unchanged since the previous entry.
+func (s *stickyHeader) observe(dst io.Writer, opts *HandlerOptions, ev *Event, skipping bool) {
+	prev := s.prev
+	s.prev = ev.Fields
+	s.n++
+	if !skipping || s.n < s.every {
+		return
+	}
+
+	var stable []string
+	for k, v := range ev.Fields {
+		if last, ok := prev[k]; ok && last == v && opts.shouldShowKey(k) && !opts.shouldShowUnchanged(k) && !containsField(opts.LoggerFields, k) {
+			stable = append(stable, k)
+		}
+	}
+	if len(stable) == 0 {
+		return
+	}
+	s.n = 0
+	sort.Strings(stable)
+
+	var line strings.Builder
+	line.WriteString("── unchanged:")
+	for _, k := range stable {
+		fmt.Fprintf(&line, " %s=%s", k, opts.truncateValue(k, ev.Fields[k]))
+	}
+	opts.ContextColor.Fprintln(dst, line.String())
+}
@@ -0,0 +1,44 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestStickyHeader(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	src := strings.Join([]string{
+		`{"time": "2024-01-02T10:00:00Z", "level": "info", "msg": "a", "host": "web-1", "env": "prod", "n": 1}`,
+		`{"time": "2024-01-02T10:00:01Z", "level": "info", "msg": "b", "host": "web-1", "env": "prod", "n": 2}`,
+		`{"time": "2024-01-02T10:00:02Z", "level": "info", "msg": "c", "host": "web-1", "env": "prod", "n": 3}`,
+		`{"time": "2024-01-02T10:00:03Z", "level": "info", "msg": "d", "host": "web-1", "env": "prod", "n": 4}`,
+		`{"time": "2024-01-02T10:00:04Z", "level": "info", "msg": "e", "host": "web-2", "env": "prod", "n": 5}`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.TimeFormat = "15:04:05"
+	opts.StickyHeader = 3
+
+	var out bytes.Buffer
+	if err := Scanner(strings.NewReader(src), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		`10:00:00 |INFO| a n=1 env="prod" host="web-1"`,
+		`10:00:01 |INFO| b n=2`,
+		`── unchanged: env="prod" host="web-1"`,
+		`10:00:02 |INFO| c n=3`,
+		`10:00:03 |INFO| d n=4`,
+		`10:00:04 |INFO| e n=5 host="web-2"`,
+		``,
+	}, "\n")
+	if got := out.String(); got != want {
+		t.Errorf("want\n%s\ngot\n%s", want, got)
+	}
+}
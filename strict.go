@@ -0,0 +1,119 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/go-logfmt/logfmt"
+)
+
+// diagnostics explains, in strict mode, why lines weren't prettified or what
+// was missing from the ones that were, and keeps count of each reason.
+type diagnostics struct {
+	lines   int
+	handled int
+	counts  map[string]int
+}
+
+func newDiagnostics() *diagnostics {
+	return &diagnostics{counts: make(map[string]int)}
+}
+
+// rejected returns why each handler refused the line.
+func (d *diagnostics) rejected(line []byte) []string {
+	d.lines++
+	var reasons []string
+	add := func(reason, detail string) {
+		d.counts[reason]++
+		reasons = append(reasons, reason+detail)
+	}
+
+	var raw map[string]interface{}
+	switch err := json.Unmarshal(line, &raw).(type) {
+	case *json.SyntaxError:
+		add("json: invalid JSON", fmt.Sprintf(" at offset %d (%v)", err.Offset, err))
+	case *json.UnmarshalTypeError:
+		add("json: not an object", fmt.Sprintf(" (got %s)", err.Value))
+	case nil:
+	default:
+		add("json: "+err.Error(), "")
+	}
+
+	if !bytes.ContainsRune(line, '=') {
+		add("logfmt: no key=value pairs", "")
+	} else {
+		dec := logfmt.NewDecoder(bytes.NewReader(line))
+		for dec.ScanRecord() {
+			for dec.ScanKeyval() {
+			}
+		}
+		if err := dec.Err(); err != nil {
+			add("logfmt: invalid logfmt", fmt.Sprintf(" (%v)", err))
+		}
+	}
+
+	if dcLogsPrefixRe.Match(line) {
+		add("docker-compose: unrecognized entry after the service prefix", "")
+	} else {
+		add("docker-compose: no service prefix", "")
+	}
+	if !criLogsPrefixRe.Match(line) {
+		add("cri: no container runtime prefix", "")
+	}
+	if !zapDevLogsPrefixRe.Match(line) {
+		add("zap: no development prefix", "")
+	}
+	return reasons
+}
+
+// incomplete returns what the handler couldn't find in the entry it parsed.
+func (d *diagnostics) incomplete(handlerName string, ev *Event) []string {
+	d.lines++
+	d.handled++
+	var reasons []string
+	add := func(reason string) {
+		d.counts[handlerName+": "+reason]++
+		reasons = append(reasons, reason)
+	}
+	if ev.Time.IsZero() {
+		add("no time field")
+	}
+	if ev.Level == "" {
+		add("no level field")
+	}
+	if ev.Message == "" {
+		add("no message field")
+	}
+	return reasons
+}
+
+func (d *diagnostics) annotate(dst io.Writer, opts *HandlerOptions, reasons []string) {
+	if len(reasons) == 0 {
+		return
+	}
+	opts.DiagnosticColor.Fprintf(dst, "  # %s", strings.Join(reasons, "; "))
+}
+
+// summarize writes how many lines were handled and how often each reason
+// came up, most frequent first.
+func (d *diagnostics) summarize(dst io.Writer, opts *HandlerOptions) {
+	opts.DiagnosticColor.Fprintf(dst, "# %d lines, %d parsed, %d passed through\n", d.lines, d.handled, d.lines-d.handled)
+
+	reasons := make([]string, 0, len(d.counts))
+	for reason := range d.counts {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if d.counts[reasons[i]] != d.counts[reasons[j]] {
+			return d.counts[reasons[i]] > d.counts[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	for _, reason := range reasons {
+		opts.DiagnosticColor.Fprintf(dst, "# %6d  %s\n", d.counts[reason], reason)
+	}
+}
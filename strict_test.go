@@ -0,0 +1,26 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticsRejected(t *testing.T) {
+	d := newDiagnostics()
+	reasons := d.rejected([]byte(`{"msg": "truncated",`))
+	if len(reasons) == 0 || !strings.HasPrefix(reasons[0], "json: invalid JSON at offset 20") {
+		t.Fatalf("want the JSON offset to be reported, got %q", reasons)
+	}
+	if d.counts["json: invalid JSON"] != 1 {
+		t.Errorf("want %d, got %d, want != got", 1, d.counts["json: invalid JSON"])
+	}
+
+	missing := d.incomplete("json", &Event{Message: "hello"})
+	want := []string{"no time field", "no level field"}
+	if strings.Join(missing, ",") != strings.Join(want, ",") {
+		t.Errorf("want %q, got %q, want != got", want, missing)
+	}
+	if d.lines != 2 || d.handled != 1 {
+		t.Errorf("want 2 lines and 1 handled, got %d and %d", d.lines, d.handled)
+	}
+}
@@ -0,0 +1,78 @@
+package humanlog
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syslogSink forwards events as RFC 5424 messages. Over TCP, messages are
+// framed by their length in front of them, as RFC 6587 octet counting
+// has it, which rsyslog and syslog-ng read, so that messages holding
+// newlines, like tracebacks, aren't split.
+type syslogSink struct {
+	dialer *conn
+	stream bool
+}
+
+const syslogFacilityUser = 1
+
+func (s *syslogSink) Send(ev *Event) error {
+	ts := ev.Time
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	rec := sinkRecord(ev)
+	delete(rec, "level")
+	delete(rec, "message")
+	keys := make([]string, 0, len(rec))
+	for k := range rec {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var msg strings.Builder
+	msg.WriteString(ev.Message)
+	for _, k := range keys {
+		fmt.Fprintf(&msg, " %s=%q", k, rec[k])
+	}
+
+	line := fmt.Sprintf("<%d>1 %s %s humanlog - - - %s",
+		syslogFacilityUser*8+syslogSeverity(ev.Level),
+		ts.Format(time.RFC3339Nano),
+		hostname,
+		strings.TrimSpace(msg.String()),
+	)
+	if s.stream {
+		line = strconv.Itoa(len(line)) + " " + line
+	}
+	_, err = s.dialer.Write([]byte(line))
+	return err
+}
+
+func (s *syslogSink) Close() error { return s.dialer.Close() }
+
+func syslogSeverity(level string) int {
+	switch strings.ToLower(level) {
+	case "trace", "debug":
+		return 7
+	case "info":
+		return 6
+	case "warn", "warning":
+		return 4
+	case "error":
+		return 3
+	case "fatal", "panic":
+		return 2
+	default:
+		return 5
+	}
+}
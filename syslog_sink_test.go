@@ -0,0 +1,49 @@
+package humanlog
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSyslogSink(t *testing.T) {
+	hostname, _ := os.Hostname()
+	ev := &Event{
+		Time:    time.Date(2024, 1, 2, 10, 0, 0, 500000000, time.UTC),
+		Level:   "error",
+		Message: "failed",
+		Fields:  map[string]string{"status": "502", "path": `"/api"`},
+	}
+	// user facility, error severity: 1*8+3
+	want := "<11>1 2024-01-02T10:00:00.5Z " + hostname + ` humanlog - - - failed path="/api" status="502"`
+
+	c := &recordingConn{}
+	s := &syslogSink{dialer: recordingDialer(c)}
+	if err := s.Send(ev); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.written) != 1 || c.written[0] != want {
+		t.Errorf("want\n%q\ngot\n%q", want, c.written)
+	}
+
+	// over TCP, messages are framed by their length, newlines and all
+	ev.Message = "failed\nTraceback (most recent call last):"
+	want = "<11>1 2024-01-02T10:00:00.5Z " + hostname + ` humanlog - - - failed` + "\nTraceback (most recent call last):" + ` path="/api" status="502"`
+	c = &recordingConn{}
+	s = &syslogSink{dialer: recordingDialer(c), stream: true}
+	if err := s.Send(ev); err != nil {
+		t.Fatal(err)
+	}
+	if want := strconv.Itoa(len(want)) + " " + want; len(c.written) != 1 || c.written[0] != want {
+		t.Errorf("want\n%q\ngot\n%q", want, c.written)
+	}
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	for level, want := range map[string]int{"debug": 7, "INFO": 6, "warning": 4, "error": 3, "panic": 2, "": 5} {
+		if got := syslogSeverity(level); got != want {
+			t.Errorf("%q: want %d, got %d", level, want, got)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package humanlog
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// maxTableColumns caps how many fields get a column of their own.
+const maxTableColumns = 8
+
+// table renders the entries as rows under labeled columns: the time, the
+// level, the fields every one of the first TableSample entries had, and the
+// message. The other fields follow as key=value.
+type table struct {
+	dst  io.Writer
+	opts *HandlerOptions
+
+	// pending holds the lines read while sampling; those that weren't
+	// parsed have a nil event.
+	pending []tableLine
+	sampled int
+
+	columns []tableColumn
+	ready   bool
+}
+
+type tableLine struct {
+	raw []byte
+	ev  *Event
+}
+
+type tableColumn struct {
+	key   string
+	width int
+}
+
+func newTable(dst io.Writer, opts *HandlerOptions) *table {
+	return &table{dst: dst, opts: opts}
+}
+
+func (t *table) render(e *Entry, next Next) error {
+	line := tableLine{raw: e.Raw}
+	if e.Event != nil {
+		line.ev = t.opts.visibleEvent(e.Event)
+	}
+	if t.ready {
+		t.writeLine(line)
+		return next(e)
+	}
+
+	line.raw = append([]byte(nil), e.Raw...)
+	t.pending = append(t.pending, line)
+	if line.ev != nil {
+		t.sampled++
+	}
+	if t.sampled >= t.opts.TableSample {
+		t.flush()
+	}
+	return next(e)
+}
+
+// flush picks the columns out of the lines sampled so far and writes them.
+func (t *table) flush() {
+	if t.ready {
+		return
+	}
+	t.ready = true
+	t.columns = t.pickColumns()
+	if t.sampled > 0 {
+		t.writeHeader()
+	}
+	for _, line := range t.pending {
+		t.writeLine(line)
+	}
+	t.pending = nil
+}
+
+// pickColumns returns the keys every sampled event had, as wide as their
+// widest value.
+func (t *table) pickColumns() []tableColumn {
+	counts := make(map[string]int)
+	for _, line := range t.pending {
+		if line.ev == nil {
+			continue
+		}
+		for k := range line.ev.Fields {
+			if !containsField(t.opts.LoggerFields, k) {
+				counts[k]++
+			}
+		}
+	}
+	var keys []string
+	for k, n := range counts {
+		if n == t.sampled {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > maxTableColumns {
+		keys = keys[:maxTableColumns]
+	}
+
+	columns := make([]tableColumn, len(keys))
+	for i, k := range keys {
+		columns[i] = tableColumn{key: k, width: displayWidth(k)}
+		for _, line := range t.pending {
+			if line.ev == nil {
+				continue
+			}
+			if w := displayWidth(t.cell(line.ev.Fields[k])); w > columns[i].width {
+				columns[i].width = w
+			}
+		}
+		if t.opts.Truncates && columns[i].width > t.opts.TruncateLength+1 {
+			columns[i].width = t.opts.TruncateLength + 1
+		}
+	}
+	return columns
+}
+
+// cell returns the text of a value in a column.
+func (t *table) cell(v string) string {
+	v = unquoteValue(v)
+	if kindOfText(v) == kindNumber {
+		v = t.opts.formatNumber(v)
+	}
+	return v
+}
+
+func (t *table) timeWidth() int {
+	return displayWidth(t.opts.TimeFormat)
+}
+
+func (t *table) writeHeader() {
+	var buf bytes.Buffer
+	writePadded(&buf, t.opts.KeyColor, "TIME", t.timeWidth())
+	writePadded(&buf, t.opts.KeyColor, "LEVL", 4)
+	for _, col := range t.columns {
+		writePadded(&buf, t.opts.KeyColor, strings.ToUpper(col.key), col.width)
+	}
+	buf.WriteString(t.opts.KeyColor.Sprint("MESSAGE"))
+	buf.WriteByte('\n')
+	_, _ = t.dst.Write(buf.Bytes())
+}
+
+func (t *table) writeLine(line tableLine) {
+	if line.ev == nil {
+		_, _ = t.dst.Write(line.raw)
+		_, _ = t.dst.Write(eol[:])
+		return
+	}
+	ev, opts := line.ev, t.opts
+
+	timeColor := opts.TimeDarkBgColor
+	msgColor := opts.MsgDarkBgColor
+	if opts.LightBg {
+		timeColor, msgColor = opts.TimeLightBgColor, opts.MsgLightBgColor
+	}
+
+	var buf bytes.Buffer
+	writePadded(&buf, timeColor, ev.Time.Format(opts.TimeFormat), t.timeWidth())
+	writePadded(&buf, opts.levelColor(ev.Level), shortLevel(ev.Level), 4)
+	inColumn := make(map[string]bool, len(t.columns))
+	for _, col := range t.columns {
+		inColumn[col.key] = true
+		v := t.cell(ev.Fields[col.key])
+		if displayWidth(v) > col.width {
+			v = truncateWidth(v, col.width-1) + "…"
+		}
+		writePadded(&buf, opts.cellColor(col.key, ev.Fields[col.key], v), v, col.width)
+	}
+	if logger := opts.loggerName(ev.Fields); logger != "" {
+		buf.WriteString(opts.LoggerColor.Sprint("[" + logger + "] "))
+	}
+	buf.WriteString(msgColor.Sprint(isolateBidi(ev.Message)))
+
+	var rest []string
+	for k, v := range ev.Fields {
+		if !inColumn[k] && !containsField(opts.LoggerFields, k) {
+			rest = append(rest, opts.KeyColor.Sprint(k)+"="+opts.cellColor(k, v, v).Sprint(isolateBidi(v)))
+		}
+	}
+	sort.Strings(rest)
+	for _, kv := range rest {
+		buf.WriteByte(' ')
+		buf.WriteString(kv)
+	}
+	buf.WriteByte('\n')
+	_, _ = t.dst.Write(buf.Bytes())
+}
+
+// writePadded writes s in color, followed by enough spaces to fill width
+// and separate it from the next column.
+func writePadded(buf *bytes.Buffer, c *color.Color, s string, width int) {
+	buf.WriteString(c.Sprint(isolateBidi(s)))
+	if pad := width - displayWidth(s); pad > 0 {
+		buf.WriteString(strings.Repeat(" ", pad))
+	}
+	buf.WriteString("  ")
+}
+
+// cellColor is the color of a value of key shown as cell in the table,
+// against the budget of key, or by the type of cell.
+func (h *HandlerOptions) cellColor(key, v, cell string) *color.Color {
+	if c, ok := h.budgetColor(key, v); ok {
+		return c
+	}
+	return h.valueColor(kindOfText(cell))
+}
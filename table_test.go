@@ -0,0 +1,44 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestTable(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	src := strings.Join([]string{
+		`{"time": "2024-01-02T10:00:00Z", "level": "info", "msg": "request", "status": 200, "path": "/a"}`,
+		`not structured`,
+		`{"time": "2024-01-02T10:00:01Z", "level": "error", "msg": "failed", "status": 500, "path": "/abcdefghijklmnopqrstuvwxyz", "err": "boom"}`,
+		`{"time": "2024-01-02T10:00:02Z", "level": "info", "msg": "request", "status": 201}`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.Table = true
+	opts.TableSample = 2
+	opts.TimeFormat = "15:04:05"
+	opts.TruncateLength = 10
+
+	var out bytes.Buffer
+	if err := Scanner(strings.NewReader(src), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"TIME      LEVL  PATH         STATUS  MESSAGE",
+		"10:00:00  INFO  /a           200     request",
+		"not structured",
+		"10:00:01  ERRO  /abcdefghi…  500     failed err=\"boom\"",
+		"10:00:02  INFO               201     request",
+		"",
+	}, "\n")
+	if got := out.String(); got != want {
+		t.Errorf("want\n%s\ngot\n%s", want, got)
+	}
+}
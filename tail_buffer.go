@@ -0,0 +1,61 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// tailBuffer remembers the last entries that were parsed but not shown,
+// because a filter dropped them, to show them as the context of the next
+// error.
+type tailBuffer struct {
+	opts  *HandlerOptions
+	min   int
+	lines spillRing
+}
+
+func newTailBuffer(opts *HandlerOptions) *tailBuffer {
+	min, _ := opts.LevelRank("error")
+	return &tailBuffer{opts: opts, min: min, lines: spillRing{max: opts.TailBuffer, budget: opts.MemoryBudget}}
+}
+
+// record is a stage remembering the entries the stages after it didn't
+// render. It has to run before the filters.
+func (b *tailBuffer) record(e *Entry, next Next) error {
+	err := next(e)
+	if e.Event != nil && !e.rendered {
+		b.lines.push(b.format(e.Event))
+	}
+	return err
+}
+
+// format writes ev on a single line, without looking at the entries before
+// it like the handlers do.
+func (b *tailBuffer) format(ev *Event) string {
+	keys := make([]string, 0, len(ev.Fields))
+	for k := range ev.Fields {
+		if b.opts.shouldShowKey(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var line strings.Builder
+	fmt.Fprintf(&line, "%s |%s| %s", ev.Time.Format(b.opts.TimeFormat), shortLevel(ev.Level), ev.Message)
+	for _, k := range keys {
+		fmt.Fprintf(&line, " %s=%s", k, b.opts.truncateValue(k, ev.Fields[k]))
+	}
+	return line.String()
+}
+
+// flush writes the entries remembered onto dst, dimmed, if ev is an error.
+func (b *tailBuffer) flush(dst io.Writer, ev *Event) {
+	if rank, ok := b.opts.LevelRank(ev.Level); !ok || rank < b.min {
+		return
+	}
+	b.lines.each(func(line string) {
+		b.opts.ContextColor.Fprintln(dst, line)
+	})
+	b.lines.reset()
+}
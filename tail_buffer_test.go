@@ -0,0 +1,48 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestTailBuffer(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	src := strings.Join([]string{
+		`{"time": "2024-01-02T10:00:00Z", "level": "debug", "msg": "a", "n": 1}`,
+		`{"time": "2024-01-02T10:00:01Z", "level": "debug", "msg": "b", "n": 2}`,
+		`{"time": "2024-01-02T10:00:02Z", "level": "debug", "msg": "c", "n": 3}`,
+		`{"time": "2024-01-02T10:00:03Z", "level": "warn", "msg": "d", "n": 4}`,
+		`{"time": "2024-01-02T10:00:04Z", "level": "error", "msg": "e", "n": 5}`,
+		`{"time": "2024-01-02T10:00:05Z", "level": "debug", "msg": "f", "n": 6}`,
+		`{"time": "2024-01-02T10:00:06Z", "level": "error", "msg": "g", "n": 7}`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.TimeFormat = "15:04:05"
+	opts.MinLevel = "warn"
+	opts.TailBuffer = 2
+	opts.SkipUnchanged = false
+
+	var out bytes.Buffer
+	if err := Scanner(strings.NewReader(src), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		`10:00:03 |WARN| d n=4`,
+		`10:00:01 |DEBU| b n=2`,
+		`10:00:02 |DEBU| c n=3`,
+		`10:00:04 |ERRO| e n=5`,
+		`10:00:05 |DEBU| f n=6`,
+		`10:00:06 |ERRO| g n=7`,
+		``,
+	}, "\n")
+	if got := out.String(); got != want {
+		t.Errorf("want\n%s\ngot\n%s", want, got)
+	}
+}
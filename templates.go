@@ -0,0 +1,116 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// templateStats is what was seen of the entries sharing a message template.
+type templateStats struct {
+	template    string
+	count       int
+	levels      map[string]int
+	first, last time.Time
+}
+
+// Templates reads the log lines of src and writes onto dst a report of the
+// templates of their messages, the messages with their variable parts
+// replaced by *, with how many entries had each, at which levels, and when
+// the first and last were logged. The most frequent templates come first,
+// up to top of them if it's positive. Lines that couldn't be parsed are
+// their own message.
+func Templates(src io.Reader, dst io.Writer, opts *HandlerOptions, top int) error {
+	byTemplate := make(map[string]*templateStats)
+	stages := append(builtinStages(opts), func(e *Entry, next Next) error {
+		var (
+			msg, level string
+			t          time.Time
+		)
+		if e.Event != nil {
+			msg, level, t = e.Event.Message, strings.ToLower(e.Event.Level), e.Event.Time
+		} else {
+			msg = string(e.Raw)
+		}
+		tmpl := messageTemplate(msg)
+		s, ok := byTemplate[tmpl]
+		if !ok {
+			s = &templateStats{template: tmpl, levels: make(map[string]int)}
+			byTemplate[tmpl] = s
+		}
+		s.count++
+		if level != "" {
+			s.levels[level]++
+		}
+		if !t.IsZero() {
+			if s.first.IsZero() || t.Before(s.first) {
+				s.first = t
+			}
+			if t.After(s.last) {
+				s.last = t
+			}
+		}
+		return next(e)
+	})
+	if err := Process(src, opts, Chain(stages...)); err != nil {
+		return err
+	}
+
+	stats := make([]*templateStats, 0, len(byTemplate))
+	for _, s := range byTemplate {
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].count != stats[j].count {
+			return stats[i].count > stats[j].count
+		}
+		return stats[i].template < stats[j].template
+	})
+	if top > 0 && len(stats) > top {
+		stats = stats[:top]
+	}
+
+	w := tabwriter.NewWriter(dst, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(w, "COUNT\tLEVELS\tFIRST\tLAST\tTEMPLATE")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", s.count, s.levelSummary(opts), formatReportTime(s.first, opts), formatReportTime(s.last, opts), s.template)
+	}
+	return w.Flush()
+}
+
+// levelSummary lists the levels of the template, the most severe first,
+// with how many entries had each when there's more than one level.
+func (s *templateStats) levelSummary(opts *HandlerOptions) string {
+	if len(s.levels) == 0 {
+		return "-"
+	}
+	levels := make([]string, 0, len(s.levels))
+	for level := range s.levels {
+		levels = append(levels, level)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		ri, _ := opts.LevelRank(levels[i])
+		rj, _ := opts.LevelRank(levels[j])
+		if ri != rj {
+			return ri > rj
+		}
+		return levels[i] < levels[j]
+	})
+	if len(levels) == 1 {
+		return levels[0]
+	}
+	for i, level := range levels {
+		levels[i] = fmt.Sprintf("%s:%d", level, s.levels[level])
+	}
+	return strings.Join(levels, ",")
+}
+
+func formatReportTime(t time.Time, opts *HandlerOptions) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(opts.TimeFormat)
+}
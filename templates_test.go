@@ -0,0 +1,50 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTemplates(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time": "2024-01-02T10:00:00Z", "level": "info", "msg": "served request 1 in 12ms"}`,
+		`{"time": "2024-01-02T10:05:00Z", "level": "error", "msg": "served request 2 in 1200ms"}`,
+		`{"time": "2024-01-02T10:01:00Z", "level": "info", "msg": "user 42 logged in"}`,
+		`{"time": "2024-01-02T09:59:00Z", "level": "info", "msg": "served request 3 in 8ms"}`,
+		`panic: oops`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.TimeFormat = "15:04"
+
+	tests := []struct {
+		top  int
+		want []string
+	}{
+		{
+			want: []string{
+				"COUNT  LEVELS          FIRST  LAST   TEMPLATE",
+				"3      error:1,info:2  09:59  10:05  served request * in *ms",
+				"1      -               -      -      panic: oops",
+				"1      info            10:01  10:01  user * logged in",
+			},
+		},
+		{
+			top: 1,
+			want: []string{
+				"COUNT  LEVELS          FIRST  LAST   TEMPLATE",
+				"3      error:1,info:2  09:59  10:05  served request * in *ms",
+			},
+		},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := Templates(strings.NewReader(src), &out, &opts, tt.top); err != nil {
+			t.Fatal(err)
+		}
+		if want := strings.Join(tt.want, "\n") + "\n"; out.String() != want {
+			t.Errorf("top %d: want\n%s\ngot\n%s", tt.top, want, out.String())
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+)
+
+// Theme names the colors of the keys, the values and the levels, with the
+// names of SourceProfile's colors, like "red" or "hi-cyan".
+type Theme struct {
+	Name  string `json:"name"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Debug string `json:"debug"`
+	Info  string `json:"info"`
+	Warn  string `json:"warn"`
+	Error string `json:"error"`
+
+	// The colors of the message, of the placeholder shown when there's none,
+	// and of the time can be left out, for humanlog's own. Those ending in
+	// Light are for light backgrounds.
+	Message            string `json:"message,omitempty"`
+	MessageLight       string `json:"message-light,omitempty"`
+	AbsentMessage      string `json:"absent-message,omitempty"`
+	AbsentMessageLight string `json:"absent-message-light,omitempty"`
+	Time               string `json:"time,omitempty"`
+	TimeLight          string `json:"time-light,omitempty"`
+
+	colors map[string]*color.Color
+}
+
+// Themes are the themes humanlog ships: its default colors, and palettes
+// keeping the levels apart for the most common color blindnesses, red-green
+// (deuteranopia and protanopia) and blue-yellow (tritanopia). They're the
+// JSON files under builtin/themes.
+var Themes = loadBuiltinThemes()
+
+func loadBuiltinThemes() []Theme {
+	var themes []Theme
+	for _, name := range builtinNames("themes", ".json") {
+		var t Theme
+		if err := json.Unmarshal(mustReadBuiltin("themes/"+name+".json"), &t); err != nil {
+			panic(fmt.Sprintf("builtin theme %s: %v", name, err))
+		}
+		themes = append(themes, t)
+	}
+	return themes
+}
+
+// ThemeSource returns the JSON file of the theme humanlog ships with that
+// name.
+func ThemeSource(name string) ([]byte, bool) {
+	for _, t := range Themes {
+		if t.Name == name {
+			return mustReadBuiltin("themes/" + name + ".json"), true
+		}
+	}
+	return nil, false
+}
+
+// themeRoles are what a theme colors, in the order they're reported.
+var themeRoles = []string{"key", "value", "debug", "info", "warn", "error"}
+
+// themeOptionalRoles are what a theme can leave to humanlog's colors.
+var themeOptionalRoles = []string{"message", "message-light", "absent-message", "absent-message-light", "time", "time-light"}
+
+// themeLevels are the roles of the levels, which have to be told apart.
+var themeLevels = []string{"debug", "info", "warn", "error"}
+
+func (t *Theme) colorOf(role string) string {
+	switch role {
+	case "key":
+		return t.Key
+	case "value":
+		return t.Value
+	case "debug":
+		return t.Debug
+	case "info":
+		return t.Info
+	case "warn":
+		return t.Warn
+	case "error":
+		return t.Error
+	case "message":
+		return t.Message
+	case "message-light":
+		return t.MessageLight
+	case "absent-message":
+		return t.AbsentMessage
+	case "absent-message-light":
+		return t.AbsentMessageLight
+	case "time":
+		return t.Time
+	case "time-light":
+		return t.TimeLight
+	default:
+		return ""
+	}
+}
+
+// LoadTheme returns the theme humanlog ships with that name, or reads the
+// theme in the JSON file at that path.
+func LoadTheme(nameOrPath string) (*Theme, error) {
+	for i := range Themes {
+		if Themes[i].Name == nameOrPath {
+			t := Themes[i]
+			return &t, t.compile()
+		}
+	}
+	f, err := os.Open(nameOrPath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no theme named %q", nameOrPath)
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t := new(Theme)
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(t); err != nil {
+		return nil, fmt.Errorf("%s: %v", nameOrPath, err)
+	}
+	if t.Name == "" {
+		t.Name = nameOrPath
+	}
+	if err := t.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %v", nameOrPath, err)
+	}
+	return t, nil
+}
+
+// compile checks that every role has a color humanlog knows.
+func (t *Theme) compile() error {
+	t.colors = make(map[string]*color.Color, len(themeRoles))
+	for _, role := range themeRoles {
+		name := t.colorOf(role)
+		if name == "" {
+			return fmt.Errorf("missing %s color", role)
+		}
+		c, err := parseColor(name)
+		if err != nil {
+			return fmt.Errorf("%s: %v", role, err)
+		}
+		t.colors[role] = c
+	}
+	for _, role := range themeOptionalRoles {
+		if name := t.colorOf(role); name != "" {
+			c, err := parseColor(name)
+			if err != nil {
+				return fmt.Errorf("%s: %v", role, err)
+			}
+			t.colors[role] = c
+		}
+	}
+	return nil
+}
+
+// Apply sets the colors of the theme in opts. Fatal and panic levels keep
+// their background, which sets them apart whatever the palette.
+func (t *Theme) Apply(opts *HandlerOptions) {
+	opts.KeyColor = t.colors["key"]
+	opts.ValColor = t.colors["value"]
+	opts.DebugLevelColor = t.colors["debug"]
+	opts.InfoLevelColor = t.colors["info"]
+	opts.WarnLevelColor = t.colors["warn"]
+	opts.ErrorLevelColor = t.colors["error"]
+
+	set := func(dst **color.Color, role string) {
+		if c, ok := t.colors[role]; ok {
+			*dst = c
+		}
+	}
+	set(&opts.MsgDarkBgColor, "message")
+	set(&opts.MsgLightBgColor, "message-light")
+	set(&opts.MsgAbsentDarkBgColor, "absent-message")
+	set(&opts.MsgAbsentLightBgColor, "absent-message-light")
+	set(&opts.TimeDarkBgColor, "time")
+	set(&opts.TimeLightBgColor, "time-light")
+}
@@ -0,0 +1,169 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+)
+
+// ansiRGB are the colors of xterm's default palette, which most terminals
+// stay close to, by the names of parseColor.
+var ansiRGB = map[string][3]float64{
+	"black": {0, 0, 0}, "red": {205, 0, 0}, "green": {0, 205, 0}, "yellow": {205, 205, 0},
+	"blue": {0, 0, 238}, "magenta": {205, 0, 205}, "cyan": {0, 205, 205}, "white": {229, 229, 229},
+	"hi-black": {127, 127, 127}, "hi-red": {255, 0, 0}, "hi-green": {0, 255, 0}, "hi-yellow": {255, 255, 0},
+	"hi-blue": {92, 92, 255}, "hi-magenta": {255, 0, 255}, "hi-cyan": {0, 255, 255}, "hi-white": {255, 255, 255},
+}
+
+// themeBackgrounds are the terminal backgrounds themes are checked against.
+var themeBackgrounds = []struct {
+	name  string
+	rgb   [3]float64
+	light bool
+}{
+	{"black", [3]float64{0, 0, 0}, false},
+	{"white", [3]float64{255, 255, 255}, true},
+	{"solarized-dark", [3]float64{0, 43, 54}, false},
+	{"solarized-light", [3]float64{253, 246, 227}, true},
+}
+
+// colorVisions simulate how colors are seen with each color blindness, as
+// matrices over linear RGB (Machado, Oliveira and Fernandes, 2009).
+var colorVisions = []struct {
+	name   string
+	matrix [3][3]float64
+}{
+	{"normal vision", [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}},
+	{"deuteranopia", [3][3]float64{{0.367322, 0.860646, -0.227968}, {0.280085, 0.672501, 0.047413}, {-0.011820, 0.042940, 0.968881}}},
+	{"protanopia", [3][3]float64{{0.152286, 1.052583, -0.204868}, {0.114503, 0.786281, 0.099216}, {-0.003882, -0.048116, 1.051998}}},
+	{"tritanopia", [3][3]float64{{1.255528, -0.076749, -0.178779}, {-0.078411, 0.930809, 0.147602}, {0.004733, 0.691367, 0.303900}}},
+}
+
+const (
+	// minContrast is the contrast ratio WCAG asks of text against its
+	// background.
+	minContrast = 4.5
+	// minColorDistance is how far apart, in CIELAB ΔE, two level colors
+	// have to be to be told apart at a glance.
+	minColorDistance = 40
+)
+
+// CheckTheme writes the contrast of each color of the theme against common
+// terminal backgrounds, followed by warnings about the colors that are hard
+// to read on one, and the levels that are hard to tell apart with a color
+// blindness. It returns how many warnings were written.
+func CheckTheme(t *Theme, w io.Writer) (int, error) {
+	tw := tabwriter.NewWriter(w, 0, 1, 2, ' ', 0)
+	header := []string{"ROLE", "COLOR"}
+	for _, bg := range themeBackgrounds {
+		header = append(header, strings.ToUpper(bg.name))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	var warnings []string
+	for _, role := range append(append([]string(nil), themeRoles...), themeOptionalRoles...) {
+		name := t.colorOf(role)
+		if name == "" {
+			continue
+		}
+		row := []string{role, name}
+		for _, bg := range themeBackgrounds {
+			// the colors for one kind of background aren't used on the other
+			if optional := !containsField(themeRoles, role); optional && strings.HasSuffix(role, "-light") != bg.light {
+				row = append(row, "-")
+				continue
+			}
+			ratio := contrastRatio(ansiRGB[name], bg.rgb)
+			row = append(row, fmt.Sprintf("%.1f:1", ratio))
+			if ratio < minContrast {
+				warnings = append(warnings, fmt.Sprintf("%s (%s) has a contrast of %.1f:1 on %s, below %.1f:1", role, name, ratio, bg.name, minContrast))
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return 0, err
+	}
+
+	for _, vision := range colorVisions {
+		for i, a := range themeLevels {
+			for _, b := range themeLevels[i+1:] {
+				ca, cb := t.colorOf(a), t.colorOf(b)
+				d := deltaE(simulateVision(vision.matrix, ansiRGB[ca]), simulateVision(vision.matrix, ansiRGB[cb]))
+				if d < minColorDistance {
+					warnings = append(warnings, fmt.Sprintf("%s (%s) and %s (%s) are hard to tell apart with %s, ΔE %.0f", a, ca, b, cb, vision.name, d))
+				}
+			}
+		}
+	}
+
+	for _, warning := range warnings {
+		if _, err := fmt.Fprintf(w, "warning: %s\n", warning); err != nil {
+			return 0, err
+		}
+	}
+	return len(warnings), nil
+}
+
+// linearRGB undoes the gamma of an sRGB color.
+func linearRGB(rgb [3]float64) [3]float64 {
+	var out [3]float64
+	for i, v := range rgb {
+		v /= 255
+		if v <= 0.04045 {
+			out[i] = v / 12.92
+		} else {
+			out[i] = math.Pow((v+0.055)/1.055, 2.4)
+		}
+	}
+	return out
+}
+
+// relativeLuminance is the luminance of a color as WCAG defines it.
+func relativeLuminance(rgb [3]float64) float64 {
+	lin := linearRGB(rgb)
+	return 0.2126*lin[0] + 0.7152*lin[1] + 0.0722*lin[2]
+}
+
+func contrastRatio(a, b [3]float64) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// simulateVision returns the linear RGB of a color as seen with the vision
+// of the matrix.
+func simulateVision(m [3][3]float64, rgb [3]float64) [3]float64 {
+	lin := linearRGB(rgb)
+	var out [3]float64
+	for i := range out {
+		v := m[i][0]*lin[0] + m[i][1]*lin[1] + m[i][2]*lin[2]
+		out[i] = math.Min(math.Max(v, 0), 1)
+	}
+	return out
+}
+
+// deltaE is the CIE76 distance between two linear RGB colors.
+func deltaE(a, b [3]float64) float64 {
+	la, lb := cieLab(a), cieLab(b)
+	return math.Sqrt(math.Pow(la[0]-lb[0], 2) + math.Pow(la[1]-lb[1], 2) + math.Pow(la[2]-lb[2], 2))
+}
+
+// cieLab converts a linear RGB color to CIELAB, under D65.
+func cieLab(lin [3]float64) [3]float64 {
+	x := (0.4124*lin[0] + 0.3576*lin[1] + 0.1805*lin[2]) / 0.95047
+	y := 0.2126*lin[0] + 0.7152*lin[1] + 0.0722*lin[2]
+	z := (0.0193*lin[0] + 0.1192*lin[1] + 0.9505*lin[2]) / 1.08883
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return [3]float64{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
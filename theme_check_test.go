@@ -0,0 +1,46 @@
+package humanlog
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestContrastRatio(t *testing.T) {
+	if got := contrastRatio(ansiRGB["hi-white"], ansiRGB["black"]); math.Abs(got-21) > 0.01 {
+		t.Errorf("want 21:1 for white on black, got %.2f", got)
+	}
+	if got := contrastRatio(ansiRGB["red"], ansiRGB["red"]); got != 1 {
+		t.Errorf("want 1:1 for a color on itself, got %.2f", got)
+	}
+}
+
+func TestCheckTheme(t *testing.T) {
+	check := func(name string) string {
+		t.Helper()
+		th, err := LoadTheme(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if _, err := CheckTheme(th, &out); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	out := check("default")
+	if !strings.Contains(out, "warn   yellow    12.3:1  1.7:1") {
+		t.Errorf("want the contrasts of each color, got\n%s", out)
+	}
+	if !strings.Contains(out, "warning: warn (yellow) and error (red) are hard to tell apart with deuteranopia") {
+		t.Errorf("want the default levels flagged for deuteranopia, got\n%s", out)
+	}
+
+	for _, name := range []string{"deuteranopia", "protanopia", "tritanopia"} {
+		if out := check(name); strings.Contains(out, "apart with "+name) {
+			t.Errorf("want the %s theme to keep the levels apart with %s, got\n%s", name, name, out)
+		}
+	}
+}
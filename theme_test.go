@@ -0,0 +1,97 @@
+package humanlog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestLoadTheme(t *testing.T) {
+	th, err := LoadTheme("deuteranopia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := *DefaultOptions
+	th.Apply(&opts)
+	if opts.WarnLevelColor != th.colors["warn"] || opts.ErrorLevelColor != th.colors["error"] {
+		t.Error("want the theme's level colors applied")
+	}
+	if opts.FatalLevelColor != DefaultOptions.FatalLevelColor {
+		t.Error("want the fatal level left alone")
+	}
+
+	dir, err := ioutil.TempDir("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mine.json")
+	custom := `{"key": "green", "value": "white", "debug": "hi-black", "info": "blue", "warn": "yellow", "error": "hi-red"}`
+	if err := ioutil.WriteFile(path, []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+	th, err = LoadTheme(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th.Name != path || th.Info != "blue" {
+		t.Errorf("want the theme of the file, got %+v", th)
+	}
+	opts = *DefaultOptions
+	th.Apply(&opts)
+	if opts.MsgDarkBgColor != DefaultOptions.MsgDarkBgColor || opts.TimeLightBgColor != DefaultOptions.TimeLightBgColor {
+		t.Error("want the message and time colors left alone when the theme has none")
+	}
+
+	withMessages := strings.TrimSuffix(custom, "}") + `, "message": "hi-yellow", "message-light": "blue", "absent-message": "white", "absent-message-light": "black", "time": "cyan", "time-light": "magenta"}`
+	if err := ioutil.WriteFile(path, []byte(withMessages), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if th, err = LoadTheme(path); err != nil {
+		t.Fatal(err)
+	}
+	opts = *DefaultOptions
+	th.Apply(&opts)
+	for role, got := range map[string]*color.Color{
+		"message":              opts.MsgDarkBgColor,
+		"message-light":        opts.MsgLightBgColor,
+		"absent-message":       opts.MsgAbsentDarkBgColor,
+		"absent-message-light": opts.MsgAbsentLightBgColor,
+		"time":                 opts.TimeDarkBgColor,
+		"time-light":           opts.TimeLightBgColor,
+	} {
+		if want := th.colors[role]; want == nil || got != want {
+			t.Errorf("want the %s color of the theme applied", role)
+		}
+	}
+	var out bytes.Buffer
+	if _, err := CheckTheme(th, &out); err != nil {
+		t.Fatal(err)
+	}
+	var row string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if f := strings.Fields(line); len(f) != 0 && f[0] == "message-light" {
+			row = strings.Join(f, " ")
+		}
+	}
+	if want := "message-light blue - 9.4:1 - 8.7:1"; row != want {
+		t.Errorf("want the light message color only checked on light backgrounds, want %q, got %q", want, row)
+	}
+
+	for _, bad := range []string{`{"key": "green"}`, strings.Replace(custom, `"blue"`, `"mauve"`, 1)} {
+		if err := ioutil.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadTheme(path); err == nil {
+			t.Errorf("want an error for %s", bad)
+		}
+	}
+	if _, err := LoadTheme("solarized"); err == nil || !strings.Contains(err.Error(), "no theme") {
+		t.Errorf("want an unknown theme to be an error, got %v", err)
+	}
+}
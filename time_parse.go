@@ -1,13 +1,24 @@
 package humanlog
 
 import (
+	"regexp"
+	"strings"
 	"time"
 )
 
+// AssumedZone is the time zone of the timestamps that don't say theirs.
+var AssumedZone = time.UTC
+
 var formats = []string{
 	"2006-01-02 15:04:05.999999999 -0700 MST",
 	"2006-01-02 15:04:05",
 	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05",
+	"20060102-150405",
+	// macOS's unified log
+	"2006-01-02 15:04:05-0700",
+	// Apache's %d/%b/%Y:%H:%M:%S %z
+	"02/Jan/2006:15:04:05 -0700",
 	time.RFC3339,
 	time.RFC3339Nano,
 	time.RFC822,
@@ -40,14 +51,22 @@ func parseTimeFloat64(value float64) time.Time {
 	return time.Unix(v/1e9, v%1e9)
 }
 
+// commaFraction matches the seconds of the timestamps using a comma before
+// their fraction, like the default formats of Java and Python do.
+var commaFraction = regexp.MustCompile(`(:\d\d),(\d)`)
+
 // tries to parse time using a couple of formats before giving up
 func tryParseTime(value interface{}) (time.Time, bool) {
 	var t time.Time
 	var err error
 	switch value.(type) {
 	case string:
+		s := value.(string)
+		if strings.IndexByte(s, ',') >= 0 {
+			s = commaFraction.ReplaceAllString(s, "$1.$2")
+		}
 		for _, layout := range formats {
-			t, err = time.Parse(layout, value.(string))
+			t, err = time.ParseInLocation(layout, s, AssumedZone)
 			if err == nil {
 				return t, true
 			}
@@ -2,6 +2,7 @@ package humanlog
 
 import (
 	"testing"
+	"time"
 )
 
 func TestTimeParseFloat64(t *testing.T) {
@@ -34,3 +35,44 @@ func TestTimeParseFloat64(t *testing.T) {
 		}
 	})
 }
+
+func TestTryParseTimeVariants(t *testing.T) {
+	est := time.FixedZone("", -5*3600)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{in: "2024-01-02 10:00:00,123", want: time.Date(2024, 1, 2, 10, 0, 0, 123e6, time.UTC)},
+		{in: "2024-01-02 10:00:00,5", want: time.Date(2024, 1, 2, 10, 0, 0, 500e6, time.UTC)},
+		{in: "2024-01-02T10:00:00,123456Z", want: time.Date(2024, 1, 2, 10, 0, 0, 123456e3, time.UTC)},
+		{in: "2024-01-02T10:00:00", want: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)},
+		{in: "20240102-100000", want: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)},
+		{in: "02/Jan/2024:10:00:00 -0500", want: time.Date(2024, 1, 2, 10, 0, 0, 0, est)},
+		{in: "2024-01-02 10:00:00.123456-0500", want: time.Date(2024, 1, 2, 10, 0, 0, 123456e3, est)},
+	}
+	for _, test := range tests {
+		got, ok := tryParseTime(test.in)
+		if !ok {
+			t.Errorf("%q: not parsed", test.in)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("%q: want %v, got %v", test.in, test.want, got)
+		}
+	}
+}
+
+func TestTryParseTimeAssumedZone(t *testing.T) {
+	defer func(zone *time.Location) { AssumedZone = zone }(AssumedZone)
+	AssumedZone = time.FixedZone("", 2*3600)
+
+	got, _ := tryParseTime("2024-01-02 10:00:00,123")
+	if want := time.Date(2024, 1, 2, 8, 0, 0, 123e6, time.UTC); !got.Equal(want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	// the zone of the timestamps saying theirs is kept
+	got, _ = tryParseTime("2024-01-02T10:00:00Z")
+	if want := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,138 @@
+package humanlog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// boundFormats are the layouts accepted for --since and --until, on top of
+// the ones humanlog recognizes in logs. Those without a zone are local time.
+var boundFormats = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTimeBound parses an absolute time, or a duration like -1h or +15m
+// relative to base.
+func ParseTimeBound(s string, base time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return base.Add(d), nil
+	}
+	if t, ok := tryParseTime(s); ok {
+		return t, nil
+	}
+	for _, layout := range boundFormats {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can't parse %q as a time or a relative duration", s)
+}
+
+// inTimeRange tells if t is within Since and Until. Entries without a time
+// are always in range, since there's no telling.
+func (h *HandlerOptions) inTimeRange(t time.Time) bool {
+	if t.IsZero() {
+		return true
+	}
+	if !h.Since.IsZero() && t.Before(h.Since) {
+		return false
+	}
+	if !h.Until.IsZero() && t.After(h.Until) {
+		return false
+	}
+	return true
+}
+
+// SeekSince finds where the first entry at or after since starts in f, a
+// file of size bytes sorted by time, by bisecting it. It only parses a few
+// lines at each step, which is much faster than reading everything before.
+func SeekSince(f io.ReaderAt, size int64, since time.Time, opts *HandlerOptions) (int64, error) {
+	lo, hi := int64(0), size
+	for hi-lo > 64*1024 {
+		mid := lo + (hi-lo)/2
+		start, t, err := firstTimeAfter(f, size, mid, opts)
+		if err != nil {
+			return 0, err
+		}
+		if start < 0 || !t.Before(since) {
+			hi = mid
+		} else {
+			lo = start
+		}
+	}
+	// back to the start of the line lo is in
+	return lineStart(f, lo)
+}
+
+// firstTimeAfter returns where the first line starting after off with a
+// parsable time begins, and that time, or -1 if there's none.
+func firstTimeAfter(f io.ReaderAt, size, off int64, opts *HandlerOptions) (int64, time.Time, error) {
+	r := bufio.NewReader(io.NewSectionReader(f, off, size-off))
+	pos := off
+	if off > 0 {
+		// skip the partial line we landed in
+		skipped, err := r.ReadBytes('\n')
+		pos += int64(len(skipped))
+		if err == io.EOF {
+			return -1, time.Time{}, nil
+		} else if err != nil {
+			return 0, time.Time{}, err
+		}
+	}
+	p := newParser(opts)
+	for i := 0; i < 100; i++ {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			res := p.parse(trimSyslog(trimEOL(line)))
+			if res.entry != nil {
+				t := res.entry.event().Time
+				res.entry.clear()
+				if !t.IsZero() {
+					return pos, t, nil
+				}
+			}
+			pos += int64(len(line))
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, time.Time{}, err
+		}
+	}
+	return -1, time.Time{}, nil
+}
+
+func lineStart(f io.ReaderAt, off int64) (int64, error) {
+	var b [1]byte
+	for off > 0 {
+		if _, err := f.ReadAt(b[:], off-1); err != nil {
+			return 0, err
+		}
+		if b[0] == '\n' {
+			break
+		}
+		off--
+	}
+	return off, nil
+}
+
+func trimEOL(line []byte) []byte {
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+		if n := len(line); n > 0 && line[n-1] == '\r' {
+			line = line[:n-1]
+		}
+	}
+	return line
+}
@@ -0,0 +1,63 @@
+package humanlog
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{in: "-1h", want: base.Add(-time.Hour)},
+		{in: "+15m", want: base.Add(15 * time.Minute)},
+		{in: "2024-01-02T03:04:05Z", want: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{in: "2024-01-02T03:04", want: time.Date(2024, 1, 2, 3, 4, 0, 0, time.Local)},
+	}
+	for _, test := range tests {
+		got, err := ParseTimeBound(test.in, base)
+		if err != nil {
+			t.Errorf("%s: %v", test.in, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("%s: want %v, got %v, want != got", test.in, test.want, got)
+		}
+	}
+	if _, err := ParseTimeBound("yesterday-ish", base); err == nil {
+		t.Error("want an error, got none")
+	}
+}
+
+func TestSeekSince(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	var wantOffset int64
+	since := start.Add(5000 * time.Second)
+	for i := 0; i < 10000; i++ {
+		if i == 5000 {
+			wantOffset = int64(buf.Len())
+		}
+		fmt.Fprintf(&buf, `{"msg": "entry %d", "time": %q}`+"\n", i, start.Add(time.Duration(i)*time.Second).Format(time.RFC3339))
+	}
+
+	opts := *DefaultOptions
+	opts.Since = since
+	off, err := SeekSince(bytes.NewReader(buf.Bytes()), int64(buf.Len()), since, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if off > wantOffset {
+		t.Fatalf("skipped past the first entry in range: want at most %d, got %d", wantOffset, off)
+	}
+	if wantOffset-off > 128*1024 {
+		t.Errorf("didn't skip ahead enough: want close to %d, got %d", wantOffset, off)
+	}
+	if off > 0 && buf.Bytes()[off-1] != '\n' {
+		t.Errorf("offset %d isn't at the start of a line", off)
+	}
+}
@@ -0,0 +1,151 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// titleWindow is how far back errors are counted in the title.
+	titleWindow = time.Minute
+	// titleInterval is how often the title is updated at most.
+	titleInterval = time.Second
+	// titleMessageWidth is how much of the last error message is shown.
+	titleMessageWidth = 60
+)
+
+// TitleStatus keeps the terminal's title, and a tmux window option, up to
+// date with the errors seen in the last minute and the last error message,
+// so that a pane humanlog runs in in the background still tells when
+// something went wrong.
+type TitleStatus struct {
+	opts *HandlerOptions
+	w    io.Writer
+	// TmuxOption is the user option of the window humanlog runs in to set,
+	// like @humanlog, for status formats to show with #{@humanlog}.
+	TmuxOption string
+	// setTmux sets the tmux option, or unsets it when value is empty.
+	setTmux func(option, value string) error
+
+	mu     sync.Mutex
+	errors []time.Time
+	last   string
+	shown  string
+	update time.Time
+}
+
+// NewTitleStatus returns a TitleStatus writing the title onto w, a terminal,
+// if w isn't nil. The level of the entries is ranked with opts.
+func NewTitleStatus(opts *HandlerOptions, w io.Writer, tmuxOption string) *TitleStatus {
+	return &TitleStatus{opts: opts, w: w, TmuxOption: tmuxOption, setTmux: setTmuxOption}
+}
+
+// Stage counts the errors among the entries.
+func (s *TitleStatus) Stage(e *Entry, next Next) error {
+	if e.Event != nil {
+		if rank, ok := s.opts.LevelRank(e.Event.Level); ok && rank >= builtinLevelRanks["error"] {
+			now := time.Now()
+			s.mu.Lock()
+			s.errors = append(s.errors, now)
+			s.last = e.Event.Message
+			s.mu.Unlock()
+			s.refresh(now, false)
+		}
+	}
+	return next(e)
+}
+
+// Refresh updates the title, for errors to age out of it when no entry
+// comes in.
+func (s *TitleStatus) Refresh() {
+	s.refresh(time.Now(), true)
+}
+
+func (s *TitleStatus) refresh(now time.Time, force bool) {
+	s.mu.Lock()
+	if !force && now.Sub(s.update) < titleInterval {
+		s.mu.Unlock()
+		return
+	}
+	status := s.status(now)
+	changed := status != s.shown
+	s.shown, s.update = status, now
+	s.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if s.w != nil {
+		fmt.Fprintf(s.w, "\x1b]2;%s\x07", status)
+	}
+	if s.TmuxOption != "" {
+		_ = s.setTmux(s.TmuxOption, status)
+	}
+}
+
+// status is the title for now, forgetting the errors too old to count.
+func (s *TitleStatus) status(now time.Time) string {
+	i := 0
+	for i < len(s.errors) && now.Sub(s.errors[i]) > titleWindow {
+		i++
+	}
+	s.errors = s.errors[i:]
+	if len(s.errors) == 0 {
+		if s.last == "" {
+			return "humanlog: no errors"
+		}
+		return "humanlog: no errors in the last minute, last: " + titleText(s.last)
+	}
+	errors := "errors"
+	if len(s.errors) == 1 {
+		errors = "error"
+	}
+	return fmt.Sprintf("humanlog: %d %s/min, last: %s", len(s.errors), errors, titleText(s.last))
+}
+
+// titleText makes msg fit in a title, on one line without control
+// characters: C0 ones, DEL, and C1 ones like U+009B, which terminals can
+// read as the start of an escape sequence. Bytes that aren't UTF-8, like a
+// raw 0x9b, become U+FFFD.
+func titleText(msg string) string {
+	msg = strings.Join(strings.Fields(msg), " ")
+	msg = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, msg)
+	if displayWidth(msg) > titleMessageWidth {
+		msg = truncateWidth(msg, titleMessageWidth) + "…"
+	}
+	return msg
+}
+
+// Close unsets the tmux option.
+func (s *TitleStatus) Close() error {
+	if s.TmuxOption == "" {
+		return nil
+	}
+	return s.setTmux(s.TmuxOption, "")
+}
+
+// setTmuxOption sets a user option of the window of the pane humanlog runs
+// in.
+func setTmuxOption(option, value string) error {
+	args := []string{"set-option", "-q", "-w"}
+	if pane := os.Getenv("TMUX_PANE"); pane != "" {
+		args = append(args, "-t", pane)
+	}
+	if value == "" {
+		args = append(args, "-u", option)
+	} else {
+		args = append(args, option, value)
+	}
+	return exec.Command("tmux", args...).Run()
+}
@@ -0,0 +1,68 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTitleStatus(t *testing.T) {
+	opts := *DefaultOptions
+	var term bytes.Buffer
+	s := NewTitleStatus(&opts, &term, "@humanlog")
+	var tmux []string
+	s.setTmux = func(option, value string) error {
+		tmux = append(tmux, option+"="+value)
+		return nil
+	}
+
+	src := strings.Join([]string{
+		`{"level": "info", "msg": "started"}`,
+		`{"level": "error", "msg": "connection\nrefused"}`,
+		`{"level": "fatal", "msg": "gave up"}`,
+	}, "\n")
+	if err := Process(strings.NewReader(src), &opts, Chain(Parse(&opts), s.Stage)); err != nil {
+		t.Fatal(err)
+	}
+	// the second error came within a second of the first
+	if want := "\x1b]2;humanlog: 1 error/min, last: connection refused\x07"; term.String() != want {
+		t.Errorf("want %q, got %q", want, term.String())
+	}
+
+	term.Reset()
+	s.refresh(time.Now(), true)
+	if want := "\x1b]2;humanlog: 2 errors/min, last: gave up\x07"; term.String() != want {
+		t.Errorf("want %q, got %q", want, term.String())
+	}
+
+	term.Reset()
+	s.refresh(time.Now().Add(2*time.Minute), true)
+	if want := "\x1b]2;humanlog: no errors in the last minute, last: gave up\x07"; term.String() != want {
+		t.Errorf("want the errors to age out, got %q", term.String())
+	}
+	term.Reset()
+	s.refresh(time.Now().Add(3*time.Minute), true)
+	if term.Len() != 0 {
+		t.Errorf("want the title left alone when unchanged, got %q", term.String())
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(tmux); n != 4 || tmux[0] != "@humanlog=humanlog: 1 error/min, last: connection refused" || tmux[3] != "@humanlog=" {
+		t.Errorf("want the tmux option set along the title and unset on close, got %q", tmux)
+	}
+}
+
+func TestTitleText(t *testing.T) {
+	if got := titleText("a\tb\x1b[31m c"); got != "a b[31m c" {
+		t.Errorf("want control characters removed, got %q", got)
+	}
+	if got := titleText("a\u009b31mb\u0085c\x9b2Jd"); got != "a31mb c\ufffd2Jd" {
+		t.Errorf("want C1 control characters removed, got %q", got)
+	}
+	if got := titleText(strings.Repeat("x", 100)); got != strings.Repeat("x", 60)+"…" {
+		t.Errorf("want long messages cut, got %q", got)
+	}
+}
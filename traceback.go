@@ -0,0 +1,54 @@
+package humanlog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tracebackBlock returns the traceback held by key on lines of their own,
+// with the middle frames folded away when it's longer than TracebackLines.
+func (h *HandlerOptions) tracebackBlock(key, val string) (string, bool) {
+	if !containsField(h.TracebackFields, key) {
+		return "", false
+	}
+	trace := strings.TrimRight(unquoteValue(val), "\n")
+	if !strings.Contains(trace, "\n") {
+		return "", false
+	}
+	lines := strings.Split(trace, "\n")
+	if max := h.TracebackLines; max > 0 && len(lines) > max {
+		head := max / 3
+		tail := max - head
+		folded := fmt.Sprintf("... %d lines folded ...", len(lines)-head-tail)
+		lines = append(append(lines[:head:head], folded), lines[len(lines)-tail:]...)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("    ")
+	sb.WriteString(h.KeyColor.Sprint(key))
+	sb.WriteString(":")
+	for _, line := range lines {
+		sb.WriteString("\n      ")
+		sb.WriteString(h.TracebackColor.Sprint(line))
+	}
+	return sb.String(), true
+}
+
+// loggerName returns the first of the LoggerFields the entry has.
+func (h *HandlerOptions) loggerName(fields map[string]string) string {
+	for _, field := range h.LoggerFields {
+		if v, ok := fields[field]; ok {
+			return unquoteValue(v)
+		}
+	}
+	return ""
+}
+
+func containsField(fields []string, key string) bool {
+	for _, field := range fields {
+		if field == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldTransform rewrites the value of a field through a pipeline of steps,
+// separated by |:
+//
+//	lowercase, uppercase, trim    change the value
+//	map(from=to, ...)             replace the values listed
+//	split(',')                    turn the value into a list
+//	parse-url(host, path)         add fields like url.host out of a URL
+type FieldTransform struct {
+	Field string
+	Steps string
+
+	steps []transformStep
+}
+
+// transformStep returns the new value of the field, and fields to add.
+type transformStep func(key, val string) (string, map[string]string)
+
+// ParseTransform compiles the steps transforming a field.
+func ParseTransform(field, steps string) (FieldTransform, error) {
+	t := FieldTransform{Field: field, Steps: steps}
+	for _, step := range strings.Split(steps, "|") {
+		step = strings.TrimSpace(step)
+		name, args := step, []string(nil)
+		if i := strings.IndexByte(step, '('); i >= 0 {
+			if !strings.HasSuffix(step, ")") {
+				return t, fmt.Errorf("%q: missing closing parenthesis", step)
+			}
+			name = strings.TrimSpace(step[:i])
+			args = transformArgs(step[i+1 : len(step)-1])
+		}
+		fn, err := newTransformStep(name, args)
+		if err != nil {
+			return t, fmt.Errorf("%q: %v", step, err)
+		}
+		t.steps = append(t.steps, fn)
+	}
+	return t, nil
+}
+
+// transformArgs splits the arguments of a step on commas, unquoting them.
+func transformArgs(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	var args []string
+	for _, arg := range splitArgs(s) {
+		arg = strings.TrimSpace(arg)
+		if len(arg) >= 2 && (arg[0] == '\'' || arg[0] == '"') && arg[len(arg)-1] == arg[0] {
+			arg = arg[1 : len(arg)-1]
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+// splitArgs splits s on the commas that aren't quoted.
+func splitArgs(s string) []string {
+	var (
+		args  []string
+		quote byte
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ',':
+			args = append(args, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(args, s[start:])
+}
+
+func newTransformStep(name string, args []string) (transformStep, error) {
+	switch name {
+	case "lowercase":
+		return func(_, v string) (string, map[string]string) { return strings.ToLower(v), nil }, nil
+	case "uppercase":
+		return func(_, v string) (string, map[string]string) { return strings.ToUpper(v), nil }, nil
+	case "trim":
+		return func(_, v string) (string, map[string]string) { return strings.TrimSpace(v), nil }, nil
+	case "map":
+		table := make(map[string]string, len(args))
+		for _, arg := range args {
+			kv := strings.SplitN(arg, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("want from=to, got %q", arg)
+			}
+			table[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+		return func(_, v string) (string, map[string]string) {
+			if to, ok := table[v]; ok {
+				return to, nil
+			}
+			return v, nil
+		}, nil
+	case "split":
+		if len(args) != 1 || args[0] == "" {
+			return nil, fmt.Errorf("want a separator")
+		}
+		sep := args[0]
+		return func(_, v string) (string, map[string]string) {
+			parts := strings.Split(v, sep)
+			for i := range parts {
+				parts[i] = strings.TrimSpace(parts[i])
+			}
+			b, _ := json.Marshal(parts)
+			return string(b), nil
+		}, nil
+	case "parse-url":
+		parts := args
+		if len(parts) == 0 {
+			parts = []string{"scheme", "host", "port", "path", "query", "fragment"}
+		}
+		for _, part := range parts {
+			if _, ok := urlPart(&url.URL{}, part); !ok {
+				return nil, fmt.Errorf("unknown URL part %q", part)
+			}
+		}
+		return func(key, v string) (string, map[string]string) {
+			u, err := url.Parse(v)
+			if err != nil {
+				return v, nil
+			}
+			added := make(map[string]string, len(parts))
+			for _, part := range parts {
+				if pv, _ := urlPart(u, part); pv != "" {
+					added[key+"."+part] = pv
+				}
+			}
+			return v, added
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown transform")
+	}
+}
+
+func urlPart(u *url.URL, part string) (string, bool) {
+	switch part {
+	case "scheme":
+		return u.Scheme, true
+	case "host":
+		return u.Hostname(), true
+	case "port":
+		return u.Port(), true
+	case "path":
+		return u.Path, true
+	case "query":
+		return u.RawQuery, true
+	case "fragment":
+		return u.Fragment, true
+	case "user":
+		return u.User.Username(), true
+	}
+	return "", false
+}
+
+// apply runs the pipeline on the field of the entry, if it has it.
+func (t *FieldTransform) apply(e *Entry) {
+	raw, ok := e.Event.Fields[t.Field]
+	if !ok {
+		return
+	}
+	v := unquoteValue(raw)
+	added := make(map[string]string)
+	for _, step := range t.steps {
+		var more map[string]string
+		v, more = step(t.Field, v)
+		for k, mv := range more {
+			added[k] = mv
+		}
+	}
+	if quoted := raw != unquoteValue(raw); quoted && !strings.HasPrefix(v, "[") {
+		v = strconv.Quote(v)
+	}
+	if v != raw {
+		e.Set(t.Field, v)
+	}
+	keys := make([]string, 0, len(added))
+	for k := range added {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		e.Set(k, added[k])
+	}
+}
+
+// transformStage runs the field transforms on the parsed entries.
+func transformStage(transforms []FieldTransform) Stage {
+	return func(e *Entry, next Next) error {
+		if e.Event != nil {
+			for i := range transforms {
+				transforms[i].apply(e)
+			}
+		}
+		return next(e)
+	}
+}
@@ -0,0 +1,62 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFieldTransforms(t *testing.T) {
+	path := writeConfig(t, `{"transform": {
+		"email": "trim | lowercase",
+		"env": "map(prod=production, stg=staging)",
+		"tags": "split(',')",
+		"url": "parse-url(host, path)"
+	}}`)
+	cfg, err := ReadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := *DefaultOptions
+	cfg.Apply(&opts)
+
+	var fields map[string]string
+	chain := Chain(append(builtinStages(&opts), func(e *Entry, next Next) error {
+		fields = e.Event.Fields
+		return next(e)
+	})...)
+	src := `{"msg": "hi", "email": " Ann@Example.COM ", "env": "prod", "tags": "a, b", "url": "https://example.com/a/b?c=d"}`
+	if err := Process(strings.NewReader(src), &opts, chain); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"email":    `"ann@example.com"`,
+		"env":      `"production"`,
+		"tags":     `["a","b"]`,
+		"url":      `"https://example.com/a/b?c=d"`,
+		"url.host": "example.com",
+		"url.path": "/a/b",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s: want %s, got %s", k, v, fields[k])
+		}
+	}
+	if _, ok := fields["url.query"]; ok {
+		t.Error("url.query wasn't asked for")
+	}
+}
+
+func TestParseTransform_Errors(t *testing.T) {
+	for _, steps := range []string{
+		"lowercase | reverse",
+		"split",
+		"map(prod)",
+		"parse-url(hostname)",
+		"split(','",
+	} {
+		if _, err := ParseTransform("f", steps); err == nil {
+			t.Errorf("%q: want an error", steps)
+		}
+	}
+}
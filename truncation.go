@@ -0,0 +1,94 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Truncations remembers the values cut down to TruncateLength, numbered in
+// the order they were shown, so that they can be shown in full on demand.
+// Only the last few thousands are kept.
+type Truncations struct {
+	mu     sync.Mutex
+	values *spillRing
+	first  int
+}
+
+// NewTruncations returns Truncations keeping the values within budget, nil
+// keeping them all in memory like the zero Truncations.
+func NewTruncations(budget *MemoryBudget) *Truncations {
+	return &Truncations{values: &spillRing{max: maxTruncations, budget: budget}}
+}
+
+// maxTruncations is how many truncated values are remembered.
+const maxTruncations = 4096
+
+// add remembers a value, returning its number.
+func (t *Truncations) add(key, value string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.values == nil {
+		t.values = &spillRing{max: maxTruncations}
+	}
+	if t.values.len() == maxTruncations {
+		t.first++
+	}
+	// keys don't have NULs
+	t.values.push(key + "\x00" + value)
+	return t.first + t.values.len()
+}
+
+// Get returns the key and the full value of the truncated value numbered n.
+func (t *Truncations) Get(n int) (key, value string, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.values == nil {
+		return "", "", false
+	}
+	kv, ok := t.values.get(n - t.first - 1)
+	if !ok {
+		return "", "", false
+	}
+	key, value = splitTruncated(kv)
+	return key, value, true
+}
+
+func splitTruncated(kv string) (key, value string) {
+	i := strings.IndexByte(kv, 0)
+	return kv[:i], kv[i+1:]
+}
+
+// writeAppendix writes the full values remembered onto dst, after the
+// entries that had them truncated.
+func (t *Truncations) writeAppendix(dst io.Writer, opts *HandlerOptions) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.values == nil || t.values.len() == 0 {
+		return
+	}
+	rule := strings.Repeat("─", 20)
+	opts.SeparatorColor.Fprintf(dst, "%s truncated values %s\n", rule, rule)
+	i := 0
+	t.values.each(func(kv string) {
+		i++
+		key, value := splitTruncated(kv)
+		opts.LineNumberColor.Fprintf(dst, "[%d] ", t.first+i)
+		fmt.Fprintf(dst, "%s=%s\n", opts.KeyColor.Sprint(key), value)
+	})
+}
+
+// truncateValue cuts v down to TruncateLength columns if it's longer. With
+// Truncations, the value is remembered and its number is shown after it.
+func (h *HandlerOptions) truncateValue(key, v string) string {
+	if !h.Truncates || displayWidth(v) <= h.TruncateLength {
+		return v
+	}
+	cut := truncateWidth(v, h.TruncateLength) + "…"
+	if h.Truncations != nil {
+		cut += "[" + strconv.Itoa(h.Truncations.add(key, v)) + "]"
+	}
+	return cut
+}
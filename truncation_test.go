@@ -0,0 +1,60 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestTruncationMarkers(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	src := strings.Join([]string{
+		`{"time": "2024-01-02T10:00:00Z", "level": "info", "msg": "a", "note": "a very long note"}`,
+		`{"time": "2024-01-02T10:00:01Z", "level": "info", "msg": "b", "note": "short"}`,
+		`{"time": "2024-01-02T10:00:02Z", "level": "info", "msg": "c", "note": "another long note"}`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.TimeFormat = "15:04:05"
+	opts.TruncateLength = 8
+	opts.Truncations = new(Truncations)
+
+	var out bytes.Buffer
+	if err := Scanner(strings.NewReader(src), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		`10:00:00 |INFO| a note="a very …[1]`,
+		`10:00:01 |INFO| b note="short"`,
+		`10:00:02 |INFO| c note="another…[2]`,
+		`──────────────────── truncated values ────────────────────`,
+		`[1] note="a very long note"`,
+		`[2] note="another long note"`,
+		``,
+	}, "\n")
+	if got := out.String(); got != want {
+		t.Errorf("want\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestTruncations_Evicted(t *testing.T) {
+	var truncs Truncations
+	for i := 0; i < maxTruncations+2; i++ {
+		truncs.add("k", strings.Repeat("v", i))
+	}
+	if _, _, ok := truncs.Get(2); ok {
+		t.Error("want the oldest values forgotten")
+	}
+	key, value, ok := truncs.Get(maxTruncations + 2)
+	if !ok || key != "k" || len(value) != maxTruncations+1 {
+		t.Errorf("want the last value kept, got %q=%d chars", key, len(value))
+	}
+	if _, _, ok := truncs.Get(maxTruncations + 3); ok {
+		t.Error("want no value past the last one")
+	}
+}
@@ -0,0 +1,122 @@
+package humanlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FieldUnit is the unit a field's numbers are in, appended to them when
+// they're shown, after converting them to another unit if To isn't empty.
+type FieldUnit struct {
+	From string
+	To   string
+}
+
+// unitScale places a unit in its dimension, as a multiple of the dimension's
+// base unit.
+type unitScale struct {
+	dimension string
+	scale     float64
+}
+
+// unitScales are the units numbers can be converted between.
+var unitScales = map[string]unitScale{
+	"ns": {"time", 1e-9}, "us": {"time", 1e-6}, "µs": {"time", 1e-6}, "ms": {"time", 1e-3},
+	"s": {"time", 1}, "min": {"time", 60}, "h": {"time", 3600}, "d": {"time", 86400},
+
+	"B": {"size", 1}, "bytes": {"size", 1},
+	"KB": {"size", 1e3}, "MB": {"size", 1e6}, "GB": {"size", 1e9}, "TB": {"size", 1e12},
+	"KiB": {"size", 1 << 10}, "MiB": {"size", 1 << 20}, "GiB": {"size", 1 << 30}, "TiB": {"size", 1 << 40},
+
+	"°C": {"temperature", 1}, "°F": {"temperature", 1}, "K": {"temperature", 1},
+}
+
+// ParseFieldUnit reads the unit of a field, like "ms", or a conversion from
+// one unit to another, like "ms->s". Units humanlog doesn't know can only be
+// appended.
+func ParseFieldUnit(spec string) (FieldUnit, error) {
+	parts := strings.SplitN(spec, "->", 2)
+	u := FieldUnit{From: strings.TrimSpace(parts[0])}
+	if u.From == "" {
+		return u, fmt.Errorf("missing unit in %q", spec)
+	}
+	if len(parts) == 1 {
+		return u, nil
+	}
+	u.To = strings.TrimSpace(parts[1])
+	from, okFrom := unitScales[u.From]
+	to, okTo := unitScales[u.To]
+	switch {
+	case !okFrom:
+		return u, fmt.Errorf("can't convert from unknown unit %q", u.From)
+	case !okTo:
+		return u, fmt.Errorf("can't convert to unknown unit %q", u.To)
+	case from.dimension != to.dimension:
+		return u, fmt.Errorf("can't convert %s to %s", u.From, u.To)
+	}
+	return u, nil
+}
+
+// convert returns v, in From, in To.
+func (u FieldUnit) convert(v float64) float64 {
+	if u.From == u.To {
+		return v
+	}
+	if unitScales[u.From].dimension == "temperature" {
+		return fromKelvin(toKelvin(v, u.From), u.To)
+	}
+	return v * unitScales[u.From].scale / unitScales[u.To].scale
+}
+
+func toKelvin(v float64, unit string) float64 {
+	switch unit {
+	case "°C":
+		return v + 273.15
+	case "°F":
+		return (v-32)*5/9 + 273.15
+	default:
+		return v
+	}
+}
+
+func fromKelvin(v float64, unit string) float64 {
+	switch unit {
+	case "°C":
+		return v - 273.15
+	case "°F":
+		return (v-273.15)*9/5 + 32
+	default:
+		return v
+	}
+}
+
+// label is the unit as appended to the numbers, right after symbols like ms
+// and after a space for words like requests.
+func (u FieldUnit) label() string {
+	unit := u.From
+	if u.To != "" {
+		unit = u.To
+	}
+	if _, ok := unitScales[unit]; ok && unit != "bytes" {
+		return unit
+	}
+	return " " + unit
+}
+
+// formatQuantity renders the number of a field with the separators and
+// precision of the options, in the field's unit if it has one.
+func (h *HandlerOptions) formatQuantity(key, v string) string {
+	u, ok := h.Units[key]
+	if !ok {
+		return h.formatNumber(v)
+	}
+	if u.To != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			// rounded off, so that 300ms is 0.3s rather than 0.30000000000000004s
+			f, _ = strconv.ParseFloat(strconv.FormatFloat(u.convert(f), 'g', 12, 64), 64)
+			v = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+	return h.formatNumber(v) + u.label()
+}
@@ -0,0 +1,79 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestParseFieldUnit(t *testing.T) {
+	for _, spec := range []string{"", "->s", "ms->", "ms->MiB", "furlongs->m"} {
+		if _, err := ParseFieldUnit(spec); err == nil {
+			t.Errorf("want an error for %q", spec)
+		}
+	}
+	u, err := ParseFieldUnit("requests")
+	if err != nil || u.From != "requests" || u.To != "" {
+		t.Errorf("want any unit to be appended, got %+v, %v", u, err)
+	}
+}
+
+func TestFormatQuantity(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Units = make(map[string]FieldUnit)
+	for key, spec := range map[string]string{
+		"latency": "ms", "duration_ms": "ms->s", "mem": "bytes->MiB", "size": "bytes",
+		"temp": "°C->°F", "rps": "requests",
+	} {
+		u, err := ParseFieldUnit(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts.Units[key] = u
+	}
+
+	tests := []struct {
+		key, v, want string
+	}{
+		{"latency", "250", "250ms"},
+		{"duration_ms", "300", "0.3s"},
+		{"mem", "1572864", "1.5MiB"},
+		{"size", "512", "512 bytes"},
+		{"temp", "100", "212°F"},
+		{"rps", "12", "12 requests"},
+		{"other", "12", "12"},
+	}
+	for _, tt := range tests {
+		if got := opts.formatQuantity(tt.key, tt.v); got != tt.want {
+			t.Errorf("%s=%s: want %q, got %q", tt.key, tt.v, tt.want, got)
+		}
+	}
+}
+
+func TestUnitsConfig(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	path := writeConfig(t, `{"units": {"duration_ms": "ms->s", "mem": "MiB"}}`)
+	cfg, err := ReadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := *DefaultOptions
+	cfg.Apply(&opts)
+	opts.ThousandsSeparator = ","
+
+	var out bytes.Buffer
+	if err := Scanner(strings.NewReader(`{"msg": "done", "duration_ms": 1500, "mem": 2048, "name": "x"}`), &out, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "duration_ms=1.5s") || !strings.Contains(got, "mem=2,048MiB") {
+		t.Errorf("want the values in their units, got %q", got)
+	}
+
+	if _, err := ReadConfig(writeConfig(t, `{"units": {"temp": "°C->s"}}`)); err == nil {
+		t.Error("want an error converting between dimensions")
+	}
+}
@@ -0,0 +1,90 @@
+package humanlog
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// valueKind is the type a field's value had before being formatted, so it
+// can be colored accordingly.
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindNumber
+	kindBool
+	kindNull
+	kindTime
+	kindURL
+	kindObject
+	// kindInvalid is the kind of the values that aren't of the type their
+	// field was given in Types.
+	kindInvalid
+)
+
+// kindOfJSON tells the kind of a value decoded by encoding/json.
+func kindOfJSON(v interface{}) valueKind {
+	switch v := v.(type) {
+	case float64:
+		return kindNumber
+	case bool:
+		return kindBool
+	case nil:
+		return kindNull
+	case string:
+		return kindOfString(v)
+	default:
+		return kindObject
+	}
+}
+
+// kindOfText guesses the kind of an untyped value, like logfmt's.
+func kindOfText(v string) valueKind {
+	switch v {
+	case "true", "false":
+		return kindBool
+	case "null", "nil", "<nil>":
+		return kindNull
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return kindNumber
+	}
+	return kindOfString(v)
+}
+
+// kindOfString recognizes the strings that hold a time or a URL.
+func kindOfString(v string) valueKind {
+	if i := strings.Index(v, "://"); i > 0 && !strings.ContainsAny(v[:i], " \t") {
+		return kindURL
+	}
+	if len(v) >= 8 && v[0] >= '0' && v[0] <= '9' && strings.ContainsAny(v, "-:") {
+		if _, ok := tryParseTime(v); ok {
+			return kindTime
+		}
+	}
+	return kindString
+}
+
+func (h *HandlerOptions) valueColor(kind valueKind) *color.Color {
+	var c *color.Color
+	switch kind {
+	case kindNumber:
+		c = h.NumberColor
+	case kindBool:
+		c = h.BoolColor
+	case kindNull:
+		c = h.NullColor
+	case kindTime:
+		c = h.TimeValueColor
+	case kindURL:
+		c = h.URLColor
+	case kindInvalid:
+		c = h.DiagnosticColor
+	}
+	if c == nil {
+		return h.ValColor
+	}
+	return c
+}
@@ -0,0 +1,82 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestKindOfText(t *testing.T) {
+	tests := []struct {
+		value string
+		want  valueKind
+	}{
+		{value: "hello", want: kindString},
+		{value: "42", want: kindNumber},
+		{value: "-1.5e3", want: kindNumber},
+		{value: "true", want: kindBool},
+		{value: "null", want: kindNull},
+		{value: "2021-02-05T12:41:48Z", want: kindTime},
+		{value: "https://example.com/a?b=c", want: kindURL},
+		{value: "not a url: //", want: kindString},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			if got := kindOfText(test.value); got != test.want {
+				t.Errorf("want %d, got %d, want != got", test.want, got)
+			}
+		})
+	}
+}
+
+func TestKindOfJSON(t *testing.T) {
+	h := &JSONHandler{}
+	if !h.TryHandle([]byte(`{"msg": "m", "n": 1, "s": "42", "b": false, "z": null, "o": {"a": 1}}`)) {
+		t.Fatal("failed to handle log")
+	}
+	want := map[string]valueKind{"n": kindNumber, "s": kindString, "b": kindBool, "z": kindNull, "o": kindObject}
+	for k, kind := range want {
+		if h.kinds[k] != kind {
+			t.Errorf("%s: want %d, got %d, want != got", k, kind, h.kinds[k])
+		}
+	}
+}
+
+func TestBoolAndNullRendering(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	opts := *DefaultOptions
+	opts.KeyColor = color.New()
+	opts.BoolColor = color.New(color.FgGreen)
+	opts.FalseColor = color.New(color.FgRed)
+	opts.NullColor = color.New(color.FgHiBlack)
+
+	for _, src := range []string{
+		`{"msg": "m", "ok": true, "cached": false, "parent": null}`,
+		`msg=m ok=true cached=false parent=null`,
+	} {
+		h := newParser(&opts).parse([]byte(src)).entry
+		if h == nil {
+			t.Fatalf("can't parse %s", src)
+		}
+		out := string(h.Prettify(false))
+		for _, want := range []string{
+			opts.KeyColor.Sprint("ok") + "=" + opts.BoolColor.Sprint("true"),
+			opts.KeyColor.Sprint("cached") + "=" + opts.FalseColor.Sprint("false"),
+			opts.KeyColor.Sprint("parent") + "=" + opts.NullColor.Sprint("null"),
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("want %q in %q", want, out)
+			}
+		}
+
+		opts.HideNull = true
+		h = newParser(&opts).parse([]byte(src)).entry
+		if out := string(h.Prettify(false)); strings.Contains(out, "parent") {
+			t.Errorf("want null values hidden, got %q", out)
+		}
+		opts.HideNull = false
+	}
+}
@@ -0,0 +1,141 @@
+package humanlog
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+)
+
+// w3cFields remembers the columns declared by the last #Fields directive of
+// a W3C extended log file, like IIS and some CDNs write:
+//
+//	#Fields: date time cs-method cs-uri-stem cs-uri-query sc-status time-taken
+//	2024-01-02 03:04:05 GET /index.html - 200 15
+type w3cFields struct {
+	names []string
+}
+
+// directive reads the #Fields directive, if the line is one. The directives
+// themselves are left as they are.
+func (w *w3cFields) directive(d []byte) {
+	if fields := bytes.TrimPrefix(d, []byte("#Fields:")); len(fields) != len(d) {
+		w.names = w.names[:0]
+		for _, name := range bytes.Fields(fields) {
+			w.names = append(w.names, string(name))
+		}
+	}
+}
+
+// sniff tells the directives, which are read by tryHandle, and the rows
+// that may follow once a #Fields directive declared their columns. Other
+// formats can be mixed in the same stream, and have their say before the
+// rows, which only tryHandle tells apart.
+func (w *w3cFields) sniff(d []byte) confidence {
+	switch {
+	case len(d) > 0 && d[0] == '#':
+		return likelyFormat
+	case len(w.names) != 0:
+		return mayBeFormat
+	default:
+		return notFormat
+	}
+}
+
+// isRow tells whether values are a row of the declared columns: there are
+// as many, and the date and time columns, if declared, hold a date and a
+// time.
+func (w *w3cFields) isRow(values [][]byte) bool {
+	if len(values) != len(w.names) {
+		return false
+	}
+	for i, name := range w.names {
+		layout := ""
+		switch name {
+		case "date":
+			layout = "2006-01-02"
+		case "time":
+			layout = "15:04:05"
+		default:
+			continue
+		}
+		if v := string(values[i]); v != "-" {
+			if _, err := time.Parse(layout, v); err != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// tryHandle maps the columns of a row onto the fields they were declared as.
+// The date and time make the time of the entry, the method and URI its
+// message, and the status its level.
+func (w *w3cFields) tryHandle(d []byte, h *LogfmtHandler) bool {
+	if len(d) == 0 || d[0] == '#' {
+		w.directive(d)
+		return false
+	}
+	if len(w.names) == 0 {
+		return false
+	}
+	values := bytes.Fields(d)
+	if !w.isRow(values) {
+		return false
+	}
+
+	var date, clock, method, stem, query string
+	for i, name := range w.names {
+		v := string(values[i])
+		if v == "-" {
+			continue
+		}
+		switch name {
+		case "date":
+			date = v
+		case "time":
+			clock = v
+		case "cs-method":
+			method = v
+		case "cs-uri-stem":
+			stem = v
+		case "cs-uri-query":
+			query = v
+		case "sc-status":
+			h.Level = statusLevel(v)
+			fallthrough
+		default:
+			if _, drop := h.drop[name]; !drop {
+				h.setField([]byte(name), values[i])
+			}
+		}
+	}
+	if date != "" && clock != "" {
+		// the times are always UTC
+		if t, err := time.Parse("2006-01-02 15:04:05", date+" "+clock); err == nil {
+			h.Time = t
+		}
+	}
+	h.Message = stem
+	if query != "" {
+		h.Message += "?" + query
+	}
+	if method != "" {
+		h.Message = method + " " + h.Message
+	}
+	return true
+}
+
+// statusLevel tells how bad an HTTP status is.
+func statusLevel(status string) string {
+	code, err := strconv.Atoi(status)
+	switch {
+	case err != nil:
+		return ""
+	case code >= 500:
+		return "error"
+	case code >= 400:
+		return "warn"
+	default:
+		return "info"
+	}
+}
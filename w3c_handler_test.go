@@ -0,0 +1,96 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestW3CFields(t *testing.T) {
+	src := strings.Join([]string{
+		`#Software: Microsoft Internet Information Services 10.0`,
+		`#Fields: date time s-ip cs-method cs-uri-stem cs-uri-query sc-status time-taken`,
+		`2024-01-02 03:04:05 10.0.0.1 GET /index.html - 200 15`,
+		`2024-01-02 03:04:06 10.0.0.1 POST /api/login a=b 500 120`,
+		`#Fields: date time cs-method cs-uri-stem sc-status`,
+		`2024-01-02 03:05:00 GET /health 404`,
+		`2024-01-02 03:05:01 GET /health 404 extra`,
+	}, "\n")
+
+	var got []*Event
+	err := ScanEvents(strings.NewReader(src), DefaultOptions, func(ev *Event) error {
+		got = append(got, ev)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		time    time.Time
+		level   string
+		message string
+		fields  map[string]string
+	}{
+		{
+			time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			level:   "info",
+			message: "GET /index.html",
+			fields:  map[string]string{"s-ip": "10.0.0.1", "sc-status": "200", "time-taken": "15"},
+		},
+		{
+			time:    time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC),
+			level:   "error",
+			message: "POST /api/login?a=b",
+			fields:  map[string]string{"s-ip": "10.0.0.1", "sc-status": "500", "time-taken": "120"},
+		},
+		{
+			time:    time.Date(2024, 1, 2, 3, 5, 0, 0, time.UTC),
+			level:   "warn",
+			message: "GET /health",
+			fields:  map[string]string{"sc-status": "404"},
+		},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %d entries, got %d", len(want), len(got))
+	}
+	for i, w := range want {
+		ev := got[i]
+		if !ev.Time.Equal(w.time) || ev.Level != w.level || ev.Message != w.message {
+			t.Errorf("%d: want %v %s %q, got %v %s %q", i, w.time, w.level, w.message, ev.Time, ev.Level, ev.Message)
+		}
+		if len(ev.Fields) != len(w.fields) {
+			t.Errorf("%d: want fields %q, got %q", i, w.fields, ev.Fields)
+		}
+		for k, v := range w.fields {
+			if ev.Fields[k] != v {
+				t.Errorf("%d: %s: want %q, got %q", i, k, v, ev.Fields[k])
+			}
+		}
+	}
+}
+
+func TestW3CFields_Mixed(t *testing.T) {
+	// the lines in between have as many words as there are columns
+	src := strings.Join([]string{
+		`#Fields: date time cs-method cs-uri-stem sc-status`,
+		`2024-01-02 03:05:00 GET /health 200`,
+		"2024-01-02T03:05:01.000-0700\tINFO\tmain.go:12\tstarting\t{\"port\":8080}",
+		`Traceback (most recent call last):`,
+		`level=info msg=ready port=80 pid=1`,
+		`2024-01-02 03:05:02 GET /health 503`,
+	}, "\n")
+
+	var got []string
+	err := ScanEvents(strings.NewReader(src), DefaultOptions, func(ev *Event) error {
+		got = append(got, ev.Level+" "+ev.Message)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"info GET /health", "info starting", "info ready", "error GET /health"}
+	if strings.Join(want, "|") != strings.Join(got, "|") {
+		t.Errorf("want %q, got %q, want != got", want, got)
+	}
+}
@@ -0,0 +1,155 @@
+package humanlog
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// wideRanges are the characters terminals draw two columns wide: the East
+// Asian wide and fullwidth ones, and emoji.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18AFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F251}, {0x1F300, 0x1F64F},
+	{0x1F680, 0x1F6FF}, {0x1F7E0, 0x1F7EB}, {0x1F90C, 0x1F9FF}, {0x1FA70, 0x1FAFF},
+	{0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+const (
+	zeroWidthJoiner = '\u200d'
+	emojiVariation  = '\ufe0f'
+)
+
+// runeWidth returns how many columns a terminal draws r on.
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || r == 0x7f:
+		return 0
+	case r < 0x300:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) || r >= 0x1160 && r <= 0x11FF:
+		return 0
+	}
+	for _, rng := range wideRanges {
+		if r < rng[0] {
+			break
+		}
+		if r <= rng[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+// nextCluster returns the length in bytes of the first character of s as
+// it's drawn, with the marks combined with it and the emoji joined to it,
+// and how many columns it takes.
+func nextCluster(s string) (size, width int) {
+	r, n := utf8.DecodeRuneInString(s)
+	size, width = n, runeWidth(r)
+	regional := isRegionalIndicator(r)
+	for size < len(s) {
+		next, n := utf8.DecodeRuneInString(s[size:])
+		switch {
+		case next == zeroWidthJoiner:
+			size += n
+			if size < len(s) {
+				_, n = utf8.DecodeRuneInString(s[size:])
+				size += n
+			}
+			continue
+		case next == emojiVariation && width == 1:
+			width = 2
+		case regional && isRegionalIndicator(next):
+			// a pair of them is a flag
+			regional = false
+			width = 2
+		case next >= 0x1F3FB && next <= 0x1F3FF:
+			// skin tone modifier
+		case runeWidth(next) != 0:
+			return size, width
+		}
+		size += n
+	}
+	return size, width
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// displayWidth returns how many columns a terminal draws s on.
+func displayWidth(s string) int {
+	width := 0
+	for len(s) > 0 {
+		size, w := nextCluster(s)
+		width += w
+		s = s[size:]
+	}
+	return width
+}
+
+// truncateWidth returns the start of s that fits in width columns, without
+// splitting the characters drawn together.
+func truncateWidth(s string, width int) string {
+	used := 0
+	for i := 0; i < len(s); {
+		size, w := nextCluster(s[i:])
+		if used+w > width {
+			return s[:i]
+		}
+		used += w
+		i += size
+	}
+	return s
+}
+
+// tailWidth returns the end of s that fits in width columns, without
+// splitting the characters drawn together.
+func tailWidth(s string, width int) string {
+	var starts, widths []int
+	for i := 0; i < len(s); {
+		size, w := nextCluster(s[i:])
+		starts, widths = append(starts, i), append(widths, w)
+		i += size
+	}
+	used, start := 0, len(s)
+	for i := len(starts) - 1; i >= 0; i-- {
+		if used+widths[i] > width {
+			break
+		}
+		used += widths[i]
+		start = starts[i]
+	}
+	return s[start:]
+}
+
+// isolateBidi wraps text holding right-to-left characters in a Unicode
+// isolate, so the terminal doesn't reorder what's around it along with it.
+func isolateBidi(s string) string {
+	for _, r := range s {
+		if isRightToLeft(r) {
+			return "\u2068" + s + "\u2069"
+		}
+	}
+	return s
+}
+
+func isRightToLeft(r rune) bool {
+	return r >= 0x0590 && r <= 0x08FF ||
+		r >= 0xFB1D && r <= 0xFDFF ||
+		r >= 0xFE70 && r <= 0xFEFC ||
+		r >= 0x10800 && r <= 0x10FFF ||
+		r >= 0x1E800 && r <= 0x1EFFF
+}
@@ -0,0 +1,58 @@
+package humanlog
+
+import "testing"
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"hello", 5},
+		{"日本語", 6},
+		{"été", 3},
+		{"🚀 go", 5},
+		{"👩\u200d💻", 2},
+		{"❤\ufe0f", 2},
+		{"🇫🇷", 2},
+		{"👍🏽", 2},
+	}
+	for _, tt := range tests {
+		if got := displayWidth(tt.s); got != tt.want {
+			t.Errorf("%q: want %d, got %d", tt.s, tt.want, got)
+		}
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+		tail  string
+	}{
+		{"hello", 3, "hel", "llo"},
+		{"日本語", 3, "日", "語"},
+		{"日本語", 4, "日本", "本語"},
+		{"cafés", 4, "café", "afés"},
+		{"a👩\u200d💻b", 2, "a", "b"},
+		{"a👩\u200d💻b", 3, "a👩\u200d💻", "👩\u200d💻b"},
+		{"short", 10, "short", "short"},
+	}
+	for _, tt := range tests {
+		if got := truncateWidth(tt.s, tt.width); got != tt.want {
+			t.Errorf("truncateWidth(%q, %d): want %q, got %q", tt.s, tt.width, tt.want, got)
+		}
+		if got := tailWidth(tt.s, tt.width); got != tt.tail {
+			t.Errorf("tailWidth(%q, %d): want %q, got %q", tt.s, tt.width, tt.tail, got)
+		}
+	}
+}
+
+func TestIsolateBidi(t *testing.T) {
+	if got := isolateBidi("hello"); got != "hello" {
+		t.Errorf("want left-to-right text unchanged, got %q", got)
+	}
+	if got := isolateBidi("שלום 42"); got != "\u2068שלום 42\u2069" {
+		t.Errorf("want right-to-left text isolated, got %q", got)
+	}
+}
@@ -26,6 +26,15 @@ var zapDevDCLogsPrefixRe = regexp.MustCompile("^(?P<timestamp>\\d{4}-\\d{2}-\\d{
 // time package which is worrisome but this pattern does work.
 const someRFC = "2006-01-02T15:04:05.000-0700"
 
+// sniffZapDevPrefix tells the lines of zap's development encoder by the time
+// they start with, followed by its milliseconds.
+func sniffZapDevPrefix(d []byte) confidence {
+	if hasDatePrefix(d, 'T') && len(d) > 23 && d[19] == '.' {
+		return likelyFormat
+	}
+	return notFormat
+}
+
 func tryZapDevPrefix(d []byte, handler *JSONHandler) bool {
 	if matches := zapDevLogsPrefixRe.FindSubmatch(d); matches != nil {
 		if handler.TryHandle(matches[5]) {